@@ -0,0 +1,109 @@
+// Command memori-mcp is a Model Context Protocol server that gives agents in
+// MCP hosts long-term memory backed by memorigo: the remember, recall,
+// list_facts, forget and get_conversation_summary tools and an entity
+// profile resource. It speaks MCP over stdio (the default, for hosts that
+// launch it) or streamable HTTP at /mcp.
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"flag"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+
+	"memorigo/internal/dsn"
+	"memorigo/memori"
+	"memorigo/memorimcp"
+)
+
+func main() {
+	transport := flag.String("transport", envOr("MEMORI_MCP_TRANSPORT", "stdio"), "stdio or http")
+	addr := flag.String("addr", envOr("MEMORI_MCP_ADDR", ":8790"), "listen address of the http transport")
+	apiKey := flag.String("api-key", os.Getenv("MEMORI_MCP_API_KEY"), "API key http callers must send as a bearer token or in X-API-Key")
+	storageDSN := flag.String("dsn", envOr("MEMORI_DSN", "memori.db"), "storage: postgres://..., mongodb://..., bolt:<path> or a SQLite path")
+	entity := flag.String("entity", os.Getenv("MEMORI_MCP_ENTITY"), "serve only this entity; tools then default to it")
+	process := flag.String("process", envOr("MEMORI_MCP_PROCESS", "mcp"), "process facts stored by the remember tool are attributed to")
+	flag.Parse()
+
+	// stdout carries the protocol on stdio; keep logs on stderr
+	log.SetOutput(os.Stderr)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	conn, closeStorage, err := dsn.Open(ctx, *storageDSN)
+	if err != nil {
+		log.Fatalf("memori-mcp: %v", err)
+	}
+	defer closeStorage()
+
+	m := memori.New(memori.WithStorageConn(conn))
+	if err := m.Storage.Build(); err != nil {
+		log.Fatalf("memori-mcp: migrate: %v", err)
+	}
+	srv := memorimcp.NewServer(m, &memorimcp.Options{Entity: *entity, Process: *process})
+
+	switch *transport {
+	case "stdio":
+		if err := srv.Run(ctx, &mcp.StdioTransport{}); err != nil && !errors.Is(err, context.Canceled) {
+			log.Fatalf("memori-mcp: %v", err)
+		}
+	case "http":
+		serveHTTP(ctx, srv, *addr, *apiKey)
+	default:
+		log.Fatalf("memori-mcp: unknown transport %q, want stdio or http", *transport)
+	}
+}
+
+func serveHTTP(ctx context.Context, srv *mcp.Server, addr, apiKey string) {
+	var handler http.Handler = mcp.NewStreamableHTTPHandler(func(*http.Request) *mcp.Server { return srv }, nil)
+	if apiKey != "" {
+		handler = requireKey(apiKey, handler)
+	} else {
+		log.Printf("memori-mcp: no API key set, the server is open to anyone who can reach %s", addr)
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/mcp", handler)
+
+	hs := &http.Server{Addr: addr, Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		<-ctx.Done()
+		shutdown, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		_ = hs.Shutdown(shutdown)
+	}()
+	log.Printf("memori-mcp listening on %s/mcp", addr)
+	if err := hs.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		log.Fatalf("memori-mcp: %v", err)
+	}
+}
+
+func requireKey(key string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got := r.Header.Get("X-API-Key")
+		if got == "" {
+			got, _ = strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		}
+		if subtle.ConstantTimeCompare([]byte(strings.TrimSpace(got)), []byte(key)) != 1 {
+			http.Error(w, "invalid or missing API key", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func envOr(key, def string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return def
+}
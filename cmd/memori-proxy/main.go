@@ -0,0 +1,116 @@
+// Command memori-proxy is an OpenAI-compatible HTTP server that forwards
+// /v1/chat/completions and /v1/embeddings to an upstream provider while
+// persisting conversations, running augmentation and injecting recalled
+// facts, so that any OpenAI SDK gets memory by changing its base URL.
+//
+// Attribution comes from the X-Memori-Entity-Id (or the request's "user"),
+// X-Memori-Process-Id and X-Memori-Session-Id headers.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
+
+	"memorigo/internal/dsn"
+	"memorigo/internal/listen"
+	"memorigo/memori"
+)
+
+func main() {
+	addr := flag.String("addr", envOr("MEMORI_PROXY_ADDR", "127.0.0.1:8787"), "listen address; other than loopback it requires -api-key when an upstream key is set")
+	upstream := flag.String("upstream", envOr("MEMORI_PROXY_UPSTREAM", "https://api.openai.com"), "upstream OpenAI-compatible base URL")
+	upstreamKey := flag.String("upstream-key", envOr("MEMORI_PROXY_UPSTREAM_KEY", os.Getenv("OPENAI_API_KEY")), "upstream API key; empty forwards the caller's key")
+	apiKey := flag.String("api-key", os.Getenv("MEMORI_PROXY_API_KEY"), "API key callers must send as a bearer token")
+	storageDSN := flag.String("dsn", envOr("MEMORI_DSN", "memori.db"), "storage: postgres://..., mongodb://..., bolt:<path> or a SQLite path")
+	recall := flag.Int("recall", envInt("MEMORI_PROXY_RECALL", 5), "facts injected per request, 0 disables injection")
+	timeout := flag.Duration("timeout", 2*time.Minute, "upstream timeout per attempt (time to first byte for streams)")
+	flag.Parse()
+
+	if err := checkExposure(*addr, *apiKey, *upstreamKey); err != nil {
+		log.Fatalf("memori-proxy: %v", err)
+	}
+	if *apiKey == "" {
+		log.Printf("memori-proxy: no API key set, anyone who can reach %s can read and write the memory of every entity", *addr)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	conn, closeStorage, err := dsn.Open(ctx, *storageDSN)
+	if err != nil {
+		log.Fatalf("memori-proxy: %v", err)
+	}
+	defer closeStorage()
+
+	m := memori.New(memori.WithStorageConn(conn))
+	if err := m.Storage.Build(); err != nil {
+		log.Fatalf("memori-proxy: migrate: %v", err)
+	}
+	m.Config.Timeout = *timeout
+
+	p := &proxy{
+		m: m,
+		upstream: memori.NewOpenAICompatClient(memori.OpenAICompatOptions{
+			BaseURL: *upstream,
+			APIKey:  *upstreamKey,
+			// no overall client timeout: streams may run for minutes
+			HTTPClient: &http.Client{},
+			Timeout:    *timeout,
+		}),
+		apiKey:      *apiKey,
+		recallLimit: *recall,
+	}
+
+	srv := &http.Server{
+		Addr:              *addr,
+		Handler:           p.handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go func() {
+		<-ctx.Done()
+		shutdown, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		_ = srv.Shutdown(shutdown)
+	}()
+
+	log.Printf("memori-proxy listening on %s, upstream %s", *addr, *upstream)
+	if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		log.Fatalf("memori-proxy: %v", err)
+	}
+}
+
+// checkExposure refuses to run as an open relay: without an API key of its
+// own, a proxy holding an upstream key lets anyone who reaches it spend that
+// key, so it may then only listen on a loopback address.
+func checkExposure(addr, apiKey, upstreamKey string) error {
+	if apiKey != "" || upstreamKey == "" {
+		return nil
+	}
+	if local, err := listen.IsLoopback(addr); err != nil || local {
+		return err
+	}
+	return fmt.Errorf("refusing to listen on %s with an upstream key and no -api-key: anyone reaching the proxy could spend the key and read or inject any entity's memory; set -api-key (MEMORI_PROXY_API_KEY) or listen on a loopback address such as 127.0.0.1:8787", addr)
+}
+
+func envOr(key, def string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return def
+}
+
+func envInt(key string, def int) int {
+	if v, err := strconv.Atoi(os.Getenv(key)); err == nil {
+		return v
+	}
+	return def
+}
@@ -0,0 +1,319 @@
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"memorigo/memori"
+)
+
+// Attribution headers. The entity falls back to the request's "user" field,
+// and a missing session id to one derived from the entity and process, so
+// that plain OpenAI SDK calls still land in a stable session.
+const (
+	headerEntity  = "X-Memori-Entity-Id"
+	headerProcess = "X-Memori-Process-Id"
+	headerSession = "X-Memori-Session-Id"
+)
+
+// maxBodyBytes bounds a request body; multimodal messages can be large.
+const maxBodyBytes = 32 << 20
+
+type proxy struct {
+	m        *memori.Memori
+	upstream *memori.OpenAICompatClient
+
+	// apiKey, when set, is the bearer token callers must present. Without
+	// it, and without an upstream key, the caller's token is forwarded.
+	apiKey string
+	// recallLimit is the number of facts injected per request; 0 disables
+	// injection.
+	recallLimit int
+}
+
+func (p *proxy) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /v1/chat/completions", p.auth(p.handleChatCompletions))
+	mux.HandleFunc("POST /v1/embeddings", p.auth(p.handleEmbeddings))
+	mux.HandleFunc("GET /healthz", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+	return mux
+}
+
+func (p *proxy) auth(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if p.apiKey != "" {
+			token := bearerToken(r)
+			if subtle.ConstantTimeCompare([]byte(token), []byte(p.apiKey)) != 1 {
+				writeError(w, http.StatusUnauthorized, "invalid_api_key", "Invalid or missing API key.")
+				return
+			}
+		}
+		next(w, r)
+	}
+}
+
+func bearerToken(r *http.Request) string {
+	token, _ := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return strings.TrimSpace(token)
+}
+
+// client returns the upstream client for r: the configured one, or a copy
+// carrying the caller's token when neither side of the proxy has a key.
+func (p *proxy) client(r *http.Request) *memori.OpenAICompatClient {
+	if p.upstream.APIKey != "" || p.apiKey != "" {
+		return p.upstream
+	}
+	c := *p.upstream
+	c.APIKey = bearerToken(r)
+	return &c
+}
+
+// scope returns a Memori attributed from the request headers.
+func (p *proxy) scope(r *http.Request, user string) (*memori.Memori, error) {
+	entity := r.Header.Get(headerEntity)
+	if entity == "" {
+		entity = user
+	}
+	process := r.Header.Get(headerProcess)
+	if len(entity) > 100 || len(process) > 100 {
+		return nil, fmt.Errorf("%s and %s are limited to 100 characters", headerEntity, headerProcess)
+	}
+
+	var session uuid.UUID
+	switch s := r.Header.Get(headerSession); {
+	case s != "":
+		var err error
+		if session, err = uuid.Parse(s); err != nil {
+			// any other session key maps to a stable uuid
+			session = uuid.NewSHA1(uuid.NameSpaceURL, []byte("memori-proxy:session:"+s))
+		}
+	case entity != "":
+		session = uuid.NewSHA1(uuid.NameSpaceURL, []byte("memori-proxy:"+entity+"\x00"+process))
+	}
+	return p.m.Scoped(entity, process, session), nil
+}
+
+// handleChatCompletions forwards the request body as received, with only the
+// injected memories spliced into its messages, and relays the upstream answer
+// as received, so that fields memori does not model pass through both ways.
+// The typed decoding of the body is only used for attribution and recall.
+func (p *proxy) handleChatCompletions(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBodyBytes))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "", "Invalid body: "+err.Error())
+		return
+	}
+	var req memori.ChatCompletionsRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		writeError(w, http.StatusBadRequest, "", "Invalid JSON body: "+err.Error())
+		return
+	}
+	m, err := p.scope(r, req.User)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "", err.Error())
+		return
+	}
+	m.OpenAI.Register(p.client(r))
+	if body, err = p.injectMemories(m, body, req.Messages); err != nil {
+		writeError(w, http.StatusBadRequest, "", "Invalid JSON body: "+err.Error())
+		return
+	}
+
+	if !req.Stream {
+		resp, err := m.OpenAIClient().ChatCompletionsCreateRaw(r.Context(), body)
+		if err != nil {
+			writeUpstreamError(w, err)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write(resp)
+		return
+	}
+
+	// Usage is always requested upstream so it can be recorded; only pass
+	// the usage chunk on when the caller asked for it.
+	wantUsage := req.StreamOptions != nil && req.StreamOptions.IncludeUsage
+	events, errs := m.OpenAIClient().ChatCompletionsStreamRaw(r.Context(), body)
+	flusher, _ := w.(http.Flusher)
+	started := false
+	for ev := range events {
+		if ev.Chunk != nil && len(ev.Chunk.Choices) == 0 && ev.Chunk.Usage != nil && !wantUsage {
+			continue
+		}
+		if !started {
+			started = true
+			w.Header().Set("Content-Type", "text/event-stream")
+			w.Header().Set("Cache-Control", "no-cache")
+			w.WriteHeader(http.StatusOK)
+		}
+		fmt.Fprintf(w, "data: %s\n\n", ev.RawLine)
+		if flusher != nil {
+			flusher.Flush()
+		}
+	}
+	err = <-errs
+	switch {
+	case err == nil || r.Context().Err() != nil:
+	case !started:
+		writeUpstreamError(w, err)
+	default:
+		// headers are sent: report the error in-band, as OpenAI does
+		b, _ := json.Marshal(errorBody(err))
+		fmt.Fprintf(w, "data: %s\n\n", b)
+		if flusher != nil {
+			flusher.Flush()
+		}
+	}
+}
+
+// injectMemories adds the entity's facts most relevant to the last user
+// message as a system message after the leading system messages of body,
+// whose decoded messages are msgs. The other messages are kept as received.
+// The Writer does not store system messages, so injected facts are never
+// re-extracted.
+func (p *proxy) injectMemories(m *memori.Memori, body []byte, msgs []memori.ChatMessage) ([]byte, error) {
+	if p.recallLimit <= 0 {
+		return body, nil
+	}
+	query := ""
+	for i := len(msgs) - 1; i >= 0; i-- {
+		if msgs[i].Role == "user" {
+			query = msgs[i].Text()
+			break
+		}
+	}
+	if strings.TrimSpace(query) == "" {
+		return body, nil
+	}
+	facts, err := m.Recall(query, p.recallLimit)
+	if err != nil || len(facts) == 0 {
+		return body, nil
+	}
+
+	var b strings.Builder
+	b.WriteString("Relevant memories about the user from earlier conversations:")
+	n := 0
+	for _, f := range facts {
+		if f.Score <= 0 {
+			continue
+		}
+		b.WriteString("\n- ")
+		b.WriteString(f.Content)
+		n++
+	}
+	if n == 0 {
+		return body, nil
+	}
+	memory, err := json.Marshal(memori.ChatMessage{Role: "system", Content: b.String()})
+	if err != nil {
+		return nil, err
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(body, &fields); err != nil {
+		return nil, err
+	}
+	var raw []json.RawMessage
+	if err := json.Unmarshal(fields["messages"], &raw); err != nil || len(raw) != len(msgs) {
+		return nil, fmt.Errorf("messages must be an array")
+	}
+	at := 0
+	for at < len(msgs) && msgs[at].Role == "system" {
+		at++
+	}
+	raw = append(raw[:at], append([]json.RawMessage{memory}, raw[at:]...)...)
+	if fields["messages"], err = json.Marshal(raw); err != nil {
+		return nil, err
+	}
+	return json.Marshal(fields)
+}
+
+func (p *proxy) handleEmbeddings(w http.ResponseWriter, r *http.Request) {
+	var req memori.EmbeddingsRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodyBytes)).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "", "Invalid JSON body: "+err.Error())
+		return
+	}
+	resp, err := p.client(r).Embeddings(r.Context(), req)
+	if err != nil {
+		writeUpstreamError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+type apiErrorBody struct {
+	Error struct {
+		Message string `json:"message"`
+		Type    string `json:"type"`
+		Param   any    `json:"param"`
+		Code    any    `json:"code"`
+	} `json:"error"`
+}
+
+func errorBody(err error) apiErrorBody {
+	var body apiErrorBody
+	body.Error.Message = err.Error()
+	body.Error.Type = "upstream_error"
+	var apiErr *memori.APIError
+	if errors.As(err, &apiErr) {
+		if apiErr.Message != "" {
+			body.Error.Message = apiErr.Message
+		}
+		if apiErr.Type != "" {
+			body.Error.Type = apiErr.Type
+		}
+		if apiErr.Param != "" {
+			body.Error.Param = apiErr.Param
+		}
+		if apiErr.Code != "" {
+			body.Error.Code = apiErr.Code
+		}
+	}
+	return body
+}
+
+// writeUpstreamError relays an upstream failure: its status and error body
+// when the upstream answered, 504 on timeouts and 502 otherwise.
+func writeUpstreamError(w http.ResponseWriter, err error) {
+	status := http.StatusBadGateway
+	var apiErr *memori.APIError
+	switch {
+	case errors.As(err, &apiErr) && apiErr.StatusCode != 0:
+		status = apiErr.StatusCode
+		if apiErr.RetryAfter > 0 {
+			w.Header().Set("Retry-After", strconv.Itoa(int(apiErr.RetryAfter.Seconds()+0.999)))
+		}
+	case errors.Is(err, context.DeadlineExceeded):
+		status = http.StatusGatewayTimeout
+	}
+	writeJSON(w, status, errorBody(err))
+}
+
+func writeError(w http.ResponseWriter, status int, code, message string) {
+	var body apiErrorBody
+	body.Error.Message = message
+	body.Error.Type = "invalid_request_error"
+	if code != "" {
+		body.Error.Code = code
+	}
+	writeJSON(w, status, body)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
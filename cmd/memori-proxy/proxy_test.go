@@ -0,0 +1,202 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	_ "modernc.org/sqlite"
+
+	"memorigo/memori"
+)
+
+func TestProxy_ChatCompletions(t *testing.T) {
+	var sawMemory, sawKey string
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sawKey = r.Header.Get("Authorization")
+		var req memori.ChatCompletionsRequest
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		if len(req.Messages) > 1 && req.Messages[0].Role == "system" {
+			sawMemory = req.Messages[0].Content
+		}
+		if !req.Stream {
+			_, _ = w.Write([]byte(`{"id":"c1","object":"chat.completion","choices":[{"index":0,"message":{"role":"assistant","content":"Blue, I remember."}}]}`))
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, data := range []string{
+			`{"id":"c2","choices":[{"index":0,"delta":{"role":"assistant","content":"Hi"}}]}`,
+			`{"id":"c2","choices":[{"index":0,"delta":{},"finish_reason":"stop"}]}`,
+			`{"id":"c2","choices":[],"usage":{"prompt_tokens":3,"completion_tokens":1,"total_tokens":4}}`,
+			`[DONE]`,
+		} {
+			fmt.Fprintf(w, "data: %s\n\n", data)
+		}
+	}))
+	defer upstream.Close()
+
+	db, err := sql.Open("sqlite", "file:memori_proxy?mode=memory&cache=shared")
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	defer db.Close()
+	m := memori.New(memori.WithStorageConn(db))
+	if err := m.Storage.Build(); err != nil {
+		t.Fatalf("migrate/build: %v", err)
+	}
+
+	// a fact from an earlier conversation of alice
+	alice := m.Scoped("alice", "", uuid.Nil)
+	if err := memori.NewWriter(alice).Execute(context.Background(), memori.ConversationPayload{
+		Messages: []memori.Message{{Role: "user", Content: "My favorite color is blue"}},
+	}); err != nil {
+		t.Fatalf("seed: %v", err)
+	}
+	for deadline := time.Now().Add(5 * time.Second); ; {
+		if facts, _ := alice.Recall("favorite color", 1); len(facts) > 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("seed fact was not augmented")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	p := &proxy{
+		m:           m,
+		upstream:    memori.NewOpenAICompatClient(memori.OpenAICompatOptions{BaseURL: upstream.URL}),
+		recallLimit: 3,
+	}
+	srv := httptest.NewServer(p.handler())
+	defer srv.Close()
+
+	post := func(body string, header http.Header) (*http.Response, string) {
+		req, _ := http.NewRequest(http.MethodPost, srv.URL+"/v1/chat/completions", strings.NewReader(body))
+		req.Header = header
+		req.Header.Set("Authorization", "Bearer caller-key")
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("post: %v", err)
+		}
+		defer resp.Body.Close()
+		b, _ := io.ReadAll(resp.Body)
+		return resp, string(b)
+	}
+
+	resp, body := post(`{"model":"gpt-4o-mini","stop":"\n","messages":[{"role":"user","content":"What is my favorite color?"}]}`,
+		http.Header{headerEntity: {"alice"}, headerProcess: {"proxy-test"}})
+	if resp.StatusCode != http.StatusOK || !strings.Contains(body, "Blue, I remember.") {
+		t.Fatalf("create: %d %s", resp.StatusCode, body)
+	}
+	if !strings.Contains(sawMemory, "My favorite color is blue") || sawKey != "Bearer caller-key" {
+		t.Fatalf("upstream saw memory %q, key %q", sawMemory, sawKey)
+	}
+
+	// the entity falls back to "user"; the usage chunk was not requested
+	resp, body = post(`{"model":"gpt-4o-mini","stream":true,"user":"bob","messages":[{"role":"user","content":"Hello"}]}`, http.Header{})
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != "text/event-stream" ||
+		!strings.Contains(body, `"content":"Hi"`) || strings.Contains(body, "usage") || !strings.Contains(body, "data: [DONE]") {
+		t.Fatalf("stream: %d %s", resp.StatusCode, body)
+	}
+
+	var n int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM memori_conversation_message WHERE role = 'assistant' AND content IN ('Blue, I remember.', 'Hi')`).Scan(&n); err != nil || n != 2 {
+		t.Fatalf("stored assistant messages = %d, %v", n, err)
+	}
+	usage, err := m.Usage(memori.UsageQuery{EntityID: "bob"})
+	if err != nil || len(usage) != 1 || usage[0].TotalTokens != 4 {
+		t.Fatalf("bob usage = %+v, %v", usage, err)
+	}
+
+	p.apiKey = "secret"
+	if resp, _ := post(`{"model":"m","messages":[]}`, http.Header{}); resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("wrong key: status %d", resp.StatusCode)
+	}
+}
+
+func TestProxy_PassesUnknownFieldsThrough(t *testing.T) {
+	var sent map[string]json.RawMessage
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&sent)
+		_, _ = w.Write([]byte(`{"id":"c1","object":"chat.completion","system_fingerprint":"fp_1","choices":[{"index":0,"message":{"role":"assistant","content":"Blue.","refusal":null},"logprobs":{"content":[]},"finish_reason":"stop"}]}`))
+	}))
+	defer upstream.Close()
+
+	m := memori.New()
+	p := &proxy{
+		m:           m,
+		upstream:    memori.NewOpenAICompatClient(memori.OpenAICompatOptions{BaseURL: upstream.URL}),
+		recallLimit: 3,
+	}
+	srv := httptest.NewServer(p.handler())
+	defer srv.Close()
+
+	resp, err := http.Post(srv.URL+"/v1/chat/completions", "application/json", strings.NewReader(`{
+		"model": "o3-mini",
+		"reasoning_effort": "low",
+		"logprobs": true,
+		"metadata": {"team": "a"},
+		"store": false,
+		"prediction": {"type": "content", "content": "Blue"},
+		"messages": [{"role": "developer", "content": "Be brief."}, {"role": "user", "content": "Color?", "name": "alice"}]
+	}`))
+	if err != nil {
+		t.Fatalf("post: %v", err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status %d: %s", resp.StatusCode, body)
+	}
+
+	for key, want := range map[string]string{
+		"reasoning_effort": `"low"`,
+		"logprobs":         `true`,
+		"metadata":         `{"team":"a"}`,
+		"store":            `false`,
+		"prediction":       `{"type":"content","content":"Blue"}`,
+		"messages":         `[{"role":"developer","content":"Be brief."},{"role":"user","content":"Color?","name":"alice"}]`,
+	} {
+		if got := string(sent[key]); got != want {
+			t.Fatalf("upstream got %s = %s, want %s", key, got, want)
+		}
+	}
+	if _, ok := sent["stream"]; ok {
+		t.Fatalf("upstream got stream = %s", sent["stream"])
+	}
+	for _, want := range []string{`"system_fingerprint":"fp_1"`, `"logprobs":{"content":[]}`, `"refusal":null`} {
+		if !strings.Contains(string(body), want) {
+			t.Fatalf("response lacks %s: %s", want, body)
+		}
+	}
+	if strings.Contains(string(body), `"delta"`) {
+		t.Fatalf("response gained a delta: %s", body)
+	}
+}
+
+func TestCheckExposure(t *testing.T) {
+	for _, tc := range []struct {
+		addr, apiKey, upstreamKey string
+		ok                        bool
+	}{
+		{":8787", "", "sk-upstream", false},
+		{"0.0.0.0:8787", "", "sk-upstream", false},
+		{"127.0.0.1:8787", "", "sk-upstream", true},
+		{"[::1]:8787", "", "sk-upstream", true},
+		{"localhost:8787", "", "sk-upstream", true},
+		{":8787", "secret", "sk-upstream", true},
+		{":8787", "", "", true}, // callers bring their own upstream key
+	} {
+		if err := checkExposure(tc.addr, tc.apiKey, tc.upstreamKey); (err == nil) != tc.ok {
+			t.Errorf("checkExposure(%q, %q, %q) = %v", tc.addr, tc.apiKey, tc.upstreamKey, err)
+		}
+	}
+}
@@ -0,0 +1,137 @@
+// Command memori-server is an HTTP JSON API for inspecting and managing the
+// memory stored by memorigo: entities, processes, sessions, conversations,
+// messages and facts, recall, forget and export, and the augmentation queue.
+// The OpenAPI document is served at /openapi.json and printed by -openapi.
+// With -grpc-addr it also serves the memori.v1 gRPC service (see
+// proto/memori/v1/memori.proto) behind the same API key.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"google.golang.org/grpc"
+
+	"memorigo/internal/dsn"
+	"memorigo/internal/listen"
+	"memorigo/memori"
+	"memorigo/memorigrpc"
+)
+
+func main() {
+	addr := flag.String("addr", envOr("MEMORI_SERVER_ADDR", "127.0.0.1:8788"), "listen address; other than loopback it requires -api-key")
+	grpcAddr := flag.String("grpc-addr", os.Getenv("MEMORI_SERVER_GRPC_ADDR"), "gRPC listen address, empty disables gRPC; other than loopback it requires -api-key")
+	apiKey := flag.String("api-key", os.Getenv("MEMORI_SERVER_API_KEY"), "API key callers must send as a bearer token or in X-API-Key")
+	storageDSN := flag.String("dsn", envOr("MEMORI_DSN", "memori.db"), "storage: postgres://..., mongodb://..., bolt:<path> or a SQLite path")
+	printSpec := flag.Bool("openapi", false, "print the OpenAPI document and exit")
+	flag.Parse()
+
+	if *printSpec {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(openAPI((&server{}).routes())); err != nil {
+			log.Fatalf("memori-server: %v", err)
+		}
+		return
+	}
+
+	if err := checkExposure(*apiKey, *addr, *grpcAddr); err != nil {
+		log.Fatalf("memori-server: %v", err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	conn, closeStorage, err := dsn.Open(ctx, *storageDSN)
+	if err != nil {
+		log.Fatalf("memori-server: %v", err)
+	}
+	defer closeStorage()
+
+	m := memori.New(memori.WithStorageConn(conn))
+	if err := m.Storage.Build(); err != nil {
+		log.Fatalf("memori-server: migrate: %v", err)
+	}
+	if *apiKey == "" {
+		log.Printf("memori-server: no API key set, the API is open to every local user and process")
+	}
+
+	srv := &http.Server{
+		Addr:              *addr,
+		Handler:           (&server{m: m, apiKey: *apiKey}).handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go func() {
+		<-ctx.Done()
+		shutdown, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		_ = srv.Shutdown(shutdown)
+	}()
+
+	if *grpcAddr != "" {
+		lis, err := net.Listen("tcp", *grpcAddr)
+		if err != nil {
+			log.Fatalf("memori-server: %v", err)
+		}
+		var opts []grpc.ServerOption
+		if *apiKey != "" {
+			opts = memorigrpc.AuthOptions(*apiKey)
+		}
+		gs := grpc.NewServer(opts...)
+		memorigrpc.Register(gs, m)
+		go func() {
+			<-ctx.Done()
+			gs.GracefulStop()
+		}()
+		go func() {
+			log.Printf("memori-server gRPC listening on %s", *grpcAddr)
+			if err := gs.Serve(lis); err != nil {
+				log.Fatalf("memori-server: grpc: %v", err)
+			}
+		}()
+	}
+
+	log.Printf("memori-server listening on %s", *addr)
+	if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		log.Fatalf("memori-server: %v", err)
+	}
+}
+
+// checkExposure refuses to serve memory without an API key on an address
+// reachable from other machines: anyone reaching it could read, edit, export
+// and forget every entity's memory. Empty addresses are disabled listeners.
+func checkExposure(apiKey string, addrs ...string) error {
+	if apiKey != "" {
+		return nil
+	}
+	for _, addr := range addrs {
+		if addr == "" {
+			continue
+		}
+		local, err := listen.IsLoopback(addr)
+		if err != nil {
+			return err
+		}
+		if !local {
+			return fmt.Errorf("refusing to listen on %s without -api-key: anyone reaching it could read, edit and delete all memory; set -api-key (MEMORI_SERVER_API_KEY) or listen on a loopback address such as 127.0.0.1:8788", addr)
+		}
+	}
+	return nil
+}
+
+func envOr(key, def string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return def
+}
@@ -0,0 +1,181 @@
+package main
+
+import (
+	"net/http"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// openAPI generates the OpenAPI 3.1 document of routes. Schemas are derived
+// from the Go types of request and response bodies through their json tags.
+func openAPI(routes []route) map[string]any {
+	g := &schemaGen{schemas: map[string]any{}}
+	paths := map[string]map[string]any{}
+	for _, rt := range routes {
+		op := map[string]any{
+			"operationId": rt.id,
+			"summary":     rt.summary,
+			"responses":   g.responses(rt),
+		}
+		if len(rt.params) > 0 {
+			var params []any
+			for _, p := range rt.params {
+				typ := "string"
+				if p.integer {
+					typ = "integer"
+				}
+				params = append(params, map[string]any{
+					"name":        p.name,
+					"in":          p.in,
+					"description": p.description,
+					"required":    p.in == "path",
+					"schema":      map[string]any{"type": typ},
+				})
+			}
+			op["parameters"] = params
+		}
+		if rt.body != nil {
+			op["requestBody"] = map[string]any{
+				"required": true,
+				"content":  map[string]any{"application/json": map[string]any{"schema": g.schema(reflect.TypeOf(rt.body))}},
+			}
+		}
+		if paths[rt.path] == nil {
+			paths[rt.path] = map[string]any{}
+		}
+		paths[rt.path][strings.ToLower(rt.method)] = op
+	}
+
+	return map[string]any{
+		"openapi": "3.1.0",
+		"info": map[string]any{
+			"title":       "memori-server",
+			"version":     "1",
+			"description": "Inspect and manage the memory stored by memorigo.",
+		},
+		"paths": paths,
+		"components": map[string]any{
+			"schemas": g.schemas,
+			"securitySchemes": map[string]any{
+				"bearer": map[string]any{"type": "http", "scheme": "bearer"},
+				"apiKey": map[string]any{"type": "apiKey", "in": "header", "name": "X-API-Key"},
+			},
+		},
+		"security": []any{
+			map[string]any{"bearer": []string{}},
+			map[string]any{"apiKey": []string{}},
+		},
+	}
+}
+
+type schemaGen struct {
+	schemas map[string]any
+}
+
+func (g *schemaGen) responses(rt route) map[string]any {
+	errorBody := map[string]any{
+		"content": map[string]any{"application/json": map[string]any{"schema": g.schema(reflect.TypeOf(errorResponse{}))}},
+	}
+	out := map[string]any{
+		"400": withDescription(errorBody, "Invalid request"),
+		"401": withDescription(errorBody, "Invalid or missing API key"),
+		"404": withDescription(errorBody, "Not found"),
+		"500": withDescription(errorBody, "Storage error"),
+	}
+	if rt.status == http.StatusNoContent {
+		out["204"] = map[string]any{"description": "Done"}
+		return out
+	}
+	out["200"] = map[string]any{
+		"description": "OK",
+		"content":     map[string]any{"application/json": map[string]any{"schema": g.schema(reflect.TypeOf(rt.response))}},
+	}
+	return out
+}
+
+func withDescription(m map[string]any, description string) map[string]any {
+	out := map[string]any{"description": description}
+	for k, v := range m {
+		out[k] = v
+	}
+	return out
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// schema returns the JSON schema of t; named structs become components
+// referenced by name.
+func (g *schemaGen) schema(t reflect.Type) map[string]any {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch {
+	case t == timeType:
+		return map[string]any{"type": "string", "format": "date-time"}
+	case t.Kind() == reflect.Struct && t.Name() != "":
+		if _, ok := g.schemas[t.Name()]; !ok {
+			g.schemas[t.Name()] = nil // break cycles
+			g.schemas[t.Name()] = g.object(t)
+		}
+		return map[string]any{"$ref": "#/components/schemas/" + t.Name()}
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		return g.object(t)
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return map[string]any{"type": "string", "contentEncoding": "base64"}
+		}
+		return map[string]any{"type": "array", "items": g.schema(t.Elem())}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": g.schema(t.Elem())}
+	default:
+		return map[string]any{}
+	}
+}
+
+func (g *schemaGen) object(t reflect.Type) map[string]any {
+	props := map[string]any{}
+	var required []string
+	g.fields(t, props, &required)
+	out := map[string]any{"type": "object", "properties": props}
+	if len(required) > 0 {
+		out["required"] = required
+	}
+	return out
+}
+
+// fields adds the JSON properties of t, flattening embedded structs as
+// encoding/json does.
+func (g *schemaGen) fields(t reflect.Type, props map[string]any, required *[]string) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" || !f.IsExported() {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		if f.Anonymous && name == "" && f.Type.Kind() == reflect.Struct {
+			g.fields(f.Type, props, required)
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		props[name] = g.schema(f.Type)
+		if !strings.Contains(opts, "omitempty") && f.Type.Kind() != reflect.Pointer {
+			*required = append(*required, name)
+		}
+	}
+}
@@ -0,0 +1,361 @@
+package main
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"memorigo/memori"
+	"memorigo/storage"
+)
+
+// maxListLimit caps the page size callers may request.
+const maxListLimit = 1000
+
+type server struct {
+	m *memori.Memori
+	// apiKey, when set, must be sent as a bearer token or in X-API-Key.
+	apiKey string
+}
+
+// route is one API operation. The same table registers the handlers and
+// generates the OpenAPI document, so the two cannot drift apart.
+type route struct {
+	method, path string
+	id, summary  string
+	params       []param
+	body         any // request body type, nil when there is none
+	response     any // success response type, nil for 204
+	status       int
+	handle       func(r *http.Request) (any, error)
+}
+
+type param struct {
+	name, in, description string
+	integer               bool
+}
+
+var pageParams = []param{
+	{name: "limit", in: "query", description: fmt.Sprintf("page size, default %d, at most %d", storage.DefaultListLimit, maxListLimit), integer: true},
+	{name: "offset", in: "query", description: "number of records to skip", integer: true},
+}
+
+func pathParam(name, description string) param {
+	return param{name: name, in: "path", description: description}
+}
+
+func (s *server) routes() []route {
+	entity := pathParam("entity_id", "external id of the entity")
+	fact := pathParam("fact_id", "uuid of the fact")
+	return []route{
+		{method: "GET", path: "/v1/entities", id: "listEntities", summary: "List entities",
+			params: pageParams, response: []storage.EntityInfo{}, handle: s.listEntities},
+		{method: "GET", path: "/v1/entities/{entity_id}", id: "getEntity", summary: "Get an entity",
+			params: []param{entity}, response: storage.EntityInfo{}, handle: s.getEntity},
+		{method: "DELETE", path: "/v1/entities/{entity_id}", id: "forgetEntity", summary: "Forget an entity and everything stored about it",
+			params: []param{entity}, status: http.StatusNoContent, handle: s.forgetEntity},
+		{method: "GET", path: "/v1/entities/{entity_id}/export", id: "exportEntity", summary: "Export everything stored about an entity",
+			params: []param{entity}, response: memori.EntityExport{}, handle: s.exportEntity},
+		{method: "GET", path: "/v1/entities/{entity_id}/facts", id: "listFacts", summary: "List or search the facts of an entity",
+			params:   append([]param{entity, {name: "q", in: "query", description: "only facts containing this text, ignoring case"}}, pageParams...),
+			response: []storage.FactInfo{}, handle: s.listFacts},
+		{method: "POST", path: "/v1/entities/{entity_id}/recall", id: "recall", summary: "Rank the facts of an entity by semantic similarity to a query",
+			params: []param{entity}, body: recallRequest{}, response: []recalledFact{}, handle: s.recall},
+		{method: "GET", path: "/v1/processes", id: "listProcesses", summary: "List processes",
+			params: pageParams, response: []storage.EntityInfo{}, handle: s.listProcesses},
+		{method: "GET", path: "/v1/sessions", id: "listSessions", summary: "List sessions",
+			params: append([]param{
+				{name: "entity_id", in: "query", description: "only sessions of this entity"},
+				{name: "process_id", in: "query", description: "only sessions of this process"},
+			}, pageParams...),
+			response: []storage.SessionInfo{}, handle: s.listSessions},
+		{method: "GET", path: "/v1/sessions/{session_id}/conversations", id: "listConversations", summary: "List the conversations of a session",
+			params:   append([]param{pathParam("session_id", "uuid of the session")}, pageParams...),
+			response: []storage.ConversationInfo{}, handle: s.listConversations},
+		{method: "GET", path: "/v1/conversations/{conversation_id}/messages", id: "listMessages", summary: "List the messages of a conversation",
+			params:   append([]param{pathParam("conversation_id", "uuid of the conversation")}, pageParams...),
+			response: []storage.MessageInfo{}, handle: s.listMessages},
+		{method: "GET", path: "/v1/facts/{fact_id}", id: "getFact", summary: "Get a fact",
+			params: []param{fact}, response: storage.FactInfo{}, handle: s.getFact},
+		{method: "PATCH", path: "/v1/facts/{fact_id}", id: "updateFact", summary: "Edit the content of a fact; it is re-embedded",
+			params: []param{fact}, body: updateFactRequest{}, response: storage.FactInfo{}, handle: s.updateFact},
+		{method: "DELETE", path: "/v1/facts/{fact_id}", id: "deleteFact", summary: "Delete a fact",
+			params: []param{fact}, status: http.StatusNoContent, handle: s.deleteFact},
+		{method: "GET", path: "/v1/augmentation", id: "augmentationStatus", summary: "Augmentation queue status",
+			response: memori.AugmentationStatus{}, handle: s.augmentationStatus},
+	}
+}
+
+func (s *server) handler() http.Handler {
+	api := http.NewServeMux()
+	for _, rt := range s.routes() {
+		api.HandleFunc(rt.method+" "+rt.path, rt.serve)
+	}
+
+	spec, err := json.MarshalIndent(openAPI(s.routes()), "", "  ")
+	if err != nil {
+		panic(err) // the document is built from static types
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/v1/", s.auth(api))
+	mux.HandleFunc("GET /openapi.json", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(spec)
+	})
+	mux.HandleFunc("GET /healthz", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+	return mux
+}
+
+func (rt route) serve(w http.ResponseWriter, r *http.Request) {
+	v, err := rt.handle(r)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if rt.status == http.StatusNoContent {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	writeJSON(w, http.StatusOK, v)
+}
+
+// auth requires the API key as "Authorization: Bearer <key>" or in the
+// X-API-Key header.
+func (s *server) auth(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.apiKey != "" {
+			key := r.Header.Get("X-API-Key")
+			if key == "" {
+				key, _ = strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			}
+			if subtle.ConstantTimeCompare([]byte(strings.TrimSpace(key)), []byte(s.apiKey)) != 1 {
+				w.Header().Set("WWW-Authenticate", "Bearer")
+				writeError(w, httpError{http.StatusUnauthorized, "invalid or missing API key"})
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// httpError is an error with the status it is reported with.
+type httpError struct {
+	status  int
+	message string
+}
+
+func (e httpError) Error() string { return e.message }
+
+func badRequest(format string, args ...any) error {
+	return httpError{http.StatusBadRequest, fmt.Sprintf(format, args...)}
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	var he httpError
+	switch {
+	case errors.As(err, &he):
+	case errors.Is(err, storage.ErrNotFound):
+		he = httpError{http.StatusNotFound, "not found"}
+	case errors.Is(err, storage.ErrDuplicate):
+		he = httpError{http.StatusConflict, "a fact with this content already exists"}
+	default:
+		log.Printf("memori-server: %v", err)
+		he = httpError{http.StatusInternalServerError, err.Error()}
+	}
+	writeJSON(w, he.status, errorResponse{Error: he.message})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func decodeBody(r *http.Request, v any) error {
+	dec := json.NewDecoder(http.MaxBytesReader(nil, r.Body, 1<<20))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		return badRequest("invalid JSON body: %v", err)
+	}
+	return nil
+}
+
+func listOptions(r *http.Request) (storage.ListOptions, error) {
+	var opts storage.ListOptions
+	for _, p := range []struct {
+		name string
+		dst  *int
+	}{{"limit", &opts.Limit}, {"offset", &opts.Offset}} {
+		v := r.URL.Query().Get(p.name)
+		if v == "" {
+			continue
+		}
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return opts, badRequest("%s must be a non-negative integer", p.name)
+		}
+		*p.dst = n
+	}
+	if opts.Limit > maxListLimit {
+		opts.Limit = maxListLimit
+	}
+	return opts, nil
+}
+
+// nonNil makes empty listings encode as [] rather than null.
+func nonNil[T any](s []T, err error) (any, error) {
+	if err != nil {
+		return nil, err
+	}
+	if s == nil {
+		s = []T{}
+	}
+	return s, nil
+}
+
+// list runs a paged listing of the browse repo.
+func list[T any](s *server, r *http.Request, fn func(storage.BrowseRepo, storage.ListOptions) ([]T, error)) (any, error) {
+	opts, err := listOptions(r)
+	if err != nil {
+		return nil, err
+	}
+	browse, err := s.m.Browse()
+	if err != nil {
+		return nil, err
+	}
+	return nonNil(fn(browse, opts))
+}
+
+func (s *server) listEntities(r *http.Request) (any, error) {
+	return list(s, r, storage.BrowseRepo.ListEntities)
+}
+
+func (s *server) getEntity(r *http.Request) (any, error) {
+	browse, err := s.m.Browse()
+	if err != nil {
+		return nil, err
+	}
+	return browse.GetEntity(r.PathValue("entity_id"))
+}
+
+func (s *server) forgetEntity(r *http.Request) (any, error) {
+	return nil, s.m.Forget(r.PathValue("entity_id"))
+}
+
+func (s *server) exportEntity(r *http.Request) (any, error) {
+	return s.m.Export(r.PathValue("entity_id"))
+}
+
+func (s *server) listFacts(r *http.Request) (any, error) {
+	filter := storage.FactFilter{EntityID: r.PathValue("entity_id"), Query: r.URL.Query().Get("q")}
+	return list(s, r, func(b storage.BrowseRepo, o storage.ListOptions) ([]storage.FactInfo, error) {
+		return b.ListFacts(filter, o)
+	})
+}
+
+type recallRequest struct {
+	Query string `json:"query"`
+	Limit int    `json:"limit,omitempty"`
+}
+
+type recalledFact struct {
+	Content      string    `json:"content"`
+	Score        float64   `json:"score"`
+	NumTimes     int64     `json:"num_times"`
+	DateLastTime time.Time `json:"date_last_time"`
+}
+
+func (s *server) recall(r *http.Request) (any, error) {
+	var req recallRequest
+	if err := decodeBody(r, &req); err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(req.Query) == "" {
+		return nil, badRequest("query is required")
+	}
+	entity := r.PathValue("entity_id")
+	if len(entity) > 100 {
+		return nil, badRequest("entity_id is limited to 100 characters")
+	}
+	facts, err := s.m.Scoped(entity, "", uuid.Nil).Recall(req.Query, req.Limit)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]recalledFact, 0, len(facts))
+	for _, f := range facts {
+		out = append(out, recalledFact{Content: f.Content, Score: f.Score, NumTimes: f.NumTimes, DateLastTime: f.DateLastTime})
+	}
+	return out, nil
+}
+
+func (s *server) listProcesses(r *http.Request) (any, error) {
+	return list(s, r, storage.BrowseRepo.ListProcesses)
+}
+
+func (s *server) listSessions(r *http.Request) (any, error) {
+	filter := storage.SessionFilter{EntityID: r.URL.Query().Get("entity_id"), ProcessID: r.URL.Query().Get("process_id")}
+	return list(s, r, func(b storage.BrowseRepo, o storage.ListOptions) ([]storage.SessionInfo, error) {
+		return b.ListSessions(filter, o)
+	})
+}
+
+func (s *server) listConversations(r *http.Request) (any, error) {
+	return list(s, r, func(b storage.BrowseRepo, o storage.ListOptions) ([]storage.ConversationInfo, error) {
+		return b.ListConversations(r.PathValue("session_id"), o)
+	})
+}
+
+func (s *server) listMessages(r *http.Request) (any, error) {
+	return list(s, r, func(b storage.BrowseRepo, o storage.ListOptions) ([]storage.MessageInfo, error) {
+		return b.ListMessages(r.PathValue("conversation_id"), o)
+	})
+}
+
+func (s *server) getFact(r *http.Request) (any, error) {
+	browse, err := s.m.Browse()
+	if err != nil {
+		return nil, err
+	}
+	return browse.GetFact(r.PathValue("fact_id"))
+}
+
+type updateFactRequest struct {
+	Content string `json:"content"`
+}
+
+func (s *server) updateFact(r *http.Request) (any, error) {
+	var req updateFactRequest
+	if err := decodeBody(r, &req); err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(req.Content) == "" {
+		return nil, badRequest("content is required")
+	}
+	return s.m.UpdateFact(r.Context(), r.PathValue("fact_id"), req.Content)
+}
+
+func (s *server) deleteFact(r *http.Request) (any, error) {
+	browse, err := s.m.Browse()
+	if err != nil {
+		return nil, err
+	}
+	return nil, browse.DeleteFact(r.PathValue("fact_id"))
+}
+
+func (s *server) augmentationStatus(r *http.Request) (any, error) {
+	return s.m.Augmentation.Status(), nil
+}
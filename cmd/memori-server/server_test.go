@@ -0,0 +1,188 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	_ "modernc.org/sqlite"
+
+	"memorigo/memori"
+	"memorigo/storage"
+)
+
+func TestServer(t *testing.T) {
+	db, err := sql.Open("sqlite", "file:memori_server?mode=memory&cache=shared")
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	defer db.Close()
+	m := memori.New(memori.WithStorageConn(db))
+	if err := m.Storage.Build(); err != nil {
+		t.Fatalf("migrate/build: %v", err)
+	}
+
+	alice := m.Scoped("alice", "support-bot", uuid.Nil)
+	if err := memori.NewWriter(alice).Execute(context.Background(), memori.ConversationPayload{
+		Messages: []memori.Message{{Role: "user", Content: "My favorite color is blue"}},
+		Response: &memori.Message{Role: "assistant", Content: "Noted."},
+	}); err != nil {
+		t.Fatalf("seed: %v", err)
+	}
+	for deadline := time.Now().Add(5 * time.Second); ; {
+		if facts, _ := alice.Recall("favorite color", 1); len(facts) > 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("seed fact was not augmented")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	s := &server{m: m, apiKey: "secret"}
+	srv := httptest.NewServer(s.handler())
+	defer srv.Close()
+
+	call := func(method, path, body string, want int, out any) {
+		t.Helper()
+		req, _ := http.NewRequest(method, srv.URL+path, strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer secret")
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("%s %s: %v", method, path, err)
+		}
+		defer resp.Body.Close()
+		b, _ := io.ReadAll(resp.Body)
+		if resp.StatusCode != want {
+			t.Fatalf("%s %s: status %d, want %d: %s", method, path, resp.StatusCode, want, b)
+		}
+		if out != nil {
+			if err := json.Unmarshal(b, out); err != nil {
+				t.Fatalf("%s %s: decode %s: %v", method, path, b, err)
+			}
+		}
+	}
+
+	var entities []storage.EntityInfo
+	call("GET", "/v1/entities", "", http.StatusOK, &entities)
+	if len(entities) != 1 || entities[0].ExternalID != "alice" || entities[0].DateCreated.IsZero() {
+		t.Fatalf("entities = %+v", entities)
+	}
+
+	var sessions []storage.SessionInfo
+	call("GET", "/v1/sessions?entity_id=alice", "", http.StatusOK, &sessions)
+	if len(sessions) != 1 || sessions[0].ProcessID != "support-bot" {
+		t.Fatalf("sessions = %+v", sessions)
+	}
+	var convs []storage.ConversationInfo
+	call("GET", "/v1/sessions/"+sessions[0].UUID+"/conversations", "", http.StatusOK, &convs)
+	if len(convs) != 1 {
+		t.Fatalf("conversations = %+v", convs)
+	}
+	var msgs []storage.MessageInfo
+	call("GET", "/v1/conversations/"+convs[0].UUID+"/messages?limit=1&offset=1", "", http.StatusOK, &msgs)
+	if len(msgs) != 1 || msgs[0].Role != "assistant" || msgs[0].Content != "Noted." {
+		t.Fatalf("messages = %+v", msgs)
+	}
+
+	var facts []storage.FactInfo
+	call("GET", "/v1/entities/alice/facts?q=COLOR", "", http.StatusOK, &facts)
+	if len(facts) != 1 || facts[0].Embedding.Dimension == 0 {
+		t.Fatalf("facts = %+v", facts)
+	}
+	call("GET", "/v1/entities/alice/facts?q=green", "", http.StatusOK, &facts)
+	if len(facts) != 0 {
+		t.Fatalf("facts matching green = %+v", facts)
+	}
+	call("GET", "/v1/entities/alice/facts", "", http.StatusOK, &facts)
+	factPath := "/v1/facts/" + facts[0].UUID
+
+	var fact storage.FactInfo
+	call("PATCH", factPath, `{"content":"My favorite color is green"}`, http.StatusOK, &fact)
+	if fact.Content != "My favorite color is green" {
+		t.Fatalf("updated fact = %+v", fact)
+	}
+	call("PATCH", factPath, `{"text":"x"}`, http.StatusBadRequest, nil)
+
+	var recalled []recalledFact
+	call("POST", "/v1/entities/alice/recall", `{"query":"favorite color","limit":3}`, http.StatusOK, &recalled)
+	if len(recalled) != 1 || recalled[0].Content != "My favorite color is green" {
+		t.Fatalf("recall = %+v", recalled)
+	}
+
+	var exp memori.EntityExport
+	call("GET", "/v1/entities/alice/export", "", http.StatusOK, &exp)
+	if len(exp.Facts) != 1 || len(exp.Sessions) != 1 || len(exp.Sessions[0].Conversations[0].Messages) != 2 {
+		t.Fatalf("export = %+v", exp)
+	}
+
+	var status memori.AugmentationStatus
+	call("GET", "/v1/augmentation", "", http.StatusOK, &status)
+	if !status.Started || status.Processed < 1 {
+		t.Fatalf("augmentation status = %+v", status)
+	}
+
+	call("DELETE", factPath, "", http.StatusNoContent, nil)
+	call("GET", factPath, "", http.StatusNotFound, nil)
+	call("DELETE", "/v1/entities/alice", "", http.StatusNoContent, nil)
+	call("GET", "/v1/entities/alice", "", http.StatusNotFound, nil)
+	call("GET", "/v1/sessions", "", http.StatusOK, &sessions)
+	if len(sessions) != 0 {
+		t.Fatalf("sessions after forget = %+v", sessions)
+	}
+
+	// the API key is required, the spec is public
+	resp, err := http.Get(srv.URL + "/v1/entities")
+	if err != nil || resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("without key: %v %v", resp.StatusCode, err)
+	}
+	resp.Body.Close()
+	req, _ := http.NewRequest("GET", srv.URL+"/v1/entities", nil)
+	req.Header.Set("X-API-Key", "secret")
+	if resp, err := http.DefaultClient.Do(req); err != nil || resp.StatusCode != http.StatusOK {
+		t.Fatalf("X-API-Key: %v %v", resp.StatusCode, err)
+	}
+	resp, err = http.Get(srv.URL + "/openapi.json")
+	if err != nil {
+		t.Fatalf("openapi: %v", err)
+	}
+	defer resp.Body.Close()
+	var spec struct {
+		Paths map[string]map[string]any `json:"paths"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&spec); err != nil {
+		t.Fatalf("decode openapi: %v", err)
+	}
+	for _, rt := range s.routes() {
+		if spec.Paths[rt.path][strings.ToLower(rt.method)] == nil {
+			t.Fatalf("openapi is missing %s %s", rt.method, rt.path)
+		}
+	}
+}
+
+func TestCheckExposure(t *testing.T) {
+	for _, tc := range []struct {
+		apiKey string
+		addrs  []string
+		ok     bool
+	}{
+		{"", []string{"127.0.0.1:8788", ""}, true},
+		{"", []string{"localhost:8788", "[::1]:8789"}, true},
+		{"", []string{":8788", ""}, false},
+		{"", []string{"0.0.0.0:8788"}, false},
+		{"", []string{"127.0.0.1:8788", ":8789"}, false}, // gRPC open
+		{"secret", []string{":8788", ":8789"}, true},
+		{"", []string{"not-an-address"}, false},
+	} {
+		if err := checkExposure(tc.apiKey, tc.addrs...); (err == nil) != tc.ok {
+			t.Errorf("checkExposure(%q, %q) = %v", tc.apiKey, tc.addrs, err)
+		}
+	}
+}
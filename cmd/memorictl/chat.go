@@ -0,0 +1,399 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"memorigo/memori"
+	"memorigo/storage"
+)
+
+const chatHelp = `commands:
+  /facts [text]          list the entity's facts, or those containing text
+  /pin <n|uuid|text>     inject a fact in every turn; text is remembered first
+  /unpin <n|uuid>        stop injecting a pinned fact
+  /forget <n|uuid>       delete a fact
+  /new                   start a new session with an empty history
+  /help                  show this help
+  /quit                  leave (as does end of input)
+[n] refers to a fact shown earlier in this chat.`
+
+// chat is the state of a memorictl chat session. Every fact shown gets a
+// number, kept for the whole chat, that commands accept in place of its uuid.
+type chat struct {
+	a       *app
+	m       *memori.Memori // scoped to the entity, process and session
+	browse  storage.BrowseRepo
+	entity  string
+	model   string
+	recall  int
+	wait    time.Duration
+	history []memori.ChatMessage
+	facts   <-chan memori.FactEvent
+
+	refs   []storage.FactInfo
+	refOf  map[string]int // fact uuid -> index in refs
+	pinned []string       // uuids of the pinned facts, in pin order
+}
+
+func runChat(ctx context.Context, a *app, args []string) error {
+	const usage = "chat -entity E [-process P] [-model M] [-base-url URL] [-api-key KEY] [-recall N] [-system TEXT]"
+	fs := a.flags("chat")
+	entity := fs.String("entity", "", "entity external id the chat is attributed to")
+	process := fs.String("process", "memorictl", "process external id the chat is attributed to")
+	model := fs.String("model", envOr("OPENAI_MODEL", "gpt-4o-mini"), "chat model")
+	baseURL := fs.String("base-url", envOr("OPENAI_BASE_URL", "https://api.openai.com"), "OpenAI-compatible base URL")
+	apiKey := fs.String("api-key", os.Getenv("OPENAI_API_KEY"), "API key of the endpoint")
+	recall := fs.Int("recall", 5, "facts recalled and injected per turn, 0 disables recall")
+	system := fs.String("system", "", "system prompt")
+	wait := fs.Duration("wait", 10*time.Second, "how long to wait for augmentation after each turn")
+	pos, err := parse(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(pos) != 0 || *entity == "" {
+		return usageError(usage)
+	}
+	if err := a.open(ctx, true); err != nil {
+		return err
+	}
+	browse, err := a.browse()
+	if err != nil {
+		return err
+	}
+
+	a.m.OpenAI.Register(memori.NewOpenAICompatClient(memori.OpenAICompatOptions{BaseURL: *baseURL, APIKey: *apiKey}))
+	c := &chat{
+		a:      a,
+		m:      a.m.Scoped(*entity, *process, uuid.Nil),
+		browse: browse,
+		entity: *entity,
+		model:  *model,
+		recall: *recall,
+		wait:   *wait,
+		facts:  a.m.Augmentation.WatchFacts(ctx, *entity),
+		refOf:  make(map[string]int),
+	}
+	if *system != "" {
+		c.history = append(c.history, memori.ChatMessage{Role: "system", Content: *system})
+	}
+
+	fmt.Fprintf(a.stdout, "chatting as %s with %s at %s, session %s; /help for commands\n", *entity, *model, *baseURL, c.m.Config.SessionID)
+	in := bufio.NewScanner(a.stdin)
+	in.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for {
+		fmt.Fprint(a.stdout, "you> ")
+		if !in.Scan() {
+			fmt.Fprintln(a.stdout)
+			return in.Err()
+		}
+		line := strings.TrimSpace(in.Text())
+		switch {
+		case line == "":
+			continue
+		case strings.HasPrefix(line, "/"):
+			name, arg, _ := strings.Cut(line[1:], " ")
+			if name == "quit" || name == "exit" {
+				return nil
+			}
+			err = c.command(ctx, name, strings.TrimSpace(arg))
+		default:
+			err = c.turn(ctx, line)
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err != nil {
+			fmt.Fprintf(a.stdout, "error: %v\n", err)
+		}
+	}
+}
+
+// turn sends one user message with the pinned and recalled facts injected,
+// prints the reply, then waits for augmentation and prints the facts it wrote.
+func (c *chat) turn(ctx context.Context, text string) error {
+	injected, err := c.memories(text)
+	if err != nil {
+		return err
+	}
+	req := memori.ChatCompletionsRequest{Model: c.model, Messages: c.withMemories(injected, memori.ChatMessage{Role: "user", Content: text})}
+
+	before := c.m.Augmentation.Status().Processed
+	resp, err := c.m.OpenAIClient().ChatCompletionsCreate(ctx, req)
+	if err != nil {
+		return err
+	}
+	reply := ""
+	if len(resp.Choices) > 0 {
+		reply = resp.Choices[0].Message.Content
+	}
+	c.history = append(c.history, memori.ChatMessage{Role: "user", Content: text}, memori.ChatMessage{Role: "assistant", Content: reply})
+	fmt.Fprintf(c.a.stdout, "assistant> %s\n", reply)
+
+	c.settle(ctx, before)
+	var extracted []storage.FactInfo
+	seen := map[string]bool{}
+	for _, ev := range c.drain() {
+		if seen[ev.Content] {
+			continue
+		}
+		seen[ev.Content] = true
+		if f, ok := c.lookup(ev.Content); ok {
+			extracted = append(extracted, f)
+		}
+	}
+	if len(extracted) > 0 {
+		fmt.Fprintln(c.a.stdout, "  extracted:")
+		for _, f := range extracted {
+			mark := "new"
+			if f.NumTimes > 1 {
+				mark = fmt.Sprintf("x%d", f.NumTimes)
+			}
+			fmt.Fprintf(c.a.stdout, "    %s %-5s %s\n", c.ref(f), mark, oneLine(f.Content, 100))
+		}
+	}
+	return nil
+}
+
+// memories returns the facts to inject for a user message, the pinned ones
+// first, and prints them.
+func (c *chat) memories(query string) ([]storage.FactInfo, error) {
+	var out []storage.FactInfo
+	seen := map[string]bool{}
+	if len(c.pinned) > 0 {
+		fmt.Fprintln(c.a.stdout, "  pinned:")
+		for _, id := range c.pinned {
+			f := c.refs[c.refOf[id]]
+			seen[f.Content] = true
+			out = append(out, f)
+			fmt.Fprintf(c.a.stdout, "    %s %s\n", c.ref(f), oneLine(f.Content, 100))
+		}
+	}
+	if c.recall <= 0 {
+		return out, nil
+	}
+	facts, err := c.m.Recall(query, c.recall)
+	if err != nil {
+		return nil, err
+	}
+	header := false
+	for _, rf := range facts {
+		// as memori-proxy, only facts with a positive score are injected
+		if rf.Score <= 0 || seen[rf.Content] {
+			continue
+		}
+		f, ok := c.lookup(rf.Content)
+		if !ok {
+			continue
+		}
+		seen[f.Content] = true
+		out = append(out, f)
+		if !header {
+			fmt.Fprintln(c.a.stdout, "  recalled:")
+			header = true
+		}
+		fmt.Fprintf(c.a.stdout, "    %s %.3f %s\n", c.ref(f), rf.Score, oneLine(f.Content, 100))
+	}
+	return out, nil
+}
+
+// withMemories returns the history followed by msg, with the facts in a
+// system message after the leading system messages, as memori-proxy injects
+// them. The Writer does not store system messages, so they are never
+// re-extracted.
+func (c *chat) withMemories(facts []storage.FactInfo, msg memori.ChatMessage) []memori.ChatMessage {
+	msgs := make([]memori.ChatMessage, 0, len(c.history)+2)
+	at := 0
+	for at < len(c.history) && c.history[at].Role == "system" {
+		at++
+	}
+	msgs = append(msgs, c.history[:at]...)
+	if len(facts) > 0 {
+		var b strings.Builder
+		b.WriteString("Relevant memories about the user from earlier conversations:")
+		for _, f := range facts {
+			b.WriteString("\n- ")
+			b.WriteString(f.Content)
+		}
+		msgs = append(msgs, memori.ChatMessage{Role: "system", Content: b.String()})
+	}
+	msgs = append(msgs, c.history[at:]...)
+	return append(msgs, msg)
+}
+
+// settle waits until augmentation has processed an input since before and
+// the queue is idle, or until the wait times out.
+func (c *chat) settle(ctx context.Context, before int64) {
+	deadline := time.Now().Add(c.wait)
+	for time.Now().Before(deadline) && ctx.Err() == nil {
+		st := c.m.Augmentation.Status()
+		if st.Processed > before && st.Queued == 0 && st.InFlight == 0 {
+			return
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+// drain returns the fact events received so far.
+func (c *chat) drain() []memori.FactEvent {
+	var evs []memori.FactEvent
+	for {
+		select {
+		case ev := <-c.facts:
+			evs = append(evs, ev)
+		default:
+			return evs
+		}
+	}
+}
+
+func (c *chat) command(ctx context.Context, name, arg string) error {
+	switch name {
+	case "help":
+		fmt.Fprintln(c.a.stdout, chatHelp)
+	case "facts":
+		facts, err := c.browse.ListFacts(storage.FactFilter{EntityID: c.entity, Query: arg}, storage.ListOptions{})
+		if err != nil && !errors.Is(err, storage.ErrNotFound) {
+			return err
+		}
+		if len(facts) == 0 {
+			fmt.Fprintln(c.a.stdout, "  no facts")
+		}
+		for _, f := range facts {
+			fmt.Fprintf(c.a.stdout, "    %s x%-3d %s\n", c.ref(f), f.NumTimes, oneLine(f.Content, 100))
+		}
+	case "pin":
+		if arg == "" {
+			return fmt.Errorf("usage: /pin <n|uuid|text>")
+		}
+		f, err := c.resolve(arg)
+		if err != nil && !isRef(arg) {
+			// Remember publishes the fact like augmentation does: keep it
+			// out of the next turn's extracted facts
+			f, err = c.m.Remember(ctx, arg)
+			c.drain()
+		}
+		if err != nil {
+			return err
+		}
+		if !c.isPinned(f.UUID) {
+			c.pinned = append(c.pinned, f.UUID)
+		}
+		fmt.Fprintf(c.a.stdout, "  pinned %s %s\n", c.ref(f), oneLine(f.Content, 100))
+	case "unpin":
+		f, err := c.resolve(arg)
+		if err != nil {
+			return err
+		}
+		if !c.isPinned(f.UUID) {
+			return fmt.Errorf("%s is not pinned", c.ref(f))
+		}
+		c.unpin(f.UUID)
+		fmt.Fprintf(c.a.stdout, "  unpinned %s\n", c.ref(f))
+	case "forget":
+		f, err := c.resolve(arg)
+		if err != nil {
+			return err
+		}
+		if err := c.browse.DeleteFact(f.UUID); err != nil {
+			return err
+		}
+		c.unpin(f.UUID)
+		fmt.Fprintf(c.a.stdout, "  forgot %s %s\n", c.ref(f), oneLine(f.Content, 100))
+	case "new":
+		c.m = c.a.m.Scoped(c.entity, c.m.Config.ProcessID, uuid.Nil)
+		at := 0
+		for at < len(c.history) && c.history[at].Role == "system" {
+			at++
+		}
+		c.history = c.history[:at]
+		fmt.Fprintf(c.a.stdout, "  session %s\n", c.m.Config.SessionID)
+	default:
+		return fmt.Errorf("unknown command /%s, see /help", name)
+	}
+	return nil
+}
+
+// resolve returns the fact a command argument refers to: a number shown in
+// this chat or the uuid of one of the entity's facts.
+func (c *chat) resolve(arg string) (storage.FactInfo, error) {
+	if n, ok := refNumber(arg); ok {
+		if n < 1 || n > len(c.refs) {
+			return storage.FactInfo{}, fmt.Errorf("no fact [%d]", n)
+		}
+		return c.refs[n-1], nil
+	}
+	if !isRef(arg) {
+		return storage.FactInfo{}, fmt.Errorf("%q is neither a fact number nor a uuid", arg)
+	}
+	f, err := c.browse.GetFact(arg)
+	if err != nil {
+		return storage.FactInfo{}, err
+	}
+	if f.EntityID != c.entity {
+		return storage.FactInfo{}, fmt.Errorf("fact %s belongs to another entity", arg)
+	}
+	return f, nil
+}
+
+func refNumber(arg string) (int, bool) {
+	n, err := strconv.Atoi(strings.Trim(arg, "[]"))
+	return n, err == nil
+}
+
+// isRef reports whether arg is a fact number or a uuid rather than text.
+func isRef(arg string) bool {
+	if _, ok := refNumber(arg); ok {
+		return true
+	}
+	_, err := uuid.Parse(arg)
+	return err == nil
+}
+
+// lookup finds the entity's fact with exactly this content.
+func (c *chat) lookup(content string) (storage.FactInfo, bool) {
+	facts, err := c.browse.ListFacts(storage.FactFilter{EntityID: c.entity, Query: content}, storage.ListOptions{})
+	if err != nil {
+		return storage.FactInfo{}, false
+	}
+	for _, f := range facts {
+		if f.Content == content {
+			return f, true
+		}
+	}
+	return storage.FactInfo{}, false
+}
+
+// ref returns the number of f in this chat, numbering it when first shown.
+// Pinned facts are marked with a star.
+func (c *chat) ref(f storage.FactInfo) string {
+	i, ok := c.refOf[f.UUID]
+	if !ok {
+		i = len(c.refs)
+		c.refs = append(c.refs, f)
+		c.refOf[f.UUID] = i
+	} else {
+		c.refs[i] = f
+	}
+	if c.isPinned(f.UUID) {
+		return fmt.Sprintf("[%d]*", i+1)
+	}
+	return fmt.Sprintf("[%d]", i+1)
+}
+
+func (c *chat) isPinned(id string) bool {
+	return slices.Contains(c.pinned, id)
+}
+
+func (c *chat) unpin(id string) {
+	c.pinned = slices.DeleteFunc(c.pinned, func(p string) bool { return p == id })
+}
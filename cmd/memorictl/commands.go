@@ -0,0 +1,481 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/google/uuid"
+
+	"memorigo/memori"
+	"memorigo/storage"
+)
+
+// print writes v as JSON, or rows under header as an aligned table.
+func (a *app) print(v any, header []string, rows [][]string) error {
+	if a.output == "json" {
+		enc := json.NewEncoder(a.stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(v)
+	}
+	tw := tabwriter.NewWriter(a.stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, strings.Join(header, "\t"))
+	for _, row := range rows {
+		fmt.Fprintln(tw, strings.Join(row, "\t"))
+	}
+	return tw.Flush()
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.Local().Format("2006-01-02 15:04:05")
+}
+
+func runMigrate(ctx context.Context, a *app, args []string) error {
+	const usage = "migrate status|up|down|dry-run [-to N]"
+	fs := a.flags("migrate")
+	to := fs.Int("to", -1, "target version; up and dry-run default to the latest, down to one below the current")
+	pos, err := parse(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(pos) != 1 {
+		return usageError(usage)
+	}
+	if err := a.open(ctx, false); err != nil {
+		return err
+	}
+	mig, err := a.m.Storage.Migrator()
+	if err != nil {
+		return err
+	}
+	version, err := mig.Version()
+	if err != nil {
+		return err
+	}
+
+	target := *to
+	switch pos[0] {
+	case "status":
+		steps, err := mig.Plan(mig.Latest())
+		if err != nil {
+			return err
+		}
+		pending := []int{}
+		for _, s := range steps {
+			pending = append(pending, s.Version)
+		}
+		status := struct {
+			Dialect string `json:"dialect"`
+			Version int    `json:"version"`
+			Latest  int    `json:"latest"`
+			Pending []int  `json:"pending"`
+		}{a.m.Storage.Dialect(), version, mig.Latest(), pending}
+		return a.print(status, []string{"DIALECT", "VERSION", "LATEST", "PENDING"},
+			[][]string{{status.Dialect, strconv.Itoa(version), strconv.Itoa(status.Latest), strings.Trim(fmt.Sprint(pending), "[]")}})
+	case "up", "dry-run":
+		if target < 0 {
+			target = mig.Latest()
+		}
+	case "down":
+		if target < 0 {
+			target = version - 1
+		}
+		if target < 0 || target >= version {
+			return fmt.Errorf("down needs a target below the current version %d", version)
+		}
+	default:
+		return usageError(usage)
+	}
+
+	steps, err := mig.Plan(target)
+	if err != nil {
+		return err
+	}
+	if pos[0] == "dry-run" {
+		if a.output == "json" {
+			return a.print(steps, nil, nil)
+		}
+		for _, s := range steps {
+			dir := "up"
+			if s.Down {
+				dir = "down"
+			}
+			fmt.Fprintf(a.stdout, "-- version %d (%s)\n", s.Version, dir)
+			for _, op := range s.Ops {
+				fmt.Fprintf(a.stdout, "%s;\n", strings.TrimSpace(op))
+			}
+		}
+		return nil
+	}
+	if err := mig.Migrate(target); err != nil {
+		return err
+	}
+	after, err := mig.Version()
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(a.stderr, "schema migrated from version %d to %d (%d steps)\n", version, after, len(steps))
+	return nil
+}
+
+func runEntities(ctx context.Context, a *app, args []string) error {
+	fs := a.flags("entities")
+	limit := fs.Int("limit", storage.DefaultListLimit, "maximum number of entities")
+	offset := fs.Int("offset", 0, "number of entities to skip")
+	if _, err := parse(fs, args); err != nil {
+		return err
+	}
+	if err := a.open(ctx, true); err != nil {
+		return err
+	}
+	browse, err := a.browse()
+	if err != nil {
+		return err
+	}
+	entities, err := browse.ListEntities(storage.ListOptions{Limit: *limit, Offset: *offset})
+	if err != nil {
+		return err
+	}
+	rows := make([][]string, 0, len(entities))
+	for _, e := range entities {
+		rows = append(rows, []string{e.ExternalID, e.UUID, formatTime(e.DateCreated)})
+	}
+	return a.print(nonNil(entities), []string{"ENTITY", "UUID", "CREATED"}, rows)
+}
+
+func runFacts(ctx context.Context, a *app, args []string) error {
+	const usage = "facts list -entity E | search -entity E <text> | delete <fact-uuid>..."
+	fs := a.flags("facts")
+	entity := fs.String("entity", "", "entity external id")
+	limit := fs.Int("limit", storage.DefaultListLimit, "maximum number of facts")
+	offset := fs.Int("offset", 0, "number of facts to skip")
+	pos, err := parse(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(pos) == 0 {
+		return usageError(usage)
+	}
+	if err := a.open(ctx, true); err != nil {
+		return err
+	}
+	browse, err := a.browse()
+	if err != nil {
+		return err
+	}
+
+	filter := storage.FactFilter{EntityID: *entity}
+	switch {
+	case pos[0] == "list" && len(pos) == 1:
+	case pos[0] == "search" && len(pos) == 2:
+		filter.Query = pos[1]
+	case pos[0] == "delete" && len(pos) > 1:
+		for _, id := range pos[1:] {
+			if err := browse.DeleteFact(id); err != nil {
+				return fmt.Errorf("fact %s: %w", id, err)
+			}
+		}
+		fmt.Fprintf(a.stderr, "deleted %d facts\n", len(pos)-1)
+		return nil
+	default:
+		return usageError(usage)
+	}
+	if *entity == "" {
+		return fmt.Errorf("-entity is required")
+	}
+	if _, err := browse.GetEntity(*entity); err != nil {
+		return fmt.Errorf("entity %s: %w", *entity, err)
+	}
+	facts, err := browse.ListFacts(filter, storage.ListOptions{Limit: *limit, Offset: *offset})
+	if err != nil {
+		return err
+	}
+	rows := make([][]string, 0, len(facts))
+	for _, f := range facts {
+		rows = append(rows, []string{f.UUID, strconv.FormatInt(f.NumTimes, 10), formatTime(f.DateLastTime), oneLine(f.Content, 80)})
+	}
+	return a.print(nonNil(facts), []string{"UUID", "MENTIONS", "LAST MENTIONED", "CONTENT"}, rows)
+}
+
+func runConversations(ctx context.Context, a *app, args []string) error {
+	const usage = "conversations list -entity E [-limit N] | show <conversation-uuid>"
+	fs := a.flags("conversations")
+	entity := fs.String("entity", "", "entity external id")
+	limit := fs.Int("limit", 20, "maximum number of conversations, most recent first")
+	pos, err := parse(fs, args)
+	if err != nil {
+		return err
+	}
+	if err := a.open(ctx, true); err != nil {
+		return err
+	}
+
+	switch {
+	case len(pos) == 1 && pos[0] == "list":
+		if *entity == "" {
+			return fmt.Errorf("-entity is required")
+		}
+		convs, err := a.m.RecentConversations(*entity, *limit)
+		if err != nil {
+			return fmt.Errorf("entity %s: %w", *entity, err)
+		}
+		rows := make([][]string, 0, len(convs))
+		for _, c := range convs {
+			updated := "-"
+			if c.DateUpdated != nil {
+				updated = formatTime(*c.DateUpdated)
+			}
+			rows = append(rows, []string{c.UUID, c.SessionID, formatTime(c.DateCreated), updated, oneLine(c.Summary, 60)})
+		}
+		return a.print(nonNil(convs), []string{"UUID", "SESSION", "CREATED", "UPDATED", "SUMMARY"}, rows)
+
+	case len(pos) == 2 && pos[0] == "show":
+		browse, err := a.browse()
+		if err != nil {
+			return err
+		}
+		var msgs []storage.MessageInfo
+		for offset := 0; ; offset += storage.DefaultListLimit {
+			page, err := browse.ListMessages(pos[1], storage.ListOptions{Limit: storage.DefaultListLimit, Offset: offset})
+			if err != nil {
+				return fmt.Errorf("conversation %s: %w", pos[1], err)
+			}
+			msgs = append(msgs, page...)
+			if len(page) < storage.DefaultListLimit {
+				break
+			}
+		}
+		if a.output == "json" {
+			return a.print(nonNil(msgs), nil, nil)
+		}
+		for _, m := range msgs {
+			role := m.Role
+			if m.Type != "" && m.Type != "text" {
+				role += " (" + m.Type + ")"
+			}
+			if m.Truncated {
+				role += " [truncated]"
+			}
+			fmt.Fprintf(a.stdout, "%s  %s\n%s\n\n", formatTime(m.DateCreated), role, strings.TrimSpace(m.Content))
+		}
+		return nil
+	}
+	return usageError(usage)
+}
+
+func runRecall(ctx context.Context, a *app, args []string) error {
+	const usage = "recall <query> -entity E [-limit N]"
+	fs := a.flags("recall")
+	entity := fs.String("entity", "", "entity external id")
+	limit := fs.Int("limit", 5, "maximum number of facts")
+	pos, err := parse(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(pos) == 0 || *entity == "" {
+		return usageError(usage)
+	}
+	if err := a.open(ctx, true); err != nil {
+		return err
+	}
+	facts, err := a.m.Scoped(*entity, "", uuid.Nil).Recall(strings.Join(pos, " "), *limit)
+	if err != nil {
+		return err
+	}
+	type recalled struct {
+		Content      string    `json:"content"`
+		Score        float64   `json:"score"`
+		NumTimes     int64     `json:"num_times"`
+		DateLastTime time.Time `json:"date_last_time"`
+	}
+	out := []recalled{}
+	rows := make([][]string, 0, len(facts))
+	for _, f := range facts {
+		out = append(out, recalled{f.Content, f.Score, f.NumTimes, f.DateLastTime})
+		rows = append(rows, []string{strconv.FormatFloat(f.Score, 'f', 3, 64), strconv.FormatInt(f.NumTimes, 10), oneLine(f.Content, 80)})
+	}
+	return a.print(out, []string{"SCORE", "MENTIONS", "CONTENT"}, rows)
+}
+
+func runExport(ctx context.Context, a *app, args []string) error {
+	fs := a.flags("export")
+	entity := fs.String("entity", "", "entity external id")
+	out := fs.String("out", "-", "output file, - for stdout")
+	if _, err := parse(fs, args); err != nil {
+		return err
+	}
+	if *entity == "" {
+		return usageError("export -entity E [-out FILE]")
+	}
+	if err := a.open(ctx, true); err != nil {
+		return err
+	}
+	exp, err := a.m.Export(*entity)
+	if err != nil {
+		return fmt.Errorf("entity %s: %w", *entity, err)
+	}
+	w := a.stdout
+	if *out != "-" {
+		f, err := os.Create(*out)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(exp); err != nil {
+		return err
+	}
+	if c, ok := w.(io.Closer); ok && w != a.stdout {
+		return c.Close()
+	}
+	return nil
+}
+
+func runImport(ctx context.Context, a *app, args []string) error {
+	fs := a.flags("import")
+	in := fs.String("in", "-", "export file, - for stdin")
+	if _, err := parse(fs, args); err != nil {
+		return err
+	}
+	r := a.stdin
+	if *in != "-" {
+		f, err := os.Open(*in)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r = f
+	}
+	var exp memori.EntityExport
+	if err := json.NewDecoder(r).Decode(&exp); err != nil {
+		return fmt.Errorf("decode export: %w", err)
+	}
+	if err := a.open(ctx, true); err != nil {
+		return err
+	}
+	res, err := a.m.Import(ctx, exp)
+	if err != nil {
+		return err
+	}
+	return a.print(res, []string{"ENTITY", "SESSIONS", "SKIPPED SESSIONS", "CONVERSATIONS", "MESSAGES", "FACTS", "SKIPPED FACTS"},
+		[][]string{{exp.Entity.ExternalID, strconv.Itoa(res.Sessions), strconv.Itoa(res.SkippedSessions),
+			strconv.Itoa(res.Conversations), strconv.Itoa(res.Messages), strconv.Itoa(res.Facts), strconv.Itoa(res.SkippedFacts)}})
+}
+
+func runReembed(ctx context.Context, a *app, args []string) error {
+	fs := a.flags("reembed")
+	if _, err := parse(fs, args); err != nil {
+		return err
+	}
+	if err := a.open(ctx, true); err != nil {
+		return err
+	}
+	res, err := a.m.Reembed(ctx)
+	if err != nil {
+		return err
+	}
+	out := struct {
+		Target     storage.EmbeddingMeta `json:"target"`
+		Reembedded int                   `json:"reembedded"`
+	}{res.Target, res.Reembedded}
+	return a.print(out, []string{"PROVIDER", "MODEL", "DIMENSION", "REEMBEDDED"},
+		[][]string{{res.Target.Provider, res.Target.Model, strconv.Itoa(res.Target.Dimension), strconv.Itoa(res.Reembedded)}})
+}
+
+func runStats(ctx context.Context, a *app, args []string) error {
+	fs := a.flags("stats")
+	by := fs.String("by", "", "comma-separated groups: entity, process, session, model")
+	var q memori.UsageQuery
+	fs.StringVar(&q.EntityID, "entity", "", "only this entity")
+	fs.StringVar(&q.ProcessID, "process", "", "only this process")
+	fs.StringVar(&q.SessionID, "session", "", "only this session uuid")
+	fs.StringVar(&q.Model, "model", "", "only this model")
+	since := fs.String("since", "", "only calls at or after this time (RFC 3339 or 2006-01-02)")
+	until := fs.String("until", "", "only calls before this time (RFC 3339 or 2006-01-02)")
+	if _, err := parse(fs, args); err != nil {
+		return err
+	}
+	var err error
+	if q.Since, err = parseTime(*since); err != nil {
+		return err
+	}
+	if q.Until, err = parseTime(*until); err != nil {
+		return err
+	}
+	for _, g := range strings.Split(*by, ",") {
+		if g = strings.TrimSpace(g); g != "" {
+			q.GroupBy = append(q.GroupBy, memori.UsageGroup(g))
+		}
+	}
+	if err := a.open(ctx, true); err != nil {
+		return err
+	}
+	rows, err := a.m.Usage(q)
+	if err != nil {
+		return err
+	}
+
+	header := []string{}
+	for _, g := range q.GroupBy {
+		header = append(header, strings.ToUpper(string(g)))
+	}
+	header = append(header, "CALLS", "PROMPT", "COMPLETION", "TOTAL", "COST")
+	table := make([][]string, 0, len(rows))
+	for _, r := range rows {
+		var row []string
+		for _, g := range q.GroupBy {
+			switch g {
+			case memori.UsageByEntity:
+				row = append(row, r.EntityID)
+			case memori.UsageByProcess:
+				row = append(row, r.ProcessID)
+			case memori.UsageBySession:
+				row = append(row, r.SessionID)
+			case memori.UsageByModel:
+				row = append(row, r.Model)
+			}
+		}
+		cost := fmt.Sprintf("%.4f", r.Cost)
+		if len(r.UnpricedModels) > 0 {
+			cost += " (unpriced: " + strings.Join(r.UnpricedModels, ", ") + ")"
+		}
+		row = append(row, strconv.FormatInt(r.Calls, 10), strconv.FormatInt(r.PromptTokens, 10),
+			strconv.FormatInt(r.CompletionTokens, 10), strconv.FormatInt(r.TotalTokens, 10), cost)
+		table = append(table, row)
+	}
+	return a.print(nonNil(rows), header, table)
+}
+
+func parseTime(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	t, err := time.ParseInLocation("2006-01-02", s, time.Local)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time %q, want RFC 3339 or 2006-01-02", s)
+	}
+	return t, nil
+}
+
+// nonNil makes empty listings encode as [] rather than null.
+func nonNil[T any](s []T) []T {
+	if s == nil {
+		return []T{}
+	}
+	return s
+}
@@ -0,0 +1,195 @@
+// Command memorictl administers the memory stored by memorigo: schema
+// migrations, entities, facts, conversations, recall, export and import,
+// re-embedding and token usage, and an interactive chat that shows the facts
+// recalled and extracted at each turn. It works on any storage memorigo
+// supports.
+//
+//	memorictl [-dsn DSN] [-o table|json] <command> [flags] [args]
+//
+// Flags may follow the command and its arguments, as in
+// memorictl recall "favorite color" -entity alice.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"syscall"
+
+	"memorigo/internal/dsn"
+	"memorigo/memori"
+	"memorigo/storage"
+)
+
+type command struct {
+	usage   string
+	summary string
+	run     func(ctx context.Context, a *app, args []string) error
+}
+
+var commands = map[string]command{
+	"migrate":       {"migrate status|up|down|dry-run [-to N]", "show, apply, roll back or preview schema migrations", runMigrate},
+	"entities":      {"entities [-limit N] [-offset N]", "list entities", runEntities},
+	"facts":         {"facts list|search|delete ...", "list, search or delete facts", runFacts},
+	"conversations": {"conversations list -entity E | show <conversation-uuid>", "list an entity's conversations or show one", runConversations},
+	"recall":        {"recall <query> -entity E [-limit N]", "rank an entity's facts by similarity to a query", runRecall},
+	"export":        {"export -entity E [-out FILE]", "write everything stored about an entity as JSON", runExport},
+	"import":        {"import [-in FILE]", "write an export into this storage, re-embedding its facts", runImport},
+	"reembed":       {"reembed", "re-embed facts produced by another embedder than the configured one", runReembed},
+	"stats":         {"stats [-by entity,process,session,model] [-entity E] [-model M] [-since T] [-until T]", "token usage and estimated cost", runStats},
+	"chat":          {"chat -entity E [-model M] [-base-url URL] [-recall N]", "chat through an OpenAI-compatible endpoint, showing recalled and extracted facts", runChat},
+}
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	if err := run(ctx, os.Args[1:], os.Stdin, os.Stdout, os.Stderr); err != nil {
+		if !errors.Is(err, flag.ErrHelp) {
+			fmt.Fprintf(os.Stderr, "memorictl: %v\n", err)
+		}
+		os.Exit(1)
+	}
+}
+
+// app holds the global flags and the storage opened for a command.
+type app struct {
+	dsn    string
+	output string
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+
+	m     *memori.Memori
+	close func() error
+}
+
+func run(ctx context.Context, args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	a := &app{stdin: stdin, stdout: stdout, stderr: stderr}
+	fs := a.flags("memorictl")
+	fs.Usage = func() { a.usage() }
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		a.usage()
+		return flag.ErrHelp
+	}
+	name := fs.Arg(0)
+	cmd, ok := commands[name]
+	if !ok {
+		a.usage()
+		return fmt.Errorf("unknown command %q", name)
+	}
+	defer func() {
+		if a.close != nil {
+			a.close()
+		}
+	}()
+	return cmd.run(ctx, a, fs.Args()[1:])
+}
+
+// flags returns a flag set with the global flags, so that they are accepted
+// before and after the command.
+func (a *app) flags(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(a.stderr)
+	if a.dsn == "" {
+		a.dsn = envOr("MEMORI_DSN", "memori.db")
+	}
+	if a.output == "" {
+		a.output = "table"
+	}
+	fs.StringVar(&a.dsn, "dsn", a.dsn, "storage: postgres://..., mongodb://..., bolt:<path> or a SQLite path")
+	fs.StringVar(&a.output, "o", a.output, "output format: table or json")
+	return fs
+}
+
+func (a *app) usage() {
+	fmt.Fprintln(a.stderr, "usage: memorictl [-dsn DSN] [-o table|json] <command> [flags] [args]\n\ncommands:")
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(a.stderr, "  %-14s %s\n      %s\n", name, commands[name].summary, commands[name].usage)
+	}
+}
+
+// parse parses flags anywhere among args and returns the positional
+// arguments.
+func parse(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		if fs.NArg() == 0 {
+			return positional, nil
+		}
+		positional = append(positional, fs.Arg(0))
+		args = fs.Args()[1:]
+	}
+}
+
+// open connects to the storage. Unless the command manages the schema
+// itself, the schema must be up to date: memorictl never migrates implicitly.
+func (a *app) open(ctx context.Context, checkSchema bool) error {
+	if a.output != "table" && a.output != "json" {
+		return fmt.Errorf("unknown output format %q, want table or json", a.output)
+	}
+	conn, closeStorage, err := dsn.Open(ctx, a.dsn)
+	if err != nil {
+		return err
+	}
+	a.close = closeStorage
+	a.m = memori.New(memori.WithStorageConn(conn))
+	if a.m.Storage.Driver() == nil {
+		return fmt.Errorf("no storage driver for %s", a.dsn)
+	}
+	if !checkSchema {
+		return nil
+	}
+	mig, err := a.m.Storage.Migrator()
+	if err != nil {
+		return err
+	}
+	version, err := mig.Version()
+	if err != nil {
+		return err
+	}
+	if version < mig.Latest() {
+		return fmt.Errorf("schema is at version %d of %d, run memorictl migrate up first", version, mig.Latest())
+	}
+	return nil
+}
+
+func (a *app) browse() (storage.BrowseRepo, error) {
+	return a.m.Browse()
+}
+
+func usageError(usage string) error {
+	return fmt.Errorf("usage: memorictl %s", usage)
+}
+
+func envOr(key, def string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return def
+}
+
+// oneLine shortens text for a table cell.
+func oneLine(s string, max int) string {
+	s = strings.Join(strings.Fields(s), " ")
+	if r := []rune(s); len(r) > max {
+		return string(r[:max-1]) + "…"
+	}
+	return s
+}
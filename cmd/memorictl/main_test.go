@@ -0,0 +1,215 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	_ "modernc.org/sqlite"
+
+	"memorigo/memori"
+	"memorigo/storage"
+)
+
+func TestMemorictl(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "src.db")
+	dst := "bolt:" + filepath.Join(dir, "dst.db")
+	ctx := context.Background()
+
+	ctl := func(stdin string, args ...string) (string, error) {
+		t.Helper()
+		var out, errOut bytes.Buffer
+		err := run(ctx, args, strings.NewReader(stdin), &out, &errOut)
+		return out.String(), err
+	}
+	mustCtl := func(args ...string) string {
+		t.Helper()
+		out, err := ctl("", args...)
+		if err != nil {
+			t.Fatalf("memorictl %s: %v", strings.Join(args, " "), err)
+		}
+		return out
+	}
+
+	// commands other than migrate refuse an outdated schema
+	if _, err := ctl("", "-dsn", src, "entities"); err == nil || !strings.Contains(err.Error(), "migrate up") {
+		t.Fatalf("entities on an empty database: %v", err)
+	}
+	if out := mustCtl("-dsn", src, "migrate", "dry-run", "-to", "1"); !strings.Contains(out, "CREATE TABLE IF NOT EXISTS memori_entity") {
+		t.Fatalf("dry-run:\n%s", out)
+	}
+	mustCtl("-dsn", src, "migrate", "up")
+
+	db, err := sql.Open("sqlite", src)
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	m := memori.New(memori.WithStorageConn(db))
+	if err := memori.NewWriter(m.Scoped("alice", "chat", uuid.Nil)).Execute(ctx, memori.ConversationPayload{
+		Messages: []memori.Message{{Role: "user", Content: "My favorite color is blue"}},
+		Response: &memori.Message{Role: "assistant", Content: "Noted."},
+	}); err != nil {
+		t.Fatalf("seed: %v", err)
+	}
+	for deadline := time.Now().Add(5 * time.Second); ; {
+		if facts, _ := m.Scoped("alice", "", uuid.Nil).Recall("favorite color", 1); len(facts) > 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("seed fact was not augmented")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	db.Close()
+
+	var entities []storage.EntityInfo
+	decode(t, mustCtl("-dsn", src, "-o", "json", "entities"), &entities)
+	if len(entities) != 1 || entities[0].ExternalID != "alice" {
+		t.Fatalf("entities = %+v", entities)
+	}
+	if out := mustCtl("-dsn", src, "facts", "search", "COLOR", "-entity", "alice"); !strings.Contains(out, "My favorite color is blue") {
+		t.Fatalf("facts search:\n%s", out)
+	}
+	if out := mustCtl("-dsn", src, "recall", "favorite", "color", "-entity", "alice"); !strings.Contains(out, "My favorite color is blue") {
+		t.Fatalf("recall:\n%s", out)
+	}
+	var convs []storage.ConversationInfo
+	decode(t, mustCtl("-dsn", src, "-o", "json", "conversations", "list", "-entity", "alice"), &convs)
+	if len(convs) != 1 {
+		t.Fatalf("conversations = %+v", convs)
+	}
+	if out := mustCtl("-dsn", src, "conversations", "show", convs[0].UUID); !strings.Contains(out, "assistant") || !strings.Contains(out, "Noted.") {
+		t.Fatalf("conversations show:\n%s", out)
+	}
+
+	// export from sqlite, import into bolt, twice
+	export := filepath.Join(dir, "alice.json")
+	mustCtl("-dsn", src, "export", "-entity", "alice", "-out", export)
+	mustCtl("-dsn", dst, "migrate", "up")
+	var res memori.ImportResult
+	decode(t, mustCtl("-dsn", dst, "-o", "json", "import", "-in", export), &res)
+	if res.Sessions != 1 || res.Conversations != 1 || res.Messages != 2 || res.Facts != 1 {
+		t.Fatalf("import = %+v", res)
+	}
+	decode(t, mustCtl("-dsn", dst, "-o", "json", "import", "-in", export), &res)
+	if res.Sessions != 0 || res.SkippedSessions != 1 || res.Facts != 0 || res.SkippedFacts != 1 {
+		t.Fatalf("second import = %+v", res)
+	}
+	var exported memori.EntityExport
+	raw, err := os.ReadFile(export)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(raw, &exported); err != nil || len(exported.Facts) != 1 {
+		t.Fatalf("export = %+v, %v", exported, err)
+	}
+	var facts []storage.FactInfo
+	decode(t, mustCtl("-dsn", dst, "-o", "json", "facts", "list", "-entity", "alice"), &facts)
+	if len(facts) != 1 || facts[0].Content != "My favorite color is blue" || facts[0].Embedding.Dimension == 0 {
+		t.Fatalf("imported facts = %+v", facts)
+	}
+	if want := exported.Facts[0]; facts[0].NumTimes != want.NumTimes || !facts[0].DateLastTime.Equal(want.DateLastTime) {
+		t.Fatalf("imported fact counters = %d, %v; want %d, %v", facts[0].NumTimes, facts[0].DateLastTime, want.NumTimes, want.DateLastTime)
+	}
+	mustCtl("-dsn", dst, "facts", "delete", facts[0].UUID)
+	if out := mustCtl("-dsn", dst, "recall", "favorite color", "-entity", "alice"); strings.Contains(out, "blue") {
+		t.Fatalf("recall after delete:\n%s", out)
+	}
+
+	// roll back and re-apply on both backends
+	for _, d := range []string{src, dst} {
+		mustCtl("-dsn", d, "migrate", "down", "-to", "2")
+		var status struct {
+			Version int   `json:"version"`
+			Pending []int `json:"pending"`
+		}
+		decode(t, mustCtl("-dsn", d, "-o", "json", "migrate", "status"), &status)
+		if status.Version != 2 || len(status.Pending) == 0 {
+			t.Fatalf("%s status after down = %+v", d, status)
+		}
+		mustCtl("-dsn", d, "migrate", "down", "-to", "0")
+		mustCtl("-dsn", d, "migrate", "up")
+		decode(t, mustCtl("-dsn", d, "-o", "json", "entities"), &entities)
+		if len(entities) != 0 {
+			t.Fatalf("%s entities after rollback to 0 = %+v", d, entities)
+		}
+	}
+
+	if out := mustCtl("-dsn", src, "stats", "-by", "model"); !strings.HasPrefix(out, "MODEL") {
+		t.Fatalf("stats:\n%s", out)
+	}
+	if _, err := ctl("", "-dsn", src, "bogus"); err == nil {
+		t.Fatalf("unknown command succeeded")
+	}
+}
+
+func TestChat(t *testing.T) {
+	var system []string
+	llm := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req memori.ChatCompletionsRequest
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		memories := ""
+		for _, m := range req.Messages {
+			if m.Role == "system" {
+				memories = m.Content
+			}
+		}
+		system = append(system, memories)
+		_, _ = w.Write([]byte(`{"id":"c1","object":"chat.completion","choices":[{"index":0,"message":{"role":"assistant","content":"Noted."}}]}`))
+	}))
+	defer llm.Close()
+
+	db := filepath.Join(t.TempDir(), "chat.db")
+	ctx := context.Background()
+	var out bytes.Buffer
+	if err := run(ctx, []string{"-dsn", db, "migrate", "up"}, strings.NewReader(""), &out, &out); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	out.Reset()
+	script := strings.Join([]string{
+		"My favorite color is blue",
+		"What is my favorite color?",
+		"/pin I live in Paris",
+		"/forget 1",
+		"Where do I live?",
+		"/facts",
+		"/bogus",
+		"/quit",
+	}, "\n")
+	if err := run(ctx, []string{"-dsn", db, "chat", "-entity", "alice", "-base-url", llm.URL}, strings.NewReader(script), &out, &out); err != nil {
+		t.Fatalf("chat: %v\n%s", err, out.String())
+	}
+	for _, want := range []string{
+		"extracted:\n    [1] new   My favorite color is blue",
+		"recalled:\n    [1] 0.",
+		"pinned [4]* I live in Paris",
+		"forgot [1] My favorite color is blue",
+		"pinned:\n    [4]* I live in Paris",
+		"[4]* x1   I live in Paris",
+		"unknown command /bogus",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Fatalf("chat output lacks %q:\n%s", want, out.String())
+		}
+	}
+	if len(system) != 3 || system[0] != "" || !strings.Contains(system[1], "My favorite color is blue") || !strings.Contains(system[2], "I live in Paris") {
+		t.Fatalf("injected memories = %q", system)
+	}
+}
+
+func decode(t *testing.T, s string, v any) {
+	t.Helper()
+	if err := json.Unmarshal([]byte(s), v); err != nil {
+		t.Fatalf("decode %s: %v", s, err)
+	}
+}
@@ -0,0 +1,255 @@
+package embed
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"sync"
+)
+
+// CacheKey identifies a cached embedding: the same text embedded by a
+// different provider or model is a different vector.
+type CacheKey struct {
+	Provider string
+	Model    string
+	Hash     string // hex sha256 of the text
+}
+
+// CacheStore is an optional persistent second level behind the in-memory LRU,
+// e.g. a database table shared between processes.
+type CacheStore interface {
+	Get(ctx context.Context, key CacheKey) ([]float32, bool, error)
+	Put(ctx context.Context, key CacheKey, embedding []float32) error
+}
+
+// ModelNamer is implemented by embedders that can report the model they use.
+type ModelNamer interface {
+	Model() string
+}
+
+// ModelOf returns the model reported by e, or "" if e does not report one.
+func ModelOf(e Embedder) string {
+	if n, ok := e.(ModelNamer); ok {
+		return n.Model()
+	}
+	return ""
+}
+
+// CacheOption configures a CachedEmbedder.
+type CacheOption func(*CachedEmbedder)
+
+// WithCacheSize sets the number of embeddings kept in memory (default 1024).
+func WithCacheSize(n int) CacheOption {
+	return func(c *CachedEmbedder) {
+		if n > 0 {
+			c.size = n
+		}
+	}
+}
+
+// CachedEmbedder decorates an Embedder with an in-memory LRU, an optional
+// persistent CacheStore, and coalescing of concurrent requests for the same
+// text so that each distinct text reaches the provider at most once at a time.
+type CachedEmbedder struct {
+	inner Embedder
+	store CacheStore
+	size  int
+
+	mu       sync.Mutex
+	lru      *list.List // of *cacheEntry, most recent first
+	entries  map[string]*list.Element
+	inflight map[string]*inflightEmbedding
+}
+
+type cacheEntry struct {
+	key string
+	vec []float32
+}
+
+type inflightEmbedding struct {
+	done chan struct{}
+	vec  []float32
+	err  error
+}
+
+// Cached wraps embedder with caching. store may be nil for memory-only caching.
+func Cached(embedder Embedder, store CacheStore, opts ...CacheOption) *CachedEmbedder {
+	c := &CachedEmbedder{
+		inner:    embedder,
+		store:    store,
+		size:     1024,
+		lru:      list.New(),
+		entries:  make(map[string]*list.Element),
+		inflight: make(map[string]*inflightEmbedding),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+func (c *CachedEmbedder) EmbedText(ctx context.Context, text string) ([]float32, error) {
+	out, err := c.EmbedTexts(ctx, []string{text})
+	if err != nil {
+		return nil, err
+	}
+	return out[0], nil
+}
+
+func (c *CachedEmbedder) EmbedTexts(ctx context.Context, texts []string) ([][]float32, error) {
+	if len(texts) == 0 {
+		return nil, nil
+	}
+
+	result := make([][]float32, len(texts))
+	keys := make([]CacheKey, len(texts))
+	missing := make(map[string][]int) // cache key -> positions in texts
+	for i, text := range texts {
+		keys[i] = c.key(text)
+		k := keyString(keys[i])
+		if vec, ok := c.lookup(k); ok {
+			result[i] = vec
+			continue
+		}
+		missing[k] = append(missing[k], i)
+	}
+	if len(missing) == 0 {
+		return result, nil
+	}
+
+	// Second level: persistent store (best-effort, errors count as misses).
+	if c.store != nil {
+		for k, idx := range missing {
+			vec, ok, err := c.store.Get(ctx, keys[idx[0]])
+			if err != nil || !ok {
+				continue
+			}
+			c.remember(k, vec)
+			for _, i := range idx {
+				result[i] = cloneVector(vec)
+			}
+			delete(missing, k)
+		}
+	}
+	if len(missing) == 0 {
+		return result, nil
+	}
+
+	// Coalesce with requests already in flight; lead the rest ourselves.
+	var leadKeys []string
+	var leadTexts []string
+	waits := make(map[string]*inflightEmbedding)
+	c.mu.Lock()
+	for k, idx := range missing {
+		if call, ok := c.inflight[k]; ok {
+			waits[k] = call
+			continue
+		}
+		call := &inflightEmbedding{done: make(chan struct{})}
+		c.inflight[k] = call
+		waits[k] = call
+		leadKeys = append(leadKeys, k)
+		leadTexts = append(leadTexts, texts[idx[0]])
+	}
+	c.mu.Unlock()
+
+	if len(leadKeys) > 0 {
+		vecs, err := c.inner.EmbedTexts(ctx, leadTexts)
+		if err == nil && len(vecs) != len(leadTexts) {
+			err = ErrEmbeddingServiceUnavailable
+		}
+		c.mu.Lock()
+		for j, k := range leadKeys {
+			call := c.inflight[k]
+			delete(c.inflight, k)
+			if err != nil {
+				call.err = err
+			} else {
+				call.vec = vecs[j]
+				c.rememberLocked(k, vecs[j])
+			}
+			close(call.done)
+		}
+		c.mu.Unlock()
+		if err == nil && c.store != nil {
+			for j, k := range leadKeys {
+				_ = c.store.Put(ctx, keys[missing[k][0]], vecs[j])
+			}
+		}
+	}
+
+	for k, call := range waits {
+		select {
+		case <-call.done:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		if call.err != nil {
+			return nil, call.err
+		}
+		for _, i := range missing[k] {
+			result[i] = cloneVector(call.vec)
+		}
+	}
+	return result, nil
+}
+
+func (c *CachedEmbedder) Dimension() int { return c.inner.Dimension() }
+
+func (c *CachedEmbedder) Provider() string { return c.inner.Provider() }
+
+func (c *CachedEmbedder) Model() string { return ModelOf(c.inner) }
+
+// Unwrap returns the decorated embedder.
+func (c *CachedEmbedder) Unwrap() Embedder { return c.inner }
+
+func (c *CachedEmbedder) key(text string) CacheKey {
+	h := sha256.Sum256([]byte(text))
+	return CacheKey{Provider: c.inner.Provider(), Model: ModelOf(c.inner), Hash: hex.EncodeToString(h[:])}
+}
+
+func keyString(k CacheKey) string {
+	return k.Provider + "\x00" + k.Model + "\x00" + k.Hash
+}
+
+func (c *CachedEmbedder) lookup(k string) ([]float32, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	el, ok := c.entries[k]
+	if !ok {
+		return nil, false
+	}
+	c.lru.MoveToFront(el)
+	return cloneVector(el.Value.(*cacheEntry).vec), true
+}
+
+func (c *CachedEmbedder) remember(k string, vec []float32) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.rememberLocked(k, vec)
+}
+
+func (c *CachedEmbedder) rememberLocked(k string, vec []float32) {
+	if el, ok := c.entries[k]; ok {
+		el.Value.(*cacheEntry).vec = cloneVector(vec)
+		c.lru.MoveToFront(el)
+		return
+	}
+	c.entries[k] = c.lru.PushFront(&cacheEntry{key: k, vec: cloneVector(vec)})
+	for c.lru.Len() > c.size {
+		oldest := c.lru.Back()
+		c.lru.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cacheEntry).key)
+	}
+}
+
+// cloneVector keeps cached vectors safe from callers that modify results.
+func cloneVector(v []float32) []float32 {
+	if v == nil {
+		return nil
+	}
+	out := make([]float32, len(v))
+	copy(out, v)
+	return out
+}
@@ -0,0 +1,117 @@
+package embed_test
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"memorigo/embed"
+)
+
+// countingEmbedder records how many texts reach the underlying provider.
+type countingEmbedder struct {
+	calls atomic.Int64
+	texts atomic.Int64
+	delay time.Duration
+}
+
+func (e *countingEmbedder) EmbedText(ctx context.Context, text string) ([]float32, error) {
+	out, err := e.EmbedTexts(ctx, []string{text})
+	if err != nil {
+		return nil, err
+	}
+	return out[0], nil
+}
+
+func (e *countingEmbedder) EmbedTexts(ctx context.Context, texts []string) ([][]float32, error) {
+	e.calls.Add(1)
+	e.texts.Add(int64(len(texts)))
+	time.Sleep(e.delay)
+	out := make([][]float32, len(texts))
+	for i, t := range texts {
+		out[i] = []float32{float32(len(t)), 1}
+	}
+	return out, nil
+}
+
+func (e *countingEmbedder) Dimension() int   { return 2 }
+func (e *countingEmbedder) Provider() string { return "counting" }
+
+type mapStore struct {
+	mu sync.Mutex
+	m  map[embed.CacheKey][]float32
+}
+
+func (s *mapStore) Get(_ context.Context, k embed.CacheKey) ([]float32, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	v, ok := s.m[k]
+	return v, ok, nil
+}
+
+func (s *mapStore) Put(_ context.Context, k embed.CacheKey, v []float32) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.m[k] = v
+	return nil
+}
+
+func TestCached_DeduplicatesAndCoalesces(t *testing.T) {
+	inner := &countingEmbedder{delay: 20 * time.Millisecond}
+	c := embed.Cached(inner, nil)
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := c.EmbedText(ctx, "favorite color"); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+	if got := inner.texts.Load(); got != 1 {
+		t.Fatalf("concurrent identical requests reached provider %d times, want 1", got)
+	}
+
+	out, err := c.EmbedTexts(ctx, []string{"favorite color", "blue", "blue"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(out) != 3 || out[1][0] != 4 || out[2][0] != 4 {
+		t.Fatalf("unexpected vectors: %v", out)
+	}
+	if got := inner.texts.Load(); got != 2 {
+		t.Fatalf("provider saw %d texts, want 2", got)
+	}
+}
+
+func TestCached_PersistentStoreAndEviction(t *testing.T) {
+	store := &mapStore{m: make(map[embed.CacheKey][]float32)}
+	inner := &countingEmbedder{}
+	ctx := context.Background()
+
+	first := embed.Cached(inner, store, embed.WithCacheSize(1))
+	for _, text := range []string{"a", "bb", "a"} {
+		if _, err := first.EmbedText(ctx, text); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// "a" was evicted from the one-entry LRU but served from the store.
+	if got := inner.texts.Load(); got != 2 {
+		t.Fatalf("provider saw %d texts, want 2", got)
+	}
+
+	// A fresh cache (e.g. after restart) is warmed from the store.
+	second := embed.Cached(inner, store)
+	if _, err := second.EmbedTexts(ctx, []string{"a", "bb"}); err != nil {
+		t.Fatal(err)
+	}
+	if got := inner.texts.Load(); got != 2 {
+		t.Fatalf("provider saw %d texts after restart, want 2", got)
+	}
+}
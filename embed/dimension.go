@@ -0,0 +1,48 @@
+package embed
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// autoDimension tracks the vector size of servers whose model is chosen at
+// deploy time. It is either configured up front or learned from the first
+// response, after which every response must match.
+type autoDimension struct {
+	mu    sync.Mutex
+	value int
+}
+
+func (d *autoDimension) get() int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.value
+}
+
+// observe records n on first use and rejects vectors of any other size.
+func (d *autoDimension) observe(n int) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.value == 0 {
+		d.value = n
+		return nil
+	}
+	if n != d.value {
+		return fmt.Errorf("%w: got %d, expected %d", ErrEmbeddingDimensionMismatch, n, d.value)
+	}
+	return nil
+}
+
+// resolve returns the dimension, probing the server with embed when it is
+// not known yet. It returns 0 if the probe fails.
+func (d *autoDimension) resolve(ctx context.Context, embed func(context.Context, []string) ([][]float32, error)) int {
+	if n := d.get(); n > 0 {
+		return n
+	}
+	vecs, err := embed(ctx, []string{"dimension probe"})
+	if err != nil || len(vecs) == 0 {
+		return 0
+	}
+	return d.get()
+}
@@ -52,3 +52,7 @@ func (e *HashEmbedder) Dimension() int {
 func (e *HashEmbedder) Provider() string {
 	return "hash"
 }
+
+func (e *HashEmbedder) Model() string {
+	return "fnv64a"
+}
@@ -0,0 +1,319 @@
+package embed
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// APIError is returned when an embedding endpoint answers with a non-2xx status.
+// Rate limits and server errors unwrap to ErrEmbeddingServiceUnavailable.
+type APIError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("API error %d: %s", e.StatusCode, e.Body)
+}
+
+func (e *APIError) Unwrap() error {
+	if retryableStatus(e.StatusCode) {
+		return ErrEmbeddingServiceUnavailable
+	}
+	return nil
+}
+
+// RetryPolicy controls how failed embedding requests are retried.
+type RetryPolicy struct {
+	MaxRetries int           // retries after the first attempt; 0 means 3, negative disables
+	BaseDelay  time.Duration // first backoff, doubled per attempt
+	MaxDelay   time.Duration // cap for a single backoff or Retry-After
+}
+
+// WithDefaults fills in the zero fields of the policy.
+func (p RetryPolicy) WithDefaults() RetryPolicy {
+	switch {
+	case p.MaxRetries == 0:
+		p.MaxRetries = 3
+	case p.MaxRetries < 0:
+		p.MaxRetries = 0
+	}
+	if p.BaseDelay <= 0 {
+		p.BaseDelay = 500 * time.Millisecond
+	}
+	if p.MaxDelay <= 0 {
+		p.MaxDelay = 30 * time.Second
+	}
+	return p
+}
+
+// Backoff returns a jittered delay in [d/2, d] for the given attempt.
+func (p RetryPolicy) Backoff(attempt int) time.Duration {
+	d := p.BaseDelay << attempt
+	if d <= 0 || d > p.MaxDelay {
+		d = p.MaxDelay
+	}
+	half := d / 2
+	return half + time.Duration(rand.Int63n(int64(half)+1))
+}
+
+// RateLimiter is a client-side token bucket limiting requests and (estimated)
+// input tokens per minute. A zero limit disables that dimension.
+type RateLimiter struct {
+	mu       sync.Mutex
+	reqRate  float64 // per second
+	tokRate  float64 // per second
+	reqAvail float64
+	tokAvail float64
+	last     time.Time
+}
+
+// NewRateLimiter returns a limiter, or nil when both limits are zero.
+func NewRateLimiter(requestsPerMinute, tokensPerMinute int) *RateLimiter {
+	if requestsPerMinute <= 0 && tokensPerMinute <= 0 {
+		return nil
+	}
+	return &RateLimiter{
+		reqRate:  float64(requestsPerMinute) / 60,
+		tokRate:  float64(tokensPerMinute) / 60,
+		reqAvail: float64(requestsPerMinute),
+		tokAvail: float64(tokensPerMinute),
+		last:     time.Now(),
+	}
+}
+
+// Wait blocks until one request carrying tokens may be sent.
+func (l *RateLimiter) Wait(ctx context.Context, tokens int) error {
+	if l == nil {
+		return nil
+	}
+	for {
+		delay := l.reserve(float64(tokens))
+		if delay == 0 {
+			return nil
+		}
+		t := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return ctx.Err()
+		case <-t.C:
+		}
+	}
+}
+
+func (l *RateLimiter) reserve(tokens float64) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	elapsed := now.Sub(l.last).Seconds()
+	l.last = now
+	if l.reqRate > 0 {
+		l.reqAvail = minFloat(l.reqAvail+elapsed*l.reqRate, l.reqRate*60)
+	}
+	if l.tokRate > 0 {
+		l.tokAvail = minFloat(l.tokAvail+elapsed*l.tokRate, l.tokRate*60)
+		// a request larger than the whole bucket is let through once full
+		tokens = minFloat(tokens, l.tokRate*60)
+	}
+
+	var wait float64
+	if l.reqRate > 0 && l.reqAvail < 1 {
+		wait = (1 - l.reqAvail) / l.reqRate
+	}
+	if l.tokRate > 0 && l.tokAvail < tokens {
+		if w := (tokens - l.tokAvail) / l.tokRate; w > wait {
+			wait = w
+		}
+	}
+	if wait > 0 {
+		return time.Duration(wait*float64(time.Second)) + time.Millisecond
+	}
+	if l.reqRate > 0 {
+		l.reqAvail--
+	}
+	if l.tokRate > 0 {
+		l.tokAvail -= tokens
+	}
+	return 0
+}
+
+func minFloat(a, b float64) float64 {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// estimateTokens is a cheap upper-bound style estimate (~4 bytes per token)
+// used only for client-side rate limiting.
+func estimateTokens(texts []string) int {
+	n := 0
+	for _, t := range texts {
+		n += len(t)/4 + 1
+	}
+	return n
+}
+
+// batches splits texts into consecutive chunks of at most size elements.
+func batches(texts []string, size int) [][]string {
+	if size <= 0 || len(texts) <= size {
+		return [][]string{texts}
+	}
+	out := make([][]string, 0, (len(texts)+size-1)/size)
+	for start := 0; start < len(texts); start += size {
+		end := start + size
+		if end > len(texts) {
+			end = len(texts)
+		}
+		out = append(out, texts[start:end])
+	}
+	return out
+}
+
+// jsonClient is the HTTP transport shared by the remote embedders: it applies
+// rate limiting, retries 429/5xx and network errors with jittered backoff and
+// honors Retry-After.
+type jsonClient struct {
+	client  *http.Client
+	apiKey  string
+	retry   RetryPolicy
+	limiter *RateLimiter
+}
+
+func newJSONClient(config Config, apiKey string) *jsonClient {
+	client := config.HTTPClient
+	if client == nil {
+		timeout := config.Timeout
+		if timeout <= 0 {
+			timeout = 30 * time.Second
+		}
+		client = &http.Client{Timeout: timeout}
+	}
+	return &jsonClient{
+		client: client,
+		apiKey: apiKey,
+		retry: RetryPolicy{
+			MaxRetries: config.MaxRetries,
+			BaseDelay:  config.RetryBaseDelay,
+		}.WithDefaults(),
+		limiter: NewRateLimiter(config.RequestsPerMinute, config.TokensPerMinute),
+	}
+}
+
+// postJSON sends body to endpoint and decodes the JSON response into out.
+// tokens is the estimated input size used by the rate limiter.
+func (c *jsonClient) postJSON(ctx context.Context, endpoint string, body any, tokens int, out any) error {
+	jsonData, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	for attempt := 0; ; attempt++ {
+		if err := c.limiter.Wait(ctx, tokens); err != nil {
+			return err
+		}
+
+		retryAfter, err := c.do(ctx, endpoint, jsonData, out)
+		if err == nil {
+			return nil
+		}
+		if attempt >= c.retry.MaxRetries || !retryable(ctx, err) {
+			return err
+		}
+
+		delay := c.retry.Backoff(attempt)
+		if retryAfter > 0 {
+			delay = retryAfter
+			if delay > c.retry.MaxDelay {
+				delay = c.retry.MaxDelay
+			}
+		}
+		t := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return ctx.Err()
+		case <-t.C:
+		}
+	}
+}
+
+func (c *jsonClient) do(ctx context.Context, endpoint string, body []byte, out any) (time.Duration, error) {
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return 0, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	httpReq.Header.Set("Content-Type", "application/json")
+	if c.apiKey != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+c.apiKey)
+	}
+
+	resp, err := c.client.Do(httpReq)
+	if err != nil {
+		return 0, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		b, _ := io.ReadAll(resp.Body)
+		return ParseRetryAfter(resp.Header), &APIError{StatusCode: resp.StatusCode, Body: string(b)}
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return 0, fmt.Errorf("failed to decode response: %w", err)
+	}
+	return 0, nil
+}
+
+func retryableStatus(code int) bool {
+	return code == http.StatusTooManyRequests || code >= 500
+}
+
+func retryable(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return retryableStatus(apiErr.StatusCode)
+	}
+	// transport errors (connection reset, timeouts, ...)
+	var urlErr *url.Error
+	return errors.As(err, &urlErr)
+}
+
+// ParseRetryAfter reads retry-after-ms (OpenAI) or Retry-After in seconds or
+// HTTP-date form. It returns 0 when neither header is usable.
+func ParseRetryAfter(h http.Header) time.Duration {
+	if v := h.Get("retry-after-ms"); v != "" {
+		if ms, err := strconv.ParseFloat(v, 64); err == nil && ms > 0 {
+			return time.Duration(ms * float64(time.Millisecond))
+		}
+	}
+	v := h.Get("Retry-After")
+	if v == "" {
+		return 0
+	}
+	if secs, err := strconv.ParseFloat(v, 64); err == nil && secs > 0 {
+		return time.Duration(secs * float64(time.Second))
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		if d := time.Until(t); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
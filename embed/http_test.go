@@ -0,0 +1,144 @@
+package embed_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"memorigo/embed"
+)
+
+// fakeEmbeddingsServer serves /v1/embeddings in the OpenAI wire format,
+// failing the first `failures` requests with the given status.
+func fakeEmbeddingsServer(t *testing.T, failures int32, status int) (*httptest.Server, *atomic.Int32, *[]int) {
+	t.Helper()
+	var calls atomic.Int32
+	var sizes []int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := calls.Add(1)
+		if n <= failures {
+			w.Header().Set("Retry-After", "0.01")
+			http.Error(w, `{"error":{"message":"slow down"}}`, status)
+			return
+		}
+		var req struct {
+			Input []string `json:"input"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		sizes = append(sizes, len(req.Input))
+		type item struct {
+			Embedding []float32 `json:"embedding"`
+			Index     int       `json:"index"`
+		}
+		resp := struct {
+			Data []item `json:"data"`
+		}{}
+		// answer in reverse order to exercise index-based placement
+		for i := len(req.Input) - 1; i >= 0; i-- {
+			resp.Data = append(resp.Data, item{Embedding: []float32{float32(len(req.Input[i]))}, Index: i})
+		}
+		_ = json.NewEncoder(w).Encode(resp)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &calls, &sizes
+}
+
+func TestOpenAIEmbedder_RetriesRateLimit(t *testing.T) {
+	srv, calls, _ := fakeEmbeddingsServer(t, 2, http.StatusTooManyRequests)
+	e := embed.NewOpenAIEmbedder(embed.Config{BaseURL: srv.URL, RetryBaseDelay: time.Millisecond})
+
+	out, err := e.EmbedTexts(context.Background(), []string{"a", "bbb"})
+	if err != nil {
+		t.Fatalf("embed: %v", err)
+	}
+	if calls.Load() != 3 {
+		t.Fatalf("calls = %d, want 3", calls.Load())
+	}
+	if out[0][0] != 1 || out[1][0] != 3 {
+		t.Fatalf("embeddings out of order: %v", out)
+	}
+}
+
+func TestOpenAIEmbedder_DoesNotRetryClientErrors(t *testing.T) {
+	srv, calls, _ := fakeEmbeddingsServer(t, 5, http.StatusUnauthorized)
+	e := embed.NewOpenAIEmbedder(embed.Config{BaseURL: srv.URL, RetryBaseDelay: time.Millisecond})
+
+	_, err := e.EmbedText(context.Background(), "a")
+	var apiErr *embed.APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("err = %v, want APIError 401", err)
+	}
+	if errors.Is(err, embed.ErrEmbeddingServiceUnavailable) {
+		t.Fatalf("401 must not be reported as service unavailable")
+	}
+	if calls.Load() != 1 {
+		t.Fatalf("calls = %d, want 1", calls.Load())
+	}
+}
+
+func TestOpenAIEmbedder_GivesUpAfterMaxRetries(t *testing.T) {
+	srv, calls, _ := fakeEmbeddingsServer(t, 100, http.StatusServiceUnavailable)
+	e := embed.NewOpenAIEmbedder(embed.Config{BaseURL: srv.URL, MaxRetries: 2, RetryBaseDelay: time.Millisecond})
+
+	_, err := e.EmbedText(context.Background(), "a")
+	if !errors.Is(err, embed.ErrEmbeddingServiceUnavailable) {
+		t.Fatalf("err = %v, want ErrEmbeddingServiceUnavailable", err)
+	}
+	if calls.Load() != 3 {
+		t.Fatalf("calls = %d, want 3", calls.Load())
+	}
+}
+
+func TestOpenAIEmbedder_ChunksBatches(t *testing.T) {
+	srv, _, sizes := fakeEmbeddingsServer(t, 0, 0)
+	e := embed.NewOpenAIEmbedder(embed.Config{BaseURL: srv.URL, BatchSize: 2})
+
+	texts := []string{"a", "", "bb", "ccc", "dddd", "eeeee"}
+	out, err := e.EmbedTexts(context.Background(), texts)
+	if err != nil {
+		t.Fatalf("embed: %v", err)
+	}
+	if got := *sizes; len(got) != 3 || got[0] != 2 || got[1] != 2 || got[2] != 1 {
+		t.Fatalf("batch sizes = %v, want [2 2 1]", got)
+	}
+	for i, text := range texts {
+		if text == "" {
+			continue
+		}
+		if out[i][0] != float32(len(text)) {
+			t.Fatalf("out[%d] = %v, want %d", i, out[i], len(text))
+		}
+	}
+}
+
+func TestRateLimiter_SpacesRequests(t *testing.T) {
+	l := embed.NewRateLimiter(600, 0) // 10 req/s, burst of 600
+	ctx := context.Background()
+	start := time.Now()
+	for i := 0; i < 5; i++ {
+		if err := l.Wait(ctx, 1); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if time.Since(start) > 50*time.Millisecond {
+		t.Fatalf("burst within limit should not wait")
+	}
+
+	l = embed.NewRateLimiter(0, 60) // 1 token/s
+	if err := l.Wait(ctx, 60); err != nil {
+		t.Fatal(err)
+	}
+	short, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	if err := l.Wait(short, 10); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("err = %v, want deadline exceeded once the token budget is spent", err)
+	}
+}
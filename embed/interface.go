@@ -3,11 +3,17 @@ package embed
 import (
 	"context"
 	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"memorigo/vecmath"
 )
 
 var (
 	ErrEmbeddingServiceUnavailable = errors.New("embedding service unavailable")
 	ErrEmbeddingDimensionMismatch  = errors.New("embedding dimension mismatch")
+	ErrCircuitOpen                 = fmt.Errorf("%w: circuit breaker open", ErrEmbeddingServiceUnavailable)
 )
 
 // Embedder 定义统一的嵌入接口
@@ -27,11 +33,23 @@ type Embedder interface {
 
 // Config 嵌入配置
 type Config struct {
-	Provider  string // "openai", "siliconflow", "hash" (fallback)
+	Provider  string // "openai", "siliconflow", "ollama", "tei", "lexical" (默认，离线), "hash"
 	APIKey    string
 	BaseURL   string
 	Model     string
 	Dimension int
+
+	// lexical 嵌入器的停用词语言，逗号分隔，如 "en,zh"（默认）；"none" 关闭
+	Stopwords string
+
+	// 以下为远程提供商的 HTTP 行为配置，零值即使用默认值
+	HTTPClient        *http.Client  // 自定义 HTTP client（优先于 Timeout）
+	Timeout           time.Duration // 单次请求超时，默认 30s
+	MaxRetries        int           // 429/5xx/网络错误的重试次数，默认 3，负数关闭
+	RetryBaseDelay    time.Duration // 首次退避时间，默认 500ms，按指数增长并加抖动
+	RequestsPerMinute int           // 客户端请求速率限制，0 表示不限
+	TokensPerMinute   int           // 客户端 token 速率限制（估算），0 表示不限
+	BatchSize         int           // EmbedTexts 单次请求的最大文本数，默认按提供商上限
 }
 
 // NewEmbedder 根据配置创建嵌入器
@@ -41,30 +59,22 @@ func NewEmbedder(config Config) Embedder {
 		return NewOpenAIEmbedder(config)
 	case "siliconflow":
 		return NewSiliconFlowEmbedder(config)
+	case "ollama":
+		return NewOllamaEmbedder(config)
+	case "tei":
+		return NewTEIEmbedder(config)
 	case "hash":
+		// legacy character hash embedding, kept for existing deployments
+		return NewHashEmbedder()
+	case "lexical":
 		fallthrough
 	default:
-		// fallback to simple hash embedding for offline/demo use
-		return NewHashEmbedder()
+		// offline n-gram embedding, needs no network access
+		return NewLexicalEmbedder(config)
 	}
 }
 
-// cosineSimilarity 计算两个向量的余弦相似度
+// CosineSimilarity 计算两个向量的余弦相似度，维度不同或含零向量时返回 0
 func CosineSimilarity(a, b []float32) float64 {
-	if len(a) != len(b) {
-		return 0.0
-	}
-
-	var dotProduct, normA, normB float64
-	for i := 0; i < len(a); i++ {
-		dotProduct += float64(a[i]) * float64(b[i])
-		normA += float64(a[i]) * float64(a[i])
-		normB += float64(b[i]) * float64(b[i])
-	}
-
-	if normA == 0 || normB == 0 {
-		return 0.0
-	}
-
-	return dotProduct / (normA * normB)
+	return float64(vecmath.Cosine(a, b))
 }
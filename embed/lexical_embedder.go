@@ -0,0 +1,265 @@
+package embed
+
+import (
+	"context"
+	"hash/fnv"
+	"math"
+	"strings"
+	"unicode"
+
+	"memorigo/vecmath"
+)
+
+// LexicalEmbedder 完全离线的词法嵌入器：把文本拆成词、词二元组、字符三元组
+// （中文为单字与双字）等特征，经特征哈希映射到固定维度，并使用次线性词频
+// 1+log(tf) 与按特征类型的静态 IDF 近似加权，最后做 L2 归一化。
+// 常见停用词被剔除，因此 "favorite color" 与 "my favourite colour" 能得到有意义的相似度。
+//
+// 向量只依赖于文本本身，不依赖语料统计，已入库的向量在进程间保持可比。
+type LexicalEmbedder struct {
+	dimension int
+	languages []string
+	stopwords map[string]struct{}
+}
+
+const (
+	lexicalDefaultDimension = 512
+
+	// 特征类型权重：更长的词组更少见、信息量更大，用作 IDF 的静态近似。
+	lexicalWordWeight    = 1.0
+	lexicalBigramWeight  = 1.5
+	lexicalTrigramWeight = 0.5
+	lexicalHanWeight     = 0.7
+	lexicalHanPairWeight = 1.2
+)
+
+// NewLexicalEmbedder 创建词法嵌入器。config.Dimension 为 0 时使用 512 维；
+// config.Stopwords 为逗号分隔的停用词语言（"en"、"zh"），留空表示两者都启用，
+// "none" 表示不剔除停用词。
+func NewLexicalEmbedder(config Config) *LexicalEmbedder {
+	e := &LexicalEmbedder{
+		dimension: config.Dimension,
+		stopwords: make(map[string]struct{}),
+	}
+	if e.dimension <= 0 {
+		e.dimension = lexicalDefaultDimension
+	}
+
+	langs := config.Stopwords
+	if langs == "" {
+		langs = "en,zh"
+	}
+	for _, lang := range strings.Split(langs, ",") {
+		lang = strings.ToLower(strings.TrimSpace(lang))
+		var words []string
+		switch lang {
+		case "en":
+			words = englishStopwords
+		case "zh":
+			words = chineseStopwords
+		default:
+			continue
+		}
+		e.languages = append(e.languages, lang)
+		for _, w := range words {
+			e.stopwords[w] = struct{}{}
+		}
+	}
+	return e
+}
+
+func (e *LexicalEmbedder) EmbedText(ctx context.Context, text string) ([]float32, error) {
+	return e.embedText(text), nil
+}
+
+func (e *LexicalEmbedder) EmbedTexts(ctx context.Context, texts []string) ([][]float32, error) {
+	result := make([][]float32, len(texts))
+	for i, text := range texts {
+		result[i] = e.embedText(text)
+	}
+	return result, nil
+}
+
+func (e *LexicalEmbedder) Dimension() int {
+	return e.dimension
+}
+
+func (e *LexicalEmbedder) Provider() string {
+	return "lexical"
+}
+
+// Model 包含停用词配置，因为它会改变向量。
+func (e *LexicalEmbedder) Model() string {
+	if len(e.languages) == 0 {
+		return "ngram-v1"
+	}
+	return "ngram-v1+" + strings.Join(e.languages, "+")
+}
+
+func (e *LexicalEmbedder) embedText(text string) []float32 {
+	v := make([]float32, e.dimension)
+
+	tf := make(map[string]float64)
+	weight := make(map[string]float64)
+	add := func(feature string, w float64) {
+		tf[feature]++
+		weight[feature] = w
+	}
+
+	for _, seg := range segmentText(text) {
+		if seg.han {
+			e.hanFeatures(seg.runes, add)
+			continue
+		}
+		e.wordFeatures(seg.words, add)
+	}
+	if len(tf) == 0 {
+		return v
+	}
+
+	h := fnv.New64a()
+	for feature, n := range tf {
+		h.Reset()
+		_, _ = h.Write([]byte(feature))
+		sum := h.Sum64()
+		idx := int(sum % uint64(e.dimension))
+		// signed hashing keeps collisions from only ever adding up
+		sign := 1.0
+		if sum>>63 == 1 {
+			sign = -1.0
+		}
+		v[idx] += float32(sign * (1 + math.Log(n)) * weight[feature])
+	}
+
+	vecmath.Normalize(v)
+	return v
+}
+
+func (e *LexicalEmbedder) isStopword(s string) bool {
+	_, ok := e.stopwords[s]
+	return ok
+}
+
+// wordFeatures emits words, adjacent word pairs and boundary-marked character
+// trigrams (which also match spelling variants such as color/colour).
+func (e *LexicalEmbedder) wordFeatures(words []string, add func(string, float64)) {
+	kept := make([]string, 0, len(words))
+	for _, w := range words {
+		if !e.isStopword(w) {
+			kept = append(kept, w)
+		}
+	}
+	// a text made only of stopwords still deserves a vector
+	if len(kept) == 0 {
+		kept = words
+	}
+
+	for i, w := range kept {
+		add("w:"+w, lexicalWordWeight)
+		if i > 0 {
+			add("b:"+kept[i-1]+" "+w, lexicalBigramWeight)
+		}
+		r := []rune("<" + w + ">")
+		for j := 0; j+3 <= len(r); j++ {
+			add("c:"+string(r[j:j+3]), lexicalTrigramWeight)
+		}
+	}
+}
+
+// hanFeatures emits single characters and character pairs of a run of Han
+// characters, skipping stopword characters and pairs.
+func (e *LexicalEmbedder) hanFeatures(runes []rune, add func(string, float64)) {
+	kept := make([]rune, 0, len(runes))
+	for _, r := range runes {
+		if !e.isStopword(string(r)) {
+			kept = append(kept, r)
+		}
+	}
+	if len(kept) == 0 {
+		kept = runes
+	}
+
+	for i, r := range kept {
+		add("h:"+string(r), lexicalHanWeight)
+		if i > 0 {
+			pair := string(kept[i-1 : i+1])
+			if !e.isStopword(pair) {
+				add("p:"+pair, lexicalHanPairWeight)
+			}
+		}
+	}
+}
+
+type textSegment struct {
+	han   bool
+	runes []rune   // han segments
+	words []string // other segments, lower-cased
+}
+
+// segmentText splits text into runs of Han characters and runs of
+// letter/digit words; everything else separates segments or words.
+func segmentText(text string) []textSegment {
+	var segs []textSegment
+	var word []rune
+	var cur *textSegment
+
+	flushWord := func() {
+		if len(word) > 0 {
+			cur.words = append(cur.words, strings.ToLower(string(word)))
+			word = word[:0]
+		}
+	}
+	open := func(han bool) {
+		if cur != nil && cur.han == han {
+			return
+		}
+		if cur != nil && !cur.han {
+			flushWord()
+		}
+		segs = append(segs, textSegment{han: han})
+		cur = &segs[len(segs)-1]
+	}
+
+	for _, r := range text {
+		switch {
+		case unicode.Is(unicode.Han, r):
+			open(true)
+			cur.runes = append(cur.runes, r)
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			open(false)
+			word = append(word, r)
+		case r == '\'' || r == '’':
+			// drop apostrophes so that "don't" and "dont" match
+		default:
+			if cur != nil && !cur.han {
+				flushWord()
+			}
+		}
+	}
+	if cur != nil && !cur.han {
+		flushWord()
+	}
+	return segs
+}
+
+var englishStopwords = []string{
+	"a", "about", "above", "after", "again", "against", "all", "am", "an", "and", "any", "are", "as", "at",
+	"be", "because", "been", "before", "being", "below", "between", "both", "but", "by",
+	"can", "could", "did", "do", "does", "doing", "dont", "down", "during",
+	"each", "few", "for", "from", "further", "had", "has", "have", "having", "he", "her", "here", "hers",
+	"herself", "him", "himself", "his", "how", "i", "if", "im", "in", "into", "is", "it", "its", "itself",
+	"just", "me", "more", "most", "my", "myself", "no", "nor", "not", "now",
+	"of", "off", "on", "once", "only", "or", "other", "our", "ours", "ourselves", "out", "over", "own",
+	"same", "she", "should", "so", "some", "such", "than", "that", "the", "their", "theirs", "them",
+	"themselves", "then", "there", "these", "they", "this", "those", "through", "to", "too",
+	"under", "until", "up", "very", "was", "we", "were", "what", "when", "where", "which", "while",
+	"who", "whom", "why", "will", "with", "would", "you", "your", "yours", "yourself", "yourselves",
+}
+
+var chineseStopwords = []string{
+	"的", "了", "是", "在", "我", "有", "和", "就", "不", "都", "一", "也", "很", "到", "要", "会",
+	"着", "你", "他", "她", "它", "这", "那", "吗", "呢", "吧", "啊", "呀", "哦", "嗯", "与", "及",
+	"把", "被", "让", "给", "对", "从", "而", "但", "并", "或", "又", "还", "个", "之", "其", "们",
+	"我们", "你们", "他们", "她们", "它们", "一个", "没有", "自己", "这个", "那个", "什么", "因为",
+	"所以", "如果", "但是", "可以", "已经", "就是", "还是", "这样", "那样", "这些", "那些",
+}
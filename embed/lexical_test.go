@@ -0,0 +1,79 @@
+package embed_test
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"memorigo/embed"
+)
+
+func lexicalSimilarity(t *testing.T, e embed.Embedder, a, b string) float64 {
+	t.Helper()
+	vecs, err := e.EmbedTexts(context.Background(), []string{a, b})
+	if err != nil {
+		t.Fatalf("embed: %v", err)
+	}
+	return embed.CosineSimilarity(vecs[0], vecs[1])
+}
+
+func TestLexicalEmbedder_RelatedTextsScoreHigher(t *testing.T) {
+	e := embed.NewLexicalEmbedder(embed.Config{})
+
+	cases := []struct {
+		query, related, unrelated string
+	}{
+		{"favorite color", "colour I like best: my favourite is blue", "the weather in Paris is rainy"},
+		{"Where do I live?", "I live in Lisbon", "My dog is called Rex"},
+		{"我喜欢什么颜色", "我最喜欢的颜色是蓝色", "今天北京的天气很好"},
+	}
+	for _, c := range cases {
+		rel := lexicalSimilarity(t, e, c.query, c.related)
+		unrel := lexicalSimilarity(t, e, c.query, c.unrelated)
+		if rel <= unrel {
+			t.Errorf("%q: related %.3f should beat unrelated %.3f", c.query, rel, unrel)
+		}
+	}
+}
+
+func TestLexicalEmbedder_NormalizedAndDeterministic(t *testing.T) {
+	e := embed.NewLexicalEmbedder(embed.Config{Dimension: 128})
+	a, _ := e.EmbedText(context.Background(), "Word order should not matter much")
+	b, _ := e.EmbedText(context.Background(), "Word order should not matter much")
+	if len(a) != 128 || e.Dimension() != 128 {
+		t.Fatalf("unexpected dimension %d", len(a))
+	}
+	var norm float64
+	for i := range a {
+		if a[i] != b[i] {
+			t.Fatalf("embedding is not deterministic at %d", i)
+		}
+		norm += float64(a[i]) * float64(a[i])
+	}
+	if math.Abs(norm-1) > 1e-5 {
+		t.Fatalf("expected unit vector, got norm² %f", norm)
+	}
+
+	// stopword-only and empty texts
+	if v, _ := e.EmbedText(context.Background(), "it is what it is"); isZero(v) {
+		t.Fatalf("stopword-only text should still embed")
+	}
+	if v, _ := e.EmbedText(context.Background(), ""); !isZero(v) {
+		t.Fatalf("empty text should embed to the zero vector")
+	}
+}
+
+func TestLexicalEmbedder_StopwordsChangeModel(t *testing.T) {
+	if a, b := embed.NewLexicalEmbedder(embed.Config{}).Model(), embed.NewLexicalEmbedder(embed.Config{Stopwords: "none"}).Model(); a == b {
+		t.Fatalf("models should differ, both %q", a)
+	}
+}
+
+func isZero(v []float32) bool {
+	for _, x := range v {
+		if x != 0 {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,107 @@
+package embed_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"memorigo/embed"
+)
+
+// vectorFor returns a deterministic 3-dim vector, or 5 dims for "wide".
+func vectorFor(text string) []float32 {
+	if text == "wide" {
+		return []float32{1, 2, 3, 4, 5}
+	}
+	return []float32{float32(len(text)), 1, 0}
+}
+
+func TestOllamaEmbedder_WireFormatAndDimension(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/embed" {
+			http.NotFound(w, r)
+			return
+		}
+		calls.Add(1)
+		var req struct {
+			Model string   `json:"model"`
+			Input []string `json:"input"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Model != "all-minilm" {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		resp := struct {
+			Model      string      `json:"model"`
+			Embeddings [][]float32 `json:"embeddings"`
+		}{Model: req.Model}
+		for _, in := range req.Input {
+			resp.Embeddings = append(resp.Embeddings, vectorFor(in))
+		}
+		_ = json.NewEncoder(w).Encode(resp)
+	}))
+	defer srv.Close()
+
+	e := embed.NewOllamaEmbedder(embed.Config{BaseURL: srv.URL, Model: "all-minilm", BatchSize: 2})
+	if d := e.Dimension(); d != 3 {
+		t.Fatalf("probed dimension = %d, want 3", d)
+	}
+
+	out, err := e.EmbedTexts(context.Background(), []string{"a", "bb", "ccc"})
+	if err != nil {
+		t.Fatalf("embed: %v", err)
+	}
+	if out[0][0] != 1 || out[1][0] != 2 || out[2][0] != 3 {
+		t.Fatalf("embeddings out of order: %v", out)
+	}
+	// one probe plus two batches
+	if calls.Load() != 3 {
+		t.Fatalf("calls = %d, want 3", calls.Load())
+	}
+
+	if _, err := e.EmbedText(context.Background(), "wide"); !errors.Is(err, embed.ErrEmbeddingDimensionMismatch) {
+		t.Fatalf("err = %v, want dimension mismatch", err)
+	}
+}
+
+func TestTEIEmbedder_WireFormatAndDimension(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/embed" {
+			http.NotFound(w, r)
+			return
+		}
+		var req struct {
+			Inputs []string `json:"inputs"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		out := make([][]float32, 0, len(req.Inputs))
+		for _, in := range req.Inputs {
+			out = append(out, vectorFor(in))
+		}
+		_ = json.NewEncoder(w).Encode(out)
+	}))
+	defer srv.Close()
+
+	e := embed.NewTEIEmbedder(embed.Config{BaseURL: srv.URL})
+	vec, err := e.EmbedText(context.Background(), "hello")
+	if err != nil {
+		t.Fatalf("embed: %v", err)
+	}
+	if len(vec) != 3 || vec[0] != 5 {
+		t.Fatalf("unexpected vector %v", vec)
+	}
+	if d := e.Dimension(); d != 3 {
+		t.Fatalf("dimension = %d, want 3 (learned from first call)", d)
+	}
+	if e.Provider() != "tei" || e.Model() == "" {
+		t.Fatalf("unexpected provider/model %q/%q", e.Provider(), e.Model())
+	}
+}
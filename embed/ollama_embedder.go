@@ -0,0 +1,111 @@
+package embed
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// ollamaDefaultBatch bounds the inputs per /api/embed request; Ollama has no
+// hard limit but very large requests hold the model for a long time.
+const ollamaDefaultBatch = 64
+
+// OllamaEmbedder 调用本地 Ollama 的 /api/embed 接口。
+// 维度未配置时在首次调用时从响应中自动探测。
+type OllamaEmbedder struct {
+	client    *jsonClient
+	baseURL   string
+	model     string
+	dimension autoDimension
+	batchSize int
+}
+
+type OllamaEmbedRequest struct {
+	Model string   `json:"model"`
+	Input []string `json:"input"`
+}
+
+type OllamaEmbedResponse struct {
+	Model      string      `json:"model"`
+	Embeddings [][]float32 `json:"embeddings"`
+}
+
+func NewOllamaEmbedder(config Config) *OllamaEmbedder {
+	baseURL := config.BaseURL
+	if baseURL == "" {
+		baseURL = os.Getenv("OLLAMA_HOST")
+		if baseURL == "" {
+			baseURL = "http://localhost:11434"
+		}
+	}
+	if !strings.Contains(baseURL, "://") {
+		// OLLAMA_HOST is commonly given as host:port
+		baseURL = "http://" + baseURL
+	}
+
+	model := config.Model
+	if model == "" {
+		model = "nomic-embed-text"
+	}
+
+	batchSize := config.BatchSize
+	if batchSize <= 0 {
+		batchSize = ollamaDefaultBatch
+	}
+
+	return &OllamaEmbedder{
+		client:    newJSONClient(config, config.APIKey),
+		baseURL:   strings.TrimRight(baseURL, "/"),
+		model:     model,
+		dimension: autoDimension{value: config.Dimension},
+		batchSize: batchSize,
+	}
+}
+
+func (e *OllamaEmbedder) EmbedText(ctx context.Context, text string) ([]float32, error) {
+	out, err := e.EmbedTexts(ctx, []string{text})
+	if err != nil {
+		return nil, err
+	}
+	return out[0], nil
+}
+
+func (e *OllamaEmbedder) EmbedTexts(ctx context.Context, texts []string) ([][]float32, error) {
+	if len(texts) == 0 {
+		return nil, nil
+	}
+
+	result := make([][]float32, len(texts))
+	for start, batch := range batches(texts, e.batchSize) {
+		offset := start * e.batchSize
+		var resp OllamaEmbedResponse
+		req := OllamaEmbedRequest{Model: e.model, Input: batch}
+		if err := e.client.postJSON(ctx, e.baseURL+"/api/embed", req, estimateTokens(batch), &resp); err != nil {
+			return nil, fmt.Errorf("failed to embed texts %d-%d: %w", offset, offset+len(batch)-1, err)
+		}
+		if len(resp.Embeddings) != len(batch) {
+			return nil, fmt.Errorf("expected %d embeddings, got %d", len(batch), len(resp.Embeddings))
+		}
+		for i, vec := range resp.Embeddings {
+			if err := e.dimension.observe(len(vec)); err != nil {
+				return nil, err
+			}
+			result[offset+i] = vec
+		}
+	}
+	return result, nil
+}
+
+// Dimension 返回向量维度；未配置且尚未调用过时会向服务器发起一次探测请求，失败时返回 0。
+func (e *OllamaEmbedder) Dimension() int {
+	return e.dimension.resolve(context.Background(), e.EmbedTexts)
+}
+
+func (e *OllamaEmbedder) Provider() string {
+	return "ollama"
+}
+
+func (e *OllamaEmbedder) Model() string {
+	return e.model
+}
@@ -1,22 +1,21 @@
 package embed
 
 import (
-	"bytes"
 	"context"
-	"encoding/json"
 	"fmt"
-	"io"
-	"net/http"
-	"time"
 )
 
+// openAIMaxBatch is the maximum number of inputs per /v1/embeddings request.
+const openAIMaxBatch = 2048
+
 type OpenAIEmbedder struct {
 	config    Config
-	client    *http.Client
+	client    *jsonClient
 	baseURL   string
 	apiKey    string
 	model     string
 	dimension int
+	batchSize int
 }
 
 type OpenAIEmbeddingRequest struct {
@@ -58,13 +57,19 @@ func NewOpenAIEmbedder(config Config) *OpenAIEmbedder {
 		dimension = 1536
 	}
 
+	batchSize := config.BatchSize
+	if batchSize <= 0 || batchSize > openAIMaxBatch {
+		batchSize = openAIMaxBatch
+	}
+
 	return &OpenAIEmbedder{
 		config:    config,
-		client:    &http.Client{Timeout: 30 * time.Second},
+		client:    newJSONClient(config, config.APIKey),
 		baseURL:   baseURL,
 		apiKey:    config.APIKey,
 		model:     model,
 		dimension: dimension,
+		batchSize: batchSize,
 	}
 }
 
@@ -111,18 +116,30 @@ func (e *OpenAIEmbedder) EmbedTexts(ctx context.Context, texts []string) ([][]fl
 		return result, nil
 	}
 
-	req := OpenAIEmbeddingRequest{
-		Input: filtered,
-		Model: e.model,
-	}
+	// Split into provider-sized batches; results are placed by response index.
+	embeddings := make([][]float32, 0, len(filtered))
+	for _, batch := range batches(filtered, e.batchSize) {
+		req := OpenAIEmbeddingRequest{
+			Input: batch,
+			Model: e.model,
+		}
 
-	resp, err := e.makeRequest(ctx, req)
-	if err != nil {
-		return nil, err
-	}
+		resp, err := e.makeRequest(ctx, req)
+		if err != nil {
+			return nil, err
+		}
 
-	if len(resp.Data) != len(filtered) {
-		return nil, fmt.Errorf("expected %d embeddings, got %d", len(filtered), len(resp.Data))
+		if len(resp.Data) != len(batch) {
+			return nil, fmt.Errorf("expected %d embeddings, got %d", len(batch), len(resp.Data))
+		}
+		ordered := make([][]float32, len(batch))
+		for _, d := range resp.Data {
+			if d.Index < 0 || d.Index >= len(batch) {
+				return nil, fmt.Errorf("embedding index %d out of range", d.Index)
+			}
+			ordered[d.Index] = d.Embedding
+		}
+		embeddings = append(embeddings, ordered...)
 	}
 
 	result := make([][]float32, len(texts))
@@ -131,7 +148,7 @@ func (e *OpenAIEmbedder) EmbedTexts(ctx context.Context, texts []string) ([][]fl
 		if text == "" {
 			result[i] = make([]float32, e.dimension)
 		} else {
-			result[i] = resp.Data[filteredIndex].Embedding
+			result[i] = embeddings[filteredIndex]
 			filteredIndex++
 		}
 	}
@@ -140,37 +157,18 @@ func (e *OpenAIEmbedder) EmbedTexts(ctx context.Context, texts []string) ([][]fl
 }
 
 func (e *OpenAIEmbedder) makeRequest(ctx context.Context, req OpenAIEmbeddingRequest) (*OpenAIEmbeddingResponse, error) {
-	jsonData, err := json.Marshal(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal request: %w", err)
-	}
-
-	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, e.baseURL+"/v1/embeddings", bytes.NewReader(jsonData))
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
-
-	httpReq.Header.Set("Content-Type", "application/json")
-	if e.apiKey != "" {
-		httpReq.Header.Set("Authorization", "Bearer "+e.apiKey)
-	}
-
-	resp, err := e.client.Do(httpReq)
-	if err != nil {
-		return nil, fmt.Errorf("request failed: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("API error %d: %s", resp.StatusCode, string(body))
+	var tokens int
+	switch in := req.Input.(type) {
+	case string:
+		tokens = estimateTokens([]string{in})
+	case []string:
+		tokens = estimateTokens(in)
 	}
 
 	var embeddingResp OpenAIEmbeddingResponse
-	if err := json.NewDecoder(resp.Body).Decode(&embeddingResp); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
+	if err := e.client.postJSON(ctx, e.baseURL+"/v1/embeddings", req, tokens, &embeddingResp); err != nil {
+		return nil, err
 	}
-
 	return &embeddingResp, nil
 }
 
@@ -181,3 +179,7 @@ func (e *OpenAIEmbedder) Dimension() int {
 func (e *OpenAIEmbedder) Provider() string {
 	return "openai"
 }
+
+func (e *OpenAIEmbedder) Model() string {
+	return e.model
+}
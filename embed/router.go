@@ -0,0 +1,174 @@
+package embed
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// Source identifies the provider and model that produced a batch of vectors.
+// Vectors from different sources live in different vector spaces and must not
+// be compared.
+type Source struct {
+	Provider string
+	Model    string
+}
+
+// SourceOf returns the source e reports for all of its vectors.
+func SourceOf(e Embedder) Source {
+	return Source{Provider: e.Provider(), Model: ModelOf(e)}
+}
+
+// SourcedEmbedder is implemented by embedders whose vectors may come from
+// more than one provider, such as Router.
+type SourcedEmbedder interface {
+	EmbedTextsSourced(ctx context.Context, texts []string) ([][]float32, Source, error)
+}
+
+// EmbedWithSource embeds texts and reports which provider produced them.
+func EmbedWithSource(ctx context.Context, e Embedder, texts []string) ([][]float32, Source, error) {
+	if s, ok := e.(SourcedEmbedder); ok {
+		return s.EmbedTextsSourced(ctx, texts)
+	}
+	vecs, err := e.EmbedTexts(ctx, texts)
+	return vecs, SourceOf(e), err
+}
+
+type noFallbackKey struct{}
+
+// WithoutFallback returns a context in which a Router only uses its primary
+// provider, e.g. for jobs that must produce vectors in the primary space.
+func WithoutFallback(ctx context.Context) context.Context {
+	return context.WithValue(ctx, noFallbackKey{}, true)
+}
+
+// RouterOption configures a Router.
+type RouterOption func(*Router)
+
+// WithBreaker opens the circuit after threshold consecutive primary failures
+// and keeps it open for cooldown before letting a single probe through.
+// Defaults are 3 failures and 30s.
+func WithBreaker(threshold int, cooldown time.Duration) RouterOption {
+	return func(r *Router) {
+		if threshold > 0 {
+			r.threshold = threshold
+		}
+		if cooldown > 0 {
+			r.cooldown = cooldown
+		}
+	}
+}
+
+// Router sends requests to a primary embedder and, when it fails or its
+// circuit breaker is open, to a declared fallback. Provider, Model and
+// Dimension describe the primary; use EmbedWithSource to learn which provider
+// produced a given batch.
+type Router struct {
+	primary  Embedder
+	fallback Embedder
+
+	threshold int
+	cooldown  time.Duration
+	now       func() time.Time
+
+	mu        sync.Mutex
+	failures  int
+	openUntil time.Time
+	probing   bool
+}
+
+// NewRouter returns a Router. fallback may be nil, in which case the breaker
+// only fails fast while the primary is down.
+func NewRouter(primary, fallback Embedder, opts ...RouterOption) *Router {
+	r := &Router{
+		primary:   primary,
+		fallback:  fallback,
+		threshold: 3,
+		cooldown:  30 * time.Second,
+		now:       time.Now,
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+func (r *Router) EmbedText(ctx context.Context, text string) ([]float32, error) {
+	out, err := r.EmbedTexts(ctx, []string{text})
+	if err != nil {
+		return nil, err
+	}
+	return out[0], nil
+}
+
+func (r *Router) EmbedTexts(ctx context.Context, texts []string) ([][]float32, error) {
+	vecs, _, err := r.EmbedTextsSourced(ctx, texts)
+	return vecs, err
+}
+
+func (r *Router) EmbedTextsSourced(ctx context.Context, texts []string) ([][]float32, Source, error) {
+	noFallback, _ := ctx.Value(noFallbackKey{}).(bool)
+
+	var primaryErr error
+	if r.allow() {
+		vecs, err := r.primary.EmbedTexts(ctx, texts)
+		r.record(ctx, err)
+		if err == nil {
+			return vecs, SourceOf(r.primary), nil
+		}
+		primaryErr = err
+	} else {
+		primaryErr = ErrCircuitOpen
+	}
+
+	if r.fallback == nil || noFallback || ctx.Err() != nil {
+		return nil, Source{}, primaryErr
+	}
+	vecs, err := r.fallback.EmbedTexts(ctx, texts)
+	if err != nil {
+		return nil, Source{}, errors.Join(primaryErr, err)
+	}
+	return vecs, SourceOf(r.fallback), nil
+}
+
+// allow reports whether the primary may be called: the circuit is closed, or
+// it is open past its cooldown and no other probe is in flight.
+func (r *Router) allow() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.failures < r.threshold {
+		return true
+	}
+	if r.probing || r.now().Before(r.openUntil) {
+		return false
+	}
+	r.probing = true
+	return true
+}
+
+func (r *Router) record(ctx context.Context, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.probing = false
+	switch {
+	case err == nil:
+		r.failures = 0
+	case ctx.Err() != nil:
+		// the caller gave up; says nothing about the provider
+	default:
+		r.failures++
+		if r.failures >= r.threshold {
+			r.openUntil = r.now().Add(r.cooldown)
+		}
+	}
+}
+
+func (r *Router) Dimension() int { return r.primary.Dimension() }
+
+func (r *Router) Provider() string { return r.primary.Provider() }
+
+func (r *Router) Model() string { return ModelOf(r.primary) }
+
+// Unwrap returns the primary embedder.
+func (r *Router) Unwrap() Embedder { return r.primary }
@@ -0,0 +1,79 @@
+package embed_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"memorigo/embed"
+)
+
+// flakyEmbedder fails while down is set.
+type flakyEmbedder struct {
+	name  string
+	down  atomic.Bool
+	calls atomic.Int32
+}
+
+func (e *flakyEmbedder) EmbedText(ctx context.Context, text string) ([]float32, error) {
+	out, err := e.EmbedTexts(ctx, []string{text})
+	if err != nil {
+		return nil, err
+	}
+	return out[0], nil
+}
+
+func (e *flakyEmbedder) EmbedTexts(ctx context.Context, texts []string) ([][]float32, error) {
+	e.calls.Add(1)
+	if e.down.Load() {
+		return nil, &embed.APIError{StatusCode: 503, Body: "down"}
+	}
+	out := make([][]float32, len(texts))
+	for i := range out {
+		out[i] = []float32{1, 0}
+	}
+	return out, nil
+}
+
+func (e *flakyEmbedder) Dimension() int   { return 2 }
+func (e *flakyEmbedder) Provider() string { return e.name }
+
+func TestRouter_FallbackAndCircuitBreaker(t *testing.T) {
+	primary := &flakyEmbedder{name: "primary"}
+	fallback := &flakyEmbedder{name: "fallback"}
+	r := embed.NewRouter(primary, fallback, embed.WithBreaker(2, 50*time.Millisecond))
+	ctx := context.Background()
+
+	_, src, err := embed.EmbedWithSource(ctx, r, []string{"a"})
+	if err != nil || src.Provider != "primary" {
+		t.Fatalf("healthy primary: src=%v err=%v", src, err)
+	}
+
+	primary.down.Store(true)
+	for i := 0; i < 2; i++ {
+		_, src, err = embed.EmbedWithSource(ctx, r, []string{"a"})
+		if err != nil || src.Provider != "fallback" {
+			t.Fatalf("failing primary: src=%v err=%v", src, err)
+		}
+	}
+	if primary.calls.Load() != 3 {
+		t.Fatalf("primary calls = %d, want 3", primary.calls.Load())
+	}
+
+	// circuit is open: the primary is not called at all
+	if _, src, _ = embed.EmbedWithSource(ctx, r, []string{"a"}); src.Provider != "fallback" || primary.calls.Load() != 3 {
+		t.Fatalf("open circuit still called primary (%d calls)", primary.calls.Load())
+	}
+	if _, err := r.EmbedTexts(embed.WithoutFallback(ctx), []string{"a"}); !errors.Is(err, embed.ErrCircuitOpen) {
+		t.Fatalf("WithoutFallback err = %v, want ErrCircuitOpen", err)
+	}
+
+	// after the cooldown a probe closes the circuit again
+	primary.down.Store(false)
+	time.Sleep(60 * time.Millisecond)
+	if _, src, err = embed.EmbedWithSource(ctx, r, []string{"a"}); err != nil || src.Provider != "primary" {
+		t.Fatalf("after cooldown: src=%v err=%v", src, err)
+	}
+}
@@ -1,28 +1,27 @@
 package embed
 
 import (
-	"bytes"
 	"context"
-	"encoding/json"
 	"fmt"
-	"io"
-	"net/http"
 	"os"
-	"time"
 )
 
+// siliconFlowMaxBatch is the maximum number of inputs SiliconFlow accepts per request.
+const siliconFlowMaxBatch = 32
+
 type SiliconFlowEmbedder struct {
 	config    Config
-	client    *http.Client
+	client    *jsonClient
 	baseURL   string
 	apiKey    string
 	model     string
 	dimension int
+	batchSize int
 }
 
 type SiliconFlowEmbeddingRequest struct {
-	Model string `json:"model"`
-	Input string `json:"input"`
+	Model string      `json:"model"`
+	Input interface{} `json:"input"` // string or []string
 }
 
 type SiliconFlowEmbeddingResponse struct {
@@ -64,13 +63,19 @@ func NewSiliconFlowEmbedder(config Config) *SiliconFlowEmbedder {
 		dimension = 1024
 	}
 
+	batchSize := config.BatchSize
+	if batchSize <= 0 || batchSize > siliconFlowMaxBatch {
+		batchSize = siliconFlowMaxBatch
+	}
+
 	return &SiliconFlowEmbedder{
 		config:    config,
-		client:    &http.Client{Timeout: 30 * time.Second},
+		client:    newJSONClient(config, apiKey),
 		baseURL:   baseURL,
 		apiKey:    apiKey,
 		model:     model,
 		dimension: dimension,
+		batchSize: batchSize,
 	}
 }
 
@@ -102,50 +107,58 @@ func (e *SiliconFlowEmbedder) EmbedTexts(ctx context.Context, texts []string) ([
 	}
 
 	result := make([][]float32, len(texts))
+	var pending []int
 	for i, text := range texts {
-		embedding, err := e.EmbedText(ctx, text)
+		if text == "" {
+			result[i] = make([]float32, e.dimension)
+			continue
+		}
+		pending = append(pending, i)
+	}
+
+	// Embed non-empty texts in provider-sized batches.
+	for start := 0; start < len(pending); start += e.batchSize {
+		end := start + e.batchSize
+		if end > len(pending) {
+			end = len(pending)
+		}
+		idx := pending[start:end]
+		batch := make([]string, len(idx))
+		for j, i := range idx {
+			batch[j] = texts[i]
+		}
+
+		resp, err := e.makeRequest(ctx, SiliconFlowEmbeddingRequest{Model: e.model, Input: batch})
 		if err != nil {
-			return nil, fmt.Errorf("failed to embed text at index %d: %w", i, err)
+			return nil, fmt.Errorf("failed to embed texts %d-%d: %w", idx[0], idx[len(idx)-1], err)
+		}
+		if len(resp.Data) != len(batch) {
+			return nil, fmt.Errorf("expected %d embeddings, got %d", len(batch), len(resp.Data))
+		}
+		for _, d := range resp.Data {
+			if d.Index < 0 || d.Index >= len(idx) {
+				return nil, fmt.Errorf("embedding index %d out of range", d.Index)
+			}
+			result[idx[d.Index]] = d.Embedding
 		}
-		result[i] = embedding
 	}
 
 	return result, nil
 }
 
 func (e *SiliconFlowEmbedder) makeRequest(ctx context.Context, req SiliconFlowEmbeddingRequest) (*SiliconFlowEmbeddingResponse, error) {
-	jsonData, err := json.Marshal(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal request: %w", err)
-	}
-
-	url := e.baseURL + "/v1/embeddings"
-	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(jsonData))
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
-
-	httpReq.Header.Set("Content-Type", "application/json")
-	if e.apiKey != "" {
-		httpReq.Header.Set("Authorization", "Bearer "+e.apiKey)
-	}
-
-	resp, err := e.client.Do(httpReq)
-	if err != nil {
-		return nil, fmt.Errorf("request failed: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("API error %d: %s", resp.StatusCode, string(body))
+	var tokens int
+	switch in := req.Input.(type) {
+	case string:
+		tokens = estimateTokens([]string{in})
+	case []string:
+		tokens = estimateTokens(in)
 	}
 
 	var embeddingResp SiliconFlowEmbeddingResponse
-	if err := json.NewDecoder(resp.Body).Decode(&embeddingResp); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
+	if err := e.client.postJSON(ctx, e.baseURL+"/v1/embeddings", req, tokens, &embeddingResp); err != nil {
+		return nil, err
 	}
-
 	return &embeddingResp, nil
 }
 
@@ -156,3 +169,7 @@ func (e *SiliconFlowEmbedder) Dimension() int {
 func (e *SiliconFlowEmbedder) Provider() string {
 	return "siliconflow"
 }
+
+func (e *SiliconFlowEmbedder) Model() string {
+	return e.model
+}
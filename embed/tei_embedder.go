@@ -0,0 +1,101 @@
+package embed
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// teiDefaultBatch matches TEI's default --max-client-batch-size.
+const teiDefaultBatch = 32
+
+// TEIEmbedder 调用 HuggingFace text-embeddings-inference 的 /embed 接口。
+// TEI 每个实例只服务一个模型，Model 仅用于记录向量来源；维度未配置时自动探测。
+type TEIEmbedder struct {
+	client    *jsonClient
+	baseURL   string
+	model     string
+	dimension autoDimension
+	batchSize int
+}
+
+type TEIEmbedRequest struct {
+	Inputs   []string `json:"inputs"`
+	Truncate bool     `json:"truncate"`
+}
+
+func NewTEIEmbedder(config Config) *TEIEmbedder {
+	baseURL := config.BaseURL
+	if baseURL == "" {
+		baseURL = os.Getenv("TEI_BASE_URL")
+		if baseURL == "" {
+			baseURL = "http://localhost:8080"
+		}
+	}
+
+	model := config.Model
+	if model == "" {
+		model = "tei"
+	}
+
+	batchSize := config.BatchSize
+	if batchSize <= 0 {
+		batchSize = teiDefaultBatch
+	}
+
+	return &TEIEmbedder{
+		client:    newJSONClient(config, config.APIKey),
+		baseURL:   strings.TrimRight(baseURL, "/"),
+		model:     model,
+		dimension: autoDimension{value: config.Dimension},
+		batchSize: batchSize,
+	}
+}
+
+func (e *TEIEmbedder) EmbedText(ctx context.Context, text string) ([]float32, error) {
+	out, err := e.EmbedTexts(ctx, []string{text})
+	if err != nil {
+		return nil, err
+	}
+	return out[0], nil
+}
+
+func (e *TEIEmbedder) EmbedTexts(ctx context.Context, texts []string) ([][]float32, error) {
+	if len(texts) == 0 {
+		return nil, nil
+	}
+
+	result := make([][]float32, len(texts))
+	for start, batch := range batches(texts, e.batchSize) {
+		offset := start * e.batchSize
+		var resp [][]float32
+		req := TEIEmbedRequest{Inputs: batch, Truncate: true}
+		if err := e.client.postJSON(ctx, e.baseURL+"/embed", req, estimateTokens(batch), &resp); err != nil {
+			return nil, fmt.Errorf("failed to embed texts %d-%d: %w", offset, offset+len(batch)-1, err)
+		}
+		if len(resp) != len(batch) {
+			return nil, fmt.Errorf("expected %d embeddings, got %d", len(batch), len(resp))
+		}
+		for i, vec := range resp {
+			if err := e.dimension.observe(len(vec)); err != nil {
+				return nil, err
+			}
+			result[offset+i] = vec
+		}
+	}
+	return result, nil
+}
+
+// Dimension 返回向量维度；未配置且尚未调用过时会向服务器发起一次探测请求，失败时返回 0。
+func (e *TEIEmbedder) Dimension() int {
+	return e.dimension.resolve(context.Background(), e.EmbedTexts)
+}
+
+func (e *TEIEmbedder) Provider() string {
+	return "tei"
+}
+
+func (e *TEIEmbedder) Model() string {
+	return e.model
+}
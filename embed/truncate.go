@@ -0,0 +1,72 @@
+package embed
+
+import (
+	"context"
+
+	"memorigo/vecmath"
+)
+
+// TruncatedEmbedder keeps the first dims components of each vector and
+// re-normalizes them. This is only meaningful for Matryoshka-trained models
+// (e.g. OpenAI text-embedding-3-*, nomic-embed-text v1.5) whose leading
+// dimensions carry most of the signal.
+type TruncatedEmbedder struct {
+	inner Embedder
+	dims  int
+}
+
+// Truncated wraps embedder so that it returns at most dims components.
+// dims <= 0 returns embedder unchanged.
+func Truncated(embedder Embedder, dims int) Embedder {
+	if dims <= 0 {
+		return embedder
+	}
+	return &TruncatedEmbedder{inner: embedder, dims: dims}
+}
+
+func (t *TruncatedEmbedder) EmbedText(ctx context.Context, text string) ([]float32, error) {
+	v, err := t.inner.EmbedText(ctx, text)
+	if err != nil {
+		return nil, err
+	}
+	return t.truncate(v), nil
+}
+
+func (t *TruncatedEmbedder) EmbedTexts(ctx context.Context, texts []string) ([][]float32, error) {
+	vecs, _, err := t.EmbedTextsSourced(ctx, texts)
+	return vecs, err
+}
+
+func (t *TruncatedEmbedder) EmbedTextsSourced(ctx context.Context, texts []string) ([][]float32, Source, error) {
+	vecs, src, err := EmbedWithSource(ctx, t.inner, texts)
+	if err != nil {
+		return nil, src, err
+	}
+	for i, v := range vecs {
+		vecs[i] = t.truncate(v)
+	}
+	return vecs, src, nil
+}
+
+func (t *TruncatedEmbedder) truncate(v []float32) []float32 {
+	if len(v) <= t.dims {
+		return v
+	}
+	v = v[:t.dims:t.dims]
+	vecmath.Normalize(v)
+	return v
+}
+
+func (t *TruncatedEmbedder) Dimension() int {
+	if d := t.inner.Dimension(); d > 0 && d < t.dims {
+		return d
+	}
+	return t.dims
+}
+
+func (t *TruncatedEmbedder) Provider() string { return t.inner.Provider() }
+
+func (t *TruncatedEmbedder) Model() string { return ModelOf(t.inner) }
+
+// Unwrap returns the decorated embedder.
+func (t *TruncatedEmbedder) Unwrap() Embedder { return t.inner }
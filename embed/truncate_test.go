@@ -0,0 +1,78 @@
+package embed_test
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"memorigo/embed"
+)
+
+// fixedEmbedder returns the same 4-dimensional vector for every text.
+type fixedEmbedder struct{}
+
+func (fixedEmbedder) EmbedText(context.Context, string) ([]float32, error) {
+	return []float32{3, 4, 12, 0}, nil
+}
+
+func (e fixedEmbedder) EmbedTexts(ctx context.Context, texts []string) ([][]float32, error) {
+	out := make([][]float32, len(texts))
+	for i := range texts {
+		out[i], _ = e.EmbedText(ctx, texts[i])
+	}
+	return out, nil
+}
+
+func (fixedEmbedder) Dimension() int   { return 4 }
+func (fixedEmbedder) Provider() string { return "fixed" }
+
+func TestTruncated(t *testing.T) {
+	if e := embed.Truncated(fixedEmbedder{}, 0); e != (fixedEmbedder{}) {
+		t.Fatalf("Truncated(e, 0) = %T, want e unchanged", e)
+	}
+
+	tests := []struct {
+		dims    int
+		wantDim int
+		want    []float32
+	}{
+		// The leading components, scaled back to unit length.
+		{2, 2, []float32{0.6, 0.8}},
+		// At or above the model dimension the vector passes through.
+		{4, 4, []float32{3, 4, 12, 0}},
+		{8, 4, []float32{3, 4, 12, 0}},
+	}
+	for _, tt := range tests {
+		e := embed.Truncated(fixedEmbedder{}, tt.dims)
+		if d := e.Dimension(); d != tt.wantDim {
+			t.Errorf("dims %d: Dimension() = %d, want %d", tt.dims, d, tt.wantDim)
+		}
+
+		v, err := e.EmbedText(context.Background(), "a")
+		if err != nil {
+			t.Fatal(err)
+		}
+		vecs, err := e.EmbedTexts(context.Background(), []string{"a", "b"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, got := range append([][]float32{v}, vecs...) {
+			if len(got) != len(tt.want) {
+				t.Fatalf("dims %d: vector = %v, want %v", tt.dims, got, tt.want)
+			}
+			for i := range got {
+				if math.Abs(float64(got[i]-tt.want[i])) > 1e-6 {
+					t.Fatalf("dims %d: vector = %v, want %v", tt.dims, got, tt.want)
+				}
+			}
+		}
+	}
+
+	e := embed.Truncated(fixedEmbedder{}, 2)
+	if e.Provider() != "fixed" {
+		t.Errorf("Provider() = %q, want fixed", e.Provider())
+	}
+	if inner := e.(interface{ Unwrap() embed.Embedder }).Unwrap(); inner != (fixedEmbedder{}) {
+		t.Errorf("Unwrap() = %T", inner)
+	}
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"memorigo/memori"
+)
+
+// Example: Anthropic Messages API (streaming) + SQLite (in-memory).
+// Requires: ANTHROPIC_API_KEY (ANTHROPIC_BASE_URL optional)
+func main() {
+	if os.Getenv("ANTHROPIC_API_KEY") == "" {
+		fmt.Println("ANTHROPIC_API_KEY not set; skipping Anthropic example")
+		return
+	}
+
+	db, cleanup := openInMemorySQLite()
+	defer cleanup()
+
+	m := memori.New(memori.WithStorageConn(db))
+	if err := m.Storage.Build(); err != nil {
+		panic(err)
+	}
+
+	m.Attribution("user-anthropic", "demo-bot")
+
+	// One-line registration
+	m.Anthropic.Register(memori.NewAnthropicClientFromEnv())
+
+	req := memori.AnthropicMessagesRequest{
+		Model:     "claude-3-5-haiku-latest",
+		MaxTokens: 256,
+		System:    "You are a concise assistant.",
+		Messages: []memori.AnthropicMessage{
+			{Role: "user", Content: memori.AnthropicTextContent("My favorite hobby is rock climbing.")},
+		},
+	}
+
+	events, errs := m.AnthropicClient().MessagesStream(context.Background(), req)
+	fmt.Println("LLM response:")
+	for ev := range events {
+		if ev.Type == "content_block_delta" && ev.Delta != nil {
+			fmt.Print(ev.Delta.Text)
+		}
+	}
+	fmt.Println()
+	if err := <-errs; err != nil {
+		panic(err)
+	}
+
+	// Wait a bit for offline augmentation to persist facts.
+	time.Sleep(100 * time.Millisecond)
+
+	facts, err := m.Recall("favorite hobby", 5)
+	if err != nil {
+		panic(err)
+	}
+
+	fmt.Println("Recall results:")
+	for i, f := range facts {
+		fmt.Printf("%d) score=%.4f times=%d content=%q\n", i+1, f.Score, f.NumTimes, f.Content)
+	}
+}
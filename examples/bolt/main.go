@@ -0,0 +1,59 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+
+	"memorigo/memori"
+)
+
+// Example: single-file embedded storage with no SQL engine, no cgo and no server.
+func main() {
+	path := os.Getenv("MEMORI_BOLT_PATH")
+	if path == "" {
+		path = filepath.Join(os.TempDir(), "memori_demo.db")
+	}
+
+	db, err := bolt.Open(path, 0o600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		panic(err)
+	}
+	defer db.Close()
+
+	m := memori.New(memori.WithStorageConn(db))
+	if err := m.Storage.Build(); err != nil {
+		panic(err)
+	}
+
+	m.Attribution("user-123", "demo-bot")
+
+	w := memori.NewWriter(m)
+	payload := memori.ConversationPayload{
+		Messages: []memori.Message{
+			{Role: "user", Content: "My favorite color is blue"},
+		},
+		Response: &memori.Message{Role: "assistant", Content: "Noted."},
+	}
+
+	if err := w.Execute(context.Background(), payload); err != nil {
+		panic(err)
+	}
+
+	// Wait a bit for async offline augmentation to upsert facts
+	time.Sleep(100 * time.Millisecond)
+
+	facts, err := m.Recall("favorite color", 5)
+	if err != nil {
+		panic(err)
+	}
+
+	fmt.Printf("Recall results (%s):\n", path)
+	for i, f := range facts {
+		fmt.Printf("%d) score=%.4f times=%d content=%q\n", i+1, f.Score, f.NumTimes, f.Content)
+	}
+}
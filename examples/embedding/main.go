@@ -24,9 +24,9 @@ func openInMemorySQLite() (*sql.DB, func()) {
 func main() {
 	fmt.Println("=== Memori Embedding Demo ===")
 
-	// Example 1: Using Hash embedding (default, offline)
-	fmt.Println("\n1. Using Hash embedding (offline, default):")
-	runEmbeddingExample("hash", "", "")
+	// Example 1: Using lexical embedding (default, offline)
+	fmt.Println("\n1. Using lexical embedding (offline, default):")
+	runEmbeddingExample("lexical", "", "")
 
 	// Example 2: Using OpenAI embedding (requires OPENAI_API_KEY)
 	if os.Getenv("OPENAI_API_KEY") != "" {
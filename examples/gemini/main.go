@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"memorigo/memori"
+)
+
+// Example: Gemini streamGenerateContent + SQLite (in-memory).
+// Requires: GEMINI_API_KEY (GEMINI_BASE_URL optional)
+func main() {
+	if os.Getenv("GEMINI_API_KEY") == "" && os.Getenv("GOOGLE_API_KEY") == "" {
+		fmt.Println("GEMINI_API_KEY not set; skipping Gemini example")
+		return
+	}
+
+	db, cleanup := openInMemorySQLite()
+	defer cleanup()
+
+	m := memori.New(memori.WithStorageConn(db))
+	if err := m.Storage.Build(); err != nil {
+		panic(err)
+	}
+
+	m.Attribution("user-gemini", "demo-bot")
+
+	// One-line registration
+	m.Gemini.Register(memori.NewGeminiClientFromEnv())
+
+	system := memori.GeminiTextContent("", "You are a concise assistant.")
+	req := memori.GeminiGenerateRequest{
+		Model:             "gemini-2.0-flash",
+		SystemInstruction: &system,
+		Contents: []memori.GeminiContent{
+			memori.GeminiTextContent("user", "My favorite hobby is rock climbing."),
+		},
+	}
+
+	chunks, errs := m.GeminiClient().StreamGenerateContent(context.Background(), req)
+	fmt.Println("LLM response:")
+	for chunk := range chunks {
+		fmt.Print(chunk.Text())
+	}
+	fmt.Println()
+	if err := <-errs; err != nil {
+		panic(err)
+	}
+
+	// Wait a bit for offline augmentation to persist facts.
+	time.Sleep(100 * time.Millisecond)
+
+	facts, err := m.Recall("favorite hobby", 5)
+	if err != nil {
+		panic(err)
+	}
+
+	fmt.Println("Recall results:")
+	for i, f := range facts {
+		fmt.Printf("%d) score=%.4f times=%d content=%q\n", i+1, f.Score, f.NumTimes, f.Content)
+	}
+}
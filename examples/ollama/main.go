@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"memorigo/memori"
+)
+
+// Example: native Ollama /api/chat (NDJSON streaming) + SQLite (in-memory).
+// Requires a running Ollama; set MEMORI_OLLAMA_EXAMPLE=1 to run
+// (OLLAMA_HOST and OLLAMA_MODEL optional).
+func main() {
+	if os.Getenv("MEMORI_OLLAMA_EXAMPLE") == "" {
+		fmt.Println("MEMORI_OLLAMA_EXAMPLE not set; skipping Ollama example")
+		return
+	}
+	model := os.Getenv("OLLAMA_MODEL")
+	if model == "" {
+		model = "llama3.2"
+	}
+
+	db, cleanup := openInMemorySQLite()
+	defer cleanup()
+
+	m := memori.New(memori.WithStorageConn(db))
+	if err := m.Storage.Build(); err != nil {
+		panic(err)
+	}
+
+	m.Attribution("user-ollama", "demo-bot")
+
+	// One-line registration
+	m.Ollama.Register(memori.NewOllamaClientFromEnv())
+
+	req := memori.OllamaChatRequest{
+		Model: model,
+		Messages: []memori.OllamaChatMessage{
+			{Role: "system", Content: "You are a concise assistant."},
+			{Role: "user", Content: "My favorite hobby is rock climbing."},
+		},
+	}
+
+	chunks, errs := m.OllamaClient().ChatStream(context.Background(), req)
+	fmt.Println("LLM response:")
+	for chunk := range chunks {
+		fmt.Print(chunk.Message.Content)
+	}
+	fmt.Println()
+	if err := <-errs; err != nil {
+		panic(err)
+	}
+
+	// Wait a bit for offline augmentation to persist facts.
+	time.Sleep(100 * time.Millisecond)
+
+	facts, err := m.Recall("favorite hobby", 5)
+	if err != nil {
+		panic(err)
+	}
+
+	fmt.Println("Recall results:")
+	for i, f := range facts {
+		fmt.Printf("%d) score=%.4f times=%d content=%q\n", i+1, f.Score, f.NumTimes, f.Content)
+	}
+}
@@ -0,0 +1,20 @@
+// Package listen holds listen-address checks shared by the command line
+// servers.
+package listen
+
+import "net"
+
+// IsLoopback reports whether addr, a host:port listen address, only accepts
+// connections from the local machine. An empty host listens on every
+// interface and is not loopback.
+func IsLoopback(addr string) (bool, error) {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return false, err
+	}
+	if host == "localhost" {
+		return true, nil
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback(), nil
+}
@@ -0,0 +1,63 @@
+package memori_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+
+	"memorigo/memori"
+)
+
+func TestAcceptance_Bolt_MigrateWriteAugmentRecall(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "memori.db")
+	db, err := bolt.Open(path, 0o600, nil)
+	if err != nil {
+		t.Fatalf("open bolt: %v", err)
+	}
+	defer db.Close()
+
+	m := memori.New(memori.WithStorageConn(db))
+	if err := m.Storage.Build(); err != nil {
+		t.Fatalf("migrate/build: %v", err)
+	}
+	if got := m.Storage.Dialect(); got != "bolt" {
+		t.Fatalf("dialect = %q, want bolt", got)
+	}
+
+	m.Attribution("user-123", "proc-abc")
+
+	w := memori.NewWriter(m)
+	ctx := context.Background()
+
+	var payload memori.ConversationPayload
+	payload.Messages = []memori.Message{
+		{Role: "user", Content: "My favorite color is blue"},
+	}
+	payload.Response = &memori.Message{Role: "assistant", Content: "Got it."}
+
+	if err := w.Execute(ctx, payload); err != nil {
+		t.Fatalf("writer execute: %v", err)
+	}
+
+	// Wait for async augmentation to write entity facts
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		facts, err := m.Recall("favorite color", 5)
+		if err != nil {
+			t.Fatalf("recall: %v", err)
+		}
+		if len(facts) > 0 {
+			if facts[0].Content != "My favorite color is blue" {
+				t.Fatalf("unexpected top fact: %#v", facts)
+			}
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for augmentation to write facts")
+		}
+		time.Sleep(25 * time.Millisecond)
+	}
+}
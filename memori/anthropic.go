@@ -0,0 +1,399 @@
+package memori
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"memorigo/sse"
+)
+
+// AnthropicVersion is the anthropic-version header sent by default.
+const AnthropicVersion = "2023-06-01"
+
+type AnthropicOptions struct {
+	BaseURL    string
+	APIKey     string
+	Version    string // anthropic-version header, defaults to AnthropicVersion
+	HTTPClient *http.Client
+}
+
+// AnthropicClient is a minimal client for Claude-style /v1/messages APIs.
+type AnthropicClient struct {
+	BaseURL    string
+	APIKey     string
+	Version    string
+	HTTPClient *http.Client
+}
+
+func NewAnthropicClient(opts AnthropicOptions) *AnthropicClient {
+	base := strings.TrimRight(opts.BaseURL, "/")
+	if base == "" {
+		base = "https://api.anthropic.com"
+	}
+	version := opts.Version
+	if version == "" {
+		version = AnthropicVersion
+	}
+	c := opts.HTTPClient
+	if c == nil {
+		c = &http.Client{Timeout: 60 * time.Second}
+	}
+	return &AnthropicClient{
+		BaseURL:    base,
+		APIKey:     opts.APIKey,
+		Version:    version,
+		HTTPClient: c,
+	}
+}
+
+// AnthropicContentBlock is one block of message content. Only the fields of
+// the block's Type are set.
+type AnthropicContentBlock struct {
+	Type string `json:"type"`
+
+	// text
+	Text string `json:"text,omitempty"`
+
+	// tool_use
+	ID    string          `json:"id,omitempty"`
+	Name  string          `json:"name,omitempty"`
+	Input json.RawMessage `json:"input,omitempty"`
+
+	// tool_result; Content may arrive as a string or a block array.
+	ToolUseID string           `json:"tool_use_id,omitempty"`
+	Content   AnthropicContent `json:"content,omitempty"`
+	IsError   bool             `json:"is_error,omitempty"`
+
+	// image and document
+	Source *AnthropicSource `json:"source,omitempty"`
+	Title  string           `json:"title,omitempty"` // document
+
+	// CacheControl marks a prompt caching breakpoint, as in
+	// {"type":"ephemeral"}.
+	CacheControl json.RawMessage `json:"cache_control,omitempty"`
+}
+
+// AnthropicSource is the data of an image or document block: inline base64
+// Data, a URL, plain-text Data (documents) or an uploaded FileID.
+type AnthropicSource struct {
+	Type      string `json:"type"` // base64, url, text, file
+	MediaType string `json:"media_type,omitempty"`
+	Data      string `json:"data,omitempty"`
+	URL       string `json:"url,omitempty"`
+	FileID    string `json:"file_id,omitempty"`
+}
+
+// AnthropicContent is a list of content blocks. A plain JSON string is
+// accepted as a single text block, as the API allows.
+type AnthropicContent []AnthropicContentBlock
+
+func (c *AnthropicContent) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err == nil {
+		*c = AnthropicContent{{Type: "text", Text: s}}
+		return nil
+	}
+	var blocks []AnthropicContentBlock
+	if err := json.Unmarshal(b, &blocks); err != nil {
+		return err
+	}
+	*c = blocks
+	return nil
+}
+
+// Text concatenates the text blocks.
+func (c AnthropicContent) Text() string {
+	var b strings.Builder
+	for _, block := range c {
+		if block.Type == "text" {
+			b.WriteString(block.Text)
+		}
+	}
+	return b.String()
+}
+
+// AnthropicTextContent returns content made of a single text block.
+func AnthropicTextContent(text string) AnthropicContent {
+	return AnthropicContent{{Type: "text", Text: text}}
+}
+
+type AnthropicMessage struct {
+	Role    string           `json:"role"`
+	Content AnthropicContent `json:"content"`
+}
+
+// AnthropicMessagesRequest is a Messages API request. The system prompt is
+// sent as the System string unless SystemBlocks is set, in which case the
+// blocks are sent instead, for instance to mark cache breakpoints; decoding
+// a block array fills SystemBlocks and sets System to its text.
+type AnthropicMessagesRequest struct {
+	Model         string             `json:"model"`
+	MaxTokens     int                `json:"max_tokens"`
+	System        string             `json:"system,omitempty"`
+	SystemBlocks  AnthropicContent   `json:"-"`
+	Messages      []AnthropicMessage `json:"messages"`
+	Temperature   *float64           `json:"temperature,omitempty"`
+	TopP          *float64           `json:"top_p,omitempty"`
+	TopK          *int               `json:"top_k,omitempty"`
+	StopSequences []string           `json:"stop_sequences,omitempty"`
+	Stream        bool               `json:"stream,omitempty"`
+
+	Tools      []AnthropicTool      `json:"tools,omitempty"`
+	ToolChoice *AnthropicToolChoice `json:"tool_choice,omitempty"`
+	Metadata   *AnthropicMetadata   `json:"metadata,omitempty"`
+}
+
+// AnthropicTool is a tool the model may call. Client tools have no Type and
+// describe their input with InputSchema; server tools set Type instead.
+type AnthropicTool struct {
+	Type        string          `json:"type,omitempty"`
+	Name        string          `json:"name"`
+	Description string          `json:"description,omitempty"`
+	InputSchema json.RawMessage `json:"input_schema,omitempty"` // JSON Schema
+
+	CacheControl json.RawMessage `json:"cache_control,omitempty"`
+}
+
+// AnthropicToolChoice is how the model picks tools: Type is "auto", "any",
+// "none" or "tool", the last with the tool's Name.
+type AnthropicToolChoice struct {
+	Type                   string `json:"type"`
+	Name                   string `json:"name,omitempty"`
+	DisableParallelToolUse bool   `json:"disable_parallel_tool_use,omitempty"`
+}
+
+type AnthropicMetadata struct {
+	UserID string `json:"user_id,omitempty"`
+}
+
+func (r AnthropicMessagesRequest) MarshalJSON() ([]byte, error) {
+	type plain AnthropicMessagesRequest
+	var system any
+	switch {
+	case len(r.SystemBlocks) > 0:
+		system = r.SystemBlocks
+	case r.System != "":
+		system = r.System
+	}
+	return json.Marshal(struct {
+		plain
+		System any `json:"system,omitempty"`
+	}{plain(r), system})
+}
+
+func (r *AnthropicMessagesRequest) UnmarshalJSON(b []byte) error {
+	type plain AnthropicMessagesRequest
+	aux := struct {
+		*plain
+		System json.RawMessage `json:"system"`
+	}{plain: (*plain)(r)}
+	if err := json.Unmarshal(b, &aux); err != nil {
+		return err
+	}
+
+	r.System, r.SystemBlocks = "", nil
+	raw := bytes.TrimSpace(aux.System)
+	switch {
+	case len(raw) == 0 || bytes.Equal(raw, []byte("null")):
+		return nil
+	case raw[0] == '[':
+		if err := json.Unmarshal(raw, &r.SystemBlocks); err != nil {
+			return err
+		}
+		r.System = r.SystemBlocks.Text()
+		return nil
+	}
+	return json.Unmarshal(raw, &r.System)
+}
+
+// SystemText returns the text of the system prompt.
+func (r AnthropicMessagesRequest) SystemText() string {
+	if len(r.SystemBlocks) > 0 {
+		return r.SystemBlocks.Text()
+	}
+	return r.System
+}
+
+type AnthropicUsage struct {
+	InputTokens  int `json:"input_tokens"`
+	OutputTokens int `json:"output_tokens"`
+}
+
+func (u *AnthropicUsage) tokenUsage() *TokenUsage {
+	if u == nil {
+		return nil
+	}
+	return &TokenUsage{PromptTokens: u.InputTokens, CompletionTokens: u.OutputTokens, TotalTokens: u.InputTokens + u.OutputTokens}
+}
+
+type AnthropicMessagesResponse struct {
+	ID           string           `json:"id"`
+	Type         string           `json:"type"`
+	Role         string           `json:"role"`
+	Model        string           `json:"model"`
+	Content      AnthropicContent `json:"content"`
+	StopReason   string           `json:"stop_reason"`
+	StopSequence string           `json:"stop_sequence"`
+	Usage        AnthropicUsage   `json:"usage"`
+}
+
+// anthropicDefaultMaxTokens is used when a request leaves MaxTokens unset;
+// the API requires the field.
+const anthropicDefaultMaxTokens = 1024
+
+func (c *AnthropicClient) newRequest(ctx context.Context, req AnthropicMessagesRequest) (*http.Request, error) {
+	if req.MaxTokens <= 0 {
+		req.MaxTokens = anthropicDefaultMaxTokens
+	}
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, c.BaseURL+"/v1/messages", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("anthropic-version", c.Version)
+	if c.APIKey != "" {
+		httpReq.Header.Set("x-api-key", c.APIKey)
+	}
+	if req.Stream {
+		httpReq.Header.Set("Accept", "text/event-stream")
+	}
+	return httpReq, nil
+}
+
+func (c *AnthropicClient) MessagesCreate(ctx context.Context, req AnthropicMessagesRequest) (AnthropicMessagesResponse, error) {
+	var out AnthropicMessagesResponse
+	req.Stream = false
+
+	httpReq, err := c.newRequest(ctx, req)
+	if err != nil {
+		return out, err
+	}
+
+	resp, err := c.HTTPClient.Do(httpReq)
+	if err != nil {
+		return out, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		b, _ := io.ReadAll(resp.Body)
+		return out, newAPIError("anthropic", resp, b)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return out, err
+	}
+	return out, nil
+}
+
+// AnthropicDelta is the payload of content_block_delta and message_delta
+// events.
+type AnthropicDelta struct {
+	Type        string `json:"type"`
+	Text        string `json:"text,omitempty"`         // text_delta
+	PartialJSON string `json:"partial_json,omitempty"` // input_json_delta
+	StopReason  string `json:"stop_reason,omitempty"`  // message_delta
+}
+
+// AnthropicStreamEvent is one server-sent event of a streamed message:
+// message_start, content_block_start, content_block_delta,
+// content_block_stop, message_delta, message_stop, ping or error.
+type AnthropicStreamEvent struct {
+	Type         string                     `json:"type"`
+	Message      *AnthropicMessagesResponse `json:"message,omitempty"`
+	Index        int                        `json:"index"`
+	ContentBlock *AnthropicContentBlock     `json:"content_block,omitempty"`
+	Delta        *AnthropicDelta            `json:"delta,omitempty"`
+	Usage        *AnthropicUsage            `json:"usage,omitempty"`
+	Error        *struct {
+		Type    string `json:"type"`
+		Message string `json:"message"`
+	} `json:"error,omitempty"`
+
+	// RawData is the event's unparsed data field.
+	RawData string `json:"-"`
+}
+
+// MessagesStream streams a message over SSE. The events channel yields every
+// event until message_stop, an error event, or context cancellation. HTTP and
+// stream errors are reported as *APIError.
+func (c *AnthropicClient) MessagesStream(ctx context.Context, req AnthropicMessagesRequest) (<-chan AnthropicStreamEvent, <-chan error) {
+	events := make(chan AnthropicStreamEvent, 128)
+	errs := make(chan error, 1)
+
+	go func() {
+		defer close(events)
+		defer close(errs)
+
+		req.Stream = true
+		httpReq, err := c.newRequest(ctx, req)
+		if err != nil {
+			errs <- err
+			return
+		}
+
+		resp, err := c.HTTPClient.Do(httpReq)
+		if err != nil {
+			errs <- err
+			return
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			b, _ := io.ReadAll(resp.Body)
+			errs <- newAPIError("anthropic", resp, b)
+			return
+		}
+
+		// The event type is repeated inside the JSON data.
+		dec := sse.NewDecoder(resp.Body)
+		for {
+			sev, err := dec.Next()
+			if err == io.EOF {
+				return
+			}
+			if err != nil {
+				errs <- err
+				return
+			}
+			if sev.Data == "" {
+				continue
+			}
+			var ev AnthropicStreamEvent
+			if err := json.Unmarshal([]byte(sev.Data), &ev); err != nil {
+				errs <- fmt.Errorf("anthropic: invalid stream event: %w", err)
+				return
+			}
+			ev.RawData = sev.Data
+			select {
+			case events <- ev:
+			case <-ctx.Done():
+				errs <- ctx.Err()
+				return
+			}
+			switch ev.Type {
+			case "message_stop":
+				return
+			case "error":
+				apiErr := streamError("anthropic", []byte(sev.Data))
+				if apiErr == nil {
+					apiErr = &APIError{Provider: "anthropic", Message: "unknown error", Body: sev.Data}
+				}
+				errs <- apiErr
+				return
+			}
+		}
+	}()
+
+	return events, errs
+}
@@ -0,0 +1,247 @@
+package memori
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+
+	"memorigo/storage"
+)
+
+// MemoriAnthropicClient wraps AnthropicClient and automatically persists the
+// system prompt, request messages and the final assistant reply (non-stream or
+// accumulated from the deltas), then triggers augmentation. tool_use and
+// tool_result blocks are stored as typed tool messages, as for OpenAI.
+type MemoriAnthropicClient struct {
+	m   *Memori
+	raw *AnthropicClient
+}
+
+func (m *Memori) AnthropicClient() *MemoriAnthropicClient {
+	if m.anthropicClient == nil {
+		m.anthropicClient = NewAnthropicClientFromEnv()
+		m.Config.mu.Lock()
+		if m.Config.LLM.Provider == "" {
+			m.Config.LLM.Provider = "anthropic"
+			m.Config.LLM.Version = AnthropicVersion
+		}
+		m.Config.mu.Unlock()
+	}
+	return &MemoriAnthropicClient{m: m, raw: m.anthropicClient}
+}
+
+func (c *MemoriAnthropicClient) MessagesCreate(ctx context.Context, req AnthropicMessagesRequest) (AnthropicMessagesResponse, error) {
+	resp, err := c.raw.MessagesCreate(ctx, req)
+	if err != nil {
+		return resp, err
+	}
+
+	// Best-effort persistence: do not fail the LLM call if local storage fails.
+	_ = c.persist(req, resp.Model, resp.Content, resp.Usage.tokenUsage(), false)
+	return resp, nil
+}
+
+func (c *MemoriAnthropicClient) MessagesStream(ctx context.Context, req AnthropicMessagesRequest) (<-chan AnthropicStreamEvent, <-chan error) {
+	ctx, cancel := context.WithCancel(ctx)
+	inEvents, inErrs := c.raw.MessagesStream(ctx, req)
+
+	outEvents := make(chan AnthropicStreamEvent, 128)
+	outErrs := make(chan error, 1)
+
+	go func() {
+		defer cancel()
+		defer close(outEvents)
+		defer close(outErrs)
+		send := newStreamSender(c.m, ctx, outEvents, cancel)
+
+		var reply anthropicReply
+		var usage *AnthropicUsage
+		var model string
+		complete := false
+
+		// Read the error only after the events: it is buffered before the
+		// raw channels close, so it would be missed if the closed events
+		// channel ended the loop first.
+		for ev := range inEvents {
+			// A caller that went away no longer gets events, but the
+			// reply is still accumulated for persisting.
+			send.send(ev)
+			complete = complete || ev.Type == "message_stop"
+
+			switch {
+			case ev.Type == "content_block_start" && ev.ContentBlock != nil:
+				reply.start(ev.Index, *ev.ContentBlock)
+			case ev.Type == "content_block_delta" && ev.Delta != nil:
+				reply.add(ev.Index, *ev.Delta)
+			case ev.Type == "message_start" && ev.Message != nil:
+				u := ev.Message.Usage
+				usage, model = &u, ev.Message.Model
+			case ev.Type == "message_delta" && ev.Usage != nil && usage != nil:
+				// output_tokens is cumulative
+				usage.OutputTokens = ev.Usage.OutputTokens
+				if ev.Usage.InputTokens > 0 {
+					usage.InputTokens = ev.Usage.InputTokens
+				}
+			}
+		}
+		if err, ok := <-inErrs; ok && err != nil {
+			outErrs <- send.err(err)
+		}
+
+		// Persist the full reply, or what was received of an incomplete one
+		// as Config.PartialResponses says
+		if content := reply.content(); len(content) > 0 {
+			_ = c.persist(req, model, content, usage.tokenUsage(), !complete)
+		}
+	}()
+
+	return outEvents, outErrs
+}
+
+// persist stores the exchange; model is the one the response reports.
+func (c *MemoriAnthropicClient) persist(req AnthropicMessagesRequest, model string, reply AnthropicContent, usage *TokenUsage, truncated bool) error {
+	msgs := make([]Message, 0, len(req.Messages)+1)
+	if system := req.SystemText(); system != "" {
+		msgs = append(msgs, Message{Role: "system", Content: system})
+	}
+	for _, m := range req.Messages {
+		msgs = append(msgs, anthropicRecords(m.Role, m.Content)...)
+	}
+
+	var calls []Message
+	for _, block := range reply {
+		if block.Type == "tool_use" {
+			calls = append(calls, anthropicToolCall(block))
+		}
+	}
+	return c.m.persistExchange("anthropic", responseModel(model, req.Model), msgs, reply.Text(), calls, usage, truncated)
+}
+
+// anthropicRecords converts message content into the messages to store: its
+// text and image or document references, then a tool_call record per
+// tool_use block and a tool_result record per tool_result block, in the
+// stored form chatMessageRecords uses.
+func anthropicRecords(role string, content AnthropicContent) []Message {
+	var out []Message
+	text, parts, tools := content.Text(), anthropicParts(content), 0
+	for _, block := range content {
+		switch block.Type {
+		case "tool_use":
+			out = append(out, anthropicToolCall(block))
+			tools++
+		case "tool_result":
+			b, _ := json.Marshal(ToolResult{ToolCallID: block.ToolUseID, Content: block.Content.Text()})
+			out = append(out, Message{Role: "tool", Type: MessageTypeToolResult, Content: string(b)})
+			tools++
+		}
+	}
+	if text != "" || len(parts) > 0 || tools == 0 {
+		out = append([]Message{{Role: role, Content: text, Parts: parts}}, out...)
+	}
+	return out
+}
+
+// anthropicParts returns the stored parts of content holding image or
+// document blocks, in the form contentPartRefs gives OpenAI parts: images
+// become image_url parts and documents file parts, inline data replaced by
+// its hash and size. Text-only content has no parts.
+func anthropicParts(content AnthropicContent) []storage.MessagePart {
+	var out []storage.MessagePart
+	media := false
+	for _, block := range content {
+		var ref storage.MessagePart
+		switch block.Type {
+		case "text":
+			ref = storage.MessagePart{Type: "text", Text: block.Text}
+		case "image":
+			ref, media = storage.MessagePart{Type: "image_url"}, true
+		case "document":
+			ref, media = storage.MessagePart{Type: "file", Filename: block.Title}, true
+		default:
+			continue
+		}
+		if src := block.Source; src != nil {
+			ref.MimeType = src.MediaType
+			switch src.Type {
+			case "base64":
+				ref.Hash, ref.Size = inlineDataRef(src.Data)
+			case "text":
+				ref.Hash, ref.Size = bytesRef([]byte(src.Data))
+			case "url":
+				ref.URL = src.URL
+			case "file":
+				ref.FileID = src.FileID
+			}
+		}
+		out = append(out, ref)
+	}
+	if !media {
+		return nil
+	}
+	return out
+}
+
+// anthropicToolCall stores a tool_use block as the ToolCall it corresponds
+// to, its input as the call's arguments.
+func anthropicToolCall(block AnthropicContentBlock) Message {
+	args := string(block.Input)
+	if args == "" {
+		args = "{}"
+	}
+	return toolCallRecord(ToolCall{ID: block.ID, Type: "function", Function: FunctionCall{Name: block.Name, Arguments: args}})
+}
+
+// anthropicReply assembles the content blocks of a streamed reply from
+// content_block_start events and their text and input_json deltas.
+type anthropicReply struct {
+	blocks []AnthropicContentBlock
+	byIdx  map[int]int // stream index -> position in blocks
+	input  map[int]*strings.Builder
+}
+
+func (r *anthropicReply) start(index int, block AnthropicContentBlock) {
+	if r.byIdx == nil {
+		r.byIdx = make(map[int]int)
+		r.input = make(map[int]*strings.Builder)
+	}
+	r.byIdx[index] = len(r.blocks)
+	r.blocks = append(r.blocks, block)
+	if block.Type == "tool_use" {
+		r.input[index] = &strings.Builder{}
+	}
+}
+
+func (r *anthropicReply) add(index int, delta AnthropicDelta) {
+	i, ok := r.byIdx[index]
+	if !ok {
+		// text deltas without a content_block_start still count
+		r.start(index, AnthropicContentBlock{Type: "text"})
+		i = r.byIdx[index]
+	}
+	switch delta.Type {
+	case "text_delta":
+		r.blocks[i].Text += delta.Text
+	case "input_json_delta":
+		if b := r.input[index]; b != nil {
+			b.WriteString(delta.PartialJSON)
+		}
+	}
+}
+
+// content returns the blocks received, with the streamed tool inputs, and
+// without empty text blocks.
+func (r *anthropicReply) content() AnthropicContent {
+	var out AnthropicContent
+	for index, i := range r.byIdx {
+		if b := r.input[index]; b != nil && b.Len() > 0 {
+			r.blocks[i].Input = json.RawMessage(b.String())
+		}
+	}
+	for _, block := range r.blocks {
+		if block.Type == "text" && block.Text == "" {
+			continue
+		}
+		out = append(out, block)
+	}
+	return out
+}
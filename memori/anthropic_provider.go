@@ -0,0 +1,31 @@
+package memori
+
+import (
+	"os"
+)
+
+type AnthropicProvider struct {
+	m *Memori
+}
+
+// Register wires an Anthropic client into Memori, mirroring OpenAIProvider.Register.
+func (p *AnthropicProvider) Register(client *AnthropicClient) *Memori {
+	if client == nil {
+		client = NewAnthropicClientFromEnv()
+	}
+	p.m.Config.mu.Lock()
+	p.m.Config.LLM.Provider = "anthropic"
+	p.m.Config.LLM.Version = client.Version
+	p.m.Config.mu.Unlock()
+
+	p.m.anthropicClient = client
+	return p.m
+}
+
+// NewAnthropicClientFromEnv reads ANTHROPIC_API_KEY and ANTHROPIC_BASE_URL.
+func NewAnthropicClientFromEnv() *AnthropicClient {
+	return NewAnthropicClient(AnthropicOptions{
+		BaseURL: os.Getenv("ANTHROPIC_BASE_URL"),
+		APIKey:  os.Getenv("ANTHROPIC_API_KEY"),
+	})
+}
@@ -0,0 +1,245 @@
+package memori_test
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	_ "modernc.org/sqlite"
+
+	"memorigo/memori"
+)
+
+// fakeAnthropicServer answers /v1/messages with a fixed reply, as JSON or as
+// an SSE stream of Messages API events.
+func fakeAnthropicServer(t *testing.T, reply string) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/messages" || r.Header.Get("x-api-key") != "test-key" || r.Header.Get("anthropic-version") == "" {
+			http.Error(w, `{"type":"error","error":{"type":"authentication_error","message":"bad request"}}`, http.StatusUnauthorized)
+			return
+		}
+		var req memori.AnthropicMessagesRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.MaxTokens == 0 {
+			http.Error(w, "invalid request", http.StatusBadRequest)
+			return
+		}
+
+		if !req.Stream {
+			_ = json.NewEncoder(w).Encode(memori.AnthropicMessagesResponse{
+				ID: "msg_1", Type: "message", Role: "assistant", Model: req.Model,
+				Content:    memori.AnthropicTextContent(reply),
+				StopReason: "end_turn",
+			})
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		flusher := w.(http.Flusher)
+		send := func(event, data string) {
+			fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, data)
+			flusher.Flush()
+		}
+		send("message_start", `{"type":"message_start","message":{"id":"msg_1","type":"message","role":"assistant","content":[],"model":"`+req.Model+`","usage":{"input_tokens":10,"output_tokens":1}}}`)
+		send("content_block_start", `{"type":"content_block_start","index":0,"content_block":{"type":"text","text":""}}`)
+		send("ping", `{"type":"ping"}`)
+		half := len(reply) / 2
+		for _, part := range []string{reply[:half], reply[half:]} {
+			b, _ := json.Marshal(part)
+			send("content_block_delta", `{"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":`+string(b)+`}}`)
+		}
+		send("content_block_stop", `{"type":"content_block_stop","index":0}`)
+		send("message_delta", `{"type":"message_delta","delta":{"stop_reason":"end_turn"},"usage":{"output_tokens":5}}`)
+		send("message_stop", `{"type":"message_stop"}`)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestAnthropic_StreamPersistsExchange(t *testing.T) {
+	srv := fakeAnthropicServer(t, "Noted, you enjoy hiking.")
+
+	db, err := sql.Open("sqlite", "file:memori_anthropic?mode=memory&cache=shared")
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	defer db.Close()
+
+	m := memori.New(memori.WithStorageConn(db))
+	if err := m.Storage.Build(); err != nil {
+		t.Fatalf("migrate/build: %v", err)
+	}
+	m.Attribution("user-anthropic", "proc-anthropic")
+	m.Anthropic.Register(memori.NewAnthropicClient(memori.AnthropicOptions{BaseURL: srv.URL, APIKey: "test-key"}))
+
+	req := memori.AnthropicMessagesRequest{
+		Model:  "claude-test",
+		System: "You are a helpful assistant.",
+		Messages: []memori.AnthropicMessage{
+			{Role: "user", Content: memori.AnthropicTextContent("I really enjoy hiking in the mountains")},
+		},
+	}
+	events, errs := m.AnthropicClient().MessagesStream(context.Background(), req)
+
+	var text strings.Builder
+	var types []string
+	for ev := range events {
+		types = append(types, ev.Type)
+		if ev.Delta != nil {
+			text.WriteString(ev.Delta.Text)
+		}
+	}
+	if err := <-errs; err != nil {
+		t.Fatalf("stream: %v", err)
+	}
+	if text.String() != "Noted, you enjoy hiking." {
+		t.Fatalf("streamed text = %q", text.String())
+	}
+	if types[0] != "message_start" || types[len(types)-1] != "message_stop" {
+		t.Fatalf("unexpected event sequence %v", types)
+	}
+
+	resp, err := m.AnthropicClient().MessagesCreate(context.Background(), req)
+	if err != nil || resp.Content.Text() != "Noted, you enjoy hiking." {
+		t.Fatalf("create: %q, %v", resp.Content.Text(), err)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		facts, err := m.Recall("hiking mountains", 5)
+		if err != nil {
+			t.Fatalf("recall: %v", err)
+		}
+		for _, f := range facts {
+			if f.Content == "I really enjoy hiking in the mountains" {
+				return
+			}
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for persisted facts, got %#v", facts)
+		}
+		time.Sleep(25 * time.Millisecond)
+	}
+}
+
+func TestAnthropic_SystemBlocksAndToolBlocks(t *testing.T) {
+	var system string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var raw struct {
+			System json.RawMessage `json:"system"`
+		}
+		body, _ := io.ReadAll(r.Body)
+		_ = json.Unmarshal(body, &raw)
+		system = string(raw.System)
+		var req memori.AnthropicMessagesRequest
+		if err := json.Unmarshal(body, &req); err != nil || req.System != "You are a weather bot." {
+			http.Error(w, "invalid request", http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, data := range []string{
+			`{"type":"message_start","message":{"id":"msg_1","type":"message","role":"assistant","content":[],"model":"claude-test","usage":{"input_tokens":10,"output_tokens":1}}}`,
+			`{"type":"content_block_start","index":0,"content_block":{"type":"tool_use","id":"toolu_2","name":"get_weather","input":{}}}`,
+			`{"type":"content_block_delta","index":0,"delta":{"type":"input_json_delta","partial_json":"{\"city\":"}}`,
+			`{"type":"content_block_delta","index":0,"delta":{"type":"input_json_delta","partial_json":"\"Bergen\"}"}}`,
+			`{"type":"content_block_stop","index":0}`,
+			`{"type":"message_delta","delta":{"stop_reason":"tool_use"},"usage":{"output_tokens":5}}`,
+			`{"type":"message_stop"}`,
+		} {
+			fmt.Fprintf(w, "data: %s\n\n", data)
+		}
+	}))
+	defer srv.Close()
+
+	db, err := sql.Open("sqlite", "file:memori_anthropic_tools?mode=memory&cache=shared")
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	defer db.Close()
+
+	m := memori.New(memori.WithStorageConn(db))
+	if err := m.Storage.Build(); err != nil {
+		t.Fatalf("migrate/build: %v", err)
+	}
+	m.Attribution("user-anthropic-tools", "proc-anthropic")
+	m.Anthropic.Register(memori.NewAnthropicClient(memori.AnthropicOptions{BaseURL: srv.URL}))
+
+	events, errs := m.AnthropicClient().MessagesStream(context.Background(), memori.AnthropicMessagesRequest{
+		Model:        "claude-test",
+		SystemBlocks: memori.AnthropicContent{{Type: "text", Text: "You are a weather bot.", CacheControl: json.RawMessage(`{"type":"ephemeral"}`)}},
+		Messages: []memori.AnthropicMessage{
+			{Role: "user", Content: memori.AnthropicTextContent("I live in Oslo")},
+			{Role: "assistant", Content: memori.AnthropicContent{{Type: "tool_use", ID: "toolu_1", Name: "get_weather", Input: json.RawMessage(`{"city":"Oslo"}`)}}},
+			{Role: "user", Content: memori.AnthropicContent{{Type: "tool_result", ToolUseID: "toolu_1", Content: memori.AnthropicTextContent("4°C, rain")}}},
+		},
+	})
+	for range events {
+	}
+	if err := <-errs; err != nil {
+		t.Fatalf("stream: %v", err)
+	}
+	if system != `[{"type":"text","text":"You are a weather bot.","cache_control":{"type":"ephemeral"}}]` {
+		t.Fatalf("system sent as %s", system)
+	}
+
+	type row struct{ role, typ, content string }
+	want := []row{
+		{"user", "", "I live in Oslo"},
+		{"assistant", memori.MessageTypeToolCall, `{"id":"toolu_1","type":"function","function":{"name":"get_weather","arguments":"{\"city\":\"Oslo\"}"}}`},
+		{"tool", memori.MessageTypeToolResult, `{"tool_call_id":"toolu_1","content":"4°C, rain"}`},
+		{"assistant", memori.MessageTypeToolCall, `{"id":"toolu_2","type":"function","function":{"name":"get_weather","arguments":"{\"city\":\"Bergen\"}"}}`},
+	}
+	var got []row
+	rows, err := db.Query(`SELECT role, COALESCE(type, ''), content FROM memori_conversation_message ORDER BY id`)
+	if err != nil {
+		t.Fatalf("query messages: %v", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var r row
+		if err := rows.Scan(&r.role, &r.typ, &r.content); err != nil {
+			t.Fatalf("scan: %v", err)
+		}
+		got = append(got, r)
+	}
+	if len(got) != len(want) {
+		t.Fatalf("stored %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("message %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestAnthropic_MidStreamErrorReachesCaller(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprintf(w, "event: content_block_delta\ndata: %s\n\n", `{"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"Blue"}}`)
+		fmt.Fprintf(w, "event: error\ndata: %s\n\n", `{"type":"error","error":{"type":"overloaded_error","message":"Overloaded"}}`)
+	}))
+	defer srv.Close()
+
+	m := memori.New()
+	m.Anthropic.Register(memori.NewAnthropicClient(memori.AnthropicOptions{BaseURL: srv.URL}))
+	for i := 0; i < 100; i++ {
+		events, errs := m.AnthropicClient().MessagesStream(context.Background(), memori.AnthropicMessagesRequest{
+			Model:    "claude-test",
+			Messages: []memori.AnthropicMessage{{Role: "user", Content: memori.AnthropicTextContent("What is my favorite color?")}},
+		})
+		for range events {
+		}
+		var apiErr *memori.APIError
+		if err := <-errs; !errors.As(err, &apiErr) || apiErr.Message != "Overloaded" {
+			t.Fatalf("run %d: err = %v", i, err)
+		}
+	}
+}
@@ -3,11 +3,11 @@ package memori
 import (
 	"context"
 	"crypto/sha256"
-	"encoding/binary"
 	"fmt"
-	"math"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"memorigo/embed"
 	"memorigo/storage"
@@ -27,6 +27,36 @@ type AugmentationManager struct {
 	queue     chan AugmentationInput
 	workers   int
 	embedder  embed.Embedder
+
+	started   atomic.Bool
+	inFlight  atomic.Int64
+	processed atomic.Int64
+	dropped   atomic.Int64
+
+	watchMu  sync.Mutex
+	watchers map[chan FactEvent]string // channel -> entity filter
+}
+
+// FactEvent reports a fact written by augmentation.
+type FactEvent struct {
+	EntityID string
+	Content  string
+	Time     time.Time
+}
+
+// factEventBuffer is the number of events a slow watcher may lag behind
+// before further events are dropped for it.
+const factEventBuffer = 64
+
+// AugmentationStatus is a snapshot of the augmentation queue.
+type AugmentationStatus struct {
+	Started   bool  `json:"started"`
+	Workers   int   `json:"workers"`
+	Queued    int   `json:"queued"`
+	Capacity  int   `json:"capacity"`
+	InFlight  int64 `json:"in_flight"`
+	Processed int64 `json:"processed"`
+	Dropped   int64 `json:"dropped"` // inputs discarded because the queue was full
 }
 
 func NewAugmentationManager(m *Memori) *AugmentationManager {
@@ -40,12 +70,63 @@ func NewAugmentationManager(m *Memori) *AugmentationManager {
 
 func (m *AugmentationManager) Start() {
 	m.startOnce.Do(func() {
+		m.started.Store(true)
 		for i := 0; i < m.workers; i++ {
 			go m.worker()
 		}
 	})
 }
 
+// WatchFacts returns a channel receiving the facts augmentation writes for
+// entityID (all entities when empty) until ctx is done, when it is closed.
+// Events are dropped rather than stalling augmentation when the receiver
+// falls behind.
+func (m *AugmentationManager) WatchFacts(ctx context.Context, entityID string) <-chan FactEvent {
+	ch := make(chan FactEvent, factEventBuffer)
+	m.watchMu.Lock()
+	if m.watchers == nil {
+		m.watchers = make(map[chan FactEvent]string)
+	}
+	m.watchers[ch] = entityID
+	m.watchMu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+		m.watchMu.Lock()
+		delete(m.watchers, ch)
+		close(ch)
+		m.watchMu.Unlock()
+	}()
+	return ch
+}
+
+func (m *AugmentationManager) publish(ev FactEvent) {
+	m.watchMu.Lock()
+	defer m.watchMu.Unlock()
+	for ch, entityID := range m.watchers {
+		if entityID != "" && entityID != ev.EntityID {
+			continue
+		}
+		select {
+		case ch <- ev:
+		default:
+		}
+	}
+}
+
+// Status reports the queue length and counters of the manager.
+func (m *AugmentationManager) Status() AugmentationStatus {
+	return AugmentationStatus{
+		Started:   m.started.Load(),
+		Workers:   m.workers,
+		Queued:    len(m.queue),
+		Capacity:  cap(m.queue),
+		InFlight:  m.inFlight.Load(),
+		Processed: m.processed.Load(),
+		Dropped:   m.dropped.Load(),
+	}
+}
+
 func (m *AugmentationManager) Enqueue(input AugmentationInput) {
 	if input.EntityID == "" {
 		return
@@ -55,12 +136,16 @@ func (m *AugmentationManager) Enqueue(input AugmentationInput) {
 	case m.queue <- input:
 	default:
 		// drop when queue is full (non-blocking, keep main path low-latency)
+		m.dropped.Add(1)
 	}
 }
 
 func (m *AugmentationManager) worker() {
 	for in := range m.queue {
+		m.inFlight.Add(1)
 		m.processInput(in)
+		m.inFlight.Add(-1)
+		m.processed.Add(1)
 	}
 }
 
@@ -88,7 +173,7 @@ func (m *AugmentationManager) processInput(in AugmentationInput) {
 	// Extract candidate facts
 	var facts []string
 	for _, msg := range in.Messages {
-		if msg.Role == "system" {
+		if msg.Role == "system" || isToolMessage(msg) {
 			continue
 		}
 		content := strings.TrimSpace(msg.Content)
@@ -102,17 +187,27 @@ func (m *AugmentationManager) processInput(in AugmentationInput) {
 		facts = append(facts, content)
 	}
 
-	// Upsert entity facts
+	// Upsert entity facts (embedded in one batch request)
 	factRepo := repos.EntityFact()
-	for _, f := range facts {
-		emb, err := m.embedder.EmbedText(context.Background(), f)
-		if err != nil {
-			// Log error but continue with next fact
-			continue
+	if len(facts) > 0 {
+		embs, src, err := embed.EmbedWithSource(context.Background(), m.embedder, facts)
+		if err == nil && len(embs) == len(facts) {
+			for i, f := range facts {
+				uniq := hashString(f)
+				if factRepo.Upsert(entityID, f, m.m.factEmbedding(src, embs[i]), uniq) == nil {
+					m.publish(FactEvent{EntityID: in.EntityID, Content: f, Time: time.Now()})
+				}
+			}
+		} else {
+			// Keep new facts without a vector rather than losing them;
+			// Memori.Reembed fills them in. Create leaves existing facts and
+			// their vectors untouched.
+			for _, f := range facts {
+				if factRepo.Create(entityID, f, storage.FactEmbedding{}, hashString(f)) == nil {
+					m.publish(FactEvent{EntityID: in.EntityID, Content: f, Time: time.Now()})
+				}
+			}
 		}
-		embBytes := encodeEmbedding(emb)
-		uniq := hashString(f)
-		_ = factRepo.Upsert(entityID, f, embBytes, uniq)
 	}
 
 	// Update conversation summary if we have an id
@@ -124,18 +219,6 @@ func (m *AugmentationManager) processInput(in AugmentationInput) {
 	}
 }
 
-// encodeEmbedding serializes []float32 into []byte (little-endian).
-func encodeEmbedding(v []float32) []byte {
-	if len(v) == 0 {
-		return nil
-	}
-	b := make([]byte, len(v)*4)
-	for i, f := range v {
-		binary.LittleEndian.PutUint32(b[i*4:], math.Float32bits(f))
-	}
-	return b
-}
-
 func hashString(s string) string {
 	h := sha256.Sum256([]byte(s))
 	return fmt.Sprintf("%x", h[:])
@@ -145,7 +228,7 @@ func buildSummary(msgs []Message) string {
 	const maxLen = 512
 	var parts []string
 	for _, m := range msgs {
-		if m.Role == "system" {
+		if m.Role == "system" || isToolMessage(m) {
 			continue
 		}
 		text := strings.TrimSpace(m.Content)
@@ -2,6 +2,7 @@ package memori
 
 import (
 	"os"
+	"strconv"
 	"sync"
 	"time"
 
@@ -31,6 +32,33 @@ type EmbeddingConfig struct {
 	BaseURL   string
 	Model     string
 	Dimension int
+	// Stopwords selects the stopword languages of the lexical embedder.
+	Stopwords string
+
+	// Fallback names a provider used while the primary one fails; its
+	// credentials and endpoint come from that provider's own environment.
+	Fallback      string
+	FallbackModel string
+
+	// TruncateDimension keeps only the leading dimensions of each vector
+	// (Matryoshka truncation); 0 keeps the full vector.
+	TruncateDimension int
+	// Quantization selects how fact vectors are stored: "float32" (default)
+	// or "int8".
+	Quantization string
+
+	// Remote provider behaviour; zero values use the embed package defaults.
+	MaxRetries        int
+	RequestsPerMinute int
+	TokensPerMinute   int
+	BatchSize         int
+
+	// CacheSize is the number of embeddings kept in the in-memory LRU; a
+	// negative value disables caching.
+	CacheSize int
+	// PersistCache additionally stores embeddings in memori_embedding_cache so
+	// they survive restarts and are shared between processes.
+	PersistCache bool
 }
 
 type Config struct {
@@ -48,13 +76,31 @@ type Config struct {
 	Timeout     time.Duration
 	SessionTTL  time.Duration
 	RecallLimit int
+
+	// Prices estimates the cost of recorded LLM usage, see Memori.Usage.
+	Prices PriceTable
+	// PartialResponses decides whether the reply of an incomplete stream is
+	// stored (marked truncated) or dropped.
+	PartialResponses PartialResponsePolicy
+	// StreamStallTimeout is how long a stream wrapper waits for a caller that
+	// stopped reading before it gives up on it, ends the upstream request and
+	// persists the reply received so far. Zero waits until the caller's
+	// context is done.
+	StreamStallTimeout time.Duration
 }
 
 func newConfig() *Config {
-	// Default to hash embedding for offline use
+	// Default to lexical embedding for offline use
 	embedProvider := os.Getenv("MEMORI_EMBEDDING_PROVIDER")
 	if embedProvider == "" {
-		embedProvider = "hash"
+		embedProvider = "lexical"
+	}
+	embedDimension, _ := strconv.Atoi(os.Getenv("MEMORI_EMBEDDING_DIMENSION"))
+	truncateDimension, _ := strconv.Atoi(os.Getenv("MEMORI_EMBEDDING_TRUNCATE"))
+
+	cacheSize := 1024
+	if v, err := strconv.Atoi(os.Getenv("MEMORI_EMBEDDING_CACHE_SIZE")); err == nil {
+		cacheSize = v
 	}
 
 	c := &Config{
@@ -64,13 +110,36 @@ func newConfig() *Config {
 		Timeout:     10 * time.Second,
 		SessionTTL:  30 * time.Minute,
 		RecallLimit: 5,
+
+		StreamStallTimeout: 30 * time.Second,
 		Embedding: EmbeddingConfig{
 			Provider: embedProvider,
 			APIKey:   os.Getenv("MEMORI_EMBEDDING_API_KEY"),
 			BaseURL:  os.Getenv("MEMORI_EMBEDDING_BASE_URL"),
 			Model:    os.Getenv("MEMORI_EMBEDDING_MODEL"),
+
+			Dimension: embedDimension,
+			Stopwords: os.Getenv("MEMORI_EMBEDDING_STOPWORDS"),
+
+			Fallback:      os.Getenv("MEMORI_EMBEDDING_FALLBACK"),
+			FallbackModel: os.Getenv("MEMORI_EMBEDDING_FALLBACK_MODEL"),
+
+			TruncateDimension: truncateDimension,
+			Quantization:      os.Getenv("MEMORI_EMBEDDING_QUANTIZATION"),
+
+			CacheSize:    cacheSize,
+			PersistCache: os.Getenv("MEMORI_EMBEDDING_CACHE_PERSIST") == "1",
 		},
 	}
+	if os.Getenv("MEMORI_PARTIAL_RESPONSES") == "discard" {
+		c.PartialResponses = PartialDiscard
+	}
+	if d, err := time.ParseDuration(os.Getenv("MEMORI_STREAM_STALL_TIMEOUT")); err == nil {
+		c.StreamStallTimeout = d
+	}
+	if path := os.Getenv("MEMORI_PRICE_TABLE"); path != "" {
+		c.Prices, _ = LoadPriceTable(path)
+	}
 	return c
 }
 
@@ -79,3 +148,24 @@ func (c *Config) ResetCache() {
 	defer c.mu.Unlock()
 	c.Cache = Cache{}
 }
+
+// clone copies the settings of c, with an empty ID cache.
+func (c *Config) clone() *Config {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return &Config{
+		APIKey:           c.APIKey,
+		Enterprise:       c.Enterprise,
+		EntityID:         c.EntityID,
+		ProcessID:        c.ProcessID,
+		SessionID:        c.SessionID,
+		LLM:              c.LLM,
+		Storage:          c.Storage,
+		Embedding:        c.Embedding,
+		Timeout:          c.Timeout,
+		SessionTTL:       c.SessionTTL,
+		RecallLimit:      c.RecallLimit,
+		Prices:           c.Prices,
+		PartialResponses: c.PartialResponses,
+	}
+}
@@ -0,0 +1,45 @@
+package memori
+
+import (
+	"context"
+
+	"memorigo/embed"
+	"memorigo/storage"
+)
+
+// embeddingCacheStore backs embed.Cached with the storage driver's
+// memori_embedding_cache. The driver is resolved per call because storage may
+// be started after the embedder is built.
+type embeddingCacheStore struct {
+	m *Memori
+}
+
+func (s *embeddingCacheStore) repo() (storage.EmbeddingCacheRepo, bool) {
+	if s.m.Storage == nil || s.m.Storage.Driver() == nil {
+		return nil, false
+	}
+	repo, err := storage.EmbeddingCacheOf(s.m.Storage.Driver())
+	return repo, err == nil
+}
+
+func (s *embeddingCacheStore) Get(ctx context.Context, key embed.CacheKey) ([]float32, bool, error) {
+	repo, ok := s.repo()
+	if !ok {
+		return nil, false, nil
+	}
+	b, err := repo.Get(key.Provider, key.Model, key.Hash)
+	if err != nil {
+		// not found and lookup failures are both treated as misses
+		return nil, false, nil
+	}
+	vec := storage.DecodeEmbedding(b, storage.EncodingFloat32)
+	return vec, vec != nil, nil
+}
+
+func (s *embeddingCacheStore) Put(ctx context.Context, key embed.CacheKey, embedding []float32) error {
+	repo, ok := s.repo()
+	if !ok {
+		return nil
+	}
+	return repo.Put(key.Provider, key.Model, key.Hash, storage.EncodeEmbedding(embedding, storage.EncodingFloat32))
+}
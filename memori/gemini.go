@@ -0,0 +1,272 @@
+package memori
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"memorigo/sse"
+)
+
+type GeminiOptions struct {
+	BaseURL    string
+	APIKey     string
+	APIVersion string // path version, defaults to "v1beta"
+	HTTPClient *http.Client
+}
+
+// GeminiClient is a minimal client for the Gemini generateContent and
+// streamGenerateContent APIs.
+type GeminiClient struct {
+	BaseURL    string
+	APIKey     string
+	APIVersion string
+	HTTPClient *http.Client
+}
+
+func NewGeminiClient(opts GeminiOptions) *GeminiClient {
+	base := strings.TrimRight(opts.BaseURL, "/")
+	if base == "" {
+		base = "https://generativelanguage.googleapis.com"
+	}
+	version := opts.APIVersion
+	if version == "" {
+		version = "v1beta"
+	}
+	c := opts.HTTPClient
+	if c == nil {
+		c = &http.Client{Timeout: 60 * time.Second}
+	}
+	return &GeminiClient{
+		BaseURL:    base,
+		APIKey:     opts.APIKey,
+		APIVersion: version,
+		HTTPClient: c,
+	}
+}
+
+// GeminiBlob is inline binary data, base64-encoded on the wire.
+type GeminiBlob struct {
+	MimeType string `json:"mimeType"`
+	Data     string `json:"data"`
+}
+
+// GeminiFileData refers to a file uploaded through the Files API or stored
+// at a URI the model can read.
+type GeminiFileData struct {
+	MimeType string `json:"mimeType,omitempty"`
+	FileURI  string `json:"fileUri"`
+}
+
+// GeminiFunctionCall is a call requested by the model. ID, when the API
+// sets it, is echoed by the matching GeminiFunctionResponse.
+type GeminiFunctionCall struct {
+	ID   string          `json:"id,omitempty"`
+	Name string          `json:"name"`
+	Args json.RawMessage `json:"args,omitempty"`
+}
+
+type GeminiFunctionResponse struct {
+	ID       string          `json:"id,omitempty"`
+	Name     string          `json:"name"`
+	Response json.RawMessage `json:"response,omitempty"`
+}
+
+// GeminiPart is one part of a content. Exactly one field is set.
+type GeminiPart struct {
+	Text             string                  `json:"text,omitempty"`
+	InlineData       *GeminiBlob             `json:"inlineData,omitempty"`
+	FileData         *GeminiFileData         `json:"fileData,omitempty"`
+	FunctionCall     *GeminiFunctionCall     `json:"functionCall,omitempty"`
+	FunctionResponse *GeminiFunctionResponse `json:"functionResponse,omitempty"`
+}
+
+// GeminiContent is a turn of a conversation. Role is "user" or "model".
+type GeminiContent struct {
+	Role  string       `json:"role,omitempty"`
+	Parts []GeminiPart `json:"parts"`
+}
+
+// Text concatenates the text parts.
+func (c GeminiContent) Text() string {
+	var b strings.Builder
+	for _, p := range c.Parts {
+		b.WriteString(p.Text)
+	}
+	return b.String()
+}
+
+// GeminiTextContent returns a content of the given role made of one text part.
+func GeminiTextContent(role, text string) GeminiContent {
+	return GeminiContent{Role: role, Parts: []GeminiPart{{Text: text}}}
+}
+
+type GeminiGenerationConfig struct {
+	Temperature     *float64 `json:"temperature,omitempty"`
+	TopP            *float64 `json:"topP,omitempty"`
+	TopK            *int     `json:"topK,omitempty"`
+	MaxOutputTokens int      `json:"maxOutputTokens,omitempty"`
+	StopSequences   []string `json:"stopSequences,omitempty"`
+}
+
+// GeminiGenerateRequest is the body of generateContent. Model is part of the
+// URL and is not sent in the body.
+type GeminiGenerateRequest struct {
+	Model             string                  `json:"-"`
+	Contents          []GeminiContent         `json:"contents"`
+	SystemInstruction *GeminiContent          `json:"systemInstruction,omitempty"`
+	GenerationConfig  *GeminiGenerationConfig `json:"generationConfig,omitempty"`
+}
+
+type GeminiCandidate struct {
+	Content      GeminiContent `json:"content"`
+	FinishReason string        `json:"finishReason,omitempty"`
+	Index        int           `json:"index"`
+}
+
+type GeminiUsageMetadata struct {
+	PromptTokenCount     int `json:"promptTokenCount"`
+	CandidatesTokenCount int `json:"candidatesTokenCount"`
+	TotalTokenCount      int `json:"totalTokenCount"`
+}
+
+func (u *GeminiUsageMetadata) tokenUsage() *TokenUsage {
+	if u == nil {
+		return nil
+	}
+	return &TokenUsage{PromptTokens: u.PromptTokenCount, CompletionTokens: u.CandidatesTokenCount, TotalTokens: u.TotalTokenCount}
+}
+
+type GeminiGenerateResponse struct {
+	Candidates    []GeminiCandidate    `json:"candidates"`
+	UsageMetadata *GeminiUsageMetadata `json:"usageMetadata,omitempty"`
+	ModelVersion  string               `json:"modelVersion,omitempty"`
+}
+
+// Text returns the text of the first candidate.
+func (r GeminiGenerateResponse) Text() string {
+	if len(r.Candidates) == 0 {
+		return ""
+	}
+	return r.Candidates[0].Content.Text()
+}
+
+func (c *GeminiClient) newRequest(ctx context.Context, req GeminiGenerateRequest, method string, query url.Values) (*http.Request, error) {
+	if req.Model == "" {
+		return nil, fmt.Errorf("gemini: model is required")
+	}
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	model := strings.TrimPrefix(req.Model, "models/")
+	endpoint := c.BaseURL + "/" + c.APIVersion + "/models/" + url.PathEscape(model) + ":" + method
+	if len(query) > 0 {
+		endpoint += "?" + query.Encode()
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	if c.APIKey != "" {
+		httpReq.Header.Set("x-goog-api-key", c.APIKey)
+	}
+	return httpReq, nil
+}
+
+func (c *GeminiClient) GenerateContent(ctx context.Context, req GeminiGenerateRequest) (GeminiGenerateResponse, error) {
+	var out GeminiGenerateResponse
+
+	httpReq, err := c.newRequest(ctx, req, "generateContent", nil)
+	if err != nil {
+		return out, err
+	}
+
+	resp, err := c.HTTPClient.Do(httpReq)
+	if err != nil {
+		return out, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		b, _ := io.ReadAll(resp.Body)
+		return out, newAPIError("gemini", resp, b)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return out, err
+	}
+	return out, nil
+}
+
+// StreamGenerateContent streams a response over SSE (alt=sse). Each chunk is
+// a partial GeminiGenerateResponse; the stream ends when the server closes it.
+// HTTP and stream errors are reported as *APIError.
+func (c *GeminiClient) StreamGenerateContent(ctx context.Context, req GeminiGenerateRequest) (<-chan GeminiGenerateResponse, <-chan error) {
+	chunks := make(chan GeminiGenerateResponse, 128)
+	errs := make(chan error, 1)
+
+	go func() {
+		defer close(chunks)
+		defer close(errs)
+
+		httpReq, err := c.newRequest(ctx, req, "streamGenerateContent", url.Values{"alt": {"sse"}})
+		if err != nil {
+			errs <- err
+			return
+		}
+		httpReq.Header.Set("Accept", "text/event-stream")
+
+		resp, err := c.HTTPClient.Do(httpReq)
+		if err != nil {
+			errs <- err
+			return
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			b, _ := io.ReadAll(resp.Body)
+			errs <- newAPIError("gemini", resp, b)
+			return
+		}
+
+		dec := sse.NewDecoder(resp.Body)
+		for {
+			ev, err := dec.Next()
+			if err == io.EOF {
+				return
+			}
+			if err != nil {
+				errs <- err
+				return
+			}
+			if ev.Data == "" {
+				continue
+			}
+			if apiErr := streamError("gemini", []byte(ev.Data)); apiErr != nil {
+				errs <- apiErr
+				return
+			}
+			var chunk GeminiGenerateResponse
+			if err := json.Unmarshal([]byte(ev.Data), &chunk); err != nil {
+				errs <- fmt.Errorf("gemini: invalid stream chunk: %w", err)
+				return
+			}
+			select {
+			case chunks <- chunk:
+			case <-ctx.Done():
+				errs <- ctx.Err()
+				return
+			}
+		}
+	}()
+
+	return chunks, errs
+}
@@ -0,0 +1,204 @@
+package memori
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+
+	"memorigo/storage"
+)
+
+// MemoriGeminiClient wraps GeminiClient and automatically persists the system
+// instruction, request contents and the final model text (non-stream or
+// accumulated from chunks), then triggers augmentation. functionCall and
+// functionResponse parts are stored as typed tool messages, as for OpenAI.
+type MemoriGeminiClient struct {
+	m   *Memori
+	raw *GeminiClient
+}
+
+func (m *Memori) GeminiClient() *MemoriGeminiClient {
+	if m.geminiClient == nil {
+		m.geminiClient = NewGeminiClientFromEnv()
+		m.Config.mu.Lock()
+		if m.Config.LLM.Provider == "" {
+			m.Config.LLM.Provider = "gemini"
+			m.Config.LLM.Version = m.geminiClient.APIVersion
+		}
+		m.Config.mu.Unlock()
+	}
+	return &MemoriGeminiClient{m: m, raw: m.geminiClient}
+}
+
+func (c *MemoriGeminiClient) GenerateContent(ctx context.Context, req GeminiGenerateRequest) (GeminiGenerateResponse, error) {
+	resp, err := c.raw.GenerateContent(ctx, req)
+	if err != nil {
+		return resp, err
+	}
+
+	// Best-effort persistence: do not fail the LLM call if local storage fails.
+	var calls []Message
+	if len(resp.Candidates) > 0 {
+		calls = geminiToolCalls(resp.Candidates[0].Content)
+	}
+	_ = c.persist(req, resp.ModelVersion, resp.Text(), calls, resp.UsageMetadata.tokenUsage(), false)
+	return resp, nil
+}
+
+func (c *MemoriGeminiClient) StreamGenerateContent(ctx context.Context, req GeminiGenerateRequest) (<-chan GeminiGenerateResponse, <-chan error) {
+	ctx, cancel := context.WithCancel(ctx)
+	inChunks, inErrs := c.raw.StreamGenerateContent(ctx, req)
+
+	outChunks := make(chan GeminiGenerateResponse, 128)
+	outErrs := make(chan error, 1)
+
+	go func() {
+		defer cancel()
+		defer close(outChunks)
+		defer close(outErrs)
+		send := newStreamSender(c.m, ctx, outChunks, cancel)
+
+		var b strings.Builder
+		var calls []Message
+		var usage *GeminiUsageMetadata
+		var model string
+		complete := false
+
+		// A stream error is sent before the chunks channel is closed, so it
+		// is read once all chunks are in.
+		for chunk := range inChunks {
+			// A caller that went away no longer gets events, but the
+			// reply is still accumulated for persisting.
+			send.send(chunk)
+			for _, cand := range chunk.Candidates {
+				complete = complete || cand.FinishReason != ""
+			}
+			b.WriteString(chunk.Text())
+			if len(chunk.Candidates) > 0 {
+				calls = append(calls, geminiToolCalls(chunk.Candidates[0].Content)...)
+			}
+			// every chunk carries the running totals
+			if chunk.UsageMetadata != nil {
+				usage = chunk.UsageMetadata
+			}
+			if chunk.ModelVersion != "" {
+				model = chunk.ModelVersion
+			}
+		}
+		if err, ok := <-inErrs; ok && err != nil {
+			outErrs <- send.err(err)
+		}
+
+		// Persist the full reply, or what was received of an incomplete one
+		// as Config.PartialResponses says
+		if b.Len() > 0 || len(calls) > 0 {
+			_ = c.persist(req, model, b.String(), calls, usage.tokenUsage(), !complete)
+		}
+	}()
+
+	return outChunks, outErrs
+}
+
+// persist stores the exchange; model is the modelVersion the response
+// reports.
+func (c *MemoriGeminiClient) persist(req GeminiGenerateRequest, model, assistant string, calls []Message, usage *TokenUsage, truncated bool) error {
+	msgs := make([]Message, 0, len(req.Contents)+1)
+	if req.SystemInstruction != nil {
+		if text := req.SystemInstruction.Text(); text != "" {
+			msgs = append(msgs, Message{Role: "system", Content: text})
+		}
+	}
+	for _, content := range req.Contents {
+		msgs = append(msgs, geminiRecords(content)...)
+	}
+
+	return c.m.persistExchange("gemini", responseModel(model, req.Model), msgs, assistant, calls, usage, truncated)
+}
+
+// geminiRecords converts a content into the messages to store: its text and
+// inline or file data references, then a tool_call record per functionCall
+// part and a tool_result record per functionResponse part, in the stored
+// form chatMessageRecords uses. A content with none of these stores nothing.
+func geminiRecords(content GeminiContent) []Message {
+	var out []Message
+	if text, parts := content.Text(), geminiParts(content); text != "" || len(parts) > 0 {
+		out = append(out, Message{Role: geminiRole(content.Role), Content: text, Parts: parts})
+	}
+	out = append(out, geminiToolCalls(content)...)
+	for _, p := range content.Parts {
+		if r := p.FunctionResponse; r != nil {
+			b, _ := json.Marshal(ToolResult{ToolCallID: r.ID, Name: r.Name, Content: string(r.Response)})
+			out = append(out, Message{Role: "tool", Type: MessageTypeToolResult, Content: string(b)})
+		}
+	}
+	return out
+}
+
+// geminiToolCalls stores the functionCall parts of a content as the ToolCalls
+// they correspond to, their args as the calls' arguments.
+func geminiToolCalls(content GeminiContent) []Message {
+	var out []Message
+	for _, p := range content.Parts {
+		if call := p.FunctionCall; call != nil {
+			args := string(call.Args)
+			if args == "" {
+				args = "{}"
+			}
+			out = append(out, toolCallRecord(ToolCall{ID: call.ID, Type: "function", Function: FunctionCall{Name: call.Name, Arguments: args}}))
+		}
+	}
+	return out
+}
+
+// geminiParts returns the stored parts of a content holding inline or file
+// data, in the form contentPartRefs gives OpenAI parts: images become
+// image_url parts, audio input_audio parts and anything else file parts,
+// inline data replaced by its hash and size. Text-only content has no parts.
+func geminiParts(content GeminiContent) []storage.MessagePart {
+	var out []storage.MessagePart
+	media := false
+	for _, p := range content.Parts {
+		var mime string
+		switch {
+		case p.InlineData != nil:
+			mime = p.InlineData.MimeType
+		case p.FileData != nil:
+			mime = p.FileData.MimeType
+		case p.Text != "":
+			out = append(out, storage.MessagePart{Type: "text", Text: p.Text})
+			continue
+		default:
+			continue
+		}
+		media = true
+		ref := storage.MessagePart{Type: "file", MimeType: mime}
+		switch {
+		case strings.HasPrefix(mime, "image/"):
+			ref.Type = "image_url"
+		case strings.HasPrefix(mime, "audio/"):
+			ref.Type = "input_audio"
+		}
+		if p.InlineData != nil {
+			ref.Hash, ref.Size = inlineDataRef(p.InlineData.Data)
+		} else {
+			ref.URL = p.FileData.FileURI
+		}
+		out = append(out, ref)
+	}
+	if !media {
+		return nil
+	}
+	return out
+}
+
+// geminiRole maps Gemini's "model" role to "assistant"; an empty role means
+// "user" in single-turn requests.
+func geminiRole(role string) string {
+	switch role {
+	case "model":
+		return "assistant"
+	case "":
+		return "user"
+	}
+	return role
+}
@@ -0,0 +1,36 @@
+package memori
+
+import (
+	"os"
+)
+
+type GeminiProvider struct {
+	m *Memori
+}
+
+// Register wires a Gemini client into Memori, mirroring OpenAIProvider.Register.
+func (p *GeminiProvider) Register(client *GeminiClient) *Memori {
+	if client == nil {
+		client = NewGeminiClientFromEnv()
+	}
+	p.m.Config.mu.Lock()
+	p.m.Config.LLM.Provider = "gemini"
+	p.m.Config.LLM.Version = client.APIVersion
+	p.m.Config.mu.Unlock()
+
+	p.m.geminiClient = client
+	return p.m
+}
+
+// NewGeminiClientFromEnv reads GEMINI_API_KEY (or GOOGLE_API_KEY) and
+// GEMINI_BASE_URL.
+func NewGeminiClientFromEnv() *GeminiClient {
+	key := os.Getenv("GEMINI_API_KEY")
+	if key == "" {
+		key = os.Getenv("GOOGLE_API_KEY")
+	}
+	return NewGeminiClient(GeminiOptions{
+		BaseURL: os.Getenv("GEMINI_BASE_URL"),
+		APIKey:  key,
+	})
+}
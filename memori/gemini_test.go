@@ -0,0 +1,150 @@
+package memori_test
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	_ "modernc.org/sqlite"
+
+	"memorigo/memori"
+)
+
+// fakeGeminiServer answers generateContent with a fixed reply and
+// streamGenerateContent?alt=sse with the reply split over two chunks.
+func fakeGeminiServer(t *testing.T, reply string) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("x-goog-api-key") != "test-key" {
+			http.Error(w, `{"error":{"code":403,"message":"bad key"}}`, http.StatusForbidden)
+			return
+		}
+		var req memori.GeminiGenerateRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || len(req.Contents) == 0 {
+			http.Error(w, "invalid request", http.StatusBadRequest)
+			return
+		}
+
+		switch r.URL.Path {
+		case "/v1beta/models/gemini-test:generateContent":
+			_ = json.NewEncoder(w).Encode(memori.GeminiGenerateResponse{
+				Candidates: []memori.GeminiCandidate{{
+					Content:      memori.GeminiTextContent("model", reply),
+					FinishReason: "STOP",
+				}},
+			})
+		case "/v1beta/models/gemini-test:streamGenerateContent":
+			if r.URL.Query().Get("alt") != "sse" {
+				http.Error(w, "expected alt=sse", http.StatusBadRequest)
+				return
+			}
+			w.Header().Set("Content-Type", "text/event-stream")
+			flusher := w.(http.Flusher)
+			half := len(reply) / 2
+			for _, part := range []string{reply[:half], reply[half:]} {
+				b, _ := json.Marshal(memori.GeminiGenerateResponse{
+					Candidates: []memori.GeminiCandidate{{Content: memori.GeminiTextContent("model", part)}},
+				})
+				fmt.Fprintf(w, "data: %s\r\n\r\n", b)
+				flusher.Flush()
+			}
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestGemini_StreamPersistsExchange(t *testing.T) {
+	srv := fakeGeminiServer(t, "Noted, you play the cello.")
+
+	db, err := sql.Open("sqlite", "file:memori_gemini?mode=memory&cache=shared")
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	defer db.Close()
+
+	m := memori.New(memori.WithStorageConn(db))
+	if err := m.Storage.Build(); err != nil {
+		t.Fatalf("migrate/build: %v", err)
+	}
+	m.Attribution("user-gemini", "proc-gemini")
+	m.Gemini.Register(memori.NewGeminiClient(memori.GeminiOptions{BaseURL: srv.URL, APIKey: "test-key"}))
+
+	system := memori.GeminiTextContent("", "You are a helpful assistant.")
+	req := memori.GeminiGenerateRequest{
+		Model:             "gemini-test",
+		SystemInstruction: &system,
+		Contents: []memori.GeminiContent{
+			memori.GeminiTextContent("user", "I play the cello in an orchestra"),
+		},
+	}
+	chunks, errs := m.GeminiClient().StreamGenerateContent(context.Background(), req)
+
+	var text strings.Builder
+	n := 0
+	for chunk := range chunks {
+		n++
+		text.WriteString(chunk.Text())
+	}
+	if err := <-errs; err != nil {
+		t.Fatalf("stream: %v", err)
+	}
+	if n != 2 || text.String() != "Noted, you play the cello." {
+		t.Fatalf("streamed %d chunks, text = %q", n, text.String())
+	}
+
+	resp, err := m.GeminiClient().GenerateContent(context.Background(), req)
+	if err != nil || resp.Text() != "Noted, you play the cello." {
+		t.Fatalf("generate: %q, %v", resp.Text(), err)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		facts, err := m.Recall("cello orchestra", 5)
+		if err != nil {
+			t.Fatalf("recall: %v", err)
+		}
+		for _, f := range facts {
+			if f.Content == "I play the cello in an orchestra" {
+				return
+			}
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for persisted facts, got %#v", facts)
+		}
+		time.Sleep(25 * time.Millisecond)
+	}
+}
+
+func TestGemini_MidStreamErrorReachesCaller(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprintf(w, "data: %s\n\n", `{"candidates":[{"content":{"role":"model","parts":[{"text":"Blue"}]}}]}`)
+		fmt.Fprintf(w, "data: %s\n\n", `{"error":{"code":429,"message":"Quota exceeded","status":"RESOURCE_EXHAUSTED"}}`)
+	}))
+	defer srv.Close()
+
+	m := memori.New()
+	m.Gemini.Register(memori.NewGeminiClient(memori.GeminiOptions{BaseURL: srv.URL}))
+	for i := 0; i < 100; i++ {
+		chunks, errs := m.GeminiClient().StreamGenerateContent(context.Background(), memori.GeminiGenerateRequest{
+			Model:    "gemini-test",
+			Contents: []memori.GeminiContent{memori.GeminiTextContent("user", "What is my favorite color?")},
+		})
+		for range chunks {
+		}
+		var apiErr *memori.APIError
+		if err := <-errs; !errors.As(err, &apiErr) || apiErr.Message != "Quota exceeded" {
+			t.Fatalf("run %d: err = %v", i, err)
+		}
+	}
+}
@@ -0,0 +1,219 @@
+package memori
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"memorigo/embed"
+)
+
+// Sentinel errors an *APIError unwraps to, so callers can branch with
+// errors.Is without inspecting status codes.
+var (
+	ErrAuthentication        = errors.New("llm: authentication failed")
+	ErrPermissionDenied      = errors.New("llm: permission denied")
+	ErrModelNotFound         = errors.New("llm: model or endpoint not found")
+	ErrInvalidRequest        = errors.New("llm: invalid request")
+	ErrContextLengthExceeded = errors.New("llm: context length exceeded")
+	ErrRateLimited           = errors.New("llm: rate limited")
+	ErrQuotaExceeded         = errors.New("llm: quota exceeded")
+	ErrServerError           = errors.New("llm: server error")
+)
+
+// APIError is an error reported by an LLM provider: a non-2xx answer, or an
+// error payload received in the middle of a stream (StatusCode 0). The fields
+// come from the {"error": {...}} body when the provider sends one; Body keeps
+// the raw payload either way.
+type APIError struct {
+	Provider   string // "openai_compat", "anthropic", "gemini", ...
+	StatusCode int
+	Type       string // error type, or the status name for Gemini
+	Code       string
+	Param      string
+	Message    string
+	Body       string
+
+	// RetryAfter is the delay requested by the server, if any.
+	RetryAfter time.Duration
+}
+
+func (e *APIError) Error() string {
+	msg := e.Message
+	if msg == "" {
+		msg = e.Body
+	}
+	kind := e.Code
+	if kind == "" {
+		kind = e.Type
+	}
+	where := fmt.Sprintf("http %d", e.StatusCode)
+	if e.StatusCode == 0 {
+		where = "stream error"
+	}
+	if kind != "" {
+		return fmt.Sprintf("%s %s (%s): %s", e.Provider, where, kind, msg)
+	}
+	return fmt.Sprintf("%s %s: %s", e.Provider, where, msg)
+}
+
+// errorTypeStatus maps the error types of stream payloads, which carry no
+// status code, to the status the same error has over plain HTTP.
+var errorTypeStatus = map[string]int{
+	// OpenAI and Anthropic
+	"invalid_request_error": http.StatusBadRequest,
+	"authentication_error":  http.StatusUnauthorized,
+	"permission_error":      http.StatusForbidden,
+	"not_found_error":       http.StatusNotFound,
+	"rate_limit_error":      http.StatusTooManyRequests,
+	"rate_limit_exceeded":   http.StatusTooManyRequests,
+	"insufficient_quota":    http.StatusTooManyRequests,
+	"server_error":          http.StatusInternalServerError,
+	"api_error":             http.StatusInternalServerError,
+	"overloaded_error":      529,
+	// Gemini
+	"INVALID_ARGUMENT":    http.StatusBadRequest,
+	"FAILED_PRECONDITION": http.StatusBadRequest,
+	"UNAUTHENTICATED":     http.StatusUnauthorized,
+	"PERMISSION_DENIED":   http.StatusForbidden,
+	"NOT_FOUND":           http.StatusNotFound,
+	"RESOURCE_EXHAUSTED":  http.StatusTooManyRequests,
+	"INTERNAL":            http.StatusInternalServerError,
+	"UNAVAILABLE":         http.StatusServiceUnavailable,
+	"DEADLINE_EXCEEDED":   http.StatusGatewayTimeout,
+}
+
+// status is the HTTP status of the error, derived from its type for stream
+// errors.
+func (e *APIError) status() int {
+	if e.StatusCode != 0 {
+		return e.StatusCode
+	}
+	if s, ok := errorTypeStatus[e.Type]; ok {
+		return s
+	}
+	return errorTypeStatus[e.Code]
+}
+
+func (e *APIError) Unwrap() error {
+	status := e.status()
+	switch {
+	case status == http.StatusUnauthorized:
+		return ErrAuthentication
+	case status == http.StatusForbidden:
+		return ErrPermissionDenied
+	case status == http.StatusNotFound:
+		return ErrModelNotFound
+	case status == http.StatusTooManyRequests:
+		if e.Code == "insufficient_quota" || e.Type == "insufficient_quota" {
+			return ErrQuotaExceeded
+		}
+		return ErrRateLimited
+	case status >= 500:
+		return ErrServerError
+	case status >= 400:
+		if e.contextLengthExceeded() {
+			return ErrContextLengthExceeded
+		}
+		return ErrInvalidRequest
+	}
+	return nil
+}
+
+// Retryable reports whether the same request may succeed later: rate limits
+// (but not an exhausted quota) and server errors.
+func (e *APIError) Retryable() bool {
+	switch e.Unwrap() {
+	case ErrRateLimited, ErrServerError:
+		return true
+	}
+	return false
+}
+
+// contextLengthExceeded also matches the message, since not every
+// compatible provider sets the code.
+func (e *APIError) contextLengthExceeded() bool {
+	if e.Code == "context_length_exceeded" {
+		return true
+	}
+	msg := strings.ToLower(e.Message)
+	return strings.Contains(msg, "maximum context length") || strings.Contains(msg, "context length exceeded") ||
+		strings.Contains(msg, "prompt is too long")
+}
+
+// newAPIError builds an APIError from a failed response.
+func newAPIError(provider string, resp *http.Response, body []byte) *APIError {
+	e := &APIError{
+		Provider:   provider,
+		StatusCode: resp.StatusCode,
+		Body:       string(body),
+		RetryAfter: embed.ParseRetryAfter(resp.Header),
+	}
+	e.parse(body)
+	return e
+}
+
+// streamError returns the error carried by a stream payload, or nil when
+// data has no "error" member.
+func streamError(provider string, data []byte) *APIError {
+	e := &APIError{Provider: provider, Body: string(data)}
+	if !e.parse(data) {
+		return nil
+	}
+	return e
+}
+
+// parse fills the fields from an {"error": {...}} or {"error": "message"}
+// payload and reports whether there was one.
+func (e *APIError) parse(body []byte) bool {
+	var payload struct {
+		Error json.RawMessage `json:"error"`
+	}
+	if json.Unmarshal(body, &payload) != nil {
+		return false
+	}
+	raw := bytes.TrimSpace(payload.Error)
+	if len(raw) == 0 || string(raw) == "null" {
+		return false
+	}
+	var detail struct {
+		Message string          `json:"message"`
+		Type    string          `json:"type"`
+		Status  string          `json:"status"` // Gemini
+		Param   json.RawMessage `json:"param"`
+		Code    json.RawMessage `json:"code"`
+	}
+	if json.Unmarshal(raw, &detail) != nil {
+		// some providers send {"error": "message"}
+		_ = json.Unmarshal(raw, &e.Message)
+		return true
+	}
+	e.Message = detail.Message
+	e.Type = detail.Type
+	if e.Type == "" {
+		e.Type = detail.Status
+	}
+	e.Param = jsonScalar(detail.Param)
+	// Gemini's numeric code, sent next to status, only repeats the HTTP status
+	if detail.Status == "" {
+		e.Code = jsonScalar(detail.Code)
+	}
+	return true
+}
+
+// jsonScalar renders a string, number or null JSON value as a string.
+func jsonScalar(raw json.RawMessage) string {
+	raw = bytes.TrimSpace(raw)
+	if len(raw) == 0 || string(raw) == "null" {
+		return ""
+	}
+	var s string
+	if json.Unmarshal(raw, &s) == nil {
+		return s
+	}
+	return string(raw)
+}
@@ -0,0 +1,150 @@
+package memori_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"memorigo/embed"
+	"memorigo/memori"
+)
+
+func TestOpenAICompat_RetriesRateLimit(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) < 3 {
+			w.Header().Set("retry-after-ms", "10")
+			w.WriteHeader(http.StatusTooManyRequests)
+			_, _ = w.Write([]byte(`{"error":{"message":"Rate limit reached","type":"requests","code":"rate_limit_exceeded"}}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"choices":[{"index":0,"message":{"role":"assistant","content":"ok"}}]}`))
+	}))
+	defer srv.Close()
+
+	client := memori.NewOpenAICompatClient(memori.OpenAICompatOptions{
+		BaseURL: srv.URL,
+		Retry:   embed.RetryPolicy{MaxDelay: 50 * time.Millisecond},
+	})
+	req := memori.ChatCompletionsRequest{Model: "m", Messages: []memori.ChatMessage{{Role: "user", Content: "hi"}}}
+	resp, err := client.ChatCompletionsCreate(context.Background(), req)
+	if err != nil || resp.Choices[0].Message.Content != "ok" || calls.Load() != 3 {
+		t.Fatalf("resp = %+v, err = %v, calls = %d", resp, err, calls.Load())
+	}
+
+	calls.Store(-10)
+	client.Retry.MaxRetries = -1
+	_, err = client.ChatCompletionsCreate(context.Background(), req)
+	var apiErr *memori.APIError
+	if !errors.Is(err, memori.ErrRateLimited) || !errors.As(err, &apiErr) ||
+		apiErr.Code != "rate_limit_exceeded" || apiErr.RetryAfter != 10*time.Millisecond {
+		t.Fatalf("err = %#v", err)
+	}
+}
+
+func TestOpenAICompat_ErrorTaxonomy(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		switch r.Header.Get("Authorization") {
+		case "Bearer bad":
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"error":{"message":"Incorrect API key provided","type":"invalid_request_error","code":"invalid_api_key"}}`))
+		case "Bearer quota":
+			w.WriteHeader(http.StatusTooManyRequests)
+			_, _ = w.Write([]byte(`{"error":{"message":"You exceeded your current quota","type":"insufficient_quota","code":"insufficient_quota"}}`))
+		case "Bearer slow":
+			time.Sleep(200 * time.Millisecond)
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"error":{"message":"This model's maximum context length is 8192 tokens.","type":"invalid_request_error","param":"messages","code":"context_length_exceeded"}}`))
+		}
+	}))
+	defer srv.Close()
+
+	req := memori.ChatCompletionsRequest{Model: "m", Messages: []memori.ChatMessage{{Role: "user", Content: "hi"}}}
+	for key, want := range map[string]error{
+		"bad":   memori.ErrAuthentication,
+		"quota": memori.ErrQuotaExceeded,
+		"":      memori.ErrContextLengthExceeded,
+	} {
+		calls.Store(0)
+		client := memori.NewOpenAICompatClient(memori.OpenAICompatOptions{BaseURL: srv.URL, APIKey: key})
+		_, err := client.ChatCompletionsCreate(context.Background(), req)
+		if !errors.Is(err, want) || calls.Load() != 1 {
+			t.Fatalf("key %q: err = %v after %d calls, want %v without retry", key, err, calls.Load(), want)
+		}
+	}
+
+	m := memori.New()
+	m.Config.Timeout = 20 * time.Millisecond
+	m.OpenAI.Register(memori.NewOpenAICompatClient(memori.OpenAICompatOptions{
+		BaseURL: srv.URL,
+		APIKey:  "slow",
+		Retry:   embed.RetryPolicy{MaxRetries: 1, BaseDelay: time.Millisecond},
+	}))
+	calls.Store(0)
+	_, err := m.OpenAIClient().ChatCompletionsCreate(context.Background(), req)
+	if !errors.Is(err, context.DeadlineExceeded) || calls.Load() != 2 {
+		t.Fatalf("err = %v after %d calls, want a timeout on each of 2 attempts", err, calls.Load())
+	}
+
+	_, errs := m.OpenAIClient().ChatCompletionsStream(context.Background(), req)
+	if err := <-errs; !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("stream err = %v, want a header timeout", err)
+	}
+}
+
+func TestStreams_SSEAndMidStreamErrors(t *testing.T) {
+	big := strings.Repeat("x", 100*1024)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		switch {
+		case strings.HasPrefix(r.URL.Path, "/v1/chat/completions"):
+			fmt.Fprintf(w, ": keep-alive\r\n\r\n")
+			fmt.Fprintf(w, "data: {\"choices\":[{\"index\":0,\r\ndata: \"delta\":{\"content\":%q}}]}\r\n\r\n", big)
+			fmt.Fprintf(w, "data: {\"error\":{\"message\":\"The server had an error\",\"type\":\"server_error\"}}\n\n")
+		case strings.HasPrefix(r.URL.Path, "/v1/messages"):
+			fmt.Fprintf(w, "event: error\ndata: {\"type\":\"error\",\"error\":{\"type\":\"overloaded_error\",\"message\":\"Overloaded\"}}\n\n")
+		default:
+			fmt.Fprintf(w, "data: {\"error\":{\"code\":429,\"message\":\"Quota exceeded\",\"status\":\"RESOURCE_EXHAUSTED\"}}\r\n\r\n")
+		}
+	}))
+	defer srv.Close()
+
+	openai := memori.NewOpenAICompatClient(memori.OpenAICompatOptions{BaseURL: srv.URL})
+	events, errs := openai.ChatCompletionsStream(context.Background(), memori.ChatCompletionsRequest{Model: "m"})
+	var text strings.Builder
+	for ev := range events {
+		text.WriteString(ev.Chunk.Choices[0].Delta.Content)
+	}
+	var apiErr *memori.APIError
+	if err := <-errs; !errors.Is(err, memori.ErrServerError) || !errors.As(err, &apiErr) || apiErr.StatusCode != 0 {
+		t.Fatalf("openai err = %v", err)
+	}
+	if text.String() != big {
+		t.Fatalf("streamed %d bytes, want %d", text.Len(), len(big))
+	}
+
+	anthropic := memori.NewAnthropicClient(memori.AnthropicOptions{BaseURL: srv.URL})
+	aEvents, aErrs := anthropic.MessagesStream(context.Background(), memori.AnthropicMessagesRequest{Model: "m"})
+	for range aEvents {
+	}
+	if err := <-aErrs; !errors.Is(err, memori.ErrServerError) {
+		t.Fatalf("anthropic err = %v", err)
+	}
+
+	gemini := memori.NewGeminiClient(memori.GeminiOptions{BaseURL: srv.URL})
+	gChunks, gErrs := gemini.StreamGenerateContent(context.Background(), memori.GeminiGenerateRequest{Model: "m"})
+	for range gChunks {
+	}
+	if err := <-gErrs; !errors.Is(err, memori.ErrRateLimited) {
+		t.Fatalf("gemini err = %v", err)
+	}
+}
@@ -0,0 +1,381 @@
+package memori
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"memorigo/embed"
+	"memorigo/storage"
+)
+
+// ExportVersion is the format version written by Export.
+const ExportVersion = 1
+
+// EntityExport is everything stored about one entity. Facts are exported
+// without their vectors; they are re-embedded when imported.
+type EntityExport struct {
+	Version    int                `json:"version"`
+	ExportedAt time.Time          `json:"exported_at"`
+	Entity     storage.EntityInfo `json:"entity"`
+	Facts      []storage.FactInfo `json:"facts"`
+	Sessions   []SessionExport    `json:"sessions"`
+}
+
+type SessionExport struct {
+	storage.SessionInfo
+	Conversations []ConversationExport `json:"conversations"`
+}
+
+type ConversationExport struct {
+	storage.ConversationInfo
+	Messages []storage.MessageInfo `json:"messages"`
+}
+
+// Browse returns the repo used to list and edit stored memory.
+func (m *Memori) Browse() (storage.BrowseRepo, error) {
+	if m.Storage == nil || m.Storage.Driver() == nil {
+		return nil, fmt.Errorf("no storage configured")
+	}
+	return storage.BrowseOf(m.Storage.Driver())
+}
+
+// UpdateFact replaces the content of a fact and re-embeds it. It returns
+// storage.ErrNotFound for an unknown fact and storage.ErrDuplicate when the
+// entity already has a fact with the new content.
+func (m *Memori) UpdateFact(ctx context.Context, factUUID, content string) (storage.FactInfo, error) {
+	content = strings.TrimSpace(content)
+	if content == "" {
+		return storage.FactInfo{}, fmt.Errorf("fact content is empty")
+	}
+	browse, err := m.Browse()
+	if err != nil {
+		return storage.FactInfo{}, err
+	}
+	if _, err := browse.GetFact(factUUID); err != nil {
+		return storage.FactInfo{}, err
+	}
+
+	// A failed embedding keeps the fact without a vector, as augmentation
+	// does; Reembed fills it in later.
+	var emb storage.FactEmbedding
+	vecs, src, err := embed.EmbedWithSource(ctx, m.Embedder, []string{content})
+	if err == nil && len(vecs) == 1 {
+		emb = m.factEmbedding(src, vecs[0])
+	}
+	if err := browse.UpdateFact(factUUID, content, hashString(content), emb); err != nil {
+		return storage.FactInfo{}, err
+	}
+	return browse.GetFact(factUUID)
+}
+
+// Remember stores content as a fact of the attributed entity without going
+// through a conversation, creating the entity when needed. Remembering an
+// existing fact again counts as another mention of it. Watchers of the
+// augmentation manager see the fact like one written by augmentation.
+func (m *Memori) Remember(ctx context.Context, content string) (storage.FactInfo, error) {
+	content = strings.TrimSpace(content)
+	if content == "" {
+		return storage.FactInfo{}, fmt.Errorf("fact content is empty")
+	}
+	entity := m.Config.EntityID
+	if entity == "" {
+		return storage.FactInfo{}, fmt.Errorf("no entity attributed")
+	}
+	browse, err := m.Browse()
+	if err != nil {
+		return storage.FactInfo{}, err
+	}
+	repos := m.Storage.Driver().(storage.Repos)
+	entityID, err := repos.Entity().Create(entity)
+	if err != nil {
+		return storage.FactInfo{}, err
+	}
+
+	// As in augmentation, a failed embedding keeps a new fact without a
+	// vector for Reembed; Create fails for, and leaves alone, an existing one.
+	var createErr error
+	vecs, src, err := embed.EmbedWithSource(ctx, m.Embedder, []string{content})
+	if err == nil && len(vecs) == 1 {
+		if err := repos.EntityFact().Upsert(entityID, content, m.factEmbedding(src, vecs[0]), hashString(content)); err != nil {
+			return storage.FactInfo{}, err
+		}
+	} else {
+		createErr = repos.EntityFact().Create(entityID, content, storage.FactEmbedding{}, hashString(content))
+	}
+	if createErr == nil && m.Augmentation != nil {
+		m.Augmentation.publish(FactEvent{EntityID: entity, Content: content, Time: time.Now()})
+	}
+
+	facts, err := listAll(func(o storage.ListOptions) ([]storage.FactInfo, error) {
+		return browse.ListFacts(storage.FactFilter{EntityID: entity, Query: content}, o)
+	})
+	if err != nil {
+		return storage.FactInfo{}, err
+	}
+	for _, f := range facts {
+		if f.Content == content {
+			return f, nil
+		}
+	}
+	if createErr != nil {
+		return storage.FactInfo{}, createErr
+	}
+	return storage.FactInfo{}, storage.ErrNotFound
+}
+
+// RecentConversations returns the conversations of an entity, most recently
+// updated first, at most limit of them when limit > 0. It returns
+// storage.ErrNotFound for an unknown entity.
+func (m *Memori) RecentConversations(entityID string, limit int) ([]storage.ConversationInfo, error) {
+	browse, err := m.Browse()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := browse.GetEntity(entityID); err != nil {
+		return nil, err
+	}
+	sessions, err := listAll(func(o storage.ListOptions) ([]storage.SessionInfo, error) {
+		return browse.ListSessions(storage.SessionFilter{EntityID: entityID}, o)
+	})
+	if err != nil {
+		return nil, err
+	}
+	var out []storage.ConversationInfo
+	for _, s := range sessions {
+		convs, err := listAll(func(o storage.ListOptions) ([]storage.ConversationInfo, error) {
+			return browse.ListConversations(s.UUID, o)
+		})
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, convs...)
+	}
+	lastActive := func(c storage.ConversationInfo) time.Time {
+		if c.DateUpdated != nil {
+			return *c.DateUpdated
+		}
+		return c.DateCreated
+	}
+	sort.SliceStable(out, func(i, j int) bool { return lastActive(out[i]).After(lastActive(out[j])) })
+	if limit > 0 && len(out) > limit {
+		out = out[:limit]
+	}
+	return out, nil
+}
+
+// Forget deletes an entity and everything stored about it: sessions,
+// conversations, messages, facts and usage records. It returns
+// storage.ErrNotFound for an unknown entity.
+func (m *Memori) Forget(entityID string) error {
+	browse, err := m.Browse()
+	if err != nil {
+		return err
+	}
+	return browse.DeleteEntity(entityID)
+}
+
+// Export collects everything stored about an entity. It returns
+// storage.ErrNotFound for an unknown entity.
+func (m *Memori) Export(entityID string) (EntityExport, error) {
+	out := EntityExport{Version: ExportVersion, ExportedAt: time.Now().UTC()}
+	browse, err := m.Browse()
+	if err != nil {
+		return out, err
+	}
+	if out.Entity, err = browse.GetEntity(entityID); err != nil {
+		return out, err
+	}
+	if out.Facts, err = listAll(func(o storage.ListOptions) ([]storage.FactInfo, error) {
+		return browse.ListFacts(storage.FactFilter{EntityID: entityID}, o)
+	}); err != nil {
+		return out, err
+	}
+	sessions, err := listAll(func(o storage.ListOptions) ([]storage.SessionInfo, error) {
+		return browse.ListSessions(storage.SessionFilter{EntityID: entityID}, o)
+	})
+	if err != nil {
+		return out, err
+	}
+	for _, s := range sessions {
+		se := SessionExport{SessionInfo: s}
+		convs, err := listAll(func(o storage.ListOptions) ([]storage.ConversationInfo, error) {
+			return browse.ListConversations(s.UUID, o)
+		})
+		if err != nil {
+			return out, err
+		}
+		for _, c := range convs {
+			ce := ConversationExport{ConversationInfo: c}
+			if ce.Messages, err = listAll(func(o storage.ListOptions) ([]storage.MessageInfo, error) {
+				return browse.ListMessages(c.UUID, o)
+			}); err != nil {
+				return out, err
+			}
+			se.Conversations = append(se.Conversations, ce)
+		}
+		out.Sessions = append(out.Sessions, se)
+	}
+	return out, nil
+}
+
+// ImportResult reports what Import wrote.
+type ImportResult struct {
+	Sessions        int `json:"sessions"`
+	SkippedSessions int `json:"skipped_sessions"`
+	Conversations   int `json:"conversations"`
+	Messages        int `json:"messages"`
+	Facts           int `json:"facts"`
+	SkippedFacts    int `json:"skipped_facts"`
+}
+
+// Import writes an export, e.g. one taken from another deployment, creating
+// the entity when needed. Sessions keep their uuid and those already stored
+// are skipped, so importing the same export twice writes its conversations
+// once; conversations and messages get new uuids and dates. Facts keep
+// their mention count and dates and are re-embedded with m.Embedder; those
+// the entity already has are skipped and left as they are. Import is not
+// atomic: on error, what was written before stays.
+func (m *Memori) Import(ctx context.Context, exp EntityExport) (ImportResult, error) {
+	var res ImportResult
+	if exp.Version != ExportVersion {
+		return res, fmt.Errorf("unsupported export version %d, want %d", exp.Version, ExportVersion)
+	}
+	entity := exp.Entity.ExternalID
+	if entity == "" || len(entity) > 100 {
+		return res, fmt.Errorf("export has an invalid entity id %q", entity)
+	}
+	browse, err := m.Browse()
+	if err != nil {
+		return res, err
+	}
+	repos := m.Storage.Driver().(storage.Repos)
+	entityID, err := repos.Entity().Create(entity)
+	if err != nil {
+		return res, err
+	}
+
+	for _, s := range exp.Sessions {
+		if err := ctx.Err(); err != nil {
+			return res, err
+		}
+		sessionUUID, err := uuid.Parse(s.UUID)
+		if err != nil {
+			return res, fmt.Errorf("session %q: %w", s.UUID, err)
+		}
+		if _, err := repos.Session().GetByUUID(sessionUUID); err == nil {
+			res.SkippedSessions++
+			continue
+		}
+		var processID *int64
+		if s.ProcessID != "" {
+			id, err := repos.Process().Create(s.ProcessID)
+			if err != nil {
+				return res, err
+			}
+			processID = &id
+		}
+		sessionID, err := repos.Session().Create(&entityID, processID, sessionUUID)
+		if err != nil {
+			return res, err
+		}
+		res.Sessions++
+		for _, c := range s.Conversations {
+			// a zero timeout always starts a new conversation
+			convID, err := repos.Conversation().Create(sessionID, 0)
+			if err != nil {
+				return res, err
+			}
+			if c.Summary != "" {
+				if err := repos.Conversation().UpdateSummary(convID, c.Summary); err != nil {
+					return res, err
+				}
+			}
+			res.Conversations++
+			for _, msg := range c.Messages {
+				if err := repos.Message().Create(storage.MessageRecord{
+					ConversationID: convID,
+					Role:           msg.Role,
+					Type:           msg.Type,
+					Content:        msg.Content,
+					Parts:          msg.Parts,
+					Truncated:      msg.Truncated,
+				}); err != nil {
+					return res, err
+				}
+				res.Messages++
+			}
+		}
+	}
+
+	existing, err := listAll(func(o storage.ListOptions) ([]storage.FactInfo, error) {
+		return browse.ListFacts(storage.FactFilter{EntityID: entity}, o)
+	})
+	if err != nil {
+		return res, err
+	}
+	seen := make(map[string]bool, len(existing))
+	for _, f := range existing {
+		seen[hashString(f.Content)] = true
+	}
+	var pending []storage.FactInfo
+	for _, f := range exp.Facts {
+		if uniq := hashString(f.Content); seen[uniq] {
+			res.SkippedFacts++
+		} else {
+			seen[uniq] = true
+			pending = append(pending, f)
+		}
+	}
+
+	for start := 0; start < len(pending); start += reembedBatchSize {
+		batch := pending[start:min(start+reembedBatchSize, len(pending))]
+		texts := make([]string, len(batch))
+		for i, f := range batch {
+			texts[i] = f.Content
+		}
+		vecs, src, err := embed.EmbedWithSource(ctx, m.Embedder, texts)
+		if err := ctx.Err(); err != nil {
+			return res, err
+		}
+		for i, f := range batch {
+			// As in augmentation, a fact that could not be embedded is kept
+			// without a vector for Reembed.
+			var emb storage.FactEmbedding
+			if err == nil && len(vecs) == len(texts) {
+				emb = m.factEmbedding(src, vecs[i])
+			}
+			f.EntityID = entity
+			switch err := browse.ImportFact(f, hashString(f.Content), emb); {
+			case errors.Is(err, storage.ErrDuplicate):
+				res.SkippedFacts++
+			case err != nil:
+				return res, err
+			default:
+				res.Facts++
+			}
+		}
+	}
+	return res, nil
+}
+
+// listAll pages through a listing until it is exhausted.
+func listAll[T any](list func(storage.ListOptions) ([]T, error)) ([]T, error) {
+	const pageSize = 500
+	var out []T
+	for offset := 0; ; offset += pageSize {
+		page, err := list(storage.ListOptions{Limit: pageSize, Offset: offset})
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, page...)
+		if len(page) < pageSize {
+			return out, nil
+		}
+	}
+}
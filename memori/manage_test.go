@@ -0,0 +1,120 @@
+package memori_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	bolt "go.etcd.io/bbolt"
+
+	"memorigo/memori"
+	"memorigo/storage"
+)
+
+func TestManage_ExportUpdateForget(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		open func(t *testing.T) any
+	}{
+		{"sqlite", func(t *testing.T) any {
+			db, err := sql.Open("sqlite", "file:memori_manage?mode=memory&cache=shared")
+			if err != nil {
+				t.Fatalf("open sqlite: %v", err)
+			}
+			t.Cleanup(func() { db.Close() })
+			return db
+		}},
+		{"bolt", func(t *testing.T) any {
+			db, err := bolt.Open(filepath.Join(t.TempDir(), "memori.db"), 0o600, nil)
+			if err != nil {
+				t.Fatalf("open bolt: %v", err)
+			}
+			t.Cleanup(func() { db.Close() })
+			return db
+		}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			m := memori.New(memori.WithStorageConn(tc.open(t)))
+			if err := m.Storage.Build(); err != nil {
+				t.Fatalf("migrate/build: %v", err)
+			}
+			ctx := context.Background()
+			for _, entity := range []string{"alice", "bob"} {
+				s := m.Scoped(entity, "proc", uuid.Nil)
+				if err := memori.NewWriter(s).Execute(ctx, memori.ConversationPayload{
+					Messages: []memori.Message{
+						{Role: "user", Content: "My favorite color is blue"},
+						{Role: "user", Content: "I live in Paris"},
+					},
+					Response: &memori.Message{Role: "assistant", Content: "Noted."},
+				}); err != nil {
+					t.Fatalf("writer execute: %v", err)
+				}
+			}
+			alice := m.Scoped("alice", "", uuid.Nil)
+			waitForFacts(t, alice, "favorite color", 2)
+
+			exp, err := m.Export("alice")
+			if err != nil {
+				t.Fatalf("export: %v", err)
+			}
+			if exp.Entity.ExternalID != "alice" || exp.Entity.UUID == "" || len(exp.Facts) != 2 ||
+				len(exp.Sessions) != 1 || exp.Sessions[0].ProcessID != "proc" ||
+				len(exp.Sessions[0].Conversations) != 1 || len(exp.Sessions[0].Conversations[0].Messages) != 3 {
+				t.Fatalf("unexpected export: %+v", exp)
+			}
+
+			var fact storage.FactInfo
+			for _, f := range exp.Facts {
+				if f.Content == "My favorite color is blue" {
+					fact = f
+				}
+			}
+			if _, err := m.UpdateFact(ctx, fact.UUID, "I live in Paris"); !errors.Is(err, storage.ErrDuplicate) {
+				t.Fatalf("update to an existing fact: %v", err)
+			}
+			updated, err := m.UpdateFact(ctx, fact.UUID, "My favorite color is green")
+			if err != nil || updated.Content != "My favorite color is green" || updated.EntityID != "alice" {
+				t.Fatalf("update: %+v, %v", updated, err)
+			}
+			facts, err := alice.Recall("favorite color green", 1)
+			if err != nil || len(facts) != 1 || facts[0].Content != "My favorite color is green" {
+				t.Fatalf("recall after update: %+v, %v", facts, err)
+			}
+
+			if err := m.Forget("alice"); err != nil {
+				t.Fatalf("forget: %v", err)
+			}
+			if _, err := m.Export("alice"); !errors.Is(err, storage.ErrNotFound) {
+				t.Fatalf("export after forget: %v", err)
+			}
+			if facts, err := alice.Recall("favorite color", 5); err != nil || len(facts) != 0 {
+				t.Fatalf("recall after forget: %+v, %v", facts, err)
+			}
+			if err := m.Forget("alice"); !errors.Is(err, storage.ErrNotFound) {
+				t.Fatalf("second forget: %v", err)
+			}
+			// other entities are untouched
+			if exp, err := m.Export("bob"); err != nil || len(exp.Facts) != 2 || len(exp.Sessions) != 1 {
+				t.Fatalf("bob after forgetting alice: %+v, %v", exp, err)
+			}
+		})
+	}
+}
+
+func waitForFacts(t *testing.T, m *memori.Memori, query string, n int) {
+	t.Helper()
+	for deadline := time.Now().Add(5 * time.Second); ; {
+		if facts, _ := m.Recall(query, n); len(facts) >= n {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for augmentation to write facts")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
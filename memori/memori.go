@@ -16,9 +16,15 @@ type Memori struct {
 	Augmentation *AugmentationManager
 	Embedder     embed.Embedder
 
-	OpenAI *OpenAIProvider
-
-	openAIClient *OpenAICompatClient
+	OpenAI    *OpenAIProvider
+	Anthropic *AnthropicProvider
+	Gemini    *GeminiProvider
+	Ollama    *OllamaProvider
+
+	openAIClient    *OpenAICompatClient
+	anthropicClient *AnthropicClient
+	geminiClient    *GeminiClient
+	ollamaClient    *OllamaClient
 }
 
 type Option func(*Memori)
@@ -36,22 +42,57 @@ func New(opts ...Option) *Memori {
 	if m.Storage == nil {
 		m.Storage = storage.NewManager()
 	}
+	if m.Embedder == nil {
+		ec := m.Config.Embedding
+		m.Embedder = m.newEmbedder(ec.Provider, ec.Model, ec.APIKey, ec.BaseURL)
+		if ec.Fallback != "" && ec.Fallback != ec.Provider {
+			// the fallback uses its provider's own credentials and endpoint
+			fallback := m.newEmbedder(ec.Fallback, ec.FallbackModel, "", "")
+			m.Embedder = embed.NewRouter(m.Embedder, fallback)
+		}
+		// Truncate outside the cache so cached vectors stay full-size.
+		m.Embedder = embed.Truncated(m.Embedder, ec.TruncateDimension)
+	}
+	// Created after the embedder, which it captures.
 	if m.Augmentation == nil {
 		m.Augmentation = NewAugmentationManager(m)
 	}
-	if m.Embedder == nil {
-		m.Embedder = embed.NewEmbedder(embed.Config{
-			Provider: m.Config.Embedding.Provider,
-			APIKey:   m.Config.Embedding.APIKey,
-			BaseURL:  m.Config.Embedding.BaseURL,
-			Model:    m.Config.Embedding.Model,
-		})
-	}
 
 	m.OpenAI = &OpenAIProvider{m: m}
+	m.Anthropic = &AnthropicProvider{m: m}
+	m.Gemini = &GeminiProvider{m: m}
+	m.Ollama = &OllamaProvider{m: m}
 	return m
 }
 
+// newEmbedder builds one configured provider behind its own cache, so that
+// cached vectors are keyed by the provider that really produced them.
+func (m *Memori) newEmbedder(provider, model, apiKey, baseURL string) embed.Embedder {
+	ec := m.Config.Embedding
+	e := embed.NewEmbedder(embed.Config{
+		Provider: provider,
+		APIKey:   apiKey,
+		BaseURL:  baseURL,
+		Model:    model,
+
+		Dimension: ec.Dimension,
+		Stopwords: ec.Stopwords,
+
+		MaxRetries:        ec.MaxRetries,
+		RequestsPerMinute: ec.RequestsPerMinute,
+		TokensPerMinute:   ec.TokensPerMinute,
+		BatchSize:         ec.BatchSize,
+	})
+	if ec.CacheSize < 0 {
+		return e
+	}
+	var store embed.CacheStore
+	if ec.PersistCache {
+		store = &embeddingCacheStore{m: m}
+	}
+	return embed.Cached(e, store, embed.WithCacheSize(ec.CacheSize))
+}
+
 func WithStorageConn(conn any) Option {
 	return func(m *Memori) {
 		m.Storage = storage.NewManager()
@@ -89,6 +130,34 @@ func (m *Memori) SetSession(id uuid.UUID) *Memori {
 	return m
 }
 
+// Scoped returns a Memori sharing the storage, embedder, augmentation and LLM
+// clients of m but with its own attribution and session, so one process can
+// serve many entities at once. A nil sessionID starts a new session. The ids
+// are limited to 100 characters as in Attribution.
+func (m *Memori) Scoped(entityID, processID string, sessionID uuid.UUID) *Memori {
+	s := &Memori{
+		Config:       m.Config.clone(),
+		Storage:      m.Storage,
+		Augmentation: m.Augmentation,
+		Embedder:     m.Embedder,
+
+		openAIClient:    m.openAIClient,
+		anthropicClient: m.anthropicClient,
+		geminiClient:    m.geminiClient,
+		ollamaClient:    m.ollamaClient,
+	}
+	s.OpenAI = &OpenAIProvider{m: s}
+	s.Anthropic = &AnthropicProvider{m: s}
+	s.Gemini = &GeminiProvider{m: s}
+	s.Ollama = &OllamaProvider{m: s}
+
+	if sessionID == uuid.Nil {
+		sessionID = uuid.New()
+	}
+	s.Attribution(entityID, processID)
+	return s.SetSession(sessionID)
+}
+
 func (m *Memori) Recall(query string, limit int) ([]Fact, error) {
 	if m.Storage == nil || m.Storage.Driver() == nil {
 		return nil, nil
@@ -0,0 +1,78 @@
+package memori
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
+
+	"memorigo/storage"
+)
+
+// contentPartRefs converts request content parts into their stored form.
+// Inline data (data: URIs, base64 audio and files) is replaced by its hash and
+// size so that conversations never hold the raw bytes.
+func contentPartRefs(parts []ContentPart) []storage.MessagePart {
+	if len(parts) == 0 {
+		return nil
+	}
+	out := make([]storage.MessagePart, 0, len(parts))
+	for _, p := range parts {
+		ref := storage.MessagePart{Type: p.Type, Text: p.Text}
+		switch {
+		case p.ImageURL != nil:
+			ref.Detail = p.ImageURL.Detail
+			if mime, data, ok := parseDataURI(p.ImageURL.URL); ok {
+				ref.MimeType = mime
+				ref.Hash, ref.Size = inlineDataRef(data)
+			} else {
+				ref.URL = p.ImageURL.URL
+			}
+		case p.InputAudio != nil:
+			ref.MimeType = "audio/" + p.InputAudio.Format
+			ref.Hash, ref.Size = inlineDataRef(p.InputAudio.Data)
+		case p.File != nil:
+			ref.FileID = p.File.FileID
+			ref.Filename = p.File.Filename
+			if p.File.FileData != "" {
+				data := p.File.FileData
+				if mime, d, ok := parseDataURI(data); ok {
+					ref.MimeType, data = mime, d
+				}
+				ref.Hash, ref.Size = inlineDataRef(data)
+			}
+		}
+		out = append(out, ref)
+	}
+	return out
+}
+
+// parseDataURI splits a base64 "data:<mime>;base64,<data>" URI.
+func parseDataURI(uri string) (mime, data string, ok bool) {
+	rest, found := strings.CutPrefix(uri, "data:")
+	if !found {
+		return "", "", false
+	}
+	meta, data, found := strings.Cut(rest, ",")
+	if !found {
+		return "", "", false
+	}
+	mime, _, _ = strings.Cut(meta, ";")
+	return mime, data, true
+}
+
+// inlineDataRef hashes base64 data by its decoded bytes, falling back to the
+// encoded text when it is not valid base64.
+func inlineDataRef(data string) (hash string, size int) {
+	b, err := base64.StdEncoding.DecodeString(data)
+	if err != nil {
+		b = []byte(data)
+	}
+	return bytesRef(b)
+}
+
+// bytesRef returns the hash and size stored in place of b.
+func bytesRef(b []byte) (hash string, size int) {
+	sum := sha256.Sum256(b)
+	return "sha256:" + hex.EncodeToString(sum[:]), len(b)
+}
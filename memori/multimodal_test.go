@@ -0,0 +1,257 @@
+package memori_test
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	_ "modernc.org/sqlite"
+
+	"memorigo/memori"
+	"memorigo/storage"
+)
+
+func TestChatMessage_ContentJSON(t *testing.T) {
+	cases := []struct {
+		msg  memori.ChatMessage
+		want string
+	}{
+		{memori.ChatMessage{Role: "user", Content: "hi"}, `{"role":"user","content":"hi"}`},
+		{
+			memori.ChatMessage{Role: "user", Parts: []memori.ContentPart{memori.TextPart("what is this?"), memori.ImageURLPart("https://example.com/a.png", "low")}},
+			`{"role":"user","content":[{"type":"text","text":"what is this?"},{"type":"image_url","image_url":{"url":"https://example.com/a.png","detail":"low"}}]}`,
+		},
+		{
+			memori.ChatMessage{Role: "assistant", ToolCalls: []memori.ToolCall{{ID: "c1", Type: "function", Function: memori.FunctionCall{Name: "f", Arguments: "{}"}}}},
+			`{"role":"assistant","content":null,"tool_calls":[{"id":"c1","type":"function","function":{"name":"f","arguments":"{}"}}]}`,
+		},
+	}
+	for _, tc := range cases {
+		b, err := json.Marshal(tc.msg)
+		if err != nil || string(b) != tc.want {
+			t.Fatalf("marshal = %s, %v; want %s", b, err, tc.want)
+		}
+		var back memori.ChatMessage
+		if err := json.Unmarshal(b, &back); err != nil {
+			t.Fatalf("unmarshal %s: %v", b, err)
+		}
+		if back.Text() != tc.msg.Text() || len(back.Parts) != len(tc.msg.Parts) {
+			t.Fatalf("round trip of %s = %+v", b, back)
+		}
+	}
+}
+
+func TestOpenAI_PersistsMultimodalPartsAsReferences(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"choices":[{"index":0,"message":{"role":"assistant","content":"A red bicycle."},"finish_reason":"stop"}]}`))
+	}))
+	defer srv.Close()
+
+	db, err := sql.Open("sqlite", "file:memori_multimodal?mode=memory&cache=shared")
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	defer db.Close()
+
+	m := memori.New(memori.WithStorageConn(db))
+	if err := m.Storage.Build(); err != nil {
+		t.Fatalf("migrate/build: %v", err)
+	}
+	m.Attribution("user-mm", "proc-mm")
+	m.OpenAI.Register(memori.NewOpenAICompatClient(memori.OpenAICompatOptions{BaseURL: srv.URL}))
+
+	const pngData = "iVBORw0KGgo=" // 8 bytes
+	req := memori.ChatCompletionsRequest{
+		Model: "gpt-test",
+		Messages: []memori.ChatMessage{{
+			Role: "user",
+			Parts: []memori.ContentPart{
+				memori.TextPart("This is my bicycle"),
+				memori.ImageURLPart("data:image/png;base64,"+pngData, ""),
+				memori.ImageURLPart("https://example.com/bike.jpg", "high"),
+			},
+		}},
+	}
+	if _, err := m.OpenAIClient().ChatCompletionsCreate(context.Background(), req); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	var content, parts string
+	if err := db.QueryRow(`SELECT content, content_parts FROM memori_conversation_message WHERE role = 'user'`).Scan(&content, &parts); err != nil {
+		t.Fatalf("query message: %v", err)
+	}
+	if content != "This is my bicycle" {
+		t.Fatalf("content = %q", content)
+	}
+	if strings.Contains(parts, pngData) {
+		t.Fatalf("stored parts contain inline data: %s", parts)
+	}
+	var refs []storage.MessagePart
+	if err := json.Unmarshal([]byte(parts), &refs); err != nil || len(refs) != 3 {
+		t.Fatalf("content_parts = %s, %v", parts, err)
+	}
+	if refs[1].Type != "image_url" || refs[1].MimeType != "image/png" || refs[1].Size != 8 || !strings.HasPrefix(refs[1].Hash, "sha256:") {
+		t.Fatalf("inline image ref = %+v", refs[1])
+	}
+	if refs[2].URL != "https://example.com/bike.jpg" || refs[2].Detail != "high" || refs[2].Hash != "" {
+		t.Fatalf("remote image ref = %+v", refs[2])
+	}
+}
+
+func TestAnthropic_PersistsSourcesAndToolResults(t *testing.T) {
+	var sent map[string]json.RawMessage
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&sent)
+		_, _ = w.Write([]byte(`{"id":"msg_1","type":"message","role":"assistant","content":[{"type":"text","text":"A red bicycle."}],"stop_reason":"end_turn"}`))
+	}))
+	defer srv.Close()
+
+	db, err := sql.Open("sqlite", "file:memori_multimodal_anthropic?mode=memory&cache=shared")
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	defer db.Close()
+
+	m := memori.New(memori.WithStorageConn(db))
+	if err := m.Storage.Build(); err != nil {
+		t.Fatalf("migrate/build: %v", err)
+	}
+	m.Attribution("user-mm-anthropic", "proc-mm")
+	m.Anthropic.Register(memori.NewAnthropicClient(memori.AnthropicOptions{BaseURL: srv.URL}))
+
+	// A request as a proxy decodes it: the tool_result content is a block
+	// array and the image is inline.
+	const pngData = "iVBORw0KGgo=" // 8 bytes
+	var req memori.AnthropicMessagesRequest
+	if err := json.Unmarshal([]byte(`{
+		"model": "claude-test", "max_tokens": 64, "top_k": 5, "metadata": {"user_id": "u1"},
+		"tools": [{"name": "get_weather", "input_schema": {"type": "object"}}],
+		"tool_choice": {"type": "auto"},
+		"messages": [
+			{"role": "user", "content": [
+				{"type": "text", "text": "This is my bicycle"},
+				{"type": "image", "source": {"type": "base64", "media_type": "image/png", "data": "`+pngData+`"}},
+				{"type": "document", "title": "manual.pdf", "source": {"type": "url", "url": "https://example.com/manual.pdf"}}
+			]},
+			{"role": "assistant", "content": [{"type": "tool_use", "id": "toolu_1", "name": "get_weather", "input": {}}]},
+			{"role": "user", "content": [{"type": "tool_result", "tool_use_id": "toolu_1", "content": [{"type": "text", "text": "sunny"}]}]}
+		]}`), &req); err != nil {
+		t.Fatalf("decode request: %v", err)
+	}
+	if _, err := m.AnthropicClient().MessagesCreate(context.Background(), req); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	for _, field := range []string{"tools", "tool_choice", "top_k", "metadata"} {
+		if sent[field] == nil {
+			t.Fatalf("%s not forwarded: %v", field, sent)
+		}
+	}
+
+	var content, parts string
+	if err := db.QueryRow(`SELECT content, content_parts FROM memori_conversation_message WHERE role = 'user'`).Scan(&content, &parts); err != nil {
+		t.Fatalf("query message: %v", err)
+	}
+	if content != "This is my bicycle" || strings.Contains(parts, pngData) {
+		t.Fatalf("stored %q with parts %s", content, parts)
+	}
+	var refs []storage.MessagePart
+	if err := json.Unmarshal([]byte(parts), &refs); err != nil || len(refs) != 3 {
+		t.Fatalf("content_parts = %s, %v", parts, err)
+	}
+	if refs[1].Type != "image_url" || refs[1].MimeType != "image/png" || refs[1].Size != 8 || !strings.HasPrefix(refs[1].Hash, "sha256:") {
+		t.Fatalf("image ref = %+v", refs[1])
+	}
+	if refs[2].Type != "file" || refs[2].URL != "https://example.com/manual.pdf" || refs[2].Filename != "manual.pdf" {
+		t.Fatalf("document ref = %+v", refs[2])
+	}
+
+	var result string
+	if err := db.QueryRow(`SELECT content FROM memori_conversation_message WHERE type = ?`, memori.MessageTypeToolResult).Scan(&result); err != nil {
+		t.Fatalf("query tool result: %v", err)
+	}
+	if result != `{"tool_call_id":"toolu_1","content":"sunny"}` {
+		t.Fatalf("tool result = %s", result)
+	}
+}
+
+func TestGemini_PersistsDataAndFunctionParts(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"candidates":[{"content":{"role":"model","parts":[{"functionCall":{"name":"get_weather","args":{"city":"Bergen"}}}]},"finishReason":"STOP","index":0}]}`))
+	}))
+	defer srv.Close()
+
+	db, err := sql.Open("sqlite", "file:memori_multimodal_gemini?mode=memory&cache=shared")
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	defer db.Close()
+
+	m := memori.New(memori.WithStorageConn(db))
+	if err := m.Storage.Build(); err != nil {
+		t.Fatalf("migrate/build: %v", err)
+	}
+	m.Attribution("user-mm-gemini", "proc-mm")
+	m.Gemini.Register(memori.NewGeminiClient(memori.GeminiOptions{BaseURL: srv.URL}))
+
+	const pngData = "iVBORw0KGgo=" // 8 bytes
+	req := memori.GeminiGenerateRequest{
+		Model: "gemini-test",
+		Contents: []memori.GeminiContent{
+			{Role: "user", Parts: []memori.GeminiPart{
+				{Text: "This is my bicycle"},
+				{InlineData: &memori.GeminiBlob{MimeType: "image/png", Data: pngData}},
+				{FileData: &memori.GeminiFileData{MimeType: "application/pdf", FileURI: "https://example.com/manual.pdf"}},
+			}},
+			{Role: "model", Parts: []memori.GeminiPart{{FunctionCall: &memori.GeminiFunctionCall{Name: "get_weather", Args: json.RawMessage(`{"city":"Oslo"}`)}}}},
+			{Role: "user", Parts: []memori.GeminiPart{{FunctionResponse: &memori.GeminiFunctionResponse{Name: "get_weather", Response: json.RawMessage(`{"forecast":"rain"}`)}}}},
+			{Role: "user"},
+		},
+	}
+	if _, err := m.GeminiClient().GenerateContent(context.Background(), req); err != nil {
+		t.Fatalf("generate: %v", err)
+	}
+
+	type row struct{ role, typ, content, parts string }
+	want := []row{
+		{"user", "", "This is my bicycle", ""},
+		{"assistant", memori.MessageTypeToolCall, `{"type":"function","function":{"name":"get_weather","arguments":"{\"city\":\"Oslo\"}"}}`, ""},
+		{"tool", memori.MessageTypeToolResult, `{"tool_call_id":"","name":"get_weather","content":"{\"forecast\":\"rain\"}"}`, ""},
+		{"assistant", memori.MessageTypeToolCall, `{"type":"function","function":{"name":"get_weather","arguments":"{\"city\":\"Bergen\"}"}}`, ""},
+	}
+	rows, err := db.Query(`SELECT role, COALESCE(type, ''), content, COALESCE(content_parts, '') FROM memori_conversation_message ORDER BY id`)
+	if err != nil {
+		t.Fatalf("query messages: %v", err)
+	}
+	defer rows.Close()
+	var got []row
+	for rows.Next() {
+		var r row
+		if err := rows.Scan(&r.role, &r.typ, &r.content, &r.parts); err != nil {
+			t.Fatalf("scan: %v", err)
+		}
+		got = append(got, r)
+	}
+	if len(got) != len(want) {
+		t.Fatalf("stored %+v, want %+v", got, want)
+	}
+	var refs []storage.MessagePart
+	if err := json.Unmarshal([]byte(got[0].parts), &refs); err != nil || len(refs) != 3 || strings.Contains(got[0].parts, pngData) {
+		t.Fatalf("content_parts = %s, %v", got[0].parts, err)
+	}
+	if refs[1].Type != "image_url" || refs[1].MimeType != "image/png" || refs[1].Size != 8 || !strings.HasPrefix(refs[1].Hash, "sha256:") {
+		t.Fatalf("inline data ref = %+v", refs[1])
+	}
+	if refs[2].Type != "file" || refs[2].URL != "https://example.com/manual.pdf" {
+		t.Fatalf("file data ref = %+v", refs[2])
+	}
+	for i := range want {
+		got[i].parts = ""
+		if got[i] != want[i] {
+			t.Fatalf("message %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
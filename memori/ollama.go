@@ -0,0 +1,188 @@
+package memori
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+type OllamaOptions struct {
+	BaseURL    string // defaults to http://localhost:11434; host:port is accepted
+	HTTPClient *http.Client
+}
+
+// OllamaClient is a minimal client for Ollama's native /api/chat endpoint.
+type OllamaClient struct {
+	BaseURL    string
+	HTTPClient *http.Client
+}
+
+func NewOllamaClient(opts OllamaOptions) *OllamaClient {
+	base := strings.TrimRight(opts.BaseURL, "/")
+	if base == "" {
+		base = "http://localhost:11434"
+	}
+	if !strings.Contains(base, "://") {
+		base = "http://" + base
+	}
+	c := opts.HTTPClient
+	if c == nil {
+		// local models can take a while to load on the first request
+		c = &http.Client{Timeout: 5 * time.Minute}
+	}
+	return &OllamaClient{BaseURL: base, HTTPClient: c}
+}
+
+type OllamaChatMessage struct {
+	Role    string   `json:"role"`
+	Content string   `json:"content"`
+	Images  []string `json:"images,omitempty"` // base64-encoded
+}
+
+type OllamaChatRequest struct {
+	Model    string              `json:"model"`
+	Messages []OllamaChatMessage `json:"messages"`
+	// Stream is always sent: Ollama streams when the field is missing.
+	Stream    bool           `json:"stream"`
+	Format    string         `json:"format,omitempty"`
+	Options   map[string]any `json:"options,omitempty"`
+	KeepAlive string         `json:"keep_alive,omitempty"`
+}
+
+// OllamaChatResponse is the non-streamed reply, and also each line of a
+// streamed one; the last line has Done set and carries the statistics.
+type OllamaChatResponse struct {
+	Model      string            `json:"model"`
+	CreatedAt  string            `json:"created_at"`
+	Message    OllamaChatMessage `json:"message"`
+	Done       bool              `json:"done"`
+	DoneReason string            `json:"done_reason,omitempty"`
+
+	TotalDuration   int64 `json:"total_duration,omitempty"`
+	PromptEvalCount int   `json:"prompt_eval_count,omitempty"`
+	EvalCount       int   `json:"eval_count,omitempty"`
+
+	// Error is set on a line reporting a failure mid-stream.
+	Error string `json:"error,omitempty"`
+}
+
+// tokenUsage returns the counts of a final (Done) response, or nil.
+func (r OllamaChatResponse) tokenUsage() *TokenUsage {
+	if !r.Done || r.PromptEvalCount+r.EvalCount == 0 {
+		return nil
+	}
+	return &TokenUsage{PromptTokens: r.PromptEvalCount, CompletionTokens: r.EvalCount, TotalTokens: r.PromptEvalCount + r.EvalCount}
+}
+
+func (c *OllamaClient) newRequest(ctx context.Context, req OllamaChatRequest) (*http.Request, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, c.BaseURL+"/api/chat", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	return httpReq, nil
+}
+
+func (c *OllamaClient) Chat(ctx context.Context, req OllamaChatRequest) (OllamaChatResponse, error) {
+	var out OllamaChatResponse
+	req.Stream = false
+
+	httpReq, err := c.newRequest(ctx, req)
+	if err != nil {
+		return out, err
+	}
+
+	resp, err := c.HTTPClient.Do(httpReq)
+	if err != nil {
+		return out, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		b, _ := io.ReadAll(resp.Body)
+		return out, newAPIError("ollama", resp, b)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return out, err
+	}
+	if out.Error != "" {
+		return out, fmt.Errorf("ollama error: %s", out.Error)
+	}
+	return out, nil
+}
+
+// ChatStream streams a reply as newline-delimited JSON. The chunks channel
+// yields every line until the one with Done set, an error line, or context
+// cancellation.
+func (c *OllamaClient) ChatStream(ctx context.Context, req OllamaChatRequest) (<-chan OllamaChatResponse, <-chan error) {
+	chunks := make(chan OllamaChatResponse, 128)
+	errs := make(chan error, 1)
+
+	go func() {
+		defer close(chunks)
+		defer close(errs)
+
+		req.Stream = true
+		httpReq, err := c.newRequest(ctx, req)
+		if err != nil {
+			errs <- err
+			return
+		}
+
+		resp, err := c.HTTPClient.Do(httpReq)
+		if err != nil {
+			errs <- err
+			return
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			b, _ := io.ReadAll(resp.Body)
+			errs <- newAPIError("ollama", resp, b)
+			return
+		}
+
+		scanner := bufio.NewScanner(resp.Body)
+		scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+		for scanner.Scan() {
+			line := bytes.TrimSpace(scanner.Bytes())
+			if len(line) == 0 {
+				continue
+			}
+			var chunk OllamaChatResponse
+			if err := json.Unmarshal(line, &chunk); err != nil {
+				errs <- fmt.Errorf("ollama: invalid stream line: %w", err)
+				return
+			}
+			if chunk.Error != "" {
+				errs <- &APIError{Provider: "ollama", Message: chunk.Error, Body: string(line)}
+				return
+			}
+			select {
+			case chunks <- chunk:
+			case <-ctx.Done():
+				errs <- ctx.Err()
+				return
+			}
+			if chunk.Done {
+				return
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			errs <- err
+		}
+	}()
+
+	return chunks, errs
+}
@@ -0,0 +1,98 @@
+package memori
+
+import (
+	"context"
+	"strings"
+)
+
+// MemoriOllamaClient wraps OllamaClient and automatically persists the request
+// messages and the final assistant text (non-stream or accumulated from
+// NDJSON chunks), then triggers augmentation.
+type MemoriOllamaClient struct {
+	m   *Memori
+	raw *OllamaClient
+}
+
+func (m *Memori) OllamaClient() *MemoriOllamaClient {
+	if m.ollamaClient == nil {
+		m.ollamaClient = NewOllamaClientFromEnv()
+		m.Config.mu.Lock()
+		if m.Config.LLM.Provider == "" {
+			m.Config.LLM.Provider = "ollama"
+		}
+		m.Config.mu.Unlock()
+	}
+	return &MemoriOllamaClient{m: m, raw: m.ollamaClient}
+}
+
+func (c *MemoriOllamaClient) Chat(ctx context.Context, req OllamaChatRequest) (OllamaChatResponse, error) {
+	resp, err := c.raw.Chat(ctx, req)
+	if err != nil {
+		return resp, err
+	}
+
+	// Best-effort persistence: do not fail the LLM call if local storage fails.
+	_ = c.persist(req, resp.Model, resp.Message.Content, resp.tokenUsage(), false)
+	return resp, nil
+}
+
+func (c *MemoriOllamaClient) ChatStream(ctx context.Context, req OllamaChatRequest) (<-chan OllamaChatResponse, <-chan error) {
+	ctx, cancel := context.WithCancel(ctx)
+	inChunks, inErrs := c.raw.ChatStream(ctx, req)
+
+	outChunks := make(chan OllamaChatResponse, 128)
+	outErrs := make(chan error, 1)
+
+	go func() {
+		defer cancel()
+		defer close(outChunks)
+		defer close(outErrs)
+		send := newStreamSender(c.m, ctx, outChunks, cancel)
+
+		var b strings.Builder
+		var usage *TokenUsage
+		var model string
+		complete := false
+
+		// Forward every line before reporting the error that ended the
+		// stream, if any: it is already buffered when the chunks close.
+		for chunk := range inChunks {
+			// A caller that went away no longer gets events, but the
+			// reply is still accumulated for persisting.
+			send.send(chunk)
+			complete = complete || chunk.Done
+			b.WriteString(chunk.Message.Content)
+			if u := chunk.tokenUsage(); u != nil {
+				usage = u
+			}
+			if chunk.Model != "" {
+				model = chunk.Model
+			}
+		}
+		if err, ok := <-inErrs; ok && err != nil {
+			outErrs <- send.err(err)
+		}
+
+		// Persist the full reply, or what was received of an incomplete one
+		// as Config.PartialResponses says
+		if b.Len() > 0 {
+			_ = c.persist(req, model, b.String(), usage, !complete)
+		}
+	}()
+
+	return outChunks, outErrs
+}
+
+// persist stores the exchange; model is the one the response reports.
+func (c *MemoriOllamaClient) persist(req OllamaChatRequest, model, assistant string, usage *TokenUsage, truncated bool) error {
+	msgs := make([]Message, 0, len(req.Messages))
+	for _, m := range req.Messages {
+		msgs = append(msgs, Message{
+			Role:    m.Role,
+			Type:    "",
+			Content: m.Content,
+		})
+	}
+
+	return c.m.persistExchange("ollama", responseModel(model, req.Model), msgs, assistant, nil, usage, truncated)
+}
@@ -0,0 +1,29 @@
+package memori
+
+import (
+	"os"
+)
+
+type OllamaProvider struct {
+	m *Memori
+}
+
+// Register wires an Ollama client into Memori, mirroring OpenAIProvider.Register.
+func (p *OllamaProvider) Register(client *OllamaClient) *Memori {
+	if client == nil {
+		client = NewOllamaClientFromEnv()
+	}
+	p.m.Config.mu.Lock()
+	p.m.Config.LLM.Provider = "ollama"
+	p.m.Config.mu.Unlock()
+
+	p.m.ollamaClient = client
+	return p.m
+}
+
+// NewOllamaClientFromEnv reads OLLAMA_HOST.
+func NewOllamaClientFromEnv() *OllamaClient {
+	return NewOllamaClient(OllamaOptions{
+		BaseURL: os.Getenv("OLLAMA_HOST"),
+	})
+}
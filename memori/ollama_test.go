@@ -0,0 +1,143 @@
+package memori_test
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	_ "modernc.org/sqlite"
+
+	"memorigo/memori"
+)
+
+// fakeOllamaServer answers /api/chat with a fixed reply, as one JSON object or
+// as newline-delimited JSON chunks.
+func fakeOllamaServer(t *testing.T, reply string) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/chat" {
+			http.NotFound(w, r)
+			return
+		}
+		var req memori.OllamaChatRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || len(req.Messages) == 0 {
+			http.Error(w, `{"error":"invalid request"}`, http.StatusBadRequest)
+			return
+		}
+
+		enc := json.NewEncoder(w)
+		if !req.Stream {
+			_ = enc.Encode(memori.OllamaChatResponse{
+				Model:   req.Model,
+				Message: memori.OllamaChatMessage{Role: "assistant", Content: reply},
+				Done:    true,
+			})
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		flusher := w.(http.Flusher)
+		for _, word := range strings.SplitAfter(reply, " ") {
+			_ = enc.Encode(memori.OllamaChatResponse{
+				Model:   req.Model,
+				Message: memori.OllamaChatMessage{Role: "assistant", Content: word},
+			})
+			flusher.Flush()
+		}
+		_ = enc.Encode(memori.OllamaChatResponse{Model: req.Model, Done: true, DoneReason: "stop", EvalCount: 5})
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestOllama_StreamPersistsExchange(t *testing.T) {
+	srv := fakeOllamaServer(t, "Noted, you keep bees.")
+
+	db, err := sql.Open("sqlite", "file:memori_ollama?mode=memory&cache=shared")
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	defer db.Close()
+
+	m := memori.New(memori.WithStorageConn(db))
+	if err := m.Storage.Build(); err != nil {
+		t.Fatalf("migrate/build: %v", err)
+	}
+	m.Attribution("user-ollama", "proc-ollama")
+	m.Ollama.Register(memori.NewOllamaClient(memori.OllamaOptions{BaseURL: strings.TrimPrefix(srv.URL, "http://")}))
+
+	req := memori.OllamaChatRequest{
+		Model: "llama-test",
+		Messages: []memori.OllamaChatMessage{
+			{Role: "system", Content: "You are a helpful assistant."},
+			{Role: "user", Content: "I keep bees in my backyard"},
+		},
+	}
+	chunks, errs := m.OllamaClient().ChatStream(context.Background(), req)
+
+	var text strings.Builder
+	var last memori.OllamaChatResponse
+	for chunk := range chunks {
+		text.WriteString(chunk.Message.Content)
+		last = chunk
+	}
+	if err := <-errs; err != nil {
+		t.Fatalf("stream: %v", err)
+	}
+	if text.String() != "Noted, you keep bees." || !last.Done {
+		t.Fatalf("streamed text = %q, last done = %v", text.String(), last.Done)
+	}
+
+	resp, err := m.OllamaClient().Chat(context.Background(), req)
+	if err != nil || resp.Message.Content != "Noted, you keep bees." {
+		t.Fatalf("chat: %q, %v", resp.Message.Content, err)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		facts, err := m.Recall("bees backyard", 5)
+		if err != nil {
+			t.Fatalf("recall: %v", err)
+		}
+		for _, f := range facts {
+			if f.Content == "I keep bees in my backyard" {
+				return
+			}
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for persisted facts, got %#v", facts)
+		}
+		time.Sleep(25 * time.Millisecond)
+	}
+}
+
+func TestOllama_MidStreamErrorReachesCaller(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		fmt.Fprintln(w, `{"model":"llama-test","message":{"role":"assistant","content":"Blue"},"done":false}`)
+		fmt.Fprintln(w, `{"error":"model runner has unexpectedly stopped"}`)
+	}))
+	defer srv.Close()
+
+	m := memori.New()
+	m.Ollama.Register(memori.NewOllamaClient(memori.OllamaOptions{BaseURL: srv.URL}))
+	for i := 0; i < 100; i++ {
+		chunks, errs := m.OllamaClient().ChatStream(context.Background(), memori.OllamaChatRequest{
+			Model:    "llama-test",
+			Messages: []memori.OllamaChatMessage{{Role: "user", Content: "What is my favorite color?"}},
+		})
+		for range chunks {
+		}
+		var apiErr *memori.APIError
+		if err := <-errs; !errors.As(err, &apiErr) || apiErr.Message != "model runner has unexpectedly stopped" {
+			t.Fatalf("run %d: err = %v", i, err)
+		}
+	}
+}
@@ -1,27 +1,40 @@
 package memori
 
 import (
-	"bufio"
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"strings"
 	"time"
+
+	"memorigo/embed"
+	"memorigo/sse"
 )
 
 type OpenAICompatOptions struct {
 	BaseURL    string
 	APIKey     string
 	HTTPClient *http.Client
+
+	// Retry controls how ChatCompletionsCreate retries rate limits, server
+	// errors and network failures; streams are never retried.
+	Retry embed.RetryPolicy
+	// Timeout bounds each attempt of a non-streamed call, and the wait for
+	// the response headers of a stream. Zero leaves it to HTTPClient.
+	Timeout time.Duration
 }
 
 type OpenAICompatClient struct {
 	BaseURL    string
 	APIKey     string
 	HTTPClient *http.Client
+	Retry      embed.RetryPolicy
+	Timeout    time.Duration
 }
 
 func NewOpenAICompatClient(opts OpenAICompatOptions) *OpenAICompatClient {
@@ -37,75 +50,506 @@ func NewOpenAICompatClient(opts OpenAICompatOptions) *OpenAICompatClient {
 		BaseURL:    base,
 		APIKey:     opts.APIKey,
 		HTTPClient: c,
+		Retry:      opts.Retry,
+		Timeout:    opts.Timeout,
 	}
 }
 
+// ChatMessage is one message of a chat completion. Content is sent as a
+// string unless Parts is set, in which case the parts array is sent instead;
+// decoding an array content fills Parts and sets Content to its text.
 type ChatMessage struct {
-	Role    string `json:"role"`
-	Content string `json:"content"`
+	Role    string        `json:"role"`
+	Content string        `json:"content"`
+	Parts   []ContentPart `json:"-"`
+	Name    string        `json:"name,omitempty"`
+
+	// ToolCalls are the calls requested by an assistant message.
+	ToolCalls []ToolCall `json:"tool_calls,omitempty"`
+	// ToolCallID links a "tool" message to the call it answers.
+	ToolCallID string `json:"tool_call_id,omitempty"`
+}
+
+// MarshalJSON sends Parts as the content array when set, and a null content
+// for assistant messages that only carry tool calls, as the API expects.
+func (m ChatMessage) MarshalJSON() ([]byte, error) {
+	var content any = m.Content
+	switch {
+	case len(m.Parts) > 0:
+		content = m.Parts
+	case m.Content == "" && len(m.ToolCalls) > 0:
+		content = nil
+	}
+	return json.Marshal(struct {
+		Role       string     `json:"role"`
+		Content    any        `json:"content"`
+		Name       string     `json:"name,omitempty"`
+		ToolCalls  []ToolCall `json:"tool_calls,omitempty"`
+		ToolCallID string     `json:"tool_call_id,omitempty"`
+	}{m.Role, content, m.Name, m.ToolCalls, m.ToolCallID})
+}
+
+func (m ChatMessage) isZero() bool {
+	return m.Role == "" && m.Content == "" && len(m.Parts) == 0 && m.Name == "" && len(m.ToolCalls) == 0 && m.ToolCallID == ""
+}
+
+func (m *ChatMessage) UnmarshalJSON(b []byte) error {
+	type plain ChatMessage
+	aux := struct {
+		*plain
+		Content json.RawMessage `json:"content"`
+	}{plain: (*plain)(m)}
+	if err := json.Unmarshal(b, &aux); err != nil {
+		return err
+	}
+
+	m.Content, m.Parts = "", nil
+	raw := bytes.TrimSpace(aux.Content)
+	switch {
+	case len(raw) == 0 || bytes.Equal(raw, []byte("null")):
+		return nil
+	case raw[0] == '[':
+		if err := json.Unmarshal(raw, &m.Parts); err != nil {
+			return err
+		}
+		m.Content = m.Text()
+		return nil
+	}
+	return json.Unmarshal(raw, &m.Content)
+}
+
+// Text returns the text of the message: the text parts joined by newlines
+// when Parts is set, Content otherwise.
+func (m ChatMessage) Text() string {
+	if len(m.Parts) == 0 {
+		return m.Content
+	}
+	var texts []string
+	for _, p := range m.Parts {
+		if p.Type == "text" && p.Text != "" {
+			texts = append(texts, p.Text)
+		}
+	}
+	return strings.Join(texts, "\n")
+}
+
+// ContentPart is one part of a multimodal message. Only the field of the
+// part's Type is set.
+type ContentPart struct {
+	Type       string      `json:"type"` // text, image_url, input_audio, file
+	Text       string      `json:"text,omitempty"`
+	ImageURL   *ImageURL   `json:"image_url,omitempty"`
+	InputAudio *InputAudio `json:"input_audio,omitempty"`
+	File       *FilePart   `json:"file,omitempty"`
+}
+
+// ImageURL is a remote image URL or a base64 data: URI.
+type ImageURL struct {
+	URL    string `json:"url"`
+	Detail string `json:"detail,omitempty"` // auto, low, high
+}
+
+type InputAudio struct {
+	Data   string `json:"data"`   // base64
+	Format string `json:"format"` // wav, mp3
+}
+
+type FilePart struct {
+	FileID   string `json:"file_id,omitempty"`
+	FileData string `json:"file_data,omitempty"` // base64 data: URI
+	Filename string `json:"filename,omitempty"`
+}
+
+func TextPart(text string) ContentPart {
+	return ContentPart{Type: "text", Text: text}
+}
+
+func ImageURLPart(url, detail string) ContentPart {
+	return ContentPart{Type: "image_url", ImageURL: &ImageURL{URL: url, Detail: detail}}
+}
+
+func InputAudioPart(data, format string) ContentPart {
+	return ContentPart{Type: "input_audio", InputAudio: &InputAudio{Data: data, Format: format}}
+}
+
+// Tool is a tool the model may call. Only "function" tools exist today.
+type Tool struct {
+	Type     string             `json:"type"`
+	Function FunctionDefinition `json:"function"`
+}
+
+type FunctionDefinition struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description,omitempty"`
+	Parameters  json.RawMessage `json:"parameters,omitempty"` // JSON Schema
+	Strict      *bool           `json:"strict,omitempty"`
+}
+
+// FunctionTool returns a function tool with the given JSON Schema parameters.
+func FunctionTool(name, description string, parameters json.RawMessage) Tool {
+	return Tool{Type: "function", Function: FunctionDefinition{Name: name, Description: description, Parameters: parameters}}
+}
+
+// ToolCall is a call requested by the model. In stream deltas Index
+// identifies the call being continued and Function.Arguments arrives in
+// fragments; see ToolCallAccumulator.
+type ToolCall struct {
+	Index    *int         `json:"index,omitempty"`
+	ID       string       `json:"id,omitempty"`
+	Type     string       `json:"type,omitempty"`
+	Function FunctionCall `json:"function"`
+}
+
+type FunctionCall struct {
+	Name      string `json:"name,omitempty"`
+	Arguments string `json:"arguments"` // JSON encoded
+}
+
+// ResponseFormat selects "text", "json_object" or "json_schema" output.
+type ResponseFormat struct {
+	Type       string          `json:"type"`
+	JSONSchema json.RawMessage `json:"json_schema,omitempty"`
+}
+
+// StopSequences is the "stop" parameter, which the API accepts as a string
+// or an array of strings; it is always sent as an array.
+type StopSequences []string
+
+func (s *StopSequences) UnmarshalJSON(b []byte) error {
+	var one string
+	if err := json.Unmarshal(b, &one); err == nil {
+		*s = StopSequences{one}
+		return nil
+	}
+	var many []string
+	if err := json.Unmarshal(b, &many); err != nil {
+		return err
+	}
+	*s = many
+	return nil
 }
 
 type ChatCompletionsRequest struct {
 	Model    string        `json:"model"`
 	Messages []ChatMessage `json:"messages"`
 	Stream   bool          `json:"stream,omitempty"`
+
+	Temperature      *float64      `json:"temperature,omitempty"`
+	TopP             *float64      `json:"top_p,omitempty"`
+	MaxTokens        int           `json:"max_tokens,omitempty"`
+	N                int           `json:"n,omitempty"`
+	Stop             StopSequences `json:"stop,omitempty"`
+	PresencePenalty  *float64      `json:"presence_penalty,omitempty"`
+	FrequencyPenalty *float64      `json:"frequency_penalty,omitempty"`
+	Seed             *int64        `json:"seed,omitempty"`
+	User             string        `json:"user,omitempty"`
+
+	// MaxCompletionTokens replaces MaxTokens for reasoning models.
+	MaxCompletionTokens int `json:"max_completion_tokens,omitempty"`
+
+	Tools []Tool `json:"tools,omitempty"`
+	// ToolChoice is "none", "auto", "required" or a
+	// {"type":"function","function":{"name":...}} object.
+	ToolChoice        any             `json:"tool_choice,omitempty"`
+	ParallelToolCalls *bool           `json:"parallel_tool_calls,omitempty"`
+	ResponseFormat    *ResponseFormat `json:"response_format,omitempty"`
+
+	// StreamOptions is only sent with streamed requests; when nil,
+	// ChatCompletionsStream asks for usage in the final chunk.
+	StreamOptions *StreamOptions `json:"stream_options,omitempty"`
+}
+
+type StreamOptions struct {
+	IncludeUsage bool `json:"include_usage"`
+}
+
+// CompletionUsage is the token usage of a completion. Streams report it in
+// a final chunk without choices.
+type CompletionUsage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	TotalTokens      int `json:"total_tokens"`
+
+	PromptTokensDetails *struct {
+		CachedTokens int `json:"cached_tokens"`
+	} `json:"prompt_tokens_details,omitempty"`
+	CompletionTokensDetails *struct {
+		ReasoningTokens int `json:"reasoning_tokens"`
+	} `json:"completion_tokens_details,omitempty"`
+}
+
+func (u *CompletionUsage) tokenUsage() *TokenUsage {
+	if u == nil {
+		return nil
+	}
+	return &TokenUsage{PromptTokens: u.PromptTokens, CompletionTokens: u.CompletionTokens, TotalTokens: u.TotalTokens}
+}
+
+type ChatCompletionChoice struct {
+	Index        int         `json:"index"`
+	Message      ChatMessage `json:"message"`
+	Delta        ChatMessage `json:"delta"`
+	FinishReason string      `json:"finish_reason"`
+}
+
+// MarshalJSON sends the delta only when set, and the message unless only the
+// delta is set: a response choice has a message, a stream chunk's a delta.
+func (c ChatCompletionChoice) MarshalJSON() ([]byte, error) {
+	type plain ChatCompletionChoice
+	var message, delta *ChatMessage
+	if !c.Delta.isZero() {
+		delta = &c.Delta
+	}
+	if delta == nil || !c.Message.isZero() {
+		message = &c.Message
+	}
+	return json.Marshal(struct {
+		plain
+		Message *ChatMessage `json:"message,omitempty"`
+		Delta   *ChatMessage `json:"delta,omitempty"`
+	}{plain(c), message, delta})
 }
 
 // OpenAI-compatible (subset) response
 type ChatCompletionsResponse struct {
-	ID      string `json:"id"`
-	Object  string `json:"object"`
-	Created int64  `json:"created"`
-	Model   string `json:"model"`
-	Choices []struct {
-		Index   int `json:"index"`
-		Message struct {
-			Role    string `json:"role"`
-			Content string `json:"content"`
-		} `json:"message"`
-		Delta struct {
-			Role    string `json:"role"`
-			Content string `json:"content"`
-		} `json:"delta"`
-		FinishReason string `json:"finish_reason"`
-	} `json:"choices"`
+	ID      string                 `json:"id"`
+	Object  string                 `json:"object"`
+	Created int64                  `json:"created"`
+	Model   string                 `json:"model"`
+	Choices []ChatCompletionChoice `json:"choices"`
+	Usage   *CompletionUsage       `json:"usage,omitempty"`
+}
+
+// ToolCallAccumulator assembles the tool calls of a streamed response from
+// the delta.tool_calls fragments of its chunks.
+type ToolCallAccumulator struct {
+	calls []ToolCall
+	byIdx map[int]int // stream index -> position in calls
+}
+
+// Add merges one chunk's delta.tool_calls.
+func (a *ToolCallAccumulator) Add(deltas []ToolCall) {
+	if a.byIdx == nil {
+		a.byIdx = make(map[int]int)
+	}
+	for _, d := range deltas {
+		pos := -1
+		if d.Index != nil {
+			if p, ok := a.byIdx[*d.Index]; ok {
+				pos = p
+			}
+		} else if d.ID == "" && len(a.calls) > 0 {
+			// providers that omit the index continue the last call
+			pos = len(a.calls) - 1
+		}
+		if pos < 0 {
+			pos = len(a.calls)
+			a.calls = append(a.calls, ToolCall{})
+			if d.Index != nil {
+				a.byIdx[*d.Index] = pos
+			}
+		}
+
+		call := &a.calls[pos]
+		if d.ID != "" {
+			call.ID = d.ID
+		}
+		if d.Type != "" {
+			call.Type = d.Type
+		}
+		if d.Function.Name != "" {
+			call.Function.Name = d.Function.Name
+		}
+		call.Function.Arguments += d.Function.Arguments
+	}
+}
+
+// ToolCalls returns the assembled calls in stream order.
+func (a *ToolCallAccumulator) ToolCalls() []ToolCall {
+	out := make([]ToolCall, len(a.calls))
+	for i, c := range a.calls {
+		if c.Type == "" {
+			c.Type = "function"
+		}
+		out[i] = c
+	}
+	return out
+}
+
+func (c *OpenAICompatClient) newRequest(ctx context.Context, path string, body []byte) (*http.Request, error) {
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, c.BaseURL+path, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	if c.APIKey != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+c.APIKey)
+	}
+	return httpReq, nil
 }
 
+// ChatCompletionsCreate sends a non-streamed request. Failures are returned as
+// *APIError when the server answered; rate limits, server errors and network
+// failures are retried according to c.Retry, honoring Retry-After.
 func (c *OpenAICompatClient) ChatCompletionsCreate(ctx context.Context, req ChatCompletionsRequest) (ChatCompletionsResponse, error) {
+	return c.create(ctx, req, c.Timeout)
+}
+
+func (c *OpenAICompatClient) create(ctx context.Context, req ChatCompletionsRequest, timeout time.Duration) (ChatCompletionsResponse, error) {
 	var out ChatCompletionsResponse
 	req.Stream = false
+	req.StreamOptions = nil
+	err := c.post(ctx, "/v1/chat/completions", req, timeout, &out)
+	return out, err
+}
 
-	body, err := json.Marshal(req)
+// ChatCompletionsCreateRaw is ChatCompletionsCreate for a request and a
+// response kept as JSON: body is sent as is, but for "stream" and
+// "stream_options" removed, and the response is returned as received, so that
+// fields this package does not model pass through.
+func (c *OpenAICompatClient) ChatCompletionsCreateRaw(ctx context.Context, body json.RawMessage) (json.RawMessage, error) {
+	return c.createRaw(ctx, body, c.Timeout)
+}
+
+func (c *OpenAICompatClient) createRaw(ctx context.Context, body json.RawMessage, timeout time.Duration) (json.RawMessage, error) {
+	body, err := patchJSONObject(body, func(fields map[string]json.RawMessage) {
+		delete(fields, "stream")
+		delete(fields, "stream_options")
+	})
 	if err != nil {
-		return out, err
+		return nil, err
+	}
+	var out json.RawMessage
+	err = c.post(ctx, "/v1/chat/completions", body, timeout, &out)
+	return out, err
+}
+
+// patchJSONObject decodes body as a JSON object, lets patch change its
+// fields, and encodes it again. Field values are kept as they were.
+func patchJSONObject(body json.RawMessage, patch func(map[string]json.RawMessage)) (json.RawMessage, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(body, &fields); err != nil {
+		return nil, err
+	}
+	if fields == nil {
+		return nil, fmt.Errorf("openai_compat: request body is not a JSON object")
 	}
+	patch(fields)
+	return json.Marshal(fields)
+}
+
+// EmbeddingsRequest is the body of /v1/embeddings. Input is a string or an
+// array of strings (or of token arrays).
+type EmbeddingsRequest struct {
+	Model          string `json:"model"`
+	Input          any    `json:"input"`
+	EncodingFormat string `json:"encoding_format,omitempty"` // "float" or "base64"
+	Dimensions     int    `json:"dimensions,omitempty"`
+	User           string `json:"user,omitempty"`
+}
 
-	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, c.BaseURL+"/v1/chat/completions", bytes.NewReader(body))
+// EmbeddingData is one embedding of the response. Embedding is kept raw: a
+// float array, or a base64 string when that encoding was requested.
+type EmbeddingData struct {
+	Object    string          `json:"object"`
+	Index     int             `json:"index"`
+	Embedding json.RawMessage `json:"embedding"`
+}
+
+type EmbeddingsResponse struct {
+	Object string          `json:"object"`
+	Data   []EmbeddingData `json:"data"`
+	Model  string          `json:"model"`
+	Usage  *struct {
+		PromptTokens int `json:"prompt_tokens"`
+		TotalTokens  int `json:"total_tokens"`
+	} `json:"usage,omitempty"`
+}
+
+// Embeddings calls /v1/embeddings, with the same retries and timeout as
+// ChatCompletionsCreate.
+func (c *OpenAICompatClient) Embeddings(ctx context.Context, req EmbeddingsRequest) (EmbeddingsResponse, error) {
+	var out EmbeddingsResponse
+	err := c.post(ctx, "/v1/embeddings", req, c.Timeout, &out)
+	return out, err
+}
+
+// post sends body to path and decodes the JSON answer into out, retrying
+// according to c.Retry; timeout bounds each attempt.
+func (c *OpenAICompatClient) post(ctx context.Context, path string, body any, timeout time.Duration, out any) error {
+	b, err := json.Marshal(body)
 	if err != nil {
-		return out, err
+		return err
 	}
-	httpReq.Header.Set("Content-Type", "application/json")
-	if c.APIKey != "" {
-		httpReq.Header.Set("Authorization", "Bearer "+c.APIKey)
+
+	retry := c.Retry.WithDefaults()
+	for attempt := 0; ; attempt++ {
+		err := c.postOnce(ctx, path, b, timeout, out)
+		if err == nil {
+			return nil
+		}
+		if attempt >= retry.MaxRetries || !retryableLLMError(ctx, err) {
+			return err
+		}
+
+		delay := retry.Backoff(attempt)
+		var apiErr *APIError
+		if errors.As(err, &apiErr) && apiErr.RetryAfter > 0 {
+			delay = min(apiErr.RetryAfter, retry.MaxDelay)
+		}
+		t := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return ctx.Err()
+		case <-t.C:
+		}
+	}
+}
+
+func (c *OpenAICompatClient) postOnce(ctx context.Context, path string, body []byte, timeout time.Duration, out any) error {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	httpReq, err := c.newRequest(ctx, path, body)
+	if err != nil {
+		return err
 	}
 
 	resp, err := c.HTTPClient.Do(httpReq)
 	if err != nil {
-		return out, err
+		return err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
 		b, _ := io.ReadAll(resp.Body)
-		return out, fmt.Errorf("openai_compat http %d: %s", resp.StatusCode, string(b))
+		return newAPIError("openai_compat", resp, b)
 	}
 
-	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
-		return out, err
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// retryableLLMError reports whether a failed attempt may be retried: the
+// caller's context is still live and the error is a retryable APIError or a
+// transport failure (including a per-attempt timeout).
+func retryableLLMError(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.Retryable()
 	}
-	return out, nil
+	var urlErr *url.Error
+	return errors.As(err, &urlErr)
 }
 
+// StreamEvent is one event of a chat completion stream. RawLine is the
+// event's data; Done is set for the final [DONE] event.
 type StreamEvent struct {
 	RawLine string
 	Chunk   *ChatCompletionsResponse
@@ -114,7 +558,43 @@ type StreamEvent struct {
 
 // ChatCompletionsStream implements SSE "data: {json}" streaming used by OpenAI-compatible providers.
 // It returns a channel that yields chunks until [DONE] or context cancellation.
+// A non-2xx answer or an error payload in the stream is reported as *APIError,
+// and a chunk that is not valid JSON ends the stream with an error; streams
+// are not retried.
 func (c *OpenAICompatClient) ChatCompletionsStream(ctx context.Context, req ChatCompletionsRequest) (<-chan StreamEvent, <-chan error) {
+	return c.stream(ctx, req, c.Timeout)
+}
+
+func (c *OpenAICompatClient) stream(ctx context.Context, req ChatCompletionsRequest, timeout time.Duration) (<-chan StreamEvent, <-chan error) {
+	req.Stream = true
+	if req.StreamOptions == nil {
+		req.StreamOptions = &StreamOptions{IncludeUsage: true}
+	}
+	body, err := json.Marshal(req)
+	return c.streamBody(ctx, body, err, timeout)
+}
+
+// ChatCompletionsStreamRaw is ChatCompletionsStream for a request kept as
+// JSON: body is sent as is, but for "stream" set and usage requested when
+// "stream_options" is absent, so that fields this package does not model
+// reach the server. Events carry each chunk as received in RawLine.
+func (c *OpenAICompatClient) ChatCompletionsStreamRaw(ctx context.Context, body json.RawMessage) (<-chan StreamEvent, <-chan error) {
+	return c.streamRaw(ctx, body, c.Timeout)
+}
+
+func (c *OpenAICompatClient) streamRaw(ctx context.Context, body json.RawMessage, timeout time.Duration) (<-chan StreamEvent, <-chan error) {
+	body, err := patchJSONObject(body, func(fields map[string]json.RawMessage) {
+		fields["stream"] = json.RawMessage("true")
+		if _, ok := fields["stream_options"]; !ok {
+			fields["stream_options"] = json.RawMessage(`{"include_usage":true}`)
+		}
+	})
+	return c.streamBody(ctx, body, err, timeout)
+}
+
+// streamBody streams the answer to body; a non-nil bodyErr, from encoding
+// the request, is reported on the error channel instead.
+func (c *OpenAICompatClient) streamBody(ctx context.Context, body []byte, bodyErr error, timeout time.Duration) (<-chan StreamEvent, <-chan error) {
 	events := make(chan StreamEvent, 128)
 	errs := make(chan error, 1)
 
@@ -122,25 +602,33 @@ func (c *OpenAICompatClient) ChatCompletionsStream(ctx context.Context, req Chat
 		defer close(events)
 		defer close(errs)
 
-		req.Stream = true
-		body, err := json.Marshal(req)
-		if err != nil {
-			errs <- err
+		if bodyErr != nil {
+			errs <- bodyErr
 			return
 		}
 
-		httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, c.BaseURL+"/v1/chat/completions", bytes.NewReader(body))
+		// The timeout only covers the wait for the response headers: a
+		// stream may legitimately run for much longer.
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		httpReq, err := c.newRequest(ctx, "/v1/chat/completions", body)
 		if err != nil {
 			errs <- err
 			return
 		}
-		httpReq.Header.Set("Content-Type", "application/json")
 		httpReq.Header.Set("Accept", "text/event-stream")
-		if c.APIKey != "" {
-			httpReq.Header.Set("Authorization", "Bearer "+c.APIKey)
-		}
 
+		var timer *time.Timer
+		if timeout > 0 {
+			timer = time.AfterFunc(timeout, cancel)
+		}
 		resp, err := c.HTTPClient.Do(httpReq)
+		if timer != nil && !timer.Stop() {
+			if err == nil {
+				resp.Body.Close()
+			}
+			err = fmt.Errorf("openai_compat: no response within %s: %w", timeout, context.DeadlineExceeded)
+		}
 		if err != nil {
 			errs <- err
 			return
@@ -149,39 +637,57 @@ func (c *OpenAICompatClient) ChatCompletionsStream(ctx context.Context, req Chat
 
 		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
 			b, _ := io.ReadAll(resp.Body)
-			errs <- fmt.Errorf("openai_compat http %d: %s", resp.StatusCode, string(b))
+			errs <- newAPIError("openai_compat", resp, b)
 			return
 		}
 
-		scanner := bufio.NewScanner(resp.Body)
-		for scanner.Scan() {
-			line := scanner.Text()
-			// SSE can include empty lines / comments
-			if !strings.HasPrefix(line, "data:") {
-				continue
+		send := func(ev StreamEvent) bool {
+			select {
+			case events <- ev:
+				return true
+			case <-ctx.Done():
+				errs <- ctx.Err()
+				return false
+			}
+		}
+
+		dec := sse.NewDecoder(resp.Body)
+		for {
+			ev, err := dec.Next()
+			if err == io.EOF {
+				return
 			}
-			raw := strings.TrimSpace(strings.TrimPrefix(line, "data:"))
+			if err != nil {
+				errs <- err
+				return
+			}
+			raw := strings.TrimSpace(ev.Data)
 			if raw == "" {
 				continue
 			}
 			if raw == "[DONE]" {
-				events <- StreamEvent{RawLine: raw, Done: true}
+				send(StreamEvent{RawLine: raw, Done: true})
+				return
+			}
+			// Errors arrive as {"error": {...}}, with or without an
+			// "event: error" field.
+			if apiErr := streamError("openai_compat", []byte(raw)); apiErr != nil || ev.Type == "error" {
+				if apiErr == nil {
+					apiErr = &APIError{Provider: "openai_compat", Message: raw, Body: raw}
+				}
+				errs <- apiErr
 				return
 			}
 			var chunk ChatCompletionsResponse
 			if err := json.Unmarshal([]byte(raw), &chunk); err != nil {
-				events <- StreamEvent{RawLine: raw, Chunk: nil}
-				continue
+				errs <- fmt.Errorf("openai_compat: invalid stream chunk: %w", err)
+				return
+			}
+			if !send(StreamEvent{RawLine: raw, Chunk: &chunk}) {
+				return
 			}
-			events <- StreamEvent{RawLine: raw, Chunk: &chunk}
-		}
-		if err := scanner.Err(); err != nil {
-			errs <- err
-			return
 		}
 	}()
 
 	return events, errs
 }
-
-
@@ -2,12 +2,14 @@ package memori
 
 import (
 	"context"
+	"encoding/json"
 	"strings"
+	"time"
 )
 
 // MemoriOpenAIClient wraps OpenAICompatClient and automatically persists:
-// - request messages
-// - final assistant response (non-stream or streamed accumulation)
+// - request messages, including tool calls and tool results
+// - final assistant response and tool calls (non-stream or streamed accumulation)
 // Then it triggers offline augmentation (writer already enqueues).
 type MemoriOpenAIClient struct {
 	m   *Memori
@@ -29,98 +31,174 @@ func (m *Memori) OpenAIClient() *MemoriOpenAIClient {
 	return &MemoriOpenAIClient{m: m, raw: m.openAIClient}
 }
 
+// timeout is the raw client's Timeout, else Config.Timeout.
+func (c *MemoriOpenAIClient) timeout() time.Duration {
+	if c.raw.Timeout > 0 {
+		return c.raw.Timeout
+	}
+	c.m.Config.mu.RLock()
+	defer c.m.Config.mu.RUnlock()
+	return c.m.Config.Timeout
+}
+
 func (c *MemoriOpenAIClient) ChatCompletionsCreate(ctx context.Context, req ChatCompletionsRequest) (ChatCompletionsResponse, error) {
-	resp, err := c.raw.ChatCompletionsCreate(ctx, req)
+	resp, err := c.raw.create(ctx, req, c.timeout())
 	if err != nil {
 		return resp, err
 	}
 
 	// Best-effort persistence: do not fail the LLM call if local storage fails.
-	_ = c.persist(req, resp, "")
+	_ = c.persist(req, resp, "", nil, false)
 	return resp, nil
 }
 
+// ChatCompletionsCreateRaw is ChatCompletionsCreate for a request and a
+// response kept as JSON, as a proxy forwards them (see
+// OpenAICompatClient.ChatCompletionsCreateRaw); the exchange is persisted from
+// their decoded form.
+func (c *MemoriOpenAIClient) ChatCompletionsCreateRaw(ctx context.Context, body json.RawMessage) (json.RawMessage, error) {
+	var req ChatCompletionsRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		return nil, err
+	}
+	raw, err := c.raw.createRaw(ctx, body, c.timeout())
+	if err != nil {
+		return raw, err
+	}
+
+	var resp ChatCompletionsResponse
+	if json.Unmarshal(raw, &resp) == nil {
+		_ = c.persist(req, resp, "", nil, false)
+	}
+	return raw, nil
+}
+
 func (c *MemoriOpenAIClient) ChatCompletionsStream(ctx context.Context, req ChatCompletionsRequest) (<-chan StreamEvent, <-chan error) {
-	inEvents, inErrs := c.raw.ChatCompletionsStream(ctx, req)
+	ctx, cancel := context.WithCancel(ctx)
+	inEvents, inErrs := c.raw.stream(ctx, req, c.timeout())
+	return c.wrapStream(ctx, cancel, req, inEvents, inErrs)
+}
 
+// ChatCompletionsStreamRaw is ChatCompletionsStream for a request kept as
+// JSON (see OpenAICompatClient.ChatCompletionsStreamRaw).
+func (c *MemoriOpenAIClient) ChatCompletionsStreamRaw(ctx context.Context, body json.RawMessage) (<-chan StreamEvent, <-chan error) {
+	var req ChatCompletionsRequest
+	err := json.Unmarshal(body, &req)
+	if err != nil {
+		events, errs := make(chan StreamEvent), make(chan error, 1)
+		errs <- err
+		close(events)
+		close(errs)
+		return events, errs
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	inEvents, inErrs := c.raw.streamRaw(ctx, body, c.timeout())
+	return c.wrapStream(ctx, cancel, req, inEvents, inErrs)
+}
+
+// wrapStream forwards the raw stream of req and persists the reply once it
+// ends. cancel ends the raw stream's request.
+func (c *MemoriOpenAIClient) wrapStream(ctx context.Context, cancel context.CancelFunc, req ChatCompletionsRequest, inEvents <-chan StreamEvent, inErrs <-chan error) (<-chan StreamEvent, <-chan error) {
 	outEvents := make(chan StreamEvent, 128)
 	outErrs := make(chan error, 1)
 
 	go func() {
+		defer cancel()
 		defer close(outEvents)
 		defer close(outErrs)
+		send := newStreamSender(c.m, ctx, outEvents, cancel)
 
 		var b strings.Builder
-		var lastResp ChatCompletionsResponse
-
-		for {
-			select {
-			case ev, ok := <-inEvents:
-				if !ok {
-					// Stream ended without explicit [DONE]
-					if b.Len() > 0 || len(lastResp.Choices) > 0 {
-						_ = c.persist(req, lastResp, b.String())
-					}
-					return
-				}
-
-				outEvents <- ev
-
-				if ev.Chunk != nil {
-					lastResp = *ev.Chunk
-					if len(ev.Chunk.Choices) > 0 {
-						b.WriteString(ev.Chunk.Choices[0].Delta.Content)
-					}
-				}
-
-				if ev.Done {
-					_ = c.persist(req, lastResp, b.String())
-					return
-				}
-
-			case err, ok := <-inErrs:
-				if ok && err != nil {
-					outErrs <- err
-				}
-				// Persist partial content best-effort
-				if b.Len() > 0 || len(lastResp.Choices) > 0 {
-					_ = c.persist(req, lastResp, b.String())
-				}
-				return
+		var calls ToolCallAccumulator
+		var usage *CompletionUsage
+		var model string
+		complete := false
+
+		// The raw stream buffers its error before closing both channels, so
+		// it is read once the events are drained: a select on both could see
+		// the events closed first and lose it.
+		for ev := range inEvents {
+			// A caller that went away no longer gets events, but the
+			// reply is still accumulated for persisting.
+			send.send(ev)
+
+			complete = complete || ev.Done
+			if ev.Chunk != nil && len(ev.Chunk.Choices) > 0 {
+				b.WriteString(ev.Chunk.Choices[0].Delta.Content)
+				calls.Add(ev.Chunk.Choices[0].Delta.ToolCalls)
+				complete = complete || ev.Chunk.Choices[0].FinishReason != ""
+			}
+			if ev.Chunk != nil && ev.Chunk.Usage != nil {
+				usage = ev.Chunk.Usage
+			}
+			if ev.Chunk != nil && ev.Chunk.Model != "" {
+				model = ev.Chunk.Model
 			}
 		}
-
+		if err, ok := <-inErrs; ok && err != nil {
+			outErrs <- send.err(err)
+		}
+		// Persist the full reply, or what was received of an incomplete one
+		// as Config.PartialResponses says
+		toolCalls := calls.ToolCalls()
+		if b.Len() > 0 || len(toolCalls) > 0 {
+			_ = c.persist(req, ChatCompletionsResponse{Model: model, Usage: usage}, b.String(), toolCalls, !complete)
+		}
 	}()
 
 	return outEvents, outErrs
 }
 
-func (c *MemoriOpenAIClient) persist(req ChatCompletionsRequest, resp ChatCompletionsResponse, streamedText string) error {
+// persist stores the exchange. streamedText and streamedCalls are the
+// accumulated stream output; for non-stream calls they are empty and the
+// first choice of resp is used. resp.Model and resp.Usage are recorded
+// either way. truncated
+// marks the output of an incomplete stream.
+func (c *MemoriOpenAIClient) persist(req ChatCompletionsRequest, resp ChatCompletionsResponse, streamedText string, streamedCalls []ToolCall, truncated bool) error {
 	// Convert request messages
 	msgs := make([]Message, 0, len(req.Messages))
 	for _, m := range req.Messages {
-		msgs = append(msgs, Message{
-			Role:    m.Role,
-			Type:    "",
-			Content: m.Content,
-		})
+		msgs = append(msgs, chatMessageRecords(m)...)
 	}
 
 	// Decide assistant output
 	assistant := streamedText
-	if assistant == "" && len(resp.Choices) > 0 {
+	toolCalls := streamedCalls
+	if assistant == "" && len(toolCalls) == 0 && len(resp.Choices) > 0 {
 		assistant = resp.Choices[0].Message.Content
+		toolCalls = resp.Choices[0].Message.ToolCalls
 	}
-
-	payload := ConversationPayload{
-		Messages: msgs,
-		Response: &Message{Role: "assistant", Type: "text", Content: assistant},
+	var calls []Message
+	for _, call := range toolCalls {
+		calls = append(calls, toolCallRecord(call))
 	}
-	payload.Client.Provider = "openai_compatible"
-	payload.Client.Title = req.Model
 
 	// Note: Writer.Execute triggers offline augmentation (enqueue) internally.
-	return NewWriter(c.m).Execute(context.Background(), payload)
+	return c.m.persistExchange("openai_compatible", responseModel(resp.Model, req.Model), msgs, assistant, calls, resp.Usage.tokenUsage(), truncated)
 }
 
+// chatMessageRecords converts a request message into the messages to store:
+// a tool message becomes a tool_result, and an assistant message with tool
+// calls becomes its text (if any) followed by one tool_call per call. Only
+// the text of multimodal content is kept in Content, for augmentation.
+func chatMessageRecords(m ChatMessage) []Message {
+	if m.Role == "tool" {
+		b, _ := json.Marshal(ToolResult{ToolCallID: m.ToolCallID, Name: m.Name, Content: m.Text()})
+		return []Message{{Role: m.Role, Type: MessageTypeToolResult, Content: string(b)}}
+	}
+
+	var out []Message
+	if m.Content != "" || len(m.Parts) > 0 || len(m.ToolCalls) == 0 {
+		out = append(out, Message{Role: m.Role, Type: "", Content: m.Text(), Parts: contentPartRefs(m.Parts)})
+	}
+	for _, call := range m.ToolCalls {
+		out = append(out, toolCallRecord(call))
+	}
+	return out
+}
 
+func toolCallRecord(call ToolCall) Message {
+	call.Index = nil
+	b, _ := json.Marshal(call)
+	return Message{Role: "assistant", Type: MessageTypeToolCall, Content: string(b)}
+}
@@ -0,0 +1,49 @@
+package memori
+
+import "context"
+
+// persistExchange writes one request/response exchange of an LLM client
+// wrapper through Writer.Execute, which also triggers augmentation. provider
+// is recorded as the payload's Client.Provider and model as its title; see
+// responseModel.
+// toolCalls are the tool calls of the response; a response made only of tool
+// calls has no assistant text message. usage may be nil when the provider did
+// not report it. truncated is set for the reply of a stream that did not
+// complete, which Config.PartialResponses may discard.
+func (m *Memori) persistExchange(provider, model string, msgs []Message, assistant string, toolCalls []Message, usage *TokenUsage, truncated bool) error {
+	if truncated {
+		m.Config.mu.RLock()
+		policy := m.Config.PartialResponses
+		m.Config.mu.RUnlock()
+		if policy == PartialDiscard {
+			return nil
+		}
+	}
+
+	payload := ConversationPayload{
+		Messages:  msgs,
+		ToolCalls: toolCalls,
+		Usage:     usage,
+	}
+	if assistant != "" || len(toolCalls) == 0 {
+		payload.Response = &Message{Role: "assistant", Type: MessageTypeText, Content: assistant, Truncated: truncated}
+	}
+	for i := range payload.ToolCalls {
+		payload.ToolCalls[i].Truncated = truncated
+	}
+	payload.Client.Provider = provider
+	payload.Client.Title = model
+
+	return NewWriter(m).Execute(context.Background(), payload)
+}
+
+// responseModel returns the model a response reports, which usage and
+// pricing should be attributed to: a request for an alias such as "gpt-4o"
+// is served by a dated snapshot. requested is the fallback for providers and
+// responses that report none.
+func responseModel(reported, requested string) string {
+	if reported != "" {
+		return reported
+	}
+	return requested
+}
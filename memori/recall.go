@@ -44,16 +44,20 @@ func (r *Recall) SearchFacts(query string, limit int) ([]Fact, error) {
 		return nil, nil
 	}
 
-	queryEmbedding, err := r.embedder.EmbedText(context.Background(), query)
+	vecs, src, err := embed.EmbedWithSource(context.Background(), r.embedder, []string{query})
 	if err != nil {
 		return nil, fmt.Errorf("failed to embed query: %w", err)
 	}
+	if len(vecs) != 1 {
+		return nil, fmt.Errorf("failed to embed query: %w", embed.ErrEmbeddingServiceUnavailable)
+	}
+	queryEmbedding := vecs[0]
 	embLimit := limit * 10
 	if embLimit < limit {
 		embLimit = limit
 	}
 
-	facts, err := repos.EntityFact().SearchByEmbedding(entityID, queryEmbedding, limit, embLimit)
+	facts, err := repos.EntityFact().SearchByEmbedding(entityID, queryEmbedding, embeddingMeta(src, len(queryEmbedding)), limit, embLimit)
 	if err != nil {
 		return nil, err
 	}
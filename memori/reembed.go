@@ -0,0 +1,109 @@
+package memori
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"memorigo/embed"
+	"memorigo/storage"
+	"memorigo/vecmath"
+)
+
+// reembedBatchSize is the number of facts loaded and embedded per round.
+const reembedBatchSize = 64
+
+// ReembedResult reports what a Reembed run did.
+type ReembedResult struct {
+	Target     storage.EmbeddingMeta
+	Reembedded int
+}
+
+// factEmbedding normalizes vec, encodes it with the configured quantization
+// and tags it with the provider that produced it. Unknown quantization names
+// fall back to float32.
+func (m *Memori) factEmbedding(src embed.Source, vec []float32) storage.FactEmbedding {
+	enc, _ := storage.ParseEmbeddingEncoding(m.Config.Embedding.Quantization)
+	enc |= storage.EncodingNormalized
+	return storage.FactEmbedding{
+		EmbeddingMeta: embeddingMeta(src, len(vec)),
+		Encoding:      enc,
+		Vector:        storage.EncodeEmbedding(vecmath.Normalized(vec), enc),
+	}
+}
+
+func embeddingMeta(src embed.Source, dimension int) storage.EmbeddingMeta {
+	return storage.EmbeddingMeta{
+		Provider:  src.Provider,
+		Model:     src.Model,
+		Dimension: dimension,
+	}
+}
+
+// Reembed rewrites every fact whose embedding was produced by a different
+// provider, model or dimension than m.Embedder. Progress is the stored
+// metadata itself, so the job can run in the background alongside normal
+// traffic and, if cancelled or interrupted, simply be started again.
+func (m *Memori) Reembed(ctx context.Context) (ReembedResult, error) {
+	var res ReembedResult
+	if m.Storage == nil || m.Storage.Driver() == nil {
+		return res, nil
+	}
+	repos, ok := m.Storage.Driver().(storage.Repos)
+	if !ok {
+		return res, fmt.Errorf("driver does not implement Repos")
+	}
+	facts := repos.EntityFact()
+
+	// Facts are moved into the primary provider's space only; a fallback
+	// answering here would re-embed everything into the wrong space.
+	ctx = embed.WithoutFallback(ctx)
+
+	// The dimension is only known once the embedder has produced a vector.
+	probe, src, err := embed.EmbedWithSource(ctx, m.Embedder, []string{"dimension probe"})
+	if err != nil {
+		return res, fmt.Errorf("failed to probe embedder: %w", err)
+	}
+	if len(probe) != 1 {
+		return res, embed.ErrEmbeddingServiceUnavailable
+	}
+	res.Target = embeddingMeta(src, len(probe[0]))
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return res, err
+		}
+		stale, err := facts.ListStale(res.Target, reembedBatchSize)
+		if err != nil {
+			return res, err
+		}
+		if len(stale) == 0 {
+			return res, nil
+		}
+
+		texts := make([]string, len(stale))
+		for i, f := range stale {
+			texts[i] = f.Content
+		}
+		vecs, src, err := embed.EmbedWithSource(ctx, m.Embedder, texts)
+		if err != nil {
+			return res, fmt.Errorf("failed to embed facts: %w", err)
+		}
+		if len(vecs) != len(stale) {
+			return res, embed.ErrEmbeddingServiceUnavailable
+		}
+
+		for i, f := range stale {
+			emb := m.factEmbedding(src, vecs[i])
+			if emb.EmbeddingMeta != res.Target {
+				// Writing would leave the fact stale and the loop would never end.
+				return res, fmt.Errorf("%w: embedder returned %s/%s with %d dims, expected %+v",
+					embed.ErrEmbeddingDimensionMismatch, src.Provider, src.Model, len(vecs[i]), res.Target)
+			}
+			if err := facts.UpdateEmbedding(f.EntityID, f.Uniq, emb); err != nil && !errors.Is(err, storage.ErrNotFound) {
+				return res, err
+			}
+			res.Reembedded++
+		}
+	}
+}
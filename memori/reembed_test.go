@@ -0,0 +1,137 @@
+package memori_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	_ "modernc.org/sqlite"
+
+	"memorigo/embed"
+	"memorigo/memori"
+)
+
+// wideEmbedder stands in for switching to a different provider: it produces
+// vectors of another dimension than the default hash embedder.
+type wideEmbedder struct{ inner *embed.HashEmbedder }
+
+func (e wideEmbedder) EmbedText(ctx context.Context, text string) ([]float32, error) {
+	v, err := e.inner.EmbedText(ctx, text)
+	return append(v, v...), err
+}
+
+func (e wideEmbedder) EmbedTexts(ctx context.Context, texts []string) ([][]float32, error) {
+	out := make([][]float32, len(texts))
+	for i, t := range texts {
+		out[i], _ = e.EmbedText(ctx, t)
+	}
+	return out, nil
+}
+
+func (e wideEmbedder) Dimension() int   { return 2 * e.inner.Dimension() }
+func (e wideEmbedder) Provider() string { return "wide" }
+
+func TestReembed_SwitchingEmbedder(t *testing.T) {
+	db, err := sql.Open("sqlite", "file:memori_reembed?mode=memory&cache=shared")
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	defer db.Close()
+
+	m := memori.New(memori.WithStorageConn(db))
+	if err := m.Storage.Build(); err != nil {
+		t.Fatalf("migrate/build: %v", err)
+	}
+	m.Attribution("user-reembed", "proc-reembed")
+
+	var payload memori.ConversationPayload
+	payload.Messages = []memori.Message{{Role: "user", Content: "I live in Lisbon"}}
+	payload.Response = &memori.Message{Role: "assistant", Content: "Nice."}
+	if err := memori.NewWriter(m).Execute(context.Background(), payload); err != nil {
+		t.Fatalf("writer execute: %v", err)
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		facts, err := m.Recall("Lisbon", 5)
+		if err != nil {
+			t.Fatalf("recall: %v", err)
+		}
+		if len(facts) > 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for augmentation to write facts")
+		}
+		time.Sleep(25 * time.Millisecond)
+	}
+
+	m.Embedder = wideEmbedder{inner: embed.NewHashEmbedder()}
+	if _, err := m.Recall("Lisbon", 5); !errors.Is(err, embed.ErrEmbeddingDimensionMismatch) {
+		t.Fatalf("expected dimension mismatch before reembed, got %v", err)
+	}
+
+	res, err := m.Reembed(context.Background())
+	if err != nil {
+		t.Fatalf("reembed: %v", err)
+	}
+	if res.Reembedded == 0 || res.Target.Provider != "wide" {
+		t.Fatalf("unexpected reembed result: %+v", res)
+	}
+
+	facts, err := m.Recall("Lisbon", 5)
+	if err != nil {
+		t.Fatalf("recall after reembed: %v", err)
+	}
+	if len(facts) == 0 || facts[0].Content == "" {
+		t.Fatalf("expected facts after reembed, got %#v", facts)
+	}
+
+	// A second run has nothing left to do.
+	if res, err := m.Reembed(context.Background()); err != nil || res.Reembedded != 0 {
+		t.Fatalf("second reembed: %+v, %v", res, err)
+	}
+}
+
+// downEmbedder fails every request, like an unreachable provider.
+type downEmbedder struct{ wideEmbedder }
+
+func (downEmbedder) EmbedTexts(context.Context, []string) ([][]float32, error) {
+	return nil, errors.New("provider down")
+}
+
+func TestReembed_FactKeptWithoutVector(t *testing.T) {
+	db, err := sql.Open("sqlite", "file:memori_reembed_down?mode=memory&cache=shared")
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	defer db.Close()
+
+	m := memori.New(memori.WithStorageConn(db))
+	if err := m.Storage.Build(); err != nil {
+		t.Fatalf("migrate/build: %v", err)
+	}
+	m.Attribution("user-reembed-down", "proc-reembed-down")
+
+	hash := m.Embedder
+	m.Embedder = downEmbedder{}
+	fact, err := m.Remember(context.Background(), "I live in Porto")
+	if err != nil {
+		t.Fatalf("remember with the embedder down: %v", err)
+	}
+	if fact.Embedding.Dimension != 0 {
+		t.Fatalf("fact embedded by a failing embedder: %+v", fact)
+	}
+
+	m.Embedder = hash
+	if facts, err := m.Recall("Porto", 5); err != nil || len(facts) != 0 {
+		t.Fatalf("recall before reembed = %+v, %v; want no facts", facts, err)
+	}
+	if res, err := m.Reembed(context.Background()); err != nil || res.Reembedded != 1 {
+		t.Fatalf("reembed: %+v, %v", res, err)
+	}
+	if facts, err := m.Recall("Porto", 5); err != nil || len(facts) != 1 {
+		t.Fatalf("recall after reembed = %+v, %v", facts, err)
+	}
+}
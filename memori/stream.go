@@ -0,0 +1,131 @@
+package memori
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+)
+
+// PartialResponsePolicy decides what the stream wrappers store when a stream
+// ends before completing: on an error, a cancelled context, or a connection
+// closed before the final event.
+type PartialResponsePolicy int
+
+const (
+	// PartialPersist stores the reply received so far, with its messages
+	// marked truncated.
+	PartialPersist PartialResponsePolicy = iota
+	// PartialDiscard stores nothing for an incomplete stream.
+	PartialDiscard
+)
+
+// ErrStreamStalled ends a stream whose caller stopped reading for longer than
+// Config.StreamStallTimeout.
+var ErrStreamStalled = errors.New("memori: stream caller stopped reading")
+
+// streamSender forwards the events of a stream wrapper to its caller. A
+// caller that cancels its context, or leaves a full buffer unread for
+// Config.StreamStallTimeout, is gone: forwarding stops and stop cancels the
+// upstream request, so the wrapper ends and persists what it received.
+type streamSender[T any] struct {
+	ctx   context.Context
+	out   chan<- T
+	stall time.Duration
+	stop  context.CancelFunc
+	gone  bool
+	// stalled is set when the caller stopped reading without cancelling.
+	stalled bool
+}
+
+func newStreamSender[T any](m *Memori, ctx context.Context, out chan<- T, stop context.CancelFunc) *streamSender[T] {
+	m.Config.mu.RLock()
+	defer m.Config.mu.RUnlock()
+	return &streamSender[T]{ctx: ctx, out: out, stall: m.Config.StreamStallTimeout, stop: stop}
+}
+
+func (s *streamSender[T]) send(v T) {
+	if s.gone {
+		return
+	}
+	select {
+	case s.out <- v:
+		return
+	default:
+	}
+	var stalled <-chan time.Time
+	if s.stall > 0 {
+		t := time.NewTimer(s.stall)
+		defer t.Stop()
+		stalled = t.C
+	}
+	select {
+	case s.out <- v:
+	case <-s.ctx.Done():
+		s.gone = true
+	case <-stalled:
+		s.gone, s.stalled = true, true
+		s.stop()
+	}
+}
+
+// err is the error the caller gets for a stream that ended with err.
+func (s *streamSender[T]) err(err error) error {
+	if s.stalled {
+		return ErrStreamStalled
+	}
+	return err
+}
+
+// CollectStream reads a chat completion stream to its end and assembles the
+// chunks into the response a non-streamed call would have returned: content
+// and tool calls per choice, the finish reasons and the usage. On error it
+// returns what was received so far together with the error.
+func CollectStream(events <-chan StreamEvent, errs <-chan error) (ChatCompletionsResponse, error) {
+	var out ChatCompletionsResponse
+	type choice struct {
+		ChatCompletionChoice
+		calls ToolCallAccumulator
+	}
+	choices := make(map[int]*choice)
+
+	for ev := range events {
+		chunk := ev.Chunk
+		if chunk == nil {
+			continue
+		}
+		if out.ID == "" {
+			out.ID, out.Created, out.Model = chunk.ID, chunk.Created, chunk.Model
+		}
+		if chunk.Usage != nil {
+			out.Usage = chunk.Usage
+		}
+		for _, d := range chunk.Choices {
+			c, ok := choices[d.Index]
+			if !ok {
+				c = &choice{ChatCompletionChoice: ChatCompletionChoice{Index: d.Index}}
+				c.Message.Role = "assistant"
+				choices[d.Index] = c
+			}
+			if d.Delta.Role != "" {
+				c.Message.Role = d.Delta.Role
+			}
+			c.Message.Content += d.Delta.Content
+			c.calls.Add(d.Delta.ToolCalls)
+			if d.FinishReason != "" {
+				c.FinishReason = d.FinishReason
+			}
+		}
+	}
+	err := <-errs
+
+	out.Object = "chat.completion"
+	for _, c := range choices {
+		if calls := c.calls.ToolCalls(); len(calls) > 0 {
+			c.Message.ToolCalls = calls
+		}
+		out.Choices = append(out.Choices, c.ChatCompletionChoice)
+	}
+	sort.Slice(out.Choices, func(i, j int) bool { return out.Choices[i].Index < out.Choices[j].Index })
+	return out, err
+}
@@ -0,0 +1,190 @@
+package memori_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	_ "modernc.org/sqlite"
+
+	"memorigo/memori"
+)
+
+func TestStream_CancelledConsumerPersistsTruncated(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprintf(w, "data: %s\n\n", `{"choices":[{"index":0,"delta":{"role":"assistant","content":"My favorite"}}]}`)
+		w.(http.Flusher).Flush()
+		// never finishes: only the client going away ends the stream
+		<-r.Context().Done()
+	}))
+	defer srv.Close()
+
+	for _, policy := range []memori.PartialResponsePolicy{memori.PartialPersist, memori.PartialDiscard} {
+		db, err := sql.Open("sqlite", fmt.Sprintf("file:memori_stream_%d?mode=memory&cache=shared", policy))
+		if err != nil {
+			t.Fatalf("open sqlite: %v", err)
+		}
+		defer db.Close()
+
+		m := memori.New(memori.WithStorageConn(db))
+		if err := m.Storage.Build(); err != nil {
+			t.Fatalf("migrate/build: %v", err)
+		}
+		m.Config.PartialResponses = policy
+		m.OpenAI.Register(memori.NewOpenAICompatClient(memori.OpenAICompatOptions{BaseURL: srv.URL}))
+
+		ctx, cancel := context.WithCancel(context.Background())
+		events, _ := m.OpenAIClient().ChatCompletionsStream(ctx, memori.ChatCompletionsRequest{
+			Model:    "gpt-4o-mini",
+			Messages: []memori.ChatMessage{{Role: "user", Content: "What is my favorite color?"}},
+		})
+		<-events
+		// stop reading and cancel; the wrapper must still finish and persist
+		cancel()
+		done := make(chan struct{})
+		go func() {
+			for range events {
+			}
+			close(done)
+		}()
+		select {
+		case <-done:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("stream wrapper did not stop after cancellation")
+		}
+
+		var content string
+		var truncated bool
+		err = db.QueryRow("SELECT content, truncated FROM memori_conversation_message WHERE role = 'assistant'").Scan(&content, &truncated)
+		switch policy {
+		case memori.PartialPersist:
+			if err != nil || content != "My favorite" || !truncated {
+				t.Fatalf("persisted %q truncated=%v, err = %v", content, truncated, err)
+			}
+		case memori.PartialDiscard:
+			if err != sql.ErrNoRows {
+				t.Fatalf("discard policy stored %q, err = %v", content, err)
+			}
+		}
+	}
+}
+
+func TestStream_StalledConsumerPersistsTruncated(t *testing.T) {
+	const chunks = 200 // more than the wrapper buffers
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		for i := 0; i < chunks; i++ {
+			fmt.Fprintf(w, "data: %s\n\n", `{"choices":[{"index":0,"delta":{"content":"x"}}]}`)
+		}
+		w.(http.Flusher).Flush()
+		// never finishes: only the client going away ends the stream
+		<-r.Context().Done()
+	}))
+	defer srv.Close()
+
+	db, err := sql.Open("sqlite", "file:memori_stream_stalled?mode=memory&cache=shared")
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	defer db.Close()
+	m := memori.New(memori.WithStorageConn(db))
+	if err := m.Storage.Build(); err != nil {
+		t.Fatalf("migrate/build: %v", err)
+	}
+	m.Config.StreamStallTimeout = 50 * time.Millisecond
+	m.OpenAI.Register(memori.NewOpenAICompatClient(memori.OpenAICompatOptions{BaseURL: srv.URL}))
+
+	// read one event, then stop reading without cancelling
+	events, errs := m.OpenAIClient().ChatCompletionsStream(context.Background(), memori.ChatCompletionsRequest{
+		Model:    "gpt-4o-mini",
+		Messages: []memori.ChatMessage{{Role: "user", Content: "Say x a lot"}},
+	})
+	<-events
+
+	var content string
+	var truncated bool
+	for deadline := time.Now().Add(5 * time.Second); ; time.Sleep(10 * time.Millisecond) {
+		err = db.QueryRow("SELECT content, truncated FROM memori_conversation_message WHERE role = 'assistant'").Scan(&content, &truncated)
+		if err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("reply of a stalled stream was not persisted: %v", err)
+		}
+	}
+	if len(content) != chunks || !truncated {
+		t.Fatalf("persisted %d bytes truncated=%v, want %d truncated", len(content), truncated, chunks)
+	}
+
+	// a caller coming back finds the buffered events and the reason
+	for range events {
+	}
+	if err := <-errs; !errors.Is(err, memori.ErrStreamStalled) {
+		t.Fatalf("err = %v, want ErrStreamStalled", err)
+	}
+}
+
+func TestStream_MidStreamErrorReachesCaller(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprintf(w, "data: %s\n\n", `{"choices":[{"index":0,"delta":{"role":"assistant","content":"Blue"}}]}`)
+		fmt.Fprintf(w, "data: %s\n\n", `{"error":{"message":"overloaded","type":"server_error"}}`)
+	}))
+	defer srv.Close()
+
+	m := memori.New()
+	m.OpenAI.Register(memori.NewOpenAICompatClient(memori.OpenAICompatOptions{BaseURL: srv.URL}))
+	// the error is buffered before the raw channels close; a wrapper that
+	// stops at the closed events channel loses it about half of the time
+	for i := 0; i < 100; i++ {
+		resp, err := memori.CollectStream(m.OpenAIClient().ChatCompletionsStream(context.Background(), memori.ChatCompletionsRequest{
+			Model:    "gpt-4o-mini",
+			Messages: []memori.ChatMessage{{Role: "user", Content: "What is my favorite color?"}},
+		}))
+		var apiErr *memori.APIError
+		if !errors.As(err, &apiErr) || apiErr.Message != "overloaded" {
+			t.Fatalf("run %d: err = %v", i, err)
+		}
+		if len(resp.Choices) != 1 || resp.Choices[0].Message.Content != "Blue" {
+			t.Fatalf("run %d: partial reply = %+v", i, resp.Choices)
+		}
+	}
+}
+
+func TestCollectStream(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, data := range []string{
+			`{"id":"c1","model":"m","created":1,"choices":[{"index":0,"delta":{"role":"assistant","content":"Let me "}}]}`,
+			`{"id":"c1","choices":[{"index":0,"delta":{"content":"check."}}]}`,
+			`{"id":"c1","choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"id":"call_1","type":"function","function":{"name":"weather","arguments":"{\"city\":"}}]}}]}`,
+			`{"id":"c1","choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"function":{"arguments":"\"Paris\"}"}}]}}]}`,
+			`{"id":"c1","choices":[{"index":0,"delta":{},"finish_reason":"tool_calls"}]}`,
+			`{"id":"c1","choices":[],"usage":{"prompt_tokens":5,"completion_tokens":7,"total_tokens":12}}`,
+			`[DONE]`,
+		} {
+			fmt.Fprintf(w, "data: %s\n\n", data)
+		}
+	}))
+	defer srv.Close()
+
+	client := memori.NewOpenAICompatClient(memori.OpenAICompatOptions{BaseURL: srv.URL})
+	resp, err := memori.CollectStream(client.ChatCompletionsStream(context.Background(), memori.ChatCompletionsRequest{Model: "m"}))
+	if err != nil {
+		t.Fatalf("collect: %v", err)
+	}
+	if resp.ID != "c1" || resp.Model != "m" || len(resp.Choices) != 1 || resp.Usage == nil || resp.Usage.TotalTokens != 12 {
+		t.Fatalf("resp = %+v", resp)
+	}
+	msg := resp.Choices[0].Message
+	if msg.Role != "assistant" || msg.Content != "Let me check." || resp.Choices[0].FinishReason != "tool_calls" ||
+		len(msg.ToolCalls) != 1 || msg.ToolCalls[0].Function.Arguments != `{"city":"Paris"}` {
+		t.Fatalf("choice = %+v", resp.Choices[0])
+	}
+}
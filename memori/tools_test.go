@@ -0,0 +1,124 @@
+package memori_test
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	_ "modernc.org/sqlite"
+
+	"memorigo/memori"
+)
+
+func TestToolCallAccumulator(t *testing.T) {
+	idx := func(i int) *int { return &i }
+	var acc memori.ToolCallAccumulator
+	acc.Add([]memori.ToolCall{{Index: idx(0), ID: "call_a", Type: "function", Function: memori.FunctionCall{Name: "get_weather"}}})
+	acc.Add([]memori.ToolCall{{Index: idx(0), Function: memori.FunctionCall{Arguments: `{"city":`}}})
+	acc.Add([]memori.ToolCall{{Index: idx(1), ID: "call_b", Function: memori.FunctionCall{Name: "get_time", Arguments: `{}`}}})
+	acc.Add([]memori.ToolCall{{Index: idx(0), Function: memori.FunctionCall{Arguments: `"Oslo"}`}}})
+
+	calls := acc.ToolCalls()
+	if len(calls) != 2 {
+		t.Fatalf("got %d calls, want 2", len(calls))
+	}
+	if calls[0].ID != "call_a" || calls[0].Function.Name != "get_weather" || calls[0].Function.Arguments != `{"city":"Oslo"}` {
+		t.Fatalf("call 0 = %+v", calls[0])
+	}
+	if calls[1].ID != "call_b" || calls[1].Type != "function" || calls[1].Function.Arguments != `{}` {
+		t.Fatalf("call 1 = %+v", calls[1])
+	}
+}
+
+// TestOpenAI_StreamPersistsToolCalls streams a response made only of a tool
+// call and checks that request tool messages and the assembled call are
+// stored with their types.
+func TestOpenAI_StreamPersistsToolCalls(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req memori.ChatCompletionsRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || len(req.Tools) != 1 {
+			http.Error(w, "invalid request", http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, data := range []string{
+			`{"choices":[{"index":0,"delta":{"role":"assistant","content":null,"tool_calls":[{"index":0,"id":"call_2","type":"function","function":{"name":"get_weather","arguments":""}}]}}]}`,
+			`{"choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"function":{"arguments":"{\"city\":"}}]}}]}`,
+			`{"choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"function":{"arguments":"\"Bergen\"}"}}]}}]}`,
+			`{"choices":[{"index":0,"delta":{},"finish_reason":"tool_calls"}]}`,
+			`[DONE]`,
+		} {
+			fmt.Fprintf(w, "data: %s\n\n", data)
+		}
+	}))
+	defer srv.Close()
+
+	db, err := sql.Open("sqlite", "file:memori_tools?mode=memory&cache=shared")
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	defer db.Close()
+
+	m := memori.New(memori.WithStorageConn(db))
+	if err := m.Storage.Build(); err != nil {
+		t.Fatalf("migrate/build: %v", err)
+	}
+	m.Attribution("user-tools", "proc-tools")
+	m.OpenAI.Register(memori.NewOpenAICompatClient(memori.OpenAICompatOptions{BaseURL: srv.URL}))
+
+	req := memori.ChatCompletionsRequest{
+		Model: "gpt-test",
+		Tools: []memori.Tool{memori.FunctionTool("get_weather", "Current weather", json.RawMessage(`{"type":"object"}`))},
+		Messages: []memori.ChatMessage{
+			{Role: "user", Content: "I live in Oslo"},
+			{Role: "assistant", ToolCalls: []memori.ToolCall{{ID: "call_1", Type: "function", Function: memori.FunctionCall{Name: "get_weather", Arguments: `{"city":"Oslo"}`}}}},
+			{Role: "tool", ToolCallID: "call_1", Content: "4°C, rain"},
+		},
+	}
+	events, errs := m.OpenAIClient().ChatCompletionsStream(context.Background(), req)
+	for range events {
+	}
+	if err := <-errs; err != nil {
+		t.Fatalf("stream: %v", err)
+	}
+
+	type row struct{ role, typ, content string }
+	want := []row{
+		{"user", "", "I live in Oslo"},
+		{"assistant", memori.MessageTypeToolCall, `{"id":"call_1","type":"function","function":{"name":"get_weather","arguments":"{\"city\":\"Oslo\"}"}}`},
+		{"tool", memori.MessageTypeToolResult, `{"tool_call_id":"call_1","content":"4°C, rain"}`},
+		{"assistant", memori.MessageTypeToolCall, `{"id":"call_2","type":"function","function":{"name":"get_weather","arguments":"{\"city\":\"Bergen\"}"}}`},
+	}
+
+	var got []row
+	deadline := time.Now().Add(2 * time.Second)
+	for len(got) < len(want) && time.Now().Before(deadline) {
+		got = got[:0]
+		rows, err := db.Query(`SELECT role, COALESCE(type, ''), content FROM memori_conversation_message ORDER BY id`)
+		if err != nil {
+			t.Fatalf("query messages: %v", err)
+		}
+		for rows.Next() {
+			var r row
+			if err := rows.Scan(&r.role, &r.typ, &r.content); err != nil {
+				t.Fatalf("scan: %v", err)
+			}
+			got = append(got, r)
+		}
+		rows.Close()
+		time.Sleep(10 * time.Millisecond)
+	}
+	if len(got) != len(want) {
+		t.Fatalf("stored %d messages, want %d: %+v", len(got), len(want), got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("message %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
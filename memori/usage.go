@@ -0,0 +1,174 @@
+package memori
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"memorigo/storage"
+)
+
+// TokenUsage is the token count of one LLM call, as reported by the provider.
+type TokenUsage struct {
+	PromptTokens     int
+	CompletionTokens int
+	TotalTokens      int
+}
+
+// ModelPrice is the price of a model per million tokens, in whatever currency
+// the price table is kept in.
+type ModelPrice struct {
+	Prompt     float64 `json:"prompt"`
+	Completion float64 `json:"completion"`
+}
+
+// PriceTable maps model names to prices. A key ending in "*" is a prefix
+// that also matches dated or suffixed model versions, e.g. "gpt-4o-mini*".
+type PriceTable map[string]ModelPrice
+
+// LoadPriceTable reads a JSON price table such as
+// {"gpt-4o-mini*": {"prompt": 0.15, "completion": 0.6}}.
+func LoadPriceTable(path string) (PriceTable, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var t PriceTable
+	if err := json.Unmarshal(b, &t); err != nil {
+		return nil, fmt.Errorf("price table %s: %w", path, err)
+	}
+	return t, nil
+}
+
+// Lookup returns the price of model: an exact entry, else the longest
+// matching prefix entry.
+func (t PriceTable) Lookup(model string) (ModelPrice, bool) {
+	if p, ok := t[model]; ok {
+		return p, true
+	}
+	best, found := "", false
+	var price ModelPrice
+	for k, p := range t {
+		prefix, ok := strings.CutSuffix(k, "*")
+		if ok && strings.HasPrefix(model, prefix) && (!found || len(prefix) > len(best)) {
+			best, price, found = prefix, p, true
+		}
+	}
+	return price, found
+}
+
+// Cost returns the price of the given token counts of model.
+func (t PriceTable) Cost(model string, promptTokens, completionTokens int64) (float64, bool) {
+	p, ok := t.Lookup(model)
+	if !ok {
+		return 0, false
+	}
+	return (float64(promptTokens)*p.Prompt + float64(completionTokens)*p.Completion) / 1e6, true
+}
+
+// UsageGroup selects a dimension usage is aggregated by.
+type UsageGroup = storage.UsageGroup
+
+const (
+	UsageByEntity  = storage.UsageByEntity
+	UsageByProcess = storage.UsageByProcess
+	UsageBySession = storage.UsageBySession
+	UsageByModel   = storage.UsageByModel
+)
+
+// UsageQuery selects the LLM calls to aggregate; zero fields match all.
+// SessionID is the session uuid.
+type UsageQuery struct {
+	EntityID  string
+	ProcessID string
+	SessionID string
+	Model     string
+	Since     time.Time
+	Until     time.Time
+
+	GroupBy []UsageGroup
+}
+
+// UsageSummary is the usage of one group. Dimensions the query did not group
+// by are empty. Cost only covers models found in the price table; the others
+// are listed in UnpricedModels.
+type UsageSummary struct {
+	EntityID  string
+	ProcessID string
+	SessionID string
+	Model     string
+
+	Calls            int64
+	PromptTokens     int64
+	CompletionTokens int64
+	TotalTokens      int64
+
+	Cost           float64
+	UnpricedModels []string
+}
+
+// Usage aggregates the recorded token usage and its estimated cost from
+// Config.Prices.
+func (m *Memori) Usage(q UsageQuery) ([]UsageSummary, error) {
+	if m.Storage == nil || m.Storage.Driver() == nil {
+		return nil, nil
+	}
+	usage, err := storage.UsageOf(m.Storage.Driver())
+	if err != nil {
+		return nil, err
+	}
+
+	// Cost is per model, so always aggregate by model and regroup after.
+	groupBy := append([]UsageGroup{}, q.GroupBy...)
+	byModel := false
+	for _, g := range groupBy {
+		byModel = byModel || g == UsageByModel
+	}
+	if !byModel {
+		groupBy = append(groupBy, UsageByModel)
+	}
+	totals, err := usage.Aggregate(storage.UsageFilter{
+		EntityID:  q.EntityID,
+		ProcessID: q.ProcessID,
+		SessionID: q.SessionID,
+		Model:     q.Model,
+		Since:     q.Since,
+		Until:     q.Until,
+	}, groupBy)
+	if err != nil {
+		return nil, err
+	}
+
+	m.Config.mu.RLock()
+	prices := m.Config.Prices
+	m.Config.mu.RUnlock()
+
+	index := make(map[storage.UsageTotal]int)
+	var out []UsageSummary
+	for _, t := range totals {
+		k := t.Key(q.GroupBy)
+		i, ok := index[k]
+		if !ok {
+			i = len(out)
+			index[k] = i
+			out = append(out, UsageSummary{EntityID: k.EntityID, ProcessID: k.ProcessID, SessionID: k.SessionID, Model: k.Model})
+		}
+		s := &out[i]
+		s.Calls += t.Calls
+		s.PromptTokens += t.PromptTokens
+		s.CompletionTokens += t.CompletionTokens
+		s.TotalTokens += t.TotalTokens
+		if cost, ok := prices.Cost(t.Model, t.PromptTokens, t.CompletionTokens); ok {
+			s.Cost += cost
+		} else {
+			s.UnpricedModels = append(s.UnpricedModels, t.Model)
+		}
+	}
+	for i := range out {
+		sort.Strings(out[i].UnpricedModels)
+	}
+	return out, nil
+}
@@ -0,0 +1,167 @@
+package memori_test
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	bolt "go.etcd.io/bbolt"
+	_ "modernc.org/sqlite"
+
+	"memorigo/memori"
+)
+
+func TestPriceTable_Lookup(t *testing.T) {
+	prices := memori.PriceTable{
+		"gpt-4o*":      {Prompt: 2.5, Completion: 10},
+		"gpt-4o-mini*": {Prompt: 0.15, Completion: 0.6},
+		"gpt-4o-mini":  {Prompt: 1, Completion: 1},
+	}
+	for model, want := range map[string]float64{
+		"gpt-4o-mini":            1,
+		"gpt-4o-mini-2024-07-18": 0.15,
+		"gpt-4o-2024-08-06":      2.5,
+	} {
+		if p, ok := prices.Lookup(model); !ok || p.Prompt != want {
+			t.Fatalf("Lookup(%q) = %+v, %v; want prompt %v", model, p, ok, want)
+		}
+	}
+	if _, ok := prices.Lookup("claude-3"); ok {
+		t.Fatalf("unexpected price for claude-3")
+	}
+}
+
+func TestUsage_OpenAIStreamAndCreate(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req memori.ChatCompletionsRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "invalid request", http.StatusBadRequest)
+			return
+		}
+		if !req.Stream {
+			_, _ = w.Write([]byte(`{"model":"gpt-4o-mini-2024-07-18","choices":[{"index":0,"message":{"role":"assistant","content":"Hi."}}],"usage":{"prompt_tokens":100,"completion_tokens":20,"total_tokens":120}}`))
+			return
+		}
+		if req.StreamOptions == nil || !req.StreamOptions.IncludeUsage {
+			http.Error(w, "include_usage not requested", http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, data := range []string{
+			`{"model":"gpt-4o-mini-2024-07-18","choices":[{"index":0,"delta":{"role":"assistant","content":"Hello"}}]}`,
+			`{"model":"gpt-4o-mini-2024-07-18","choices":[{"index":0,"delta":{},"finish_reason":"stop"}]}`,
+			`{"model":"gpt-4o-mini-2024-07-18","choices":[],"usage":{"prompt_tokens":1000,"completion_tokens":500,"total_tokens":1500}}`,
+			`[DONE]`,
+		} {
+			fmt.Fprintf(w, "data: %s\n\n", data)
+		}
+	}))
+	defer srv.Close()
+
+	db, err := sql.Open("sqlite", "file:memori_usage?mode=memory&cache=shared")
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	defer db.Close()
+
+	m := memori.New(memori.WithStorageConn(db))
+	if err := m.Storage.Build(); err != nil {
+		t.Fatalf("migrate/build: %v", err)
+	}
+	m.Config.Prices = memori.PriceTable{"gpt-4o-mini*": {Prompt: 0.15, Completion: 0.6}}
+	m.OpenAI.Register(memori.NewOpenAICompatClient(memori.OpenAICompatOptions{BaseURL: srv.URL}))
+
+	req := memori.ChatCompletionsRequest{
+		Model:    "gpt-4o-mini",
+		Messages: []memori.ChatMessage{{Role: "user", Content: "Hello there"}},
+	}
+
+	m.Attribution("team-a", "billing")
+	events, errs := m.OpenAIClient().ChatCompletionsStream(context.Background(), req)
+	for range events {
+	}
+	if err := <-errs; err != nil {
+		t.Fatalf("stream: %v", err)
+	}
+
+	m.Attribution("team-b", "billing").NewSession()
+	if _, err := m.OpenAIClient().ChatCompletionsCreate(context.Background(), req); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	got, err := m.Usage(memori.UsageQuery{GroupBy: []memori.UsageGroup{memori.UsageByEntity}})
+	if err != nil {
+		t.Fatalf("usage: %v", err)
+	}
+	want := []memori.UsageSummary{
+		{EntityID: "team-a", Calls: 1, PromptTokens: 1000, CompletionTokens: 500, TotalTokens: 1500, Cost: (1000*0.15 + 500*0.6) / 1e6},
+		{EntityID: "team-b", Calls: 1, PromptTokens: 100, CompletionTokens: 20, TotalTokens: 120, Cost: (100*0.15 + 20*0.6) / 1e6},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("usage = %+v", got)
+	}
+	for i := range want {
+		g, w := got[i], want[i]
+		if g.EntityID != w.EntityID || g.Calls != w.Calls || g.PromptTokens != w.PromptTokens ||
+			g.CompletionTokens != w.CompletionTokens || g.TotalTokens != w.TotalTokens || math.Abs(g.Cost-w.Cost) > 1e-12 {
+			t.Fatalf("usage[%d] = %+v, want %+v", i, g, w)
+		}
+	}
+
+	// Usage is recorded under the snapshot that served the requested alias.
+	total, err := m.Usage(memori.UsageQuery{ProcessID: "billing", GroupBy: []memori.UsageGroup{memori.UsageByModel}})
+	if err != nil || len(total) != 1 || total[0].Calls != 2 || total[0].TotalTokens != 1620 || total[0].Model != "gpt-4o-mini-2024-07-18" {
+		t.Fatalf("total usage = %+v, %v", total, err)
+	}
+}
+
+func TestUsage_BoltGroupByModel(t *testing.T) {
+	db, err := bolt.Open(filepath.Join(t.TempDir(), "memori.db"), 0o600, nil)
+	if err != nil {
+		t.Fatalf("open bolt: %v", err)
+	}
+	defer db.Close()
+
+	m := memori.New(memori.WithStorageConn(db))
+	if err := m.Storage.Build(); err != nil {
+		t.Fatalf("migrate/build: %v", err)
+	}
+	m.Config.Prices = memori.PriceTable{"model-a": {Prompt: 1, Completion: 2}}
+	m.Attribution("user-1", "proc-1")
+
+	w := memori.NewWriter(m)
+	for _, c := range []struct {
+		model            string
+		prompt, complete int
+	}{{"model-a", 10, 5}, {"model-b", 7, 3}, {"model-a", 20, 10}} {
+		var payload memori.ConversationPayload
+		payload.Client.Title = c.model
+		payload.Messages = []memori.Message{{Role: "user", Content: "hi"}}
+		payload.Usage = &memori.TokenUsage{PromptTokens: c.prompt, CompletionTokens: c.complete}
+		if err := w.Execute(context.Background(), payload); err != nil {
+			t.Fatalf("execute: %v", err)
+		}
+	}
+
+	got, err := m.Usage(memori.UsageQuery{EntityID: "user-1", GroupBy: []memori.UsageGroup{memori.UsageByModel}})
+	if err != nil {
+		t.Fatalf("usage: %v", err)
+	}
+	if len(got) != 2 || got[0].Model != "model-a" || got[0].Calls != 2 || got[0].TotalTokens != 45 || got[0].Cost != (30*1+15*2)/1e6 {
+		t.Fatalf("usage = %+v", got)
+	}
+	if got[1].Model != "model-b" || got[1].Cost != 0 || len(got[1].UnpricedModels) != 1 {
+		t.Fatalf("unpriced usage = %+v", got[1])
+	}
+
+	all, err := m.Usage(memori.UsageQuery{})
+	if err != nil || len(all) != 1 || all[0].Calls != 3 || len(all[0].UnpricedModels) != 1 {
+		t.Fatalf("ungrouped usage = %+v, %v", all, err)
+	}
+}
@@ -21,12 +21,55 @@ type ConversationPayload struct {
 	}
 	Messages []Message
 	Response *Message
+	// ToolCalls are the tool calls requested by the response, written after it.
+	ToolCalls []Message
+	// Usage, when the provider reported it, is recorded in memori_llm_usage
+	// if the driver supports usage records.
+	Usage *TokenUsage
 }
 
+// Message types stored in memori_conversation_message.type. Plain request
+// messages leave Type empty.
+const (
+	MessageTypeText       = "text"
+	MessageTypeToolCall   = "tool_call"   // Content is the JSON encoded ToolCall
+	MessageTypeToolResult = "tool_result" // Content is the JSON encoded ToolResult
+)
+
+// Message is one message of a conversation. Content holds only its text, which
+// is what augmentation reads; Parts, when set, is the structured content of a
+// multimodal message with non-text parts kept as references. Truncated marks
+// a reply cut short by an incomplete stream.
 type Message struct {
-	Role    string
-	Type    string
-	Content string
+	Role      string
+	Type      string
+	Content   string
+	Parts     []storage.MessagePart
+	Truncated bool
+}
+
+func (m Message) record(conversationID int64) storage.MessageRecord {
+	return storage.MessageRecord{
+		ConversationID: conversationID,
+		Role:           m.Role,
+		Type:           m.Type,
+		Content:        m.Content,
+		Parts:          m.Parts,
+		Truncated:      m.Truncated,
+	}
+}
+
+// ToolResult is the stored form of a tool message: the output of one call.
+type ToolResult struct {
+	ToolCallID string `json:"tool_call_id"`
+	Name       string `json:"name,omitempty"`
+	Content    string `json:"content"`
+}
+
+// isToolMessage reports whether msg records a tool call or its result; those
+// are kept in the conversation but are not facts about the entity.
+func isToolMessage(msg Message) bool {
+	return msg.Type == MessageTypeToolCall || msg.Type == MessageTypeToolResult
 }
 
 type Writer struct {
@@ -113,7 +156,7 @@ func (w *Writer) executeTransaction(ctx context.Context, repos storage.Repos, pa
 	msgRepo := repos.Message()
 	for _, msg := range payload.Messages {
 		if msg.Role != "system" {
-			if err := msgRepo.Create(conversationID, msg.Role, msg.Type, msg.Content); err != nil {
+			if err := msgRepo.Create(msg.record(conversationID)); err != nil {
 				return err
 			}
 		}
@@ -121,7 +164,29 @@ func (w *Writer) executeTransaction(ctx context.Context, repos storage.Repos, pa
 
 	// Write response
 	if payload.Response != nil {
-		if err := msgRepo.Create(conversationID, payload.Response.Role, payload.Response.Type, payload.Response.Content); err != nil {
+		if err := msgRepo.Create(payload.Response.record(conversationID)); err != nil {
+			return err
+		}
+	}
+	for _, call := range payload.ToolCalls {
+		if err := msgRepo.Create(call.record(conversationID)); err != nil {
+			return err
+		}
+	}
+
+	// drivers without usage records simply do not keep it
+	if usage, ok := repos.(storage.UsageRepos); ok && payload.Usage != nil {
+		if err := usage.Usage().Create(storage.UsageRecord{
+			ConversationID:   conversationID,
+			EntityID:         cfg.EntityID,
+			ProcessID:        cfg.ProcessID,
+			SessionID:        cfg.SessionID.String(),
+			Provider:         payload.Client.Provider,
+			Model:            payload.Client.Title,
+			PromptTokens:     payload.Usage.PromptTokens,
+			CompletionTokens: payload.Usage.CompletionTokens,
+			TotalTokens:      payload.Usage.TotalTokens,
+		}); err != nil {
 			return err
 		}
 	}
@@ -138,7 +203,7 @@ func (w *Writer) executeTransaction(ctx context.Context, repos storage.Repos, pa
 }
 
 func (w *Writer) ensureCachedID(cacheKey string, createFunc func() (int64, error)) (int64, error) {
-	cache := w.m.Config.Cache
+	cache := &w.m.Config.Cache
 	cache.mu.Lock()
 	defer cache.mu.Unlock()
 
@@ -198,5 +263,3 @@ func indexOfSubstring(s, substr string) int {
 	}
 	return -1
 }
-
-
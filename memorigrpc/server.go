@@ -0,0 +1,243 @@
+// Package memorigrpc serves the memori.v1 gRPC service (package memoripb) on
+// top of a *memori.Memori: writes go through memori.Writer, recall through
+// Memori.Recall and fact events come from the AugmentationManager.
+package memorigrpc
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"io"
+	"strings"
+
+	"github.com/google/uuid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"memorigo/embed"
+	"memorigo/memori"
+	"memorigo/memoripb"
+	"memorigo/storage"
+)
+
+// Server implements memoripb.MemoriServiceServer.
+type Server struct {
+	memoripb.UnimplementedMemoriServiceServer
+	m *memori.Memori
+}
+
+func NewServer(m *memori.Memori) *Server {
+	return &Server{m: m}
+}
+
+// Register adds the memori.v1 service backed by m to s.
+func Register(s grpc.ServiceRegistrar, m *memori.Memori) {
+	memoripb.RegisterMemoriServiceServer(s, NewServer(m))
+}
+
+func (s *Server) Write(ctx context.Context, req *memoripb.WriteRequest) (*memoripb.WriteResponse, error) {
+	session, err := s.write(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return &memoripb.WriteResponse{SessionId: session.String()}, nil
+}
+
+func (s *Server) write(ctx context.Context, req *memoripb.WriteRequest) (uuid.UUID, error) {
+	a := req.GetAttribution()
+	if len(a.GetEntityId()) > 100 || len(a.GetProcessId()) > 100 {
+		return uuid.Nil, status.Error(codes.InvalidArgument, "entity_id and process_id are limited to 100 characters")
+	}
+	session := uuid.New()
+	if id := a.GetSessionId(); id != "" {
+		var err error
+		if session, err = uuid.Parse(id); err != nil {
+			return uuid.Nil, status.Errorf(codes.InvalidArgument, "session_id: %v", err)
+		}
+	}
+	if req.GetPayload() == nil {
+		return uuid.Nil, status.Error(codes.InvalidArgument, "payload is required")
+	}
+
+	m := s.m.Scoped(a.GetEntityId(), a.GetProcessId(), session)
+	if err := memori.NewWriter(m).Execute(ctx, payloadFromProto(req.GetPayload())); err != nil {
+		return uuid.Nil, errorStatus(err)
+	}
+	return session, nil
+}
+
+func (s *Server) Recall(ctx context.Context, req *memoripb.RecallRequest) (*memoripb.RecallResponse, error) {
+	switch {
+	case req.GetEntityId() == "":
+		return nil, status.Error(codes.InvalidArgument, "entity_id is required")
+	case len(req.GetEntityId()) > 100:
+		return nil, status.Error(codes.InvalidArgument, "entity_id is limited to 100 characters")
+	case strings.TrimSpace(req.GetQuery()) == "":
+		return nil, status.Error(codes.InvalidArgument, "query is required")
+	}
+	facts, err := s.m.Scoped(req.GetEntityId(), "", uuid.Nil).Recall(req.GetQuery(), int(req.GetLimit()))
+	if err != nil {
+		return nil, errorStatus(err)
+	}
+	resp := &memoripb.RecallResponse{Facts: make([]*memoripb.Fact, 0, len(facts))}
+	for _, f := range facts {
+		resp.Facts = append(resp.Facts, &memoripb.Fact{
+			Content:      f.Content,
+			Score:        f.Score,
+			NumTimes:     f.NumTimes,
+			DateLastTime: timestamppb.New(f.DateLastTime),
+		})
+	}
+	return resp, nil
+}
+
+func (s *Server) ForgetEntity(ctx context.Context, req *memoripb.ForgetEntityRequest) (*memoripb.ForgetEntityResponse, error) {
+	if req.GetEntityId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "entity_id is required")
+	}
+	if err := s.m.Forget(req.GetEntityId()); err != nil {
+		return nil, errorStatus(err)
+	}
+	return &memoripb.ForgetEntityResponse{}, nil
+}
+
+func (s *Server) Ingest(stream grpc.ClientStreamingServer[memoripb.WriteRequest, memoripb.IngestResponse]) error {
+	var written int64
+	for {
+		req, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return stream.SendAndClose(&memoripb.IngestResponse{Written: written})
+		}
+		if err != nil {
+			return err
+		}
+		if _, err := s.write(stream.Context(), req); err != nil {
+			st := status.Convert(err)
+			return status.Errorf(st.Code(), "request %d: %s", written, st.Message())
+		}
+		written++
+	}
+}
+
+func (s *Server) WatchFacts(req *memoripb.WatchFactsRequest, stream grpc.ServerStreamingServer[memoripb.FactEvent]) error {
+	events := s.m.Augmentation.WatchFacts(stream.Context(), req.GetEntityId())
+	// tell the client the watch is active, so it can start writing
+	if err := stream.SendHeader(metadata.MD{}); err != nil {
+		return err
+	}
+	for ev := range events {
+		if err := stream.Send(&memoripb.FactEvent{
+			EntityId: ev.EntityID,
+			Content:  ev.Content,
+			Time:     timestamppb.New(ev.Time),
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// errorStatus maps memori and storage errors to gRPC status codes.
+func errorStatus(err error) error {
+	switch {
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return status.FromContextError(err).Err()
+	case errors.Is(err, storage.ErrNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, embed.ErrEmbeddingDimensionMismatch):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	case errors.Is(err, embed.ErrEmbeddingServiceUnavailable):
+		return status.Error(codes.Unavailable, err.Error())
+	default:
+		return status.Error(codes.Internal, err.Error())
+	}
+}
+
+// AuthOptions returns server options requiring apiKey in the "authorization"
+// ("Bearer <key>") or "x-api-key" metadata of every call.
+func AuthOptions(apiKey string) []grpc.ServerOption {
+	check := func(ctx context.Context) error {
+		md, _ := metadata.FromIncomingContext(ctx)
+		key := first(md.Get("x-api-key"))
+		if key == "" {
+			key, _ = strings.CutPrefix(first(md.Get("authorization")), "Bearer ")
+		}
+		if subtle.ConstantTimeCompare([]byte(strings.TrimSpace(key)), []byte(apiKey)) != 1 {
+			return status.Error(codes.Unauthenticated, "invalid or missing API key")
+		}
+		return nil
+	}
+	return []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(func(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+			if err := check(ctx); err != nil {
+				return nil, err
+			}
+			return handler(ctx, req)
+		}),
+		grpc.ChainStreamInterceptor(func(srv any, ss grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+			if err := check(ss.Context()); err != nil {
+				return err
+			}
+			return handler(srv, ss)
+		}),
+	}
+}
+
+func first(values []string) string {
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
+
+func payloadFromProto(p *memoripb.ConversationPayload) memori.ConversationPayload {
+	var out memori.ConversationPayload
+	out.Client.Provider = p.GetProvider()
+	out.Client.Title = p.GetModel()
+	for _, msg := range p.GetMessages() {
+		out.Messages = append(out.Messages, messageFromProto(msg))
+	}
+	if p.GetResponse() != nil {
+		resp := messageFromProto(p.GetResponse())
+		out.Response = &resp
+	}
+	for _, msg := range p.GetToolCalls() {
+		out.ToolCalls = append(out.ToolCalls, messageFromProto(msg))
+	}
+	if u := p.GetUsage(); u != nil {
+		out.Usage = &memori.TokenUsage{
+			PromptTokens:     int(u.GetPromptTokens()),
+			CompletionTokens: int(u.GetCompletionTokens()),
+			TotalTokens:      int(u.GetTotalTokens()),
+		}
+	}
+	return out
+}
+
+func messageFromProto(m *memoripb.Message) memori.Message {
+	out := memori.Message{
+		Role:      m.GetRole(),
+		Type:      m.GetType(),
+		Content:   m.GetContent(),
+		Truncated: m.GetTruncated(),
+	}
+	for _, p := range m.GetParts() {
+		out.Parts = append(out.Parts, storage.MessagePart{
+			Type:     p.GetType(),
+			Text:     p.GetText(),
+			URL:      p.GetUrl(),
+			Hash:     p.GetHash(),
+			MimeType: p.GetMimeType(),
+			Size:     int(p.GetSize()),
+			Detail:   p.GetDetail(),
+			FileID:   p.GetFileId(),
+			Filename: p.GetFilename(),
+		})
+	}
+	return out
+}
+
+var _ memoripb.MemoriServiceServer = (*Server)(nil)
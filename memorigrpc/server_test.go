@@ -0,0 +1,122 @@
+package memorigrpc_test
+
+import (
+	"context"
+	"database/sql"
+	"net"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	_ "modernc.org/sqlite"
+
+	"memorigo/memori"
+	"memorigo/memorigrpc"
+	"memorigo/memoripb"
+)
+
+func TestServer(t *testing.T) {
+	db, err := sql.Open("sqlite", "file:memori_grpc?mode=memory&cache=shared")
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	defer db.Close()
+	m := memori.New(memori.WithStorageConn(db))
+	if err := m.Storage.Build(); err != nil {
+		t.Fatalf("migrate/build: %v", err)
+	}
+
+	lis := bufconn.Listen(1 << 20)
+	srv := grpc.NewServer(memorigrpc.AuthOptions("secret")...)
+	memorigrpc.Register(srv, m)
+	go srv.Serve(lis)
+	defer srv.Stop()
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.Close()
+	client := memoripb.NewMemoriServiceClient(conn)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if _, err := client.Recall(ctx, &memoripb.RecallRequest{EntityId: "alice", Query: "x"}); status.Code(err) != codes.Unauthenticated {
+		t.Fatalf("recall without key: %v", err)
+	}
+	ctx = metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer secret")
+
+	watch, err := client.WatchFacts(ctx, &memoripb.WatchFactsRequest{EntityId: "alice"})
+	if err != nil {
+		t.Fatalf("watch: %v", err)
+	}
+	if _, err := watch.Header(); err != nil {
+		t.Fatalf("watch header: %v", err)
+	}
+
+	write := func(content string) *memoripb.WriteRequest {
+		return &memoripb.WriteRequest{
+			Attribution: &memoripb.Attribution{EntityId: "alice", ProcessId: "grpc-test"},
+			Payload: &memoripb.ConversationPayload{
+				Messages: []*memoripb.Message{{Role: "user", Content: content}},
+				Response: &memoripb.Message{Role: "assistant", Content: "Noted."},
+			},
+		}
+	}
+	resp, err := client.Write(ctx, write("My favorite color is blue"))
+	if err != nil || resp.GetSessionId() == "" {
+		t.Fatalf("write: %v, %v", resp, err)
+	}
+	ev, err := watch.Recv()
+	if err != nil || ev.GetEntityId() != "alice" || ev.GetContent() != "My favorite color is blue" {
+		t.Fatalf("watched event: %v, %v", ev, err)
+	}
+
+	recall, err := client.Recall(ctx, &memoripb.RecallRequest{EntityId: "alice", Query: "favorite color", Limit: 1})
+	if err != nil || len(recall.GetFacts()) != 1 || recall.GetFacts()[0].GetContent() != "My favorite color is blue" {
+		t.Fatalf("recall: %v, %v", recall, err)
+	}
+
+	ingest, err := client.Ingest(ctx)
+	if err != nil {
+		t.Fatalf("ingest: %v", err)
+	}
+	for _, content := range []string{"I live in Paris", "I work as a chef"} {
+		if err := ingest.Send(write(content)); err != nil {
+			t.Fatalf("ingest send: %v", err)
+		}
+	}
+	ingested, err := ingest.CloseAndRecv()
+	if err != nil || ingested.GetWritten() != 2 {
+		t.Fatalf("ingest: %v, %v", ingested, err)
+	}
+	for range 2 {
+		if _, err := watch.Recv(); err != nil {
+			t.Fatalf("watch after ingest: %v", err)
+		}
+	}
+
+	bad := write("x")
+	bad.Attribution.SessionId = "not-a-uuid"
+	if _, err := client.Write(ctx, bad); status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("write with a bad session id: %v", err)
+	}
+
+	if _, err := client.ForgetEntity(ctx, &memoripb.ForgetEntityRequest{EntityId: "alice"}); err != nil {
+		t.Fatalf("forget: %v", err)
+	}
+	if _, err := client.ForgetEntity(ctx, &memoripb.ForgetEntityRequest{EntityId: "alice"}); status.Code(err) != codes.NotFound {
+		t.Fatalf("second forget: %v", err)
+	}
+	recall, err = client.Recall(ctx, &memoripb.RecallRequest{EntityId: "alice", Query: "favorite color"})
+	if err != nil || len(recall.GetFacts()) != 0 {
+		t.Fatalf("recall after forget: %v, %v", recall, err)
+	}
+}
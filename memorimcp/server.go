@@ -0,0 +1,401 @@
+// Package memorimcp exposes memorigo to agents as a Model Context Protocol
+// server: tools to remember, recall, list and forget facts and to read
+// conversation summaries, and a resource with each entity's profile. The
+// server works with any transport of the MCP SDK; cmd/memori-mcp serves it
+// over stdio or streamable HTTP.
+package memorimcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+
+	"memorigo/memori"
+	"memorigo/storage"
+)
+
+// Options configure NewServer. The zero value serves every entity.
+type Options struct {
+	// Entity pins the server to one entity: tools default to it and reject
+	// any other, and its profile is listed as a resource.
+	Entity string
+	// Process attributes facts stored through the remember tool.
+	Process string
+	// Version is reported to clients during initialization.
+	Version string
+}
+
+const (
+	profileTemplate = "memori://entities/{entity_id}/profile"
+	profilePrefix   = "memori://entities/"
+	profileSuffix   = "/profile"
+
+	defaultSummaries    = 5
+	profileFactLimit    = 50
+	profileSummaryLimit = 5
+)
+
+type server struct {
+	m    *memori.Memori
+	opts Options
+}
+
+// NewServer returns an MCP server backed by m.
+func NewServer(m *memori.Memori, opts *Options) *mcp.Server {
+	s := &server{m: m}
+	if opts != nil {
+		s.opts = *opts
+	}
+	version := s.opts.Version
+	if version == "" {
+		version = "dev"
+	}
+	srv := mcp.NewServer(&mcp.Implementation{Name: "memori", Version: version}, &mcp.ServerOptions{
+		Instructions: "Long-term memory about users (entities). Recall facts before answering " +
+			"questions that depend on the user, and remember durable facts they share.",
+	})
+
+	destructive := true
+	mcp.AddTool(srv, &mcp.Tool{
+		Name:        "remember",
+		Description: "Store a fact about an entity. Remembering a known fact again counts as another mention.",
+		Annotations: &mcp.ToolAnnotations{IdempotentHint: true},
+	}, s.remember)
+	mcp.AddTool(srv, &mcp.Tool{
+		Name:        "recall",
+		Description: "Find the facts about an entity most relevant to a query, by semantic similarity.",
+		Annotations: &mcp.ToolAnnotations{ReadOnlyHint: true},
+	}, s.recall)
+	mcp.AddTool(srv, &mcp.Tool{
+		Name:        "list_facts",
+		Description: "List the stored facts about an entity, optionally only those containing some text.",
+		Annotations: &mcp.ToolAnnotations{ReadOnlyHint: true},
+	}, s.listFacts)
+	mcp.AddTool(srv, &mcp.Tool{
+		Name: "forget",
+		Description: "Delete one fact about an entity by id, or, without fact_id, the entity and " +
+			"everything stored about it.",
+		Annotations: &mcp.ToolAnnotations{DestructiveHint: &destructive},
+	}, s.forget)
+	mcp.AddTool(srv, &mcp.Tool{
+		Name:        "get_conversation_summary",
+		Description: "Summaries of an entity's most recent conversations, newest first.",
+		Annotations: &mcp.ToolAnnotations{ReadOnlyHint: true},
+	}, s.conversationSummaries)
+
+	srv.AddResourceTemplate(&mcp.ResourceTemplate{
+		Name:        "entity-profile",
+		Description: "An entity's profile: its most mentioned facts and recent conversation summaries.",
+		MIMEType:    "application/json",
+		URITemplate: profileTemplate,
+	}, s.readProfile)
+	if s.opts.Entity != "" {
+		srv.AddResource(&mcp.Resource{
+			Name:        "profile",
+			Description: "Profile of " + s.opts.Entity,
+			MIMEType:    "application/json",
+			URI:         profileURI(s.opts.Entity),
+		}, s.readProfile)
+	}
+	return srv
+}
+
+// Fact is a fact as returned by the tools. Score is set by recall only.
+type Fact struct {
+	ID            string    `json:"id,omitempty"`
+	Content       string    `json:"content"`
+	Score         float64   `json:"score,omitempty"`
+	NumTimes      int64     `json:"num_times"`
+	LastMentioned time.Time `json:"last_mentioned"`
+}
+
+type ConversationSummary struct {
+	ID         string    `json:"id"`
+	SessionID  string    `json:"session_id"`
+	Summary    string    `json:"summary"`
+	LastActive time.Time `json:"last_active"`
+}
+
+// Profile is the content of the entity profile resource.
+type Profile struct {
+	EntityID      string                `json:"entity_id"`
+	DateCreated   time.Time             `json:"date_created"`
+	FactCount     int                   `json:"fact_count"`
+	Facts         []Fact                `json:"facts"`
+	Conversations []ConversationSummary `json:"recent_conversations"`
+}
+
+type entityArg struct {
+	EntityID string `json:"entity_id,omitempty" jsonschema:"the entity (user) the memory is about; may be omitted when the server is pinned to one"`
+}
+
+type rememberInput struct {
+	entityArg
+	Content string `json:"content" jsonschema:"the fact, as a short self-contained sentence"`
+}
+
+type recallInput struct {
+	entityArg
+	Query string `json:"query" jsonschema:"what to look for"`
+	Limit int    `json:"limit,omitempty" jsonschema:"maximum number of facts, default 5"`
+}
+
+type listFactsInput struct {
+	entityArg
+	Query  string `json:"query,omitempty" jsonschema:"only facts containing this text, ignoring case"`
+	Limit  int    `json:"limit,omitempty" jsonschema:"maximum number of facts, default 100"`
+	Offset int    `json:"offset,omitempty" jsonschema:"number of facts to skip"`
+}
+
+type forgetInput struct {
+	entityArg
+	FactID string `json:"fact_id,omitempty" jsonschema:"id of the fact to delete; omit to forget the whole entity"`
+}
+
+type summariesInput struct {
+	entityArg
+	Limit int `json:"limit,omitempty" jsonschema:"maximum number of conversations, default 5"`
+}
+
+type factOutput struct {
+	Fact Fact `json:"fact"`
+}
+
+type factsOutput struct {
+	Facts []Fact `json:"facts"`
+}
+
+type forgetOutput struct {
+	Forgotten string `json:"forgotten"`
+}
+
+type summariesOutput struct {
+	Conversations []ConversationSummary `json:"conversations"`
+}
+
+// entity resolves the entity a call is about.
+func (s *server) entity(arg entityArg) (string, error) {
+	switch {
+	case s.opts.Entity != "" && arg.EntityID != "" && arg.EntityID != s.opts.Entity:
+		return "", fmt.Errorf("this server only serves entity %q", s.opts.Entity)
+	case s.opts.Entity != "":
+		return s.opts.Entity, nil
+	case arg.EntityID == "":
+		return "", errors.New("entity_id is required")
+	case len(arg.EntityID) > 100:
+		return "", errors.New("entity_id is limited to 100 characters")
+	}
+	return arg.EntityID, nil
+}
+
+func (s *server) remember(ctx context.Context, _ *mcp.CallToolRequest, in rememberInput) (*mcp.CallToolResult, factOutput, error) {
+	entity, err := s.entity(in.entityArg)
+	if err != nil {
+		return nil, factOutput{}, err
+	}
+	f, err := s.m.Scoped(entity, s.opts.Process, uuid.Nil).Remember(ctx, in.Content)
+	if err != nil {
+		return nil, factOutput{}, err
+	}
+	return nil, factOutput{Fact: factFromInfo(f)}, nil
+}
+
+func (s *server) recall(_ context.Context, _ *mcp.CallToolRequest, in recallInput) (*mcp.CallToolResult, factsOutput, error) {
+	entity, err := s.entity(in.entityArg)
+	if err != nil {
+		return nil, factsOutput{}, err
+	}
+	if strings.TrimSpace(in.Query) == "" {
+		return nil, factsOutput{}, errors.New("query is required")
+	}
+	facts, err := s.m.Scoped(entity, "", uuid.Nil).Recall(in.Query, in.Limit)
+	if err != nil {
+		return nil, factsOutput{}, err
+	}
+	out := factsOutput{Facts: []Fact{}}
+	for _, f := range facts {
+		out.Facts = append(out.Facts, Fact{
+			Content:       f.Content,
+			Score:         f.Score,
+			NumTimes:      f.NumTimes,
+			LastMentioned: f.DateLastTime,
+		})
+	}
+	return nil, out, nil
+}
+
+func (s *server) listFacts(_ context.Context, _ *mcp.CallToolRequest, in listFactsInput) (*mcp.CallToolResult, factsOutput, error) {
+	entity, err := s.entity(in.entityArg)
+	if err != nil {
+		return nil, factsOutput{}, err
+	}
+	browse, err := s.m.Browse()
+	if err != nil {
+		return nil, factsOutput{}, err
+	}
+	facts, err := browse.ListFacts(storage.FactFilter{EntityID: entity, Query: in.Query},
+		storage.ListOptions{Limit: in.Limit, Offset: in.Offset})
+	if err != nil {
+		return nil, factsOutput{}, err
+	}
+	out := factsOutput{Facts: []Fact{}}
+	for _, f := range facts {
+		out.Facts = append(out.Facts, factFromInfo(f))
+	}
+	return nil, out, nil
+}
+
+func (s *server) forget(_ context.Context, _ *mcp.CallToolRequest, in forgetInput) (*mcp.CallToolResult, forgetOutput, error) {
+	entity, err := s.entity(in.entityArg)
+	if err != nil {
+		return nil, forgetOutput{}, err
+	}
+	if in.FactID == "" {
+		if err := s.m.Forget(entity); err != nil {
+			return nil, forgetOutput{}, notFound(err, "entity "+entity)
+		}
+		return nil, forgetOutput{Forgotten: "entity " + entity}, nil
+	}
+	browse, err := s.m.Browse()
+	if err != nil {
+		return nil, forgetOutput{}, err
+	}
+	// only delete facts of the entity the call is about
+	f, err := browse.GetFact(in.FactID)
+	if err == nil && f.EntityID != entity {
+		err = storage.ErrNotFound
+	}
+	if err == nil {
+		err = browse.DeleteFact(in.FactID)
+	}
+	if err != nil {
+		return nil, forgetOutput{}, notFound(err, "fact "+in.FactID)
+	}
+	return nil, forgetOutput{Forgotten: "fact " + in.FactID}, nil
+}
+
+func (s *server) conversationSummaries(_ context.Context, _ *mcp.CallToolRequest, in summariesInput) (*mcp.CallToolResult, summariesOutput, error) {
+	entity, err := s.entity(in.entityArg)
+	if err != nil {
+		return nil, summariesOutput{}, err
+	}
+	limit := in.Limit
+	if limit <= 0 {
+		limit = defaultSummaries
+	}
+	convs, err := s.summaries(entity, limit)
+	if err != nil {
+		return nil, summariesOutput{}, notFound(err, "entity "+entity)
+	}
+	return nil, summariesOutput{Conversations: convs}, nil
+}
+
+func (s *server) summaries(entity string, limit int) ([]ConversationSummary, error) {
+	convs, err := s.m.RecentConversations(entity, limit)
+	if err != nil {
+		return nil, err
+	}
+	out := []ConversationSummary{}
+	for _, c := range convs {
+		cs := ConversationSummary{ID: c.UUID, SessionID: c.SessionID, Summary: c.Summary, LastActive: c.DateCreated}
+		if c.DateUpdated != nil {
+			cs.LastActive = *c.DateUpdated
+		}
+		out = append(out, cs)
+	}
+	return out, nil
+}
+
+func (s *server) readProfile(_ context.Context, req *mcp.ReadResourceRequest) (*mcp.ReadResourceResult, error) {
+	uri := req.Params.URI
+	entity, ok := entityFromURI(uri)
+	if !ok {
+		return nil, mcp.ResourceNotFoundError(uri)
+	}
+	if _, err := s.entity(entityArg{EntityID: entity}); err != nil {
+		return nil, mcp.ResourceNotFoundError(uri)
+	}
+	browse, err := s.m.Browse()
+	if err != nil {
+		return nil, err
+	}
+	info, err := browse.GetEntity(entity)
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil, mcp.ResourceNotFoundError(uri)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	p := Profile{EntityID: entity, DateCreated: info.DateCreated, Facts: []Fact{}}
+	var facts []storage.FactInfo
+	for offset := 0; ; offset += storage.DefaultListLimit {
+		page, err := browse.ListFacts(storage.FactFilter{EntityID: entity},
+			storage.ListOptions{Limit: storage.DefaultListLimit, Offset: offset})
+		if err != nil {
+			return nil, err
+		}
+		facts = append(facts, page...)
+		if len(page) < storage.DefaultListLimit {
+			break
+		}
+	}
+	p.FactCount = len(facts)
+	sort.SliceStable(facts, func(i, j int) bool {
+		if facts[i].NumTimes != facts[j].NumTimes {
+			return facts[i].NumTimes > facts[j].NumTimes
+		}
+		return facts[i].DateLastTime.After(facts[j].DateLastTime)
+	})
+	for _, f := range facts[:min(len(facts), profileFactLimit)] {
+		p.Facts = append(p.Facts, factFromInfo(f))
+	}
+	if p.Conversations, err = s.summaries(entity, profileSummaryLimit); err != nil {
+		return nil, err
+	}
+
+	b, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return &mcp.ReadResourceResult{Contents: []*mcp.ResourceContents{
+		{URI: uri, MIMEType: "application/json", Text: string(b)},
+	}}, nil
+}
+
+func profileURI(entity string) string {
+	return profilePrefix + url.PathEscape(entity) + profileSuffix
+}
+
+func entityFromURI(uri string) (string, bool) {
+	rest, ok := strings.CutPrefix(uri, profilePrefix)
+	if !ok {
+		return "", false
+	}
+	escaped, ok := strings.CutSuffix(rest, profileSuffix)
+	if !ok || escaped == "" || strings.Contains(escaped, "/") {
+		return "", false
+	}
+	entity, err := url.PathUnescape(escaped)
+	return entity, err == nil
+}
+
+func factFromInfo(f storage.FactInfo) Fact {
+	return Fact{ID: f.UUID, Content: f.Content, NumTimes: f.NumTimes, LastMentioned: f.DateLastTime}
+}
+
+// notFound words storage.ErrNotFound for an agent; other errors pass through.
+func notFound(err error, what string) error {
+	if errors.Is(err, storage.ErrNotFound) {
+		return fmt.Errorf("%s not found", what)
+	}
+	return err
+}
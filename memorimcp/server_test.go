@@ -0,0 +1,162 @@
+package memorimcp_test
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	_ "modernc.org/sqlite"
+
+	"memorigo/memori"
+	"memorigo/memorimcp"
+)
+
+func TestServer(t *testing.T) {
+	db, err := sql.Open("sqlite", "file:memori_mcp?mode=memory&cache=shared")
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	defer db.Close()
+	m := memori.New(memori.WithStorageConn(db))
+	if err := m.Storage.Build(); err != nil {
+		t.Fatalf("migrate/build: %v", err)
+	}
+	ctx := context.Background()
+	if err := memori.NewWriter(m.Scoped("alice", "chat", uuid.Nil)).Execute(ctx, memori.ConversationPayload{
+		Messages: []memori.Message{{Role: "user", Content: "Book me a table for two"}},
+		Response: &memori.Message{Role: "assistant", Content: "Done."},
+	}); err != nil {
+		t.Fatalf("seed: %v", err)
+	}
+
+	connect := func(opts *memorimcp.Options) *mcp.ClientSession {
+		t.Helper()
+		st, ct := mcp.NewInMemoryTransports()
+		if _, err := memorimcp.NewServer(m, opts).Connect(ctx, st, nil); err != nil {
+			t.Fatalf("server connect: %v", err)
+		}
+		cs, err := mcp.NewClient(&mcp.Implementation{Name: "test"}, nil).Connect(ctx, ct, nil)
+		if err != nil {
+			t.Fatalf("client connect: %v", err)
+		}
+		t.Cleanup(func() { cs.Close() })
+		return cs
+	}
+	call := func(cs *mcp.ClientSession, tool string, args map[string]any, out any) *mcp.CallToolResult {
+		t.Helper()
+		res, err := cs.CallTool(ctx, &mcp.CallToolParams{Name: tool, Arguments: args})
+		if err != nil {
+			t.Fatalf("%s: %v", tool, err)
+		}
+		if out != nil && !res.IsError {
+			b, _ := json.Marshal(res.StructuredContent)
+			if err := json.Unmarshal(b, out); err != nil {
+				t.Fatalf("%s: decode %s: %v", tool, b, err)
+			}
+		}
+		return res
+	}
+	type facts struct {
+		Facts []memorimcp.Fact `json:"facts"`
+	}
+
+	cs := connect(nil)
+	var tools []string
+	for tool, err := range cs.Tools(ctx, nil) {
+		if err != nil {
+			t.Fatalf("list tools: %v", err)
+		}
+		tools = append(tools, tool.Name)
+	}
+	if got := strings.Join(tools, ","); got != "forget,get_conversation_summary,list_facts,recall,remember" {
+		t.Fatalf("tools = %s", got)
+	}
+
+	var remembered struct{ Fact memorimcp.Fact }
+	call(cs, "remember", map[string]any{"entity_id": "alice", "content": "Alice is allergic to peanuts"}, &remembered)
+	if remembered.Fact.ID == "" || remembered.Fact.NumTimes != 1 {
+		t.Fatalf("remember = %+v", remembered)
+	}
+	call(cs, "remember", map[string]any{"entity_id": "alice", "content": "Alice is allergic to peanuts"}, &remembered)
+	if remembered.Fact.NumTimes != 2 {
+		t.Fatalf("remember again = %+v", remembered)
+	}
+	if res := call(cs, "remember", map[string]any{"content": "no entity"}, nil); !res.IsError {
+		t.Fatalf("remember without entity_id succeeded")
+	}
+
+	var recalled facts
+	call(cs, "recall", map[string]any{"entity_id": "alice", "query": "allergic to peanuts", "limit": 1}, &recalled)
+	if len(recalled.Facts) != 1 || recalled.Facts[0].Content != "Alice is allergic to peanuts" {
+		t.Fatalf("recall = %+v", recalled)
+	}
+	var listed facts
+	call(cs, "list_facts", map[string]any{"entity_id": "alice", "query": "PEANUT"}, &listed)
+	if len(listed.Facts) != 1 || listed.Facts[0].ID != remembered.Fact.ID {
+		t.Fatalf("list_facts = %+v", listed)
+	}
+
+	var summaries struct {
+		Conversations []memorimcp.ConversationSummary `json:"conversations"`
+	}
+	call(cs, "get_conversation_summary", map[string]any{"entity_id": "alice"}, &summaries)
+	if len(summaries.Conversations) != 1 {
+		t.Fatalf("summaries = %+v", summaries)
+	}
+
+	res, err := cs.ReadResource(ctx, &mcp.ReadResourceParams{URI: "memori://entities/alice/profile"})
+	if err != nil {
+		t.Fatalf("read profile: %v", err)
+	}
+	var profile memorimcp.Profile
+	if err := json.Unmarshal([]byte(res.Contents[0].Text), &profile); err != nil {
+		t.Fatalf("decode profile: %v", err)
+	}
+	if profile.EntityID != "alice" || profile.FactCount < 1 || profile.Facts[0].Content != "Alice is allergic to peanuts" {
+		t.Fatalf("profile = %+v", profile)
+	}
+	if _, err := cs.ReadResource(ctx, &mcp.ReadResourceParams{URI: "memori://entities/nobody/profile"}); err == nil {
+		t.Fatalf("profile of an unknown entity was read")
+	}
+
+	// a pinned server defaults to its entity and refuses others
+	pinned := connect(&memorimcp.Options{Entity: "alice"})
+	call(pinned, "list_facts", nil, &listed)
+	if len(listed.Facts) == 0 {
+		t.Fatalf("pinned list_facts = %+v", listed)
+	}
+	if res := call(pinned, "recall", map[string]any{"entity_id": "bob", "query": "x"}, nil); !res.IsError {
+		t.Fatalf("pinned server served another entity")
+	}
+	var resources []string
+	for r, err := range pinned.Resources(ctx, nil) {
+		if err != nil {
+			t.Fatalf("list resources: %v", err)
+		}
+		resources = append(resources, r.URI)
+	}
+	if len(resources) != 1 || resources[0] != "memori://entities/alice/profile" {
+		t.Fatalf("pinned resources = %v", resources)
+	}
+
+	if res := call(cs, "forget", map[string]any{"entity_id": "bob", "fact_id": remembered.Fact.ID}, nil); !res.IsError {
+		t.Fatalf("forgot a fact of another entity")
+	}
+	call(cs, "forget", map[string]any{"entity_id": "alice", "fact_id": remembered.Fact.ID}, nil)
+	call(cs, "list_facts", map[string]any{"entity_id": "alice", "query": "peanut"}, &listed)
+	if len(listed.Facts) != 0 {
+		t.Fatalf("fact survived forget: %+v", listed)
+	}
+	var forgot struct{ Forgotten string }
+	call(cs, "forget", map[string]any{"entity_id": "alice"}, &forgot)
+	if forgot.Forgotten != "entity alice" {
+		t.Fatalf("forget entity = %+v", forgot)
+	}
+	if res := call(cs, "get_conversation_summary", map[string]any{"entity_id": "alice"}, nil); !res.IsError {
+		t.Fatalf("summaries of a forgotten entity")
+	}
+}
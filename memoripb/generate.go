@@ -0,0 +1,6 @@
+// Package memoripb holds the protobuf messages and gRPC stubs of the memori.v1
+// service defined in proto/memori/v1/memori.proto. The server is implemented
+// by package memorigrpc.
+package memoripb
+
+//go:generate protoc -I ../proto --go_out=.. --go_opt=module=memorigo --go-grpc_out=.. --go-grpc_opt=module=memorigo memori/v1/memori.proto
@@ -0,0 +1,1077 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        (unknown)
+// source: memori/v1/memori.proto
+
+// Memory operations of memorigo over gRPC. Regenerate the Go code with
+// `go generate ./memoripb` (requires protoc, protoc-gen-go and
+// protoc-gen-go-grpc).
+
+package memoripb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// Attribution identifies whose conversation is written. A missing session
+// id starts a new session.
+type Attribution struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	EntityId      string                 `protobuf:"bytes,1,opt,name=entity_id,json=entityId,proto3" json:"entity_id,omitempty"`
+	ProcessId     string                 `protobuf:"bytes,2,opt,name=process_id,json=processId,proto3" json:"process_id,omitempty"`
+	SessionId     string                 `protobuf:"bytes,3,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Attribution) Reset() {
+	*x = Attribution{}
+	mi := &file_memori_v1_memori_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Attribution) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Attribution) ProtoMessage() {}
+
+func (x *Attribution) ProtoReflect() protoreflect.Message {
+	mi := &file_memori_v1_memori_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Attribution.ProtoReflect.Descriptor instead.
+func (*Attribution) Descriptor() ([]byte, []int) {
+	return file_memori_v1_memori_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Attribution) GetEntityId() string {
+	if x != nil {
+		return x.EntityId
+	}
+	return ""
+}
+
+func (x *Attribution) GetProcessId() string {
+	if x != nil {
+		return x.ProcessId
+	}
+	return ""
+}
+
+func (x *Attribution) GetSessionId() string {
+	if x != nil {
+		return x.SessionId
+	}
+	return ""
+}
+
+type MessagePart struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Type          string                 `protobuf:"bytes,1,opt,name=type,proto3" json:"type,omitempty"`
+	Text          string                 `protobuf:"bytes,2,opt,name=text,proto3" json:"text,omitempty"`
+	Url           string                 `protobuf:"bytes,3,opt,name=url,proto3" json:"url,omitempty"`
+	Hash          string                 `protobuf:"bytes,4,opt,name=hash,proto3" json:"hash,omitempty"`
+	MimeType      string                 `protobuf:"bytes,5,opt,name=mime_type,json=mimeType,proto3" json:"mime_type,omitempty"`
+	Size          int64                  `protobuf:"varint,6,opt,name=size,proto3" json:"size,omitempty"`
+	Detail        string                 `protobuf:"bytes,7,opt,name=detail,proto3" json:"detail,omitempty"`
+	FileId        string                 `protobuf:"bytes,8,opt,name=file_id,json=fileId,proto3" json:"file_id,omitempty"`
+	Filename      string                 `protobuf:"bytes,9,opt,name=filename,proto3" json:"filename,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MessagePart) Reset() {
+	*x = MessagePart{}
+	mi := &file_memori_v1_memori_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MessagePart) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MessagePart) ProtoMessage() {}
+
+func (x *MessagePart) ProtoReflect() protoreflect.Message {
+	mi := &file_memori_v1_memori_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MessagePart.ProtoReflect.Descriptor instead.
+func (*MessagePart) Descriptor() ([]byte, []int) {
+	return file_memori_v1_memori_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *MessagePart) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *MessagePart) GetText() string {
+	if x != nil {
+		return x.Text
+	}
+	return ""
+}
+
+func (x *MessagePart) GetUrl() string {
+	if x != nil {
+		return x.Url
+	}
+	return ""
+}
+
+func (x *MessagePart) GetHash() string {
+	if x != nil {
+		return x.Hash
+	}
+	return ""
+}
+
+func (x *MessagePart) GetMimeType() string {
+	if x != nil {
+		return x.MimeType
+	}
+	return ""
+}
+
+func (x *MessagePart) GetSize() int64 {
+	if x != nil {
+		return x.Size
+	}
+	return 0
+}
+
+func (x *MessagePart) GetDetail() string {
+	if x != nil {
+		return x.Detail
+	}
+	return ""
+}
+
+func (x *MessagePart) GetFileId() string {
+	if x != nil {
+		return x.FileId
+	}
+	return ""
+}
+
+func (x *MessagePart) GetFilename() string {
+	if x != nil {
+		return x.Filename
+	}
+	return ""
+}
+
+type Message struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Role  string                 `protobuf:"bytes,1,opt,name=role,proto3" json:"role,omitempty"`
+	// empty, "text", "tool_call" or "tool_result"
+	Type          string         `protobuf:"bytes,2,opt,name=type,proto3" json:"type,omitempty"`
+	Content       string         `protobuf:"bytes,3,opt,name=content,proto3" json:"content,omitempty"`
+	Parts         []*MessagePart `protobuf:"bytes,4,rep,name=parts,proto3" json:"parts,omitempty"`
+	Truncated     bool           `protobuf:"varint,5,opt,name=truncated,proto3" json:"truncated,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Message) Reset() {
+	*x = Message{}
+	mi := &file_memori_v1_memori_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Message) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Message) ProtoMessage() {}
+
+func (x *Message) ProtoReflect() protoreflect.Message {
+	mi := &file_memori_v1_memori_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Message.ProtoReflect.Descriptor instead.
+func (*Message) Descriptor() ([]byte, []int) {
+	return file_memori_v1_memori_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *Message) GetRole() string {
+	if x != nil {
+		return x.Role
+	}
+	return ""
+}
+
+func (x *Message) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *Message) GetContent() string {
+	if x != nil {
+		return x.Content
+	}
+	return ""
+}
+
+func (x *Message) GetParts() []*MessagePart {
+	if x != nil {
+		return x.Parts
+	}
+	return nil
+}
+
+func (x *Message) GetTruncated() bool {
+	if x != nil {
+		return x.Truncated
+	}
+	return false
+}
+
+type TokenUsage struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	PromptTokens     int64                  `protobuf:"varint,1,opt,name=prompt_tokens,json=promptTokens,proto3" json:"prompt_tokens,omitempty"`
+	CompletionTokens int64                  `protobuf:"varint,2,opt,name=completion_tokens,json=completionTokens,proto3" json:"completion_tokens,omitempty"`
+	TotalTokens      int64                  `protobuf:"varint,3,opt,name=total_tokens,json=totalTokens,proto3" json:"total_tokens,omitempty"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *TokenUsage) Reset() {
+	*x = TokenUsage{}
+	mi := &file_memori_v1_memori_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TokenUsage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TokenUsage) ProtoMessage() {}
+
+func (x *TokenUsage) ProtoReflect() protoreflect.Message {
+	mi := &file_memori_v1_memori_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TokenUsage.ProtoReflect.Descriptor instead.
+func (*TokenUsage) Descriptor() ([]byte, []int) {
+	return file_memori_v1_memori_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *TokenUsage) GetPromptTokens() int64 {
+	if x != nil {
+		return x.PromptTokens
+	}
+	return 0
+}
+
+func (x *TokenUsage) GetCompletionTokens() int64 {
+	if x != nil {
+		return x.CompletionTokens
+	}
+	return 0
+}
+
+func (x *TokenUsage) GetTotalTokens() int64 {
+	if x != nil {
+		return x.TotalTokens
+	}
+	return 0
+}
+
+// ConversationPayload mirrors memori.ConversationPayload.
+type ConversationPayload struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Provider      string                 `protobuf:"bytes,1,opt,name=provider,proto3" json:"provider,omitempty"`
+	Model         string                 `protobuf:"bytes,2,opt,name=model,proto3" json:"model,omitempty"`
+	Messages      []*Message             `protobuf:"bytes,3,rep,name=messages,proto3" json:"messages,omitempty"`
+	Response      *Message               `protobuf:"bytes,4,opt,name=response,proto3" json:"response,omitempty"`
+	ToolCalls     []*Message             `protobuf:"bytes,5,rep,name=tool_calls,json=toolCalls,proto3" json:"tool_calls,omitempty"`
+	Usage         *TokenUsage            `protobuf:"bytes,6,opt,name=usage,proto3" json:"usage,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ConversationPayload) Reset() {
+	*x = ConversationPayload{}
+	mi := &file_memori_v1_memori_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ConversationPayload) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ConversationPayload) ProtoMessage() {}
+
+func (x *ConversationPayload) ProtoReflect() protoreflect.Message {
+	mi := &file_memori_v1_memori_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ConversationPayload.ProtoReflect.Descriptor instead.
+func (*ConversationPayload) Descriptor() ([]byte, []int) {
+	return file_memori_v1_memori_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *ConversationPayload) GetProvider() string {
+	if x != nil {
+		return x.Provider
+	}
+	return ""
+}
+
+func (x *ConversationPayload) GetModel() string {
+	if x != nil {
+		return x.Model
+	}
+	return ""
+}
+
+func (x *ConversationPayload) GetMessages() []*Message {
+	if x != nil {
+		return x.Messages
+	}
+	return nil
+}
+
+func (x *ConversationPayload) GetResponse() *Message {
+	if x != nil {
+		return x.Response
+	}
+	return nil
+}
+
+func (x *ConversationPayload) GetToolCalls() []*Message {
+	if x != nil {
+		return x.ToolCalls
+	}
+	return nil
+}
+
+func (x *ConversationPayload) GetUsage() *TokenUsage {
+	if x != nil {
+		return x.Usage
+	}
+	return nil
+}
+
+type WriteRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Attribution   *Attribution           `protobuf:"bytes,1,opt,name=attribution,proto3" json:"attribution,omitempty"`
+	Payload       *ConversationPayload   `protobuf:"bytes,2,opt,name=payload,proto3" json:"payload,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WriteRequest) Reset() {
+	*x = WriteRequest{}
+	mi := &file_memori_v1_memori_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WriteRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WriteRequest) ProtoMessage() {}
+
+func (x *WriteRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_memori_v1_memori_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WriteRequest.ProtoReflect.Descriptor instead.
+func (*WriteRequest) Descriptor() ([]byte, []int) {
+	return file_memori_v1_memori_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *WriteRequest) GetAttribution() *Attribution {
+	if x != nil {
+		return x.Attribution
+	}
+	return nil
+}
+
+func (x *WriteRequest) GetPayload() *ConversationPayload {
+	if x != nil {
+		return x.Payload
+	}
+	return nil
+}
+
+type WriteResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// the session written to, generated when the request had none
+	SessionId     string `protobuf:"bytes,1,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WriteResponse) Reset() {
+	*x = WriteResponse{}
+	mi := &file_memori_v1_memori_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WriteResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WriteResponse) ProtoMessage() {}
+
+func (x *WriteResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_memori_v1_memori_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WriteResponse.ProtoReflect.Descriptor instead.
+func (*WriteResponse) Descriptor() ([]byte, []int) {
+	return file_memori_v1_memori_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *WriteResponse) GetSessionId() string {
+	if x != nil {
+		return x.SessionId
+	}
+	return ""
+}
+
+type RecallRequest struct {
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	EntityId string                 `protobuf:"bytes,1,opt,name=entity_id,json=entityId,proto3" json:"entity_id,omitempty"`
+	Query    string                 `protobuf:"bytes,2,opt,name=query,proto3" json:"query,omitempty"`
+	// 0 uses the server's default
+	Limit         int32 `protobuf:"varint,3,opt,name=limit,proto3" json:"limit,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RecallRequest) Reset() {
+	*x = RecallRequest{}
+	mi := &file_memori_v1_memori_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RecallRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RecallRequest) ProtoMessage() {}
+
+func (x *RecallRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_memori_v1_memori_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RecallRequest.ProtoReflect.Descriptor instead.
+func (*RecallRequest) Descriptor() ([]byte, []int) {
+	return file_memori_v1_memori_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *RecallRequest) GetEntityId() string {
+	if x != nil {
+		return x.EntityId
+	}
+	return ""
+}
+
+func (x *RecallRequest) GetQuery() string {
+	if x != nil {
+		return x.Query
+	}
+	return ""
+}
+
+func (x *RecallRequest) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+type Fact struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Content       string                 `protobuf:"bytes,1,opt,name=content,proto3" json:"content,omitempty"`
+	Score         float64                `protobuf:"fixed64,2,opt,name=score,proto3" json:"score,omitempty"`
+	NumTimes      int64                  `protobuf:"varint,3,opt,name=num_times,json=numTimes,proto3" json:"num_times,omitempty"`
+	DateLastTime  *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=date_last_time,json=dateLastTime,proto3" json:"date_last_time,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Fact) Reset() {
+	*x = Fact{}
+	mi := &file_memori_v1_memori_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Fact) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Fact) ProtoMessage() {}
+
+func (x *Fact) ProtoReflect() protoreflect.Message {
+	mi := &file_memori_v1_memori_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Fact.ProtoReflect.Descriptor instead.
+func (*Fact) Descriptor() ([]byte, []int) {
+	return file_memori_v1_memori_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *Fact) GetContent() string {
+	if x != nil {
+		return x.Content
+	}
+	return ""
+}
+
+func (x *Fact) GetScore() float64 {
+	if x != nil {
+		return x.Score
+	}
+	return 0
+}
+
+func (x *Fact) GetNumTimes() int64 {
+	if x != nil {
+		return x.NumTimes
+	}
+	return 0
+}
+
+func (x *Fact) GetDateLastTime() *timestamppb.Timestamp {
+	if x != nil {
+		return x.DateLastTime
+	}
+	return nil
+}
+
+type RecallResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Facts         []*Fact                `protobuf:"bytes,1,rep,name=facts,proto3" json:"facts,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RecallResponse) Reset() {
+	*x = RecallResponse{}
+	mi := &file_memori_v1_memori_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RecallResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RecallResponse) ProtoMessage() {}
+
+func (x *RecallResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_memori_v1_memori_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RecallResponse.ProtoReflect.Descriptor instead.
+func (*RecallResponse) Descriptor() ([]byte, []int) {
+	return file_memori_v1_memori_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *RecallResponse) GetFacts() []*Fact {
+	if x != nil {
+		return x.Facts
+	}
+	return nil
+}
+
+type ForgetEntityRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	EntityId      string                 `protobuf:"bytes,1,opt,name=entity_id,json=entityId,proto3" json:"entity_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ForgetEntityRequest) Reset() {
+	*x = ForgetEntityRequest{}
+	mi := &file_memori_v1_memori_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ForgetEntityRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ForgetEntityRequest) ProtoMessage() {}
+
+func (x *ForgetEntityRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_memori_v1_memori_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ForgetEntityRequest.ProtoReflect.Descriptor instead.
+func (*ForgetEntityRequest) Descriptor() ([]byte, []int) {
+	return file_memori_v1_memori_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *ForgetEntityRequest) GetEntityId() string {
+	if x != nil {
+		return x.EntityId
+	}
+	return ""
+}
+
+type ForgetEntityResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ForgetEntityResponse) Reset() {
+	*x = ForgetEntityResponse{}
+	mi := &file_memori_v1_memori_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ForgetEntityResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ForgetEntityResponse) ProtoMessage() {}
+
+func (x *ForgetEntityResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_memori_v1_memori_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ForgetEntityResponse.ProtoReflect.Descriptor instead.
+func (*ForgetEntityResponse) Descriptor() ([]byte, []int) {
+	return file_memori_v1_memori_proto_rawDescGZIP(), []int{11}
+}
+
+type IngestResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Written       int64                  `protobuf:"varint,1,opt,name=written,proto3" json:"written,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *IngestResponse) Reset() {
+	*x = IngestResponse{}
+	mi := &file_memori_v1_memori_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *IngestResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*IngestResponse) ProtoMessage() {}
+
+func (x *IngestResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_memori_v1_memori_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use IngestResponse.ProtoReflect.Descriptor instead.
+func (*IngestResponse) Descriptor() ([]byte, []int) {
+	return file_memori_v1_memori_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *IngestResponse) GetWritten() int64 {
+	if x != nil {
+		return x.Written
+	}
+	return 0
+}
+
+type WatchFactsRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// empty watches all entities
+	EntityId      string `protobuf:"bytes,1,opt,name=entity_id,json=entityId,proto3" json:"entity_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WatchFactsRequest) Reset() {
+	*x = WatchFactsRequest{}
+	mi := &file_memori_v1_memori_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchFactsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchFactsRequest) ProtoMessage() {}
+
+func (x *WatchFactsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_memori_v1_memori_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchFactsRequest.ProtoReflect.Descriptor instead.
+func (*WatchFactsRequest) Descriptor() ([]byte, []int) {
+	return file_memori_v1_memori_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *WatchFactsRequest) GetEntityId() string {
+	if x != nil {
+		return x.EntityId
+	}
+	return ""
+}
+
+type FactEvent struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	EntityId      string                 `protobuf:"bytes,1,opt,name=entity_id,json=entityId,proto3" json:"entity_id,omitempty"`
+	Content       string                 `protobuf:"bytes,2,opt,name=content,proto3" json:"content,omitempty"`
+	Time          *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=time,proto3" json:"time,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FactEvent) Reset() {
+	*x = FactEvent{}
+	mi := &file_memori_v1_memori_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FactEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FactEvent) ProtoMessage() {}
+
+func (x *FactEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_memori_v1_memori_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FactEvent.ProtoReflect.Descriptor instead.
+func (*FactEvent) Descriptor() ([]byte, []int) {
+	return file_memori_v1_memori_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *FactEvent) GetEntityId() string {
+	if x != nil {
+		return x.EntityId
+	}
+	return ""
+}
+
+func (x *FactEvent) GetContent() string {
+	if x != nil {
+		return x.Content
+	}
+	return ""
+}
+
+func (x *FactEvent) GetTime() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Time
+	}
+	return nil
+}
+
+var File_memori_v1_memori_proto protoreflect.FileDescriptor
+
+const file_memori_v1_memori_proto_rawDesc = "" +
+	"\n" +
+	"\x16memori/v1/memori.proto\x12\tmemori.v1\x1a\x1fgoogle/protobuf/timestamp.proto\"h\n" +
+	"\vAttribution\x12\x1b\n" +
+	"\tentity_id\x18\x01 \x01(\tR\bentityId\x12\x1d\n" +
+	"\n" +
+	"process_id\x18\x02 \x01(\tR\tprocessId\x12\x1d\n" +
+	"\n" +
+	"session_id\x18\x03 \x01(\tR\tsessionId\"\xd9\x01\n" +
+	"\vMessagePart\x12\x12\n" +
+	"\x04type\x18\x01 \x01(\tR\x04type\x12\x12\n" +
+	"\x04text\x18\x02 \x01(\tR\x04text\x12\x10\n" +
+	"\x03url\x18\x03 \x01(\tR\x03url\x12\x12\n" +
+	"\x04hash\x18\x04 \x01(\tR\x04hash\x12\x1b\n" +
+	"\tmime_type\x18\x05 \x01(\tR\bmimeType\x12\x12\n" +
+	"\x04size\x18\x06 \x01(\x03R\x04size\x12\x16\n" +
+	"\x06detail\x18\a \x01(\tR\x06detail\x12\x17\n" +
+	"\afile_id\x18\b \x01(\tR\x06fileId\x12\x1a\n" +
+	"\bfilename\x18\t \x01(\tR\bfilename\"\x97\x01\n" +
+	"\aMessage\x12\x12\n" +
+	"\x04role\x18\x01 \x01(\tR\x04role\x12\x12\n" +
+	"\x04type\x18\x02 \x01(\tR\x04type\x12\x18\n" +
+	"\acontent\x18\x03 \x01(\tR\acontent\x12,\n" +
+	"\x05parts\x18\x04 \x03(\v2\x16.memori.v1.MessagePartR\x05parts\x12\x1c\n" +
+	"\ttruncated\x18\x05 \x01(\bR\ttruncated\"\x81\x01\n" +
+	"\n" +
+	"TokenUsage\x12#\n" +
+	"\rprompt_tokens\x18\x01 \x01(\x03R\fpromptTokens\x12+\n" +
+	"\x11completion_tokens\x18\x02 \x01(\x03R\x10completionTokens\x12!\n" +
+	"\ftotal_tokens\x18\x03 \x01(\x03R\vtotalTokens\"\x87\x02\n" +
+	"\x13ConversationPayload\x12\x1a\n" +
+	"\bprovider\x18\x01 \x01(\tR\bprovider\x12\x14\n" +
+	"\x05model\x18\x02 \x01(\tR\x05model\x12.\n" +
+	"\bmessages\x18\x03 \x03(\v2\x12.memori.v1.MessageR\bmessages\x12.\n" +
+	"\bresponse\x18\x04 \x01(\v2\x12.memori.v1.MessageR\bresponse\x121\n" +
+	"\n" +
+	"tool_calls\x18\x05 \x03(\v2\x12.memori.v1.MessageR\ttoolCalls\x12+\n" +
+	"\x05usage\x18\x06 \x01(\v2\x15.memori.v1.TokenUsageR\x05usage\"\x82\x01\n" +
+	"\fWriteRequest\x128\n" +
+	"\vattribution\x18\x01 \x01(\v2\x16.memori.v1.AttributionR\vattribution\x128\n" +
+	"\apayload\x18\x02 \x01(\v2\x1e.memori.v1.ConversationPayloadR\apayload\".\n" +
+	"\rWriteResponse\x12\x1d\n" +
+	"\n" +
+	"session_id\x18\x01 \x01(\tR\tsessionId\"X\n" +
+	"\rRecallRequest\x12\x1b\n" +
+	"\tentity_id\x18\x01 \x01(\tR\bentityId\x12\x14\n" +
+	"\x05query\x18\x02 \x01(\tR\x05query\x12\x14\n" +
+	"\x05limit\x18\x03 \x01(\x05R\x05limit\"\x95\x01\n" +
+	"\x04Fact\x12\x18\n" +
+	"\acontent\x18\x01 \x01(\tR\acontent\x12\x14\n" +
+	"\x05score\x18\x02 \x01(\x01R\x05score\x12\x1b\n" +
+	"\tnum_times\x18\x03 \x01(\x03R\bnumTimes\x12@\n" +
+	"\x0edate_last_time\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\fdateLastTime\"7\n" +
+	"\x0eRecallResponse\x12%\n" +
+	"\x05facts\x18\x01 \x03(\v2\x0f.memori.v1.FactR\x05facts\"2\n" +
+	"\x13ForgetEntityRequest\x12\x1b\n" +
+	"\tentity_id\x18\x01 \x01(\tR\bentityId\"\x16\n" +
+	"\x14ForgetEntityResponse\"*\n" +
+	"\x0eIngestResponse\x12\x18\n" +
+	"\awritten\x18\x01 \x01(\x03R\awritten\"0\n" +
+	"\x11WatchFactsRequest\x12\x1b\n" +
+	"\tentity_id\x18\x01 \x01(\tR\bentityId\"r\n" +
+	"\tFactEvent\x12\x1b\n" +
+	"\tentity_id\x18\x01 \x01(\tR\bentityId\x12\x18\n" +
+	"\acontent\x18\x02 \x01(\tR\acontent\x12.\n" +
+	"\x04time\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\x04time2\xdf\x02\n" +
+	"\rMemoriService\x12:\n" +
+	"\x05Write\x12\x17.memori.v1.WriteRequest\x1a\x18.memori.v1.WriteResponse\x12=\n" +
+	"\x06Recall\x12\x18.memori.v1.RecallRequest\x1a\x19.memori.v1.RecallResponse\x12O\n" +
+	"\fForgetEntity\x12\x1e.memori.v1.ForgetEntityRequest\x1a\x1f.memori.v1.ForgetEntityResponse\x12>\n" +
+	"\x06Ingest\x12\x17.memori.v1.WriteRequest\x1a\x19.memori.v1.IngestResponse(\x01\x12B\n" +
+	"\n" +
+	"WatchFacts\x12\x1c.memori.v1.WatchFactsRequest\x1a\x14.memori.v1.FactEvent0\x01B\x1cZ\x1amemorigo/memoripb;memoripbb\x06proto3"
+
+var (
+	file_memori_v1_memori_proto_rawDescOnce sync.Once
+	file_memori_v1_memori_proto_rawDescData []byte
+)
+
+func file_memori_v1_memori_proto_rawDescGZIP() []byte {
+	file_memori_v1_memori_proto_rawDescOnce.Do(func() {
+		file_memori_v1_memori_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_memori_v1_memori_proto_rawDesc), len(file_memori_v1_memori_proto_rawDesc)))
+	})
+	return file_memori_v1_memori_proto_rawDescData
+}
+
+var file_memori_v1_memori_proto_msgTypes = make([]protoimpl.MessageInfo, 15)
+var file_memori_v1_memori_proto_goTypes = []any{
+	(*Attribution)(nil),           // 0: memori.v1.Attribution
+	(*MessagePart)(nil),           // 1: memori.v1.MessagePart
+	(*Message)(nil),               // 2: memori.v1.Message
+	(*TokenUsage)(nil),            // 3: memori.v1.TokenUsage
+	(*ConversationPayload)(nil),   // 4: memori.v1.ConversationPayload
+	(*WriteRequest)(nil),          // 5: memori.v1.WriteRequest
+	(*WriteResponse)(nil),         // 6: memori.v1.WriteResponse
+	(*RecallRequest)(nil),         // 7: memori.v1.RecallRequest
+	(*Fact)(nil),                  // 8: memori.v1.Fact
+	(*RecallResponse)(nil),        // 9: memori.v1.RecallResponse
+	(*ForgetEntityRequest)(nil),   // 10: memori.v1.ForgetEntityRequest
+	(*ForgetEntityResponse)(nil),  // 11: memori.v1.ForgetEntityResponse
+	(*IngestResponse)(nil),        // 12: memori.v1.IngestResponse
+	(*WatchFactsRequest)(nil),     // 13: memori.v1.WatchFactsRequest
+	(*FactEvent)(nil),             // 14: memori.v1.FactEvent
+	(*timestamppb.Timestamp)(nil), // 15: google.protobuf.Timestamp
+}
+var file_memori_v1_memori_proto_depIdxs = []int32{
+	1,  // 0: memori.v1.Message.parts:type_name -> memori.v1.MessagePart
+	2,  // 1: memori.v1.ConversationPayload.messages:type_name -> memori.v1.Message
+	2,  // 2: memori.v1.ConversationPayload.response:type_name -> memori.v1.Message
+	2,  // 3: memori.v1.ConversationPayload.tool_calls:type_name -> memori.v1.Message
+	3,  // 4: memori.v1.ConversationPayload.usage:type_name -> memori.v1.TokenUsage
+	0,  // 5: memori.v1.WriteRequest.attribution:type_name -> memori.v1.Attribution
+	4,  // 6: memori.v1.WriteRequest.payload:type_name -> memori.v1.ConversationPayload
+	15, // 7: memori.v1.Fact.date_last_time:type_name -> google.protobuf.Timestamp
+	8,  // 8: memori.v1.RecallResponse.facts:type_name -> memori.v1.Fact
+	15, // 9: memori.v1.FactEvent.time:type_name -> google.protobuf.Timestamp
+	5,  // 10: memori.v1.MemoriService.Write:input_type -> memori.v1.WriteRequest
+	7,  // 11: memori.v1.MemoriService.Recall:input_type -> memori.v1.RecallRequest
+	10, // 12: memori.v1.MemoriService.ForgetEntity:input_type -> memori.v1.ForgetEntityRequest
+	5,  // 13: memori.v1.MemoriService.Ingest:input_type -> memori.v1.WriteRequest
+	13, // 14: memori.v1.MemoriService.WatchFacts:input_type -> memori.v1.WatchFactsRequest
+	6,  // 15: memori.v1.MemoriService.Write:output_type -> memori.v1.WriteResponse
+	9,  // 16: memori.v1.MemoriService.Recall:output_type -> memori.v1.RecallResponse
+	11, // 17: memori.v1.MemoriService.ForgetEntity:output_type -> memori.v1.ForgetEntityResponse
+	12, // 18: memori.v1.MemoriService.Ingest:output_type -> memori.v1.IngestResponse
+	14, // 19: memori.v1.MemoriService.WatchFacts:output_type -> memori.v1.FactEvent
+	15, // [15:20] is the sub-list for method output_type
+	10, // [10:15] is the sub-list for method input_type
+	10, // [10:10] is the sub-list for extension type_name
+	10, // [10:10] is the sub-list for extension extendee
+	0,  // [0:10] is the sub-list for field type_name
+}
+
+func init() { file_memori_v1_memori_proto_init() }
+func file_memori_v1_memori_proto_init() {
+	if File_memori_v1_memori_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_memori_v1_memori_proto_rawDesc), len(file_memori_v1_memori_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   15,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_memori_v1_memori_proto_goTypes,
+		DependencyIndexes: file_memori_v1_memori_proto_depIdxs,
+		MessageInfos:      file_memori_v1_memori_proto_msgTypes,
+	}.Build()
+	File_memori_v1_memori_proto = out.File
+	file_memori_v1_memori_proto_goTypes = nil
+	file_memori_v1_memori_proto_depIdxs = nil
+}
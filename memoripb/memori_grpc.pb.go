@@ -0,0 +1,292 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: memori/v1/memori.proto
+
+// Memory operations of memorigo over gRPC. Regenerate the Go code with
+// `go generate ./memoripb` (requires protoc, protoc-gen-go and
+// protoc-gen-go-grpc).
+
+package memoripb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	MemoriService_Write_FullMethodName        = "/memori.v1.MemoriService/Write"
+	MemoriService_Recall_FullMethodName       = "/memori.v1.MemoriService/Recall"
+	MemoriService_ForgetEntity_FullMethodName = "/memori.v1.MemoriService/ForgetEntity"
+	MemoriService_Ingest_FullMethodName       = "/memori.v1.MemoriService/Ingest"
+	MemoriService_WatchFacts_FullMethodName   = "/memori.v1.MemoriService/WatchFacts"
+)
+
+// MemoriServiceClient is the client API for MemoriService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type MemoriServiceClient interface {
+	// Write persists one conversation exchange and queues it for augmentation.
+	Write(ctx context.Context, in *WriteRequest, opts ...grpc.CallOption) (*WriteResponse, error)
+	// Recall ranks an entity's facts by semantic similarity to a query.
+	Recall(ctx context.Context, in *RecallRequest, opts ...grpc.CallOption) (*RecallResponse, error)
+	// ForgetEntity deletes an entity and everything stored about it.
+	ForgetEntity(ctx context.Context, in *ForgetEntityRequest, opts ...grpc.CallOption) (*ForgetEntityResponse, error)
+	// Ingest writes a stream of exchanges, e.g. to import history. It stops at
+	// the first failing request with an error naming its index; the requests
+	// before it stay written.
+	Ingest(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[WriteRequest, IngestResponse], error)
+	// WatchFacts streams facts as augmentation writes them, until the client
+	// cancels. The response headers are sent once the watch is active. Events
+	// are dropped for a client that falls too far behind.
+	WatchFacts(ctx context.Context, in *WatchFactsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[FactEvent], error)
+}
+
+type memoriServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewMemoriServiceClient(cc grpc.ClientConnInterface) MemoriServiceClient {
+	return &memoriServiceClient{cc}
+}
+
+func (c *memoriServiceClient) Write(ctx context.Context, in *WriteRequest, opts ...grpc.CallOption) (*WriteResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(WriteResponse)
+	err := c.cc.Invoke(ctx, MemoriService_Write_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *memoriServiceClient) Recall(ctx context.Context, in *RecallRequest, opts ...grpc.CallOption) (*RecallResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RecallResponse)
+	err := c.cc.Invoke(ctx, MemoriService_Recall_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *memoriServiceClient) ForgetEntity(ctx context.Context, in *ForgetEntityRequest, opts ...grpc.CallOption) (*ForgetEntityResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ForgetEntityResponse)
+	err := c.cc.Invoke(ctx, MemoriService_ForgetEntity_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *memoriServiceClient) Ingest(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[WriteRequest, IngestResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &MemoriService_ServiceDesc.Streams[0], MemoriService_Ingest_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[WriteRequest, IngestResponse]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type MemoriService_IngestClient = grpc.ClientStreamingClient[WriteRequest, IngestResponse]
+
+func (c *memoriServiceClient) WatchFacts(ctx context.Context, in *WatchFactsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[FactEvent], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &MemoriService_ServiceDesc.Streams[1], MemoriService_WatchFacts_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[WatchFactsRequest, FactEvent]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type MemoriService_WatchFactsClient = grpc.ServerStreamingClient[FactEvent]
+
+// MemoriServiceServer is the server API for MemoriService service.
+// All implementations must embed UnimplementedMemoriServiceServer
+// for forward compatibility.
+type MemoriServiceServer interface {
+	// Write persists one conversation exchange and queues it for augmentation.
+	Write(context.Context, *WriteRequest) (*WriteResponse, error)
+	// Recall ranks an entity's facts by semantic similarity to a query.
+	Recall(context.Context, *RecallRequest) (*RecallResponse, error)
+	// ForgetEntity deletes an entity and everything stored about it.
+	ForgetEntity(context.Context, *ForgetEntityRequest) (*ForgetEntityResponse, error)
+	// Ingest writes a stream of exchanges, e.g. to import history. It stops at
+	// the first failing request with an error naming its index; the requests
+	// before it stay written.
+	Ingest(grpc.ClientStreamingServer[WriteRequest, IngestResponse]) error
+	// WatchFacts streams facts as augmentation writes them, until the client
+	// cancels. The response headers are sent once the watch is active. Events
+	// are dropped for a client that falls too far behind.
+	WatchFacts(*WatchFactsRequest, grpc.ServerStreamingServer[FactEvent]) error
+	mustEmbedUnimplementedMemoriServiceServer()
+}
+
+// UnimplementedMemoriServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedMemoriServiceServer struct{}
+
+func (UnimplementedMemoriServiceServer) Write(context.Context, *WriteRequest) (*WriteResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Write not implemented")
+}
+func (UnimplementedMemoriServiceServer) Recall(context.Context, *RecallRequest) (*RecallResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Recall not implemented")
+}
+func (UnimplementedMemoriServiceServer) ForgetEntity(context.Context, *ForgetEntityRequest) (*ForgetEntityResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ForgetEntity not implemented")
+}
+func (UnimplementedMemoriServiceServer) Ingest(grpc.ClientStreamingServer[WriteRequest, IngestResponse]) error {
+	return status.Errorf(codes.Unimplemented, "method Ingest not implemented")
+}
+func (UnimplementedMemoriServiceServer) WatchFacts(*WatchFactsRequest, grpc.ServerStreamingServer[FactEvent]) error {
+	return status.Errorf(codes.Unimplemented, "method WatchFacts not implemented")
+}
+func (UnimplementedMemoriServiceServer) mustEmbedUnimplementedMemoriServiceServer() {}
+func (UnimplementedMemoriServiceServer) testEmbeddedByValue()                       {}
+
+// UnsafeMemoriServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to MemoriServiceServer will
+// result in compilation errors.
+type UnsafeMemoriServiceServer interface {
+	mustEmbedUnimplementedMemoriServiceServer()
+}
+
+func RegisterMemoriServiceServer(s grpc.ServiceRegistrar, srv MemoriServiceServer) {
+	// If the following call pancis, it indicates UnimplementedMemoriServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&MemoriService_ServiceDesc, srv)
+}
+
+func _MemoriService_Write_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(WriteRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MemoriServiceServer).Write(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MemoriService_Write_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MemoriServiceServer).Write(ctx, req.(*WriteRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MemoriService_Recall_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RecallRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MemoriServiceServer).Recall(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MemoriService_Recall_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MemoriServiceServer).Recall(ctx, req.(*RecallRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MemoriService_ForgetEntity_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ForgetEntityRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MemoriServiceServer).ForgetEntity(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MemoriService_ForgetEntity_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MemoriServiceServer).ForgetEntity(ctx, req.(*ForgetEntityRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MemoriService_Ingest_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(MemoriServiceServer).Ingest(&grpc.GenericServerStream[WriteRequest, IngestResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type MemoriService_IngestServer = grpc.ClientStreamingServer[WriteRequest, IngestResponse]
+
+func _MemoriService_WatchFacts_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchFactsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(MemoriServiceServer).WatchFacts(m, &grpc.GenericServerStream[WatchFactsRequest, FactEvent]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type MemoriService_WatchFactsServer = grpc.ServerStreamingServer[FactEvent]
+
+// MemoriService_ServiceDesc is the grpc.ServiceDesc for MemoriService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var MemoriService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "memori.v1.MemoriService",
+	HandlerType: (*MemoriServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Write",
+			Handler:    _MemoriService_Write_Handler,
+		},
+		{
+			MethodName: "Recall",
+			Handler:    _MemoriService_Recall_Handler,
+		},
+		{
+			MethodName: "ForgetEntity",
+			Handler:    _MemoriService_ForgetEntity_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Ingest",
+			Handler:       _MemoriService_Ingest_Handler,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "WatchFacts",
+			Handler:       _MemoriService_WatchFacts_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "memori/v1/memori.proto",
+}
@@ -0,0 +1,162 @@
+// Package sse decodes text/event-stream bodies as specified by the WHATWG
+// HTML "Server-sent events" section: LF, CR and CRLF line endings, comments,
+// multi-line data fields, event types and ids, with no per-line length limit
+// beyond MaxEventSize.
+package sse
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"strconv"
+	"time"
+)
+
+// DefaultMaxEventSize is the default limit of a single line or event.
+const DefaultMaxEventSize = 16 << 20
+
+// ErrEventTooLarge is returned when a line or the data of an event exceeds
+// the decoder's MaxEventSize.
+var ErrEventTooLarge = errors.New("sse: event too large")
+
+var bom = []byte("\xEF\xBB\xBF")
+
+// Event is one dispatched event.
+type Event struct {
+	// Type is the event field, "message" when the event has none.
+	Type string
+	// Data is the data fields joined by newlines.
+	Data string
+	// ID is the last event id seen on the stream, which persists across
+	// events until another id field changes it.
+	ID string
+}
+
+// Decoder reads events from a stream.
+type Decoder struct {
+	// MaxEventSize bounds a line and the accumulated data of one event.
+	MaxEventSize int
+
+	r       *bufio.Reader
+	line    []byte
+	started bool // the leading byte order mark has been skipped
+	skipLF  bool // the previous line ended with CR; a following LF belongs to it
+
+	typ    string
+	data   []byte
+	lastID string
+	retry  time.Duration
+}
+
+func NewDecoder(r io.Reader) *Decoder {
+	return &Decoder{MaxEventSize: DefaultMaxEventSize, r: bufio.NewReader(r)}
+}
+
+// Retry returns the reconnection time last sent by the server, or 0.
+func (d *Decoder) Retry() time.Duration {
+	return d.retry
+}
+
+// Next returns the next event. It returns io.EOF when the stream ends; an
+// event not terminated by a blank line before the end is discarded, as the
+// specification requires.
+func (d *Decoder) Next() (Event, error) {
+	for {
+		line, err := d.readLine()
+		if err != nil {
+			return Event{}, err
+		}
+
+		if len(line) == 0 {
+			if len(d.data) == 0 {
+				d.typ = ""
+				continue
+			}
+			ev := Event{Type: d.typ, Data: string(d.data[:len(d.data)-1]), ID: d.lastID}
+			if ev.Type == "" {
+				ev.Type = "message"
+			}
+			d.typ, d.data = "", d.data[:0]
+			return ev, nil
+		}
+		if line[0] == ':' {
+			continue
+		}
+
+		field, value, _ := bytes.Cut(line, []byte(":"))
+		value = bytes.TrimPrefix(value, []byte(" "))
+		switch string(field) {
+		case "event":
+			d.typ = string(value)
+		case "data":
+			if len(d.data)+len(value)+1 > d.maxSize() {
+				return Event{}, ErrEventTooLarge
+			}
+			d.data = append(d.data, value...)
+			d.data = append(d.data, '\n')
+		case "id":
+			if bytes.IndexByte(value, 0) < 0 {
+				d.lastID = string(value)
+			}
+		case "retry":
+			if ms, err := strconv.ParseUint(string(value), 10, 63); err == nil {
+				d.retry = time.Duration(ms) * time.Millisecond
+			}
+		}
+	}
+}
+
+func (d *Decoder) maxSize() int {
+	if d.MaxEventSize <= 0 {
+		return DefaultMaxEventSize
+	}
+	return d.MaxEventSize
+}
+
+// readLine returns the next line without its terminator. The slice is only
+// valid until the next call. A final line without a terminator is dropped
+// and io.EOF returned.
+func (d *Decoder) readLine() ([]byte, error) {
+	d.line = d.line[:0]
+	if d.skipLF {
+		d.skipLF = false
+		b, err := d.r.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+		if b != '\n' {
+			_ = d.r.UnreadByte()
+		}
+	}
+
+	if !d.started {
+		d.started = true
+		if b, err := d.r.Peek(3); err == nil && bytes.Equal(b, bom) {
+			_, _ = d.r.Discard(3)
+		}
+	}
+
+	for {
+		if d.r.Buffered() == 0 {
+			if _, err := d.r.Peek(1); err != nil {
+				return nil, err
+			}
+		}
+		buf, _ := d.r.Peek(d.r.Buffered())
+		i := bytes.IndexAny(buf, "\r\n")
+		if i < 0 {
+			i = len(buf)
+		}
+		if len(d.line)+i > d.maxSize() {
+			return nil, ErrEventTooLarge
+		}
+		d.line = append(d.line, buf[:i]...)
+		if i < len(buf) {
+			d.skipLF = buf[i] == '\r'
+			_, _ = d.r.Discard(i + 1)
+			return d.line, nil
+		}
+		_, _ = d.r.Discard(i)
+	}
+}
@@ -0,0 +1,104 @@
+package sse_test
+
+import (
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/iotest"
+	"time"
+
+	"memorigo/sse"
+)
+
+func decodeAll(r io.Reader) ([]sse.Event, error) {
+	d := sse.NewDecoder(r)
+	d.MaxEventSize = 1 << 16
+	var out []sse.Event
+	for {
+		ev, err := d.Next()
+		if err == io.EOF {
+			return out, nil
+		}
+		if err != nil {
+			return out, err
+		}
+		out = append(out, ev)
+	}
+}
+
+func TestDecoder(t *testing.T) {
+	stream := "\xEF\xBB\xBF: comment\n" +
+		"data: first\n\n" +
+		"event: delta\r\nid: 7\r\ndata:a\r\ndata:  b\r\n\r\n" +
+		"data\rretry: 1500\r\rdata: {\"x\":1}\n\n" +
+		"event: ignored\n\n" +
+		"id\ndata: last\n\n" +
+		"data: incomplete"
+	want := []sse.Event{
+		{Type: "message", Data: "first"},
+		{Type: "delta", Data: "a\n b", ID: "7"},
+		{Type: "message", Data: "", ID: "7"},
+		{Type: "message", Data: `{"x":1}`, ID: "7"},
+		{Type: "message", Data: "last"},
+	}
+
+	for name, r := range map[string]io.Reader{
+		"whole":    strings.NewReader(stream),
+		"one byte": iotest.OneByteReader(strings.NewReader(stream)),
+	} {
+		got, err := decodeAll(r)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("%s: got %q\nwant %q", name, got, want)
+		}
+	}
+
+	d := sse.NewDecoder(strings.NewReader("retry: 1500\n\n"))
+	if _, err := d.Next(); err != io.EOF || d.Retry() != 1500*time.Millisecond {
+		t.Fatalf("retry = %v, err = %v", d.Retry(), err)
+	}
+}
+
+func TestDecoder_LargeEvents(t *testing.T) {
+	big := strings.Repeat("x", 200*1024)
+	got, err := decodeAll(strings.NewReader("data: " + big + "\n\n"))
+	if !errors.Is(err, sse.ErrEventTooLarge) || len(got) != 0 {
+		t.Fatalf("got %d events, err = %v", len(got), err)
+	}
+
+	d := sse.NewDecoder(strings.NewReader("data: " + big + "\n\n"))
+	ev, err := d.Next()
+	if err != nil || ev.Data != big {
+		t.Fatalf("len = %d, err = %v", len(ev.Data), err)
+	}
+}
+
+// FuzzDecoder checks that decoding never panics and does not depend on how
+// the input is split into reads.
+func FuzzDecoder(f *testing.F) {
+	for _, seed := range []string{
+		"data: hello\n\n",
+		"event: x\r\ndata: a\r\ndata: b\r\n\r\n",
+		": keep-alive\n\nid: 1\nretry: 10\ndata\n\n",
+		"\xEF\xBB\xBFdata:\r\r\n\n",
+		"data: {\"error\":{\"message\":\"boom\"}}\n\ndata: [DONE]\n\n",
+	} {
+		f.Add([]byte(seed))
+	}
+	f.Fuzz(func(t *testing.T, b []byte) {
+		whole, errWhole := decodeAll(strings.NewReader(string(b)))
+		split, errSplit := decodeAll(iotest.OneByteReader(strings.NewReader(string(b))))
+		if !reflect.DeepEqual(whole, split) || !errors.Is(errWhole, errSplit) && errWhole != errSplit {
+			t.Fatalf("whole = %q, %v; one byte = %q, %v", whole, errWhole, split, errSplit)
+		}
+		for _, ev := range whole {
+			if ev.Type == "" {
+				t.Fatalf("event without type: %q", ev)
+			}
+		}
+	})
+}
@@ -0,0 +1,23 @@
+package storage
+
+import (
+	bolt "go.etcd.io/bbolt"
+)
+
+// BoltAdapter wraps an embedded bbolt key-value database, letting memorigo run
+// as a single-file store with no SQL engine, no cgo and no server.
+type BoltAdapter struct {
+	DB *bolt.DB
+}
+
+func (a *BoltAdapter) Dialect() string { return "bolt" }
+
+func isBoltDB(conn any) bool {
+	_, ok := conn.(*bolt.DB)
+	return ok
+}
+
+func newBoltAdapter(conn any) (Adapter, error) {
+	db := conn.(*bolt.DB)
+	return &BoltAdapter{DB: db}, nil
+}
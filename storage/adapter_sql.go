@@ -2,16 +2,17 @@ package storage
 
 import (
 	"database/sql"
-	"fmt"
-	"strings"
 )
 
 type SQLAdapter struct {
 	DB      *sql.DB
-	dialect string
+	dialect SQLDialect
 }
 
-func (a *SQLAdapter) Dialect() string { return a.dialect }
+func (a *SQLAdapter) Dialect() string { return a.dialect.Name() }
+
+// SQLDialect returns the dialect detected for the connection.
+func (a *SQLAdapter) SQLDialect() SQLDialect { return a.dialect }
 
 func isSQLDB(conn any) bool {
 	_, ok := conn.(*sql.DB)
@@ -20,17 +21,10 @@ func isSQLDB(conn any) bool {
 
 func newSQLAdapter(conn any) (Adapter, error) {
 	db := conn.(*sql.DB)
-	// best-effort dialect detection
-	driver := db.Driver()
-	name := strings.ToLower(fmt.Sprintf("%T", driver))
-	dialect := "postgres"
-	switch {
-	case strings.Contains(name, "sqlite"):
-		dialect = "sqlite"
-	case strings.Contains(name, "pgx"), strings.Contains(name, "postgres"):
-		dialect = "postgres"
+	// best-effort dialect detection; unknown drivers are treated as postgres
+	dialect, ok := detectSQLDialect(db)
+	if !ok {
+		dialect = PostgresDialect
 	}
 	return &SQLAdapter{DB: db, dialect: dialect}, nil
 }
-
-
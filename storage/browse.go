@@ -0,0 +1,1401 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	bolt "go.etcd.io/bbolt"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// BrowseRepo lists, edits and deletes stored memory for management tools.
+// Records are addressed by their external ids and uuids, never by internal
+// ids. Lookups of a single record return ErrNotFound when nothing matches;
+// listings filtered by an unknown parent are simply empty.
+type BrowseRepo interface {
+	ListEntities(opts ListOptions) ([]EntityInfo, error)
+	GetEntity(externalID string) (EntityInfo, error)
+	ListProcesses(opts ListOptions) ([]EntityInfo, error)
+	ListSessions(filter SessionFilter, opts ListOptions) ([]SessionInfo, error)
+	// ListConversations lists the conversations of a session; an empty
+	// sessionUUID lists all of them.
+	ListConversations(sessionUUID string, opts ListOptions) ([]ConversationInfo, error)
+	ListMessages(conversationUUID string, opts ListOptions) ([]MessageInfo, error)
+	ListFacts(filter FactFilter, opts ListOptions) ([]FactInfo, error)
+
+	GetFact(factUUID string) (FactInfo, error)
+	// UpdateFact replaces the content, uniq and embedding of a fact. It
+	// returns ErrDuplicate when the entity already has a fact with uniq.
+	UpdateFact(factUUID, content, uniq string, embedding FactEmbedding) error
+	DeleteFact(factUUID string) error
+	// ImportFact stores a fact of fact.EntityID with its mention count and
+	// dates, e.g. from an export. It returns ErrNotFound for an unknown
+	// entity and ErrDuplicate when the entity already has a fact with uniq.
+	ImportFact(fact FactInfo, uniq string, embedding FactEmbedding) error
+	// DeleteEntity removes an entity together with its sessions,
+	// conversations, messages, facts and usage records.
+	DeleteEntity(externalID string) error
+}
+
+// ListOptions pages through a listing in insertion order.
+type ListOptions struct {
+	Limit  int // 0 uses DefaultListLimit
+	Offset int
+}
+
+// DefaultListLimit is the page size used when ListOptions.Limit is not set.
+const DefaultListLimit = 100
+
+func (o ListOptions) limit() int {
+	if o.Limit <= 0 {
+		return DefaultListLimit
+	}
+	return o.Limit
+}
+
+func (o ListOptions) offset() int {
+	if o.Offset < 0 {
+		return 0
+	}
+	return o.Offset
+}
+
+// SessionFilter restricts ListSessions by external ids; zero fields match
+// everything.
+type SessionFilter struct {
+	EntityID  string
+	ProcessID string
+}
+
+// FactFilter restricts ListFacts; Query matches facts containing it,
+// ignoring case.
+type FactFilter struct {
+	EntityID string
+	Query    string
+}
+
+// EntityInfo describes an entity or a process.
+type EntityInfo struct {
+	ExternalID  string    `json:"external_id"`
+	UUID        string    `json:"uuid"`
+	DateCreated time.Time `json:"date_created"`
+}
+
+type SessionInfo struct {
+	UUID        string    `json:"uuid"`
+	EntityID    string    `json:"entity_id,omitempty"`
+	ProcessID   string    `json:"process_id,omitempty"`
+	DateCreated time.Time `json:"date_created"`
+}
+
+type ConversationInfo struct {
+	UUID        string     `json:"uuid"`
+	SessionID   string     `json:"session_id"`
+	Summary     string     `json:"summary,omitempty"`
+	DateCreated time.Time  `json:"date_created"`
+	DateUpdated *time.Time `json:"date_updated,omitempty"`
+}
+
+type MessageInfo struct {
+	UUID        string        `json:"uuid"`
+	Role        string        `json:"role"`
+	Type        string        `json:"type,omitempty"`
+	Content     string        `json:"content"`
+	Parts       []MessagePart `json:"content_parts,omitempty"`
+	Truncated   bool          `json:"truncated,omitempty"`
+	DateCreated time.Time     `json:"date_created"`
+}
+
+type FactInfo struct {
+	UUID         string        `json:"uuid"`
+	EntityID     string        `json:"entity_id"`
+	Content      string        `json:"content"`
+	NumTimes     int64         `json:"num_times"`
+	DateLastTime time.Time     `json:"date_last_time"`
+	DateCreated  time.Time     `json:"date_created"`
+	Embedding    EmbeddingMeta `json:"embedding"`
+}
+
+// imported fills in the counters an imported fact may lack.
+func (f FactInfo) imported() FactInfo {
+	if f.DateCreated.IsZero() {
+		f.DateCreated = time.Now()
+	}
+	if f.DateLastTime.IsZero() {
+		f.DateLastTime = f.DateCreated
+	}
+	f.NumTimes = max(f.NumTimes, 1)
+	return f
+}
+
+func (f FactFilter) match(content string) bool {
+	return f.Query == "" || strings.Contains(strings.ToLower(content), strings.ToLower(f.Query))
+}
+
+// likePattern returns a LIKE pattern (with '\' as escape) matching s
+// anywhere in a lower-cased column.
+func likePattern(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+	return "%" + r.Replace(strings.ToLower(s)) + "%"
+}
+
+type sqlBrowseRepo struct {
+	db *sql.DB
+	d  SQLDialect
+}
+
+func (r *sqlBrowseRepo) ListEntities(opts ListOptions) ([]EntityInfo, error) {
+	return r.listExternal("memori_entity", opts)
+}
+
+func (r *sqlBrowseRepo) GetEntity(externalID string) (EntityInfo, error) {
+	e := EntityInfo{ExternalID: externalID}
+	var created any
+	err := r.db.QueryRow(Rebind(r.d, "SELECT uuid, date_created FROM memori_entity WHERE external_id = ?"), externalID).Scan(&e.UUID, &created)
+	if errors.Is(err, sql.ErrNoRows) {
+		return e, ErrNotFound
+	}
+	e.DateCreated, _ = decodeAnyTime(created)
+	return e, err
+}
+
+func (r *sqlBrowseRepo) ListProcesses(opts ListOptions) ([]EntityInfo, error) {
+	return r.listExternal("memori_process", opts)
+}
+
+func (r *sqlBrowseRepo) listExternal(table string, opts ListOptions) ([]EntityInfo, error) {
+	query := "SELECT uuid, external_id, date_created FROM " + table + " ORDER BY id LIMIT ? OFFSET ?"
+	rows, err := r.db.Query(Rebind(r.d, query), opts.limit(), opts.offset())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []EntityInfo
+	for rows.Next() {
+		var e EntityInfo
+		var created any
+		if err := rows.Scan(&e.UUID, &e.ExternalID, &created); err != nil {
+			return nil, err
+		}
+		e.DateCreated, _ = decodeAnyTime(created)
+		out = append(out, e)
+	}
+	return out, rows.Err()
+}
+
+func (r *sqlBrowseRepo) ListSessions(filter SessionFilter, opts ListOptions) ([]SessionInfo, error) {
+	var where []string
+	var args []any
+	if filter.EntityID != "" {
+		where = append(where, "e.external_id = ?")
+		args = append(args, filter.EntityID)
+	}
+	if filter.ProcessID != "" {
+		where = append(where, "p.external_id = ?")
+		args = append(args, filter.ProcessID)
+	}
+	query := `SELECT s.uuid, COALESCE(e.external_id, ''), COALESCE(p.external_id, ''), s.date_created FROM memori_session s
+		LEFT JOIN memori_entity e ON e.id = s.entity_id
+		LEFT JOIN memori_process p ON p.id = s.process_id`
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	query += " ORDER BY s.id LIMIT ? OFFSET ?"
+	rows, err := r.db.Query(Rebind(r.d, query), append(args, opts.limit(), opts.offset())...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []SessionInfo
+	for rows.Next() {
+		var s SessionInfo
+		var created any
+		if err := rows.Scan(&s.UUID, &s.EntityID, &s.ProcessID, &created); err != nil {
+			return nil, err
+		}
+		s.DateCreated, _ = decodeAnyTime(created)
+		out = append(out, s)
+	}
+	return out, rows.Err()
+}
+
+func (r *sqlBrowseRepo) ListConversations(sessionUUID string, opts ListOptions) ([]ConversationInfo, error) {
+	query := `SELECT c.uuid, s.uuid, COALESCE(c.summary, ''), c.date_created, c.date_updated FROM memori_conversation c
+		JOIN memori_session s ON s.id = c.session_id`
+	var args []any
+	if sessionUUID != "" {
+		query += " WHERE s.uuid = ?"
+		args = append(args, sessionUUID)
+	}
+	query += " ORDER BY c.id LIMIT ? OFFSET ?"
+	rows, err := r.db.Query(Rebind(r.d, query), append(args, opts.limit(), opts.offset())...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []ConversationInfo
+	for rows.Next() {
+		var c ConversationInfo
+		var created, updated any
+		if err := rows.Scan(&c.UUID, &c.SessionID, &c.Summary, &created, &updated); err != nil {
+			return nil, err
+		}
+		c.DateCreated, _ = decodeAnyTime(created)
+		if t, ok := decodeAnyTime(updated); ok {
+			c.DateUpdated = &t
+		}
+		out = append(out, c)
+	}
+	return out, rows.Err()
+}
+
+func (r *sqlBrowseRepo) ListMessages(conversationUUID string, opts ListOptions) ([]MessageInfo, error) {
+	query := `SELECT m.uuid, m.role, COALESCE(m.type, ''), m.content, m.content_parts, m.truncated, m.date_created FROM memori_conversation_message m
+		JOIN memori_conversation c ON c.id = m.conversation_id
+		WHERE c.uuid = ? ORDER BY m.id LIMIT ? OFFSET ?`
+	rows, err := r.db.Query(Rebind(r.d, query), conversationUUID, opts.limit(), opts.offset())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []MessageInfo
+	for rows.Next() {
+		var m MessageInfo
+		var parts sql.NullString
+		var created any
+		if err := rows.Scan(&m.UUID, &m.Role, &m.Type, &m.Content, &parts, &m.Truncated, &created); err != nil {
+			return nil, err
+		}
+		if parts.String != "" {
+			if err := json.Unmarshal([]byte(parts.String), &m.Parts); err != nil {
+				return nil, err
+			}
+		}
+		m.DateCreated, _ = decodeAnyTime(created)
+		out = append(out, m)
+	}
+	return out, rows.Err()
+}
+
+const sqlFactInfoQuery = `SELECT f.uuid, e.external_id, f.content, f.num_times, f.date_last_time, f.date_created,
+	f.embedding_provider, f.embedding_model, f.embedding_dimension FROM memori_entity_fact f
+	JOIN memori_entity e ON e.id = f.entity_id`
+
+func (r *sqlBrowseRepo) ListFacts(filter FactFilter, opts ListOptions) ([]FactInfo, error) {
+	var where []string
+	var args []any
+	if filter.EntityID != "" {
+		where = append(where, "e.external_id = ?")
+		args = append(args, filter.EntityID)
+	}
+	if filter.Query != "" {
+		where = append(where, `LOWER(f.content) LIKE ? ESCAPE '\'`)
+		args = append(args, likePattern(filter.Query))
+	}
+	query := sqlFactInfoQuery
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	query += " ORDER BY f.id LIMIT ? OFFSET ?"
+	rows, err := r.db.Query(Rebind(r.d, query), append(args, opts.limit(), opts.offset())...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []FactInfo
+	for rows.Next() {
+		f, err := scanFactInfo(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, f)
+	}
+	return out, rows.Err()
+}
+
+func scanFactInfo(row interface{ Scan(...any) error }) (FactInfo, error) {
+	var f FactInfo
+	var lastTime, created any
+	var provider, model sql.NullString
+	var dimension sql.NullInt64
+	if err := row.Scan(&f.UUID, &f.EntityID, &f.Content, &f.NumTimes, &lastTime, &created, &provider, &model, &dimension); err != nil {
+		return f, err
+	}
+	f.DateLastTime, _ = decodeAnyTime(lastTime)
+	f.DateCreated, _ = decodeAnyTime(created)
+	f.Embedding = EmbeddingMeta{Provider: provider.String, Model: model.String, Dimension: int(dimension.Int64)}
+	return f, nil
+}
+
+func (r *sqlBrowseRepo) GetFact(factUUID string) (FactInfo, error) {
+	f, err := scanFactInfo(r.db.QueryRow(Rebind(r.d, sqlFactInfoQuery+" WHERE f.uuid = ?"), factUUID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return f, ErrNotFound
+	}
+	return f, err
+}
+
+func (r *sqlBrowseRepo) UpdateFact(factUUID, content, uniq string, embedding FactEmbedding) error {
+	var entityID int64
+	err := r.db.QueryRow(Rebind(r.d, "SELECT entity_id FROM memori_entity_fact WHERE uuid = ?"), factUUID).Scan(&entityID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+	var dup int64
+	query := "SELECT COUNT(*) FROM memori_entity_fact WHERE entity_id = ? AND uniq = ? AND uuid <> ?"
+	if err := r.db.QueryRow(Rebind(r.d, query), entityID, uniq, factUUID).Scan(&dup); err != nil {
+		return err
+	}
+	if dup > 0 {
+		return ErrDuplicate
+	}
+
+	query = `UPDATE memori_entity_fact SET content = ?, uniq = ?, content_embedding = ?, embedding_encoding = ?, embedding_provider = ?, embedding_model = ?, embedding_dimension = ?, date_updated = ?
+		WHERE uuid = ?`
+	_, err = r.db.Exec(
+		Rebind(r.d, query),
+		content, uniq, embedding.sqlVector(), embedding.Encoding, embedding.Provider, embedding.Model, embedding.Dimension, time.Now(), factUUID,
+	)
+	return err
+}
+
+func (r *sqlBrowseRepo) ImportFact(fact FactInfo, uniq string, embedding FactEmbedding) error {
+	var entityID int64
+	err := r.db.QueryRow(Rebind(r.d, "SELECT id FROM memori_entity WHERE external_id = ?"), fact.EntityID).Scan(&entityID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+	var dup int64
+	query := "SELECT COUNT(*) FROM memori_entity_fact WHERE entity_id = ? AND uniq = ?"
+	if err := r.db.QueryRow(Rebind(r.d, query), entityID, uniq).Scan(&dup); err != nil {
+		return err
+	}
+	if dup > 0 {
+		return ErrDuplicate
+	}
+
+	fact = fact.imported()
+	query = `INSERT INTO memori_entity_fact (uuid, entity_id, content, content_embedding, embedding_encoding, embedding_provider, embedding_model, embedding_dimension, num_times, date_last_time, uniq, date_created)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	_, err = r.db.Exec(
+		Rebind(r.d, query),
+		uuid.New().String(), entityID, fact.Content, embedding.sqlVector(), embedding.Encoding, embedding.Provider, embedding.Model, embedding.Dimension, fact.NumTimes, fact.DateLastTime, uniq, fact.DateCreated,
+	)
+	return err
+}
+
+func (r *sqlBrowseRepo) DeleteFact(factUUID string) error {
+	res, err := r.db.Exec(Rebind(r.d, "DELETE FROM memori_entity_fact WHERE uuid = ?"), factUUID)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *sqlBrowseRepo) DeleteEntity(externalID string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var id int64
+	err = tx.QueryRow(Rebind(r.d, "SELECT id FROM memori_entity WHERE external_id = ?"), externalID).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	// Children first: foreign keys are not enforced on every engine (SQLite
+	// only with PRAGMA foreign_keys), so ON DELETE CASCADE is not relied on.
+	for _, q := range []struct {
+		query string
+		arg   any
+	}{
+		{`DELETE FROM memori_conversation_message WHERE conversation_id IN (
+			SELECT c.id FROM memori_conversation c JOIN memori_session s ON s.id = c.session_id WHERE s.entity_id = ?)`, id},
+		{"DELETE FROM memori_conversation WHERE session_id IN (SELECT id FROM memori_session WHERE entity_id = ?)", id},
+		{"DELETE FROM memori_session WHERE entity_id = ?", id},
+		{"DELETE FROM memori_entity_fact WHERE entity_id = ?", id},
+		{"DELETE FROM memori_knowledge_graph WHERE entity_id = ?", id},
+		{"DELETE FROM memori_llm_usage WHERE entity_external_id = ?", externalID},
+		{"DELETE FROM memori_entity WHERE id = ?", id},
+	} {
+		if _, err := tx.Exec(Rebind(r.d, q.query), q.arg); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+type mongoBrowseRepo struct {
+	db *mongo.Database
+}
+
+func mongoPage(opts ListOptions, sortKey string) *options.FindOptions {
+	return options.Find().
+		SetSort(bson.D{{Key: sortKey, Value: 1}}).
+		SetSkip(int64(opts.offset())).
+		SetLimit(int64(opts.limit()))
+}
+
+// mongoID resolves a document's sequence id; ErrNotFound when none matches.
+func mongoID(ctx context.Context, coll *mongo.Collection, filter bson.M) (int64, error) {
+	var doc struct {
+		ID int64 `bson:"id"`
+	}
+	err := coll.FindOne(ctx, filter).Decode(&doc)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return 0, ErrNotFound
+	}
+	return doc.ID, err
+}
+
+// mongoNames resolves sequence ids of a collection to a string field,
+// caching lookups for the duration of one listing.
+type mongoNames struct {
+	coll  *mongo.Collection
+	field string
+	cache map[int64]string
+}
+
+func (n *mongoNames) get(ctx context.Context, id *int64) string {
+	if id == nil {
+		return ""
+	}
+	if name, ok := n.cache[*id]; ok {
+		return name
+	}
+	var doc bson.M
+	if err := n.coll.FindOne(ctx, bson.M{"id": *id}).Decode(&doc); err != nil {
+		return ""
+	}
+	name, _ := doc[n.field].(string)
+	if n.cache == nil {
+		n.cache = make(map[int64]string)
+	}
+	n.cache[*id] = name
+	return name
+}
+
+func (r *mongoBrowseRepo) ListEntities(opts ListOptions) ([]EntityInfo, error) {
+	return r.listExternal("memori_entity", opts)
+}
+
+func (r *mongoBrowseRepo) GetEntity(externalID string) (EntityInfo, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var doc struct {
+		UUID        string    `bson:"uuid"`
+		DateCreated time.Time `bson:"date_created"`
+	}
+	err := r.db.Collection("memori_entity").FindOne(ctx, bson.M{"external_id": externalID}).Decode(&doc)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return EntityInfo{}, ErrNotFound
+	}
+	return EntityInfo{ExternalID: externalID, UUID: doc.UUID, DateCreated: doc.DateCreated}, err
+}
+
+func (r *mongoBrowseRepo) ListProcesses(opts ListOptions) ([]EntityInfo, error) {
+	return r.listExternal("memori_process", opts)
+}
+
+func (r *mongoBrowseRepo) listExternal(name string, opts ListOptions) ([]EntityInfo, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	cur, err := r.db.Collection(name).Find(ctx, bson.M{}, mongoPage(opts, "id"))
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	var out []EntityInfo
+	for cur.Next(ctx) {
+		var doc struct {
+			UUID        string    `bson:"uuid"`
+			ExternalID  string    `bson:"external_id"`
+			DateCreated time.Time `bson:"date_created"`
+		}
+		if err := cur.Decode(&doc); err != nil {
+			return nil, err
+		}
+		out = append(out, EntityInfo{ExternalID: doc.ExternalID, UUID: doc.UUID, DateCreated: doc.DateCreated})
+	}
+	return out, cur.Err()
+}
+
+func (r *mongoBrowseRepo) ListSessions(filter SessionFilter, opts ListOptions) ([]SessionInfo, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	q := bson.M{}
+	for field, c := range map[string]struct {
+		coll       string
+		externalID string
+	}{
+		"entity_id":  {"memori_entity", filter.EntityID},
+		"process_id": {"memori_process", filter.ProcessID},
+	} {
+		if c.externalID == "" {
+			continue
+		}
+		id, err := mongoID(ctx, r.db.Collection(c.coll), bson.M{"external_id": c.externalID})
+		if errors.Is(err, ErrNotFound) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		q[field] = id
+	}
+
+	cur, err := r.db.Collection("memori_session").Find(ctx, q, mongoPage(opts, "id"))
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	entities := &mongoNames{coll: r.db.Collection("memori_entity"), field: "external_id"}
+	processes := &mongoNames{coll: r.db.Collection("memori_process"), field: "external_id"}
+	var out []SessionInfo
+	for cur.Next(ctx) {
+		var doc struct {
+			UUID        string    `bson:"uuid"`
+			EntityID    *int64    `bson:"entity_id"`
+			ProcessID   *int64    `bson:"process_id"`
+			DateCreated time.Time `bson:"date_created"`
+		}
+		if err := cur.Decode(&doc); err != nil {
+			return nil, err
+		}
+		out = append(out, SessionInfo{
+			UUID:        doc.UUID,
+			EntityID:    entities.get(ctx, doc.EntityID),
+			ProcessID:   processes.get(ctx, doc.ProcessID),
+			DateCreated: doc.DateCreated,
+		})
+	}
+	return out, cur.Err()
+}
+
+func (r *mongoBrowseRepo) ListConversations(sessionUUID string, opts ListOptions) ([]ConversationInfo, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	q := bson.M{}
+	if sessionUUID != "" {
+		id, err := mongoID(ctx, r.db.Collection("memori_session"), bson.M{"uuid": sessionUUID})
+		if errors.Is(err, ErrNotFound) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		q["session_id"] = id
+	}
+
+	cur, err := r.db.Collection("memori_conversation").Find(ctx, q, mongoPage(opts, "id"))
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	sessions := &mongoNames{coll: r.db.Collection("memori_session"), field: "uuid"}
+	var out []ConversationInfo
+	for cur.Next(ctx) {
+		var doc struct {
+			UUID        string     `bson:"uuid"`
+			SessionID   int64      `bson:"session_id"`
+			Summary     string     `bson:"summary"`
+			DateCreated time.Time  `bson:"date_created"`
+			DateUpdated *time.Time `bson:"date_updated"`
+		}
+		if err := cur.Decode(&doc); err != nil {
+			return nil, err
+		}
+		out = append(out, ConversationInfo{
+			UUID:        doc.UUID,
+			SessionID:   sessions.get(ctx, &doc.SessionID),
+			Summary:     doc.Summary,
+			DateCreated: doc.DateCreated,
+			DateUpdated: doc.DateUpdated,
+		})
+	}
+	return out, cur.Err()
+}
+
+func (r *mongoBrowseRepo) ListMessages(conversationUUID string, opts ListOptions) ([]MessageInfo, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	id, err := mongoID(ctx, r.db.Collection("memori_conversation"), bson.M{"uuid": conversationUUID})
+	if errors.Is(err, ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// messages have no sequence id; _id follows insertion order
+	cur, err := r.db.Collection("memori_conversation_message").Find(ctx, bson.M{"conversation_id": id}, mongoPage(opts, "_id"))
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	var out []MessageInfo
+	for cur.Next(ctx) {
+		var doc struct {
+			UUID        string        `bson:"uuid"`
+			Role        string        `bson:"role"`
+			Type        string        `bson:"type"`
+			Content     string        `bson:"content"`
+			Parts       []MessagePart `bson:"content_parts"`
+			Truncated   bool          `bson:"truncated"`
+			DateCreated time.Time     `bson:"date_created"`
+		}
+		if err := cur.Decode(&doc); err != nil {
+			return nil, err
+		}
+		out = append(out, MessageInfo(doc))
+	}
+	return out, cur.Err()
+}
+
+// mongoFactDoc is the subset of a memori_entity_fact document shown by
+// BrowseRepo.
+type mongoFactDoc struct {
+	UUID         string    `bson:"uuid"`
+	EntityID     int64     `bson:"entity_id"`
+	Content      string    `bson:"content"`
+	NumTimes     int64     `bson:"num_times"`
+	DateLastTime time.Time `bson:"date_last_time"`
+	DateCreated  time.Time `bson:"date_created"`
+	Provider     string    `bson:"embedding_provider"`
+	Model        string    `bson:"embedding_model"`
+	Dimension    int       `bson:"embedding_dimension"`
+}
+
+func (d mongoFactDoc) info(entityID string) FactInfo {
+	return FactInfo{
+		UUID:         d.UUID,
+		EntityID:     entityID,
+		Content:      d.Content,
+		NumTimes:     d.NumTimes,
+		DateLastTime: d.DateLastTime,
+		DateCreated:  d.DateCreated,
+		Embedding:    EmbeddingMeta{Provider: d.Provider, Model: d.Model, Dimension: d.Dimension},
+	}
+}
+
+func (r *mongoBrowseRepo) ListFacts(filter FactFilter, opts ListOptions) ([]FactInfo, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	q := bson.M{}
+	if filter.EntityID != "" {
+		id, err := mongoID(ctx, r.db.Collection("memori_entity"), bson.M{"external_id": filter.EntityID})
+		if errors.Is(err, ErrNotFound) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		q["entity_id"] = id
+	}
+	if filter.Query != "" {
+		q["content"] = bson.M{"$regex": regexp.QuoteMeta(filter.Query), "$options": "i"}
+	}
+
+	cur, err := r.db.Collection("memori_entity_fact").Find(ctx, q, mongoPage(opts, "_id"))
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	entities := &mongoNames{coll: r.db.Collection("memori_entity"), field: "external_id"}
+	var out []FactInfo
+	for cur.Next(ctx) {
+		var doc mongoFactDoc
+		if err := cur.Decode(&doc); err != nil {
+			return nil, err
+		}
+		out = append(out, doc.info(entities.get(ctx, &doc.EntityID)))
+	}
+	return out, cur.Err()
+}
+
+func (r *mongoBrowseRepo) GetFact(factUUID string) (FactInfo, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var doc mongoFactDoc
+	err := r.db.Collection("memori_entity_fact").FindOne(ctx, bson.M{"uuid": factUUID}).Decode(&doc)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return FactInfo{}, ErrNotFound
+	}
+	if err != nil {
+		return FactInfo{}, err
+	}
+	entities := &mongoNames{coll: r.db.Collection("memori_entity"), field: "external_id"}
+	return doc.info(entities.get(ctx, &doc.EntityID)), nil
+}
+
+func (r *mongoBrowseRepo) UpdateFact(factUUID, content, uniq string, embedding FactEmbedding) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	coll := r.db.Collection("memori_entity_fact")
+	var doc mongoFactDoc
+	err := coll.FindOne(ctx, bson.M{"uuid": factUUID}).Decode(&doc)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+	dup, err := coll.CountDocuments(ctx, bson.M{"entity_id": doc.EntityID, "uniq": uniq, "uuid": bson.M{"$ne": factUUID}})
+	if err != nil {
+		return err
+	}
+	if dup > 0 {
+		return ErrDuplicate
+	}
+
+	_, err = coll.UpdateOne(ctx, bson.M{"uuid": factUUID}, bson.M{"$set": bson.M{
+		"content":             content,
+		"uniq":                uniq,
+		"content_embedding":   embedding.Vector,
+		"embedding_encoding":  embedding.Encoding,
+		"embedding_provider":  embedding.Provider,
+		"embedding_model":     embedding.Model,
+		"embedding_dimension": embedding.Dimension,
+		"date_updated":        time.Now(),
+	}})
+	return err
+}
+
+func (r *mongoBrowseRepo) ImportFact(fact FactInfo, uniq string, embedding FactEmbedding) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	entityID, err := mongoID(ctx, r.db.Collection("memori_entity"), bson.M{"external_id": fact.EntityID})
+	if err != nil {
+		return err
+	}
+	coll := r.db.Collection("memori_entity_fact")
+	dup, err := coll.CountDocuments(ctx, bson.M{"entity_id": entityID, "uniq": uniq})
+	if err != nil {
+		return err
+	}
+	if dup > 0 {
+		return ErrDuplicate
+	}
+
+	fact = fact.imported()
+	_, err = coll.InsertOne(ctx, bson.M{
+		"uuid":                uuid.New().String(),
+		"entity_id":           entityID,
+		"content":             fact.Content,
+		"content_embedding":   embedding.Vector,
+		"embedding_encoding":  embedding.Encoding,
+		"embedding_provider":  embedding.Provider,
+		"embedding_model":     embedding.Model,
+		"embedding_dimension": embedding.Dimension,
+		"num_times":           fact.NumTimes,
+		"date_last_time":      fact.DateLastTime,
+		"uniq":                uniq,
+		"date_created":        fact.DateCreated,
+	})
+	return err
+}
+
+func (r *mongoBrowseRepo) DeleteFact(factUUID string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	res, err := r.db.Collection("memori_entity_fact").DeleteOne(ctx, bson.M{"uuid": factUUID})
+	if err != nil {
+		return err
+	}
+	if res.DeletedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *mongoBrowseRepo) DeleteEntity(externalID string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	id, err := mongoID(ctx, r.db.Collection("memori_entity"), bson.M{"external_id": externalID})
+	if err != nil {
+		return err
+	}
+
+	sessionIDs, conversationIDs := bson.A{}, bson.A{}
+	cur, err := r.db.Collection("memori_session").Find(ctx, bson.M{"entity_id": id})
+	if err != nil {
+		return err
+	}
+	for cur.Next(ctx) {
+		var doc struct {
+			ID int64 `bson:"id"`
+		}
+		if err := cur.Decode(&doc); err != nil {
+			cur.Close(ctx)
+			return err
+		}
+		sessionIDs = append(sessionIDs, doc.ID)
+	}
+	cur.Close(ctx)
+	if len(sessionIDs) > 0 {
+		cur, err := r.db.Collection("memori_conversation").Find(ctx, bson.M{"session_id": bson.M{"$in": sessionIDs}})
+		if err != nil {
+			return err
+		}
+		for cur.Next(ctx) {
+			var doc struct {
+				ID int64 `bson:"id"`
+			}
+			if err := cur.Decode(&doc); err != nil {
+				cur.Close(ctx)
+				return err
+			}
+			conversationIDs = append(conversationIDs, doc.ID)
+		}
+		cur.Close(ctx)
+	}
+
+	// No multi-document transaction: children go first, so an interrupted
+	// delete leaves the entity in place and can simply be repeated.
+	for _, op := range []struct {
+		coll   string
+		filter bson.M
+	}{
+		{"memori_conversation_message", bson.M{"conversation_id": bson.M{"$in": conversationIDs}}},
+		{"memori_conversation", bson.M{"session_id": bson.M{"$in": sessionIDs}}},
+		{"memori_session", bson.M{"entity_id": id}},
+		{"memori_entity_fact", bson.M{"entity_id": id}},
+		{"memori_knowledge_graph", bson.M{"entity_id": id}},
+		{"memori_llm_usage", bson.M{"entity_external_id": externalID}},
+		{"memori_entity", bson.M{"id": id}},
+	} {
+		if _, err := r.db.Collection(op.coll).DeleteMany(ctx, op.filter); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type boltBrowseRepo struct {
+	db    *bolt.DB
+	index *boltVectorIndex
+}
+
+// boltPage calls fn for the records of a bucket, in id order, that keep
+// returns true for, skipping opts.Offset of them and stopping after
+// opts.Limit.
+func boltPage[T any](tx *bolt.Tx, bucket string, opts ListOptions, keep func(T) bool, fn func(T) error) error {
+	b, err := boltBucket(tx, bucket)
+	if err != nil {
+		return err
+	}
+	skip, n := opts.offset(), 0
+	c := b.Cursor()
+	for k, v := c.First(); k != nil && n < opts.limit(); k, v = c.Next() {
+		var rec T
+		if err := json.Unmarshal(v, &rec); err != nil {
+			return err
+		}
+		if !keep(rec) {
+			continue
+		}
+		if skip > 0 {
+			skip--
+			continue
+		}
+		if err := fn(rec); err != nil {
+			return err
+		}
+		n++
+	}
+	return nil
+}
+
+// boltExternalID returns the external id of an entity or process record.
+func boltExternalID(tx *bolt.Tx, bucket string, id *int64) string {
+	if id == nil {
+		return ""
+	}
+	var rec boltEntityRecord
+	if err := boltGetJSON(tx, bucket, *id, &rec); err != nil {
+		return ""
+	}
+	return rec.ExternalID
+}
+
+// boltFilterID resolves an optional external id filter; ok is false when the
+// external id is unknown, so nothing can match.
+func boltFilterID(tx *bolt.Tx, index, externalID string) (id *int64, ok bool, err error) {
+	if externalID == "" {
+		return nil, true, nil
+	}
+	v, err := boltLookup(tx, index, []byte(externalID))
+	if errors.Is(err, ErrNotFound) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return &v, true, nil
+}
+
+func (r *boltBrowseRepo) ListEntities(opts ListOptions) ([]EntityInfo, error) {
+	return r.listExternal("memori_entity", opts)
+}
+
+func (r *boltBrowseRepo) GetEntity(externalID string) (EntityInfo, error) {
+	var rec boltEntityRecord
+	err := r.db.View(func(tx *bolt.Tx) error {
+		id, err := boltLookup(tx, "memori_entity_by_external_id", []byte(externalID))
+		if err != nil {
+			return err
+		}
+		return boltGetJSON(tx, "memori_entity", id, &rec)
+	})
+	return EntityInfo{ExternalID: rec.ExternalID, UUID: rec.UUID, DateCreated: rec.DateCreated}, err
+}
+
+func (r *boltBrowseRepo) ListProcesses(opts ListOptions) ([]EntityInfo, error) {
+	return r.listExternal("memori_process", opts)
+}
+
+func (r *boltBrowseRepo) listExternal(bucket string, opts ListOptions) ([]EntityInfo, error) {
+	var out []EntityInfo
+	err := r.db.View(func(tx *bolt.Tx) error {
+		return boltPage(tx, bucket, opts, func(boltEntityRecord) bool { return true }, func(rec boltEntityRecord) error {
+			out = append(out, EntityInfo{ExternalID: rec.ExternalID, UUID: rec.UUID, DateCreated: rec.DateCreated})
+			return nil
+		})
+	})
+	return out, err
+}
+
+func (r *boltBrowseRepo) ListSessions(filter SessionFilter, opts ListOptions) ([]SessionInfo, error) {
+	var out []SessionInfo
+	err := r.db.View(func(tx *bolt.Tx) error {
+		entityID, ok, err := boltFilterID(tx, "memori_entity_by_external_id", filter.EntityID)
+		if err != nil || !ok {
+			return err
+		}
+		processID, ok, err := boltFilterID(tx, "memori_process_by_external_id", filter.ProcessID)
+		if err != nil || !ok {
+			return err
+		}
+		keep := func(rec boltSessionRecord) bool {
+			return (entityID == nil || (rec.EntityID != nil && *rec.EntityID == *entityID)) &&
+				(processID == nil || (rec.ProcessID != nil && *rec.ProcessID == *processID))
+		}
+		return boltPage(tx, "memori_session", opts, keep, func(rec boltSessionRecord) error {
+			out = append(out, SessionInfo{
+				UUID:        rec.UUID,
+				EntityID:    boltExternalID(tx, "memori_entity", rec.EntityID),
+				ProcessID:   boltExternalID(tx, "memori_process", rec.ProcessID),
+				DateCreated: rec.DateCreated,
+			})
+			return nil
+		})
+	})
+	return out, err
+}
+
+func (r *boltBrowseRepo) ListConversations(sessionUUID string, opts ListOptions) ([]ConversationInfo, error) {
+	var out []ConversationInfo
+	err := r.db.View(func(tx *bolt.Tx) error {
+		var sessionID int64
+		if sessionUUID != "" {
+			var err error
+			sessionID, err = boltLookup(tx, "memori_session_by_uuid", []byte(sessionUUID))
+			if errors.Is(err, ErrNotFound) {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+		}
+		keep := func(rec boltConversationRecord) bool { return sessionUUID == "" || rec.SessionID == sessionID }
+		return boltPage(tx, "memori_conversation", opts, keep, func(rec boltConversationRecord) error {
+			var session boltSessionRecord
+			_ = boltGetJSON(tx, "memori_session", rec.SessionID, &session)
+			out = append(out, ConversationInfo{
+				UUID:        rec.UUID,
+				SessionID:   session.UUID,
+				Summary:     rec.Summary,
+				DateCreated: rec.DateCreated,
+				DateUpdated: rec.DateUpdated,
+			})
+			return nil
+		})
+	})
+	return out, err
+}
+
+// boltConversationByUUID scans for a conversation; there is no uuid index.
+func boltConversationByUUID(tx *bolt.Tx, conversationUUID string) (int64, error) {
+	b, err := boltBucket(tx, "memori_conversation")
+	if err != nil {
+		return 0, err
+	}
+	c := b.Cursor()
+	for k, v := c.First(); k != nil; k, v = c.Next() {
+		var rec boltConversationRecord
+		if err := json.Unmarshal(v, &rec); err != nil {
+			return 0, err
+		}
+		if rec.UUID == conversationUUID {
+			return rec.ID, nil
+		}
+	}
+	return 0, ErrNotFound
+}
+
+func (r *boltBrowseRepo) ListMessages(conversationUUID string, opts ListOptions) ([]MessageInfo, error) {
+	var out []MessageInfo
+	err := r.db.View(func(tx *bolt.Tx) error {
+		conversationID, err := boltConversationByUUID(tx, conversationUUID)
+		if errors.Is(err, ErrNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		byConversation, err := boltBucket(tx, "memori_conversation_message_by_conversation")
+		if err != nil {
+			return err
+		}
+		prefix := itob(conversationID)
+		skip := opts.offset()
+		c := byConversation.Cursor()
+		for k, _ := c.Seek(prefix); k != nil && len(k) == 16 && btoi(k[:8]) == conversationID && len(out) < opts.limit(); k, _ = c.Next() {
+			if skip > 0 {
+				skip--
+				continue
+			}
+			var rec boltMessageRecord
+			if err := boltGetJSON(tx, "memori_conversation_message", btoi(k[8:]), &rec); err != nil {
+				return err
+			}
+			out = append(out, MessageInfo{
+				UUID:        rec.UUID,
+				Role:        rec.Role,
+				Type:        rec.Type,
+				Content:     rec.Content,
+				Parts:       rec.Parts,
+				Truncated:   rec.Truncated,
+				DateCreated: rec.DateCreated,
+			})
+		}
+		return nil
+	})
+	return out, err
+}
+
+func (rec boltFactRecord) info(tx *bolt.Tx) FactInfo {
+	return FactInfo{
+		UUID:         rec.UUID,
+		EntityID:     boltExternalID(tx, "memori_entity", &rec.EntityID),
+		Content:      rec.Content,
+		NumTimes:     rec.NumTimes,
+		DateLastTime: rec.DateLastTime,
+		DateCreated:  rec.DateCreated,
+		Embedding:    EmbeddingMeta{Provider: rec.Provider, Model: rec.Model, Dimension: rec.Dimension},
+	}
+}
+
+func (r *boltBrowseRepo) ListFacts(filter FactFilter, opts ListOptions) ([]FactInfo, error) {
+	var out []FactInfo
+	err := r.db.View(func(tx *bolt.Tx) error {
+		entityID, ok, err := boltFilterID(tx, "memori_entity_by_external_id", filter.EntityID)
+		if err != nil || !ok {
+			return err
+		}
+		keep := func(rec boltFactRecord) bool {
+			return (entityID == nil || rec.EntityID == *entityID) && filter.match(rec.Content)
+		}
+		return boltPage(tx, "memori_entity_fact", opts, keep, func(rec boltFactRecord) error {
+			out = append(out, rec.info(tx))
+			return nil
+		})
+	})
+	return out, err
+}
+
+// boltFactByUUID scans for a fact; there is no uuid index.
+func boltFactByUUID(tx *bolt.Tx, factUUID string) (boltFactRecord, error) {
+	b, err := boltBucket(tx, "memori_entity_fact")
+	if err != nil {
+		return boltFactRecord{}, err
+	}
+	c := b.Cursor()
+	for k, v := c.First(); k != nil; k, v = c.Next() {
+		var rec boltFactRecord
+		if err := json.Unmarshal(v, &rec); err != nil {
+			return rec, err
+		}
+		if rec.UUID == factUUID {
+			return rec, nil
+		}
+	}
+	return boltFactRecord{}, ErrNotFound
+}
+
+func (r *boltBrowseRepo) GetFact(factUUID string) (FactInfo, error) {
+	var out FactInfo
+	err := r.db.View(func(tx *bolt.Tx) error {
+		rec, err := boltFactByUUID(tx, factUUID)
+		if err != nil {
+			return err
+		}
+		out = rec.info(tx)
+		return nil
+	})
+	return out, err
+}
+
+func (r *boltBrowseRepo) UpdateFact(factUUID, content, uniq string, embedding FactEmbedding) error {
+	var rec boltFactRecord
+	err := r.index.update(r.db, func(tx *bolt.Tx) error {
+		var err error
+		if rec, err = boltFactByUUID(tx, factUUID); err != nil {
+			return err
+		}
+		byUniq := tx.Bucket([]byte("memori_entity_fact_by_uniq"))
+		newKey := compositeKey(rec.EntityID, []byte(uniq))
+		if id, err := boltLookup(tx, "memori_entity_fact_by_uniq", newKey); err == nil && id != rec.ID {
+			return ErrDuplicate
+		}
+		if err := byUniq.Delete(compositeKey(rec.EntityID, []byte(rec.Uniq))); err != nil {
+			return err
+		}
+		if err := byUniq.Put(newKey, itob(rec.ID)); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		rec.Content = content
+		rec.Uniq = uniq
+		rec.Provider = embedding.Provider
+		rec.Model = embedding.Model
+		rec.Dimension = embedding.Dimension
+		rec.Encoding = embedding.Encoding
+		rec.DateUpdated = &now
+		if err := boltPutJSON(tx, "memori_entity_fact", rec.ID, rec); err != nil {
+			return err
+		}
+		return tx.Bucket([]byte("memori_entity_fact_embedding")).Put(itob(rec.ID), embedding.Vector)
+	}, func() {
+		r.index.put(rec.EntityID, rec.ID, boltIndexedVector{meta: embedding.EmbeddingMeta, vec: decodeUnit(embedding.Vector, embedding.Encoding)})
+	})
+	return err
+}
+
+func (r *boltBrowseRepo) ImportFact(fact FactInfo, uniq string, embedding FactEmbedding) error {
+	fact = fact.imported()
+	var rec boltFactRecord
+	err := r.index.update(r.db, func(tx *bolt.Tx) error {
+		entityID, err := boltLookup(tx, "memori_entity_by_external_id", []byte(fact.EntityID))
+		if err != nil {
+			return err
+		}
+		if _, err := boltLookup(tx, "memori_entity_fact_by_uniq", compositeKey(entityID, []byte(uniq))); err == nil {
+			return ErrDuplicate
+		}
+		rec = boltFactRecord{
+			EntityID:     entityID,
+			Content:      fact.Content,
+			Provider:     embedding.Provider,
+			Model:        embedding.Model,
+			Dimension:    embedding.Dimension,
+			Encoding:     embedding.Encoding,
+			NumTimes:     fact.NumTimes,
+			DateLastTime: fact.DateLastTime,
+			Uniq:         uniq,
+			DateCreated:  fact.DateCreated,
+		}
+		rec.ID, err = boltInsertFact(tx, rec, embedding.Vector)
+		return err
+	}, func() {
+		r.index.put(rec.EntityID, rec.ID, boltIndexedVector{meta: embedding.EmbeddingMeta, vec: decodeUnit(embedding.Vector, embedding.Encoding)})
+	})
+	return err
+}
+
+func (r *boltBrowseRepo) DeleteFact(factUUID string) error {
+	var rec boltFactRecord
+	err := r.index.update(r.db, func(tx *bolt.Tx) error {
+		var err error
+		if rec, err = boltFactByUUID(tx, factUUID); err != nil {
+			return err
+		}
+		return boltDeleteFact(tx, rec)
+	}, func() {
+		r.index.remove(rec.EntityID, rec.ID)
+	})
+	return err
+}
+
+func boltDeleteFact(tx *bolt.Tx, rec boltFactRecord) error {
+	for _, d := range []struct {
+		bucket string
+		key    []byte
+	}{
+		{"memori_entity_fact", itob(rec.ID)},
+		{"memori_entity_fact_embedding", itob(rec.ID)},
+		{"memori_entity_fact_by_entity", compositeKey(rec.EntityID, itob(rec.ID))},
+		{"memori_entity_fact_by_uniq", compositeKey(rec.EntityID, []byte(rec.Uniq))},
+	} {
+		if err := tx.Bucket([]byte(d.bucket)).Delete(d.key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *boltBrowseRepo) DeleteEntity(externalID string) error {
+	var entityID int64
+	err := r.index.update(r.db, func(tx *bolt.Tx) error {
+		var err error
+		if entityID, err = boltLookup(tx, "memori_entity_by_external_id", []byte(externalID)); err != nil {
+			return err
+		}
+
+		// Collect first: bolt cursors must not delete while iterating.
+		var sessions []boltSessionRecord
+		var conversations []boltConversationRecord
+		var facts []boltFactRecord
+		var usage [][]byte
+		sessionIDs := make(map[int64]bool)
+		if err := boltEach(tx, "memori_session", func(_ []byte, rec boltSessionRecord) {
+			if rec.EntityID != nil && *rec.EntityID == entityID {
+				sessions = append(sessions, rec)
+				sessionIDs[rec.ID] = true
+			}
+		}); err != nil {
+			return err
+		}
+		if err := boltEach(tx, "memori_conversation", func(_ []byte, rec boltConversationRecord) {
+			if sessionIDs[rec.SessionID] {
+				conversations = append(conversations, rec)
+			}
+		}); err != nil {
+			return err
+		}
+		if err := boltEach(tx, "memori_entity_fact", func(_ []byte, rec boltFactRecord) {
+			if rec.EntityID == entityID {
+				facts = append(facts, rec)
+			}
+		}); err != nil {
+			return err
+		}
+		if err := boltEach(tx, "memori_llm_usage", func(k []byte, rec usageDoc) {
+			if rec.EntityID == externalID {
+				usage = append(usage, append([]byte(nil), k...))
+			}
+		}); err != nil {
+			return err
+		}
+
+		messages := tx.Bucket([]byte("memori_conversation_message"))
+		byConversation := tx.Bucket([]byte("memori_conversation_message_by_conversation"))
+		for _, conv := range conversations {
+			var keys [][]byte
+			prefix := itob(conv.ID)
+			c := byConversation.Cursor()
+			for k, _ := c.Seek(prefix); k != nil && len(k) == 16 && btoi(k[:8]) == conv.ID; k, _ = c.Next() {
+				keys = append(keys, append([]byte(nil), k...))
+			}
+			for _, k := range keys {
+				if err := messages.Delete(k[8:]); err != nil {
+					return err
+				}
+				if err := byConversation.Delete(k); err != nil {
+					return err
+				}
+			}
+			if err := tx.Bucket([]byte("memori_conversation")).Delete(itob(conv.ID)); err != nil {
+				return err
+			}
+			if err := tx.Bucket([]byte("memori_conversation_by_session")).Delete(itob(conv.SessionID)); err != nil {
+				return err
+			}
+		}
+		for _, s := range sessions {
+			if err := tx.Bucket([]byte("memori_session")).Delete(itob(s.ID)); err != nil {
+				return err
+			}
+			if err := tx.Bucket([]byte("memori_session_by_uuid")).Delete([]byte(s.UUID)); err != nil {
+				return err
+			}
+		}
+		for _, f := range facts {
+			if err := boltDeleteFact(tx, f); err != nil {
+				return err
+			}
+		}
+		for _, k := range usage {
+			if err := tx.Bucket([]byte("memori_llm_usage")).Delete(k); err != nil {
+				return err
+			}
+		}
+		if err := tx.Bucket([]byte("memori_entity")).Delete(itob(entityID)); err != nil {
+			return err
+		}
+		return tx.Bucket([]byte("memori_entity_by_external_id")).Delete([]byte(externalID))
+	}, func() {
+		r.index.drop(entityID)
+	})
+	return err
+}
+
+// boltEach decodes every record of a bucket.
+func boltEach[T any](tx *bolt.Tx, bucket string, fn func(k []byte, rec T)) error {
+	b, err := boltBucket(tx, bucket)
+	if err != nil {
+		return err
+	}
+	return b.ForEach(func(k, v []byte) error {
+		var rec T
+		if err := json.Unmarshal(v, &rec); err != nil {
+			return err
+		}
+		fn(k, rec)
+		return nil
+	})
+}
@@ -0,0 +1,250 @@
+package storage_test
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	bolt "go.etcd.io/bbolt"
+
+	"memorigo/storage"
+)
+
+// browseBackends opens an empty, migrated store per built-in driver that
+// runs without a server.
+var browseBackends = []struct {
+	name string
+	open func(t *testing.T) any
+}{
+	{"sqlite", func(t *testing.T) any {
+		return openSQLite(t, "storage_browse_"+strings.ReplaceAll(t.Name(), "/", "_"))
+	}},
+	{"bolt", func(t *testing.T) any {
+		db, err := bolt.Open(filepath.Join(t.TempDir(), "browse.db"), 0o600, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { db.Close() })
+		return db
+	}},
+}
+
+// browseFixture is what seedBrowse stores.
+type browseFixture struct {
+	browse    storage.BrowseRepo
+	usage     storage.UsageRepo
+	aliceConv string
+	bobConv   string
+}
+
+// seedBrowse stores two entities, each with a session, a conversation of two
+// messages, a usage record and some facts.
+func seedBrowse(t *testing.T, conn any) browseFixture {
+	t.Helper()
+	m := storage.NewManager()
+	if err := m.Start(conn); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Build(); err != nil {
+		t.Fatal(err)
+	}
+	repos := m.Driver().(storage.Repos)
+	browse, err := storage.BrowseOf(m.Driver())
+	if err != nil {
+		t.Fatal(err)
+	}
+	usage, err := storage.UsageOf(m.Driver())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	seed := func(entity string, facts ...string) string {
+		entityID, err := repos.Entity().Create(entity)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sessionUUID := uuid.New()
+		sessionID, err := repos.Session().Create(&entityID, nil, sessionUUID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		convID, err := repos.Conversation().Create(sessionID, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, role := range []string{"user", "assistant"} {
+			if err := repos.Message().Create(storage.MessageRecord{ConversationID: convID, Role: role, Type: "text", Content: entity + " " + role}); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := usage.Create(storage.UsageRecord{ConversationID: convID, EntityID: entity, SessionID: sessionUUID.String(), Model: "m", TotalTokens: 3}); err != nil {
+			t.Fatal(err)
+		}
+		for i, fact := range facts {
+			if err := repos.EntityFact().Create(entityID, fact, storage.FactEmbedding{}, fmt.Sprintf("%s-%d", entity, i)); err != nil {
+				t.Fatal(err)
+			}
+		}
+		convs, err := browse.ListConversations(sessionUUID.String(), storage.ListOptions{})
+		if err != nil || len(convs) != 1 {
+			t.Fatalf("conversations of %s = %+v, %v", entity, convs, err)
+		}
+		return convs[0].UUID
+	}
+	return browseFixture{
+		browse:    browse,
+		usage:     usage,
+		aliceConv: seed("alice", "Likes tea", "likes COFFEE", "Owns a cat", "100% sure"),
+		bobConv:   seed("bob", "Likes tea too"),
+	}
+}
+
+func factContents(facts []storage.FactInfo) []string {
+	out := make([]string, len(facts))
+	for i, f := range facts {
+		out[i] = f.Content
+	}
+	return out
+}
+
+// second returns the error of a two-value call.
+func second[T any](_ T, err error) error { return err }
+
+func TestBrowse_ListFacts(t *testing.T) {
+	tests := []struct {
+		name   string
+		filter storage.FactFilter
+		opts   storage.ListOptions
+		want   []string
+	}{
+		{"all of an entity", storage.FactFilter{EntityID: "alice"}, storage.ListOptions{}, []string{"Likes tea", "likes COFFEE", "Owns a cat", "100% sure"}},
+		{"first page", storage.FactFilter{EntityID: "alice"}, storage.ListOptions{Limit: 3}, []string{"Likes tea", "likes COFFEE", "Owns a cat"}},
+		{"second page", storage.FactFilter{EntityID: "alice"}, storage.ListOptions{Limit: 3, Offset: 3}, []string{"100% sure"}},
+		{"past the end", storage.FactFilter{EntityID: "alice"}, storage.ListOptions{Offset: 4}, nil},
+		{"all entities", storage.FactFilter{}, storage.ListOptions{Offset: 3}, []string{"100% sure", "Likes tea too"}},
+		{"query ignores case", storage.FactFilter{EntityID: "alice", Query: "LIKES"}, storage.ListOptions{}, []string{"Likes tea", "likes COFFEE"}},
+		{"query across entities", storage.FactFilter{Query: "tea"}, storage.ListOptions{}, []string{"Likes tea", "Likes tea too"}},
+		{"query pages the matches", storage.FactFilter{Query: "tea"}, storage.ListOptions{Limit: 1, Offset: 1}, []string{"Likes tea too"}},
+		{"query is literal", storage.FactFilter{Query: "0%"}, storage.ListOptions{}, []string{"100% sure"}},
+		{"query wildcard is literal", storage.FactFilter{Query: "_"}, storage.ListOptions{}, nil},
+		{"unknown entity", storage.FactFilter{EntityID: "nobody"}, storage.ListOptions{}, nil},
+	}
+	for _, backend := range browseBackends {
+		t.Run(backend.name, func(t *testing.T) {
+			f := seedBrowse(t, backend.open(t))
+			for _, tt := range tests {
+				facts, err := f.browse.ListFacts(tt.filter, tt.opts)
+				if err != nil {
+					t.Fatalf("%s: %v", tt.name, err)
+				}
+				if got := factContents(facts); !slices.Equal(got, tt.want) {
+					t.Errorf("%s: facts = %q, want %q", tt.name, got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestBrowse_ListPaging(t *testing.T) {
+	for _, backend := range browseBackends {
+		t.Run(backend.name, func(t *testing.T) {
+			f := seedBrowse(t, backend.open(t))
+			entities, err := f.browse.ListEntities(storage.ListOptions{Limit: 1, Offset: 1})
+			if err != nil || len(entities) != 1 || entities[0].ExternalID != "bob" {
+				t.Fatalf("second entity = %+v, %v", entities, err)
+			}
+			sessions, err := f.browse.ListSessions(storage.SessionFilter{EntityID: "alice"}, storage.ListOptions{})
+			if err != nil || len(sessions) != 1 || sessions[0].EntityID != "alice" {
+				t.Fatalf("sessions of alice = %+v, %v", sessions, err)
+			}
+			convs, err := f.browse.ListConversations("", storage.ListOptions{Limit: 1, Offset: 1})
+			if err != nil || len(convs) != 1 || convs[0].UUID != f.bobConv {
+				t.Fatalf("second conversation = %+v, %v", convs, err)
+			}
+			msgs, err := f.browse.ListMessages(f.aliceConv, storage.ListOptions{Limit: 1, Offset: 1})
+			if err != nil || len(msgs) != 1 || msgs[0].Content != "alice assistant" {
+				t.Fatalf("second message = %+v, %v", msgs, err)
+			}
+		})
+	}
+}
+
+func TestBrowse_Errors(t *testing.T) {
+	for _, backend := range browseBackends {
+		t.Run(backend.name, func(t *testing.T) {
+			f := seedBrowse(t, backend.open(t))
+			facts, err := f.browse.ListFacts(storage.FactFilter{EntityID: "alice"}, storage.ListOptions{Limit: 2})
+			if err != nil || len(facts) != 2 {
+				t.Fatalf("facts = %+v, %v", facts, err)
+			}
+			missing := uuid.NewString()
+
+			tests := []struct {
+				name string
+				err  error
+				want error
+			}{
+				{"GetEntity", second(f.browse.GetEntity("nobody")), storage.ErrNotFound},
+				{"GetFact", second(f.browse.GetFact(missing)), storage.ErrNotFound},
+				{"UpdateFact unknown", f.browse.UpdateFact(missing, "x", "x", storage.FactEmbedding{}), storage.ErrNotFound},
+				{"UpdateFact onto another fact", f.browse.UpdateFact(facts[0].UUID, "likes coffee", "alice-1", storage.FactEmbedding{}), storage.ErrDuplicate},
+				{"UpdateFact onto itself", f.browse.UpdateFact(facts[1].UUID, "Likes coffee", "alice-1", storage.FactEmbedding{}), nil},
+				{"DeleteFact unknown", f.browse.DeleteFact(missing), storage.ErrNotFound},
+				{"ImportFact unknown entity", f.browse.ImportFact(storage.FactInfo{EntityID: "nobody", Content: "x"}, "x", storage.FactEmbedding{}), storage.ErrNotFound},
+				{"ImportFact existing", f.browse.ImportFact(storage.FactInfo{EntityID: "alice", Content: "Likes tea"}, "alice-0", storage.FactEmbedding{}), storage.ErrDuplicate},
+				{"DeleteEntity unknown", f.browse.DeleteEntity("nobody"), storage.ErrNotFound},
+			}
+			for _, tt := range tests {
+				if !errors.Is(tt.err, tt.want) || (tt.want == nil && tt.err != nil) {
+					t.Errorf("%s: error = %v, want %v", tt.name, tt.err, tt.want)
+				}
+			}
+			if fact, err := f.browse.GetFact(facts[0].UUID); err != nil || fact.Content != "Likes tea" {
+				t.Fatalf("fact after rejected update = %+v, %v", fact, err)
+			}
+		})
+	}
+}
+
+func TestBrowse_DeleteEntityCascades(t *testing.T) {
+	for _, backend := range browseBackends {
+		t.Run(backend.name, func(t *testing.T) {
+			f := seedBrowse(t, backend.open(t))
+			if err := f.browse.DeleteEntity("alice"); err != nil {
+				t.Fatal(err)
+			}
+
+			if _, err := f.browse.GetEntity("alice"); !errors.Is(err, storage.ErrNotFound) {
+				t.Errorf("GetEntity after delete: %v", err)
+			}
+			if facts, err := f.browse.ListFacts(storage.FactFilter{}, storage.ListOptions{}); err != nil || !slices.Equal(factContents(facts), []string{"Likes tea too"}) {
+				t.Errorf("facts after delete = %+v, %v", facts, err)
+			}
+			if sessions, err := f.browse.ListSessions(storage.SessionFilter{}, storage.ListOptions{}); err != nil || len(sessions) != 1 || sessions[0].EntityID != "bob" {
+				t.Errorf("sessions after delete = %+v, %v", sessions, err)
+			}
+			if convs, err := f.browse.ListConversations("", storage.ListOptions{}); err != nil || len(convs) != 1 || convs[0].UUID != f.bobConv {
+				t.Errorf("conversations after delete = %+v, %v", convs, err)
+			}
+			if msgs, _ := f.browse.ListMessages(f.aliceConv, storage.ListOptions{}); len(msgs) != 0 {
+				t.Errorf("messages of a deleted conversation = %+v", msgs)
+			}
+			if msgs, err := f.browse.ListMessages(f.bobConv, storage.ListOptions{}); err != nil || len(msgs) != 2 {
+				t.Errorf("messages of bob = %+v, %v", msgs, err)
+			}
+			totals, err := f.usage.Aggregate(storage.UsageFilter{}, []storage.UsageGroup{storage.UsageByEntity})
+			if err != nil || len(totals) != 1 || totals[0].EntityID != "bob" {
+				t.Errorf("usage after delete = %+v, %v", totals, err)
+			}
+
+			// A deleted entity is unknown.
+			if err := f.browse.DeleteEntity("alice"); !errors.Is(err, storage.ErrNotFound) {
+				t.Errorf("second DeleteEntity: %v", err)
+			}
+		})
+	}
+}
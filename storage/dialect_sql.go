@@ -0,0 +1,141 @@
+package storage
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// SQLDialect abstracts the syntax differences between SQL engines that the
+// built-in SQL repos depend on. Queries are written with "?" placeholders and
+// rewritten with Rebind before execution.
+type SQLDialect interface {
+	// Name is the dialect name reported by the adapter, e.g. "sqlite".
+	Name() string
+	// Placeholder returns the bind parameter for the n-th (1-based) argument.
+	Placeholder(n int) string
+	// SupportsReturning reports whether INSERT ... RETURNING id is available.
+	// When false, the repos fall back to sql.Result.LastInsertId.
+	SupportsReturning() bool
+	// Upsert returns the clause appended to an INSERT statement so that a row
+	// conflicting on the given columns is updated with assignments instead.
+	// Assignments may reference the existing row as <table>.<column>.
+	Upsert(conflict []string, assignments string) string
+}
+
+// Rebind rewrites "?" placeholders in query into the dialect's own syntax.
+// Queries must not contain literal question marks.
+func Rebind(d SQLDialect, query string) string {
+	if d.Placeholder(1) == "?" {
+		return query
+	}
+	var b strings.Builder
+	b.Grow(len(query) + 8)
+	n := 0
+	for i := 0; i < len(query); i++ {
+		if query[i] == '?' {
+			n++
+			b.WriteString(d.Placeholder(n))
+			continue
+		}
+		b.WriteByte(query[i])
+	}
+	return b.String()
+}
+
+type sqliteDialect struct{}
+
+func (sqliteDialect) Name() string            { return "sqlite" }
+func (sqliteDialect) Placeholder(int) string  { return "?" }
+func (sqliteDialect) SupportsReturning() bool { return true }
+func (sqliteDialect) Upsert(conflict []string, assignments string) string {
+	return "ON CONFLICT(" + strings.Join(conflict, ", ") + ") DO UPDATE SET " + assignments
+}
+
+type postgresDialect struct{}
+
+func (postgresDialect) Name() string             { return "postgres" }
+func (postgresDialect) Placeholder(n int) string { return fmt.Sprintf("$%d", n) }
+func (postgresDialect) SupportsReturning() bool  { return true }
+func (postgresDialect) Upsert(conflict []string, assignments string) string {
+	return "ON CONFLICT(" + strings.Join(conflict, ", ") + ") DO UPDATE SET " + assignments
+}
+
+var (
+	// SQLiteDialect is the dialect used for SQLite connections.
+	SQLiteDialect SQLDialect = sqliteDialect{}
+	// PostgresDialect is the dialect used for PostgreSQL connections.
+	PostgresDialect SQLDialect = postgresDialect{}
+)
+
+// SQLDialectDescriptor registers a SQL engine reachable through *sql.DB.
+// The built-in SQL repos are reused, so the engine only has to understand the
+// dialect's syntax and the schema created by Migrations.
+type SQLDialectDescriptor struct {
+	Dialect SQLDialect
+	// Match reports whether db is served by this engine. Dialects registered
+	// later are tried first.
+	Match func(db *sql.DB) bool
+	// Migrations maps schema versions to the statements that reach them.
+	Migrations map[int][]string
+	// Rollbacks maps schema versions to the statements that revert them, for
+	// Migrator.Migrate to a lower version. Optional.
+	Rollbacks map[int][]string
+}
+
+var (
+	sqlDialectsMu sync.RWMutex
+	sqlDialects   []SQLDialectDescriptor
+)
+
+// RegisterSQLDialect makes a SQL engine available to the *sql.DB adapter and
+// registers a driver for it under Dialect.Name().
+func RegisterSQLDialect(desc SQLDialectDescriptor) error {
+	if desc.Dialect == nil {
+		return errors.New("storage: sql dialect descriptor requires a dialect")
+	}
+	if desc.Match == nil {
+		return fmt.Errorf("storage: sql dialect %q requires Match", desc.Dialect.Name())
+	}
+	sqlDialectsMu.Lock()
+	sqlDialects = append(sqlDialects, desc)
+	sqlDialectsMu.Unlock()
+	return Register(DriverDescriptor{
+		Dialect:   desc.Dialect.Name(),
+		NewDriver: newSQLDriverFactory(desc.Dialect, desc.Migrations, desc.Rollbacks),
+	})
+}
+
+func detectSQLDialect(db *sql.DB) (SQLDialect, bool) {
+	sqlDialectsMu.RLock()
+	defer sqlDialectsMu.RUnlock()
+	for i := len(sqlDialects) - 1; i >= 0; i-- {
+		if sqlDialects[i].Match(db) {
+			return sqlDialects[i].Dialect, true
+		}
+	}
+	return nil, false
+}
+
+// sqlDriverTypeName returns the lower-cased type name of db's driver, which is
+// what the built-in dialects match on.
+func sqlDriverTypeName(db *sql.DB) string {
+	return strings.ToLower(fmt.Sprintf("%T", db.Driver()))
+}
+
+// insertReturningID executes an INSERT and returns the new row id, using
+// RETURNING where the dialect supports it.
+func insertReturningID(db *sql.DB, d SQLDialect, query string, args ...any) (int64, error) {
+	if d.SupportsReturning() {
+		var id int64
+		err := db.QueryRow(Rebind(d, query+" RETURNING id"), args...).Scan(&id)
+		return id, err
+	}
+	res, err := db.Exec(Rebind(d, query), args...)
+	if err != nil {
+		return 0, err
+	}
+	return res.LastInsertId()
+}
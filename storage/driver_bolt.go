@@ -0,0 +1,344 @@
+package storage
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+
+	bolt "go.etcd.io/bbolt"
+	bolterrors "go.etcd.io/bbolt/errors"
+)
+
+type BoltDriver struct {
+	a     *BoltAdapter
+	index *boltVectorIndex
+}
+
+func newBoltDriver(adapter Adapter) (Driver, error) {
+	a, ok := adapter.(*BoltAdapter)
+	if !ok {
+		return nil, fmt.Errorf("bolt driver expects *BoltAdapter, got %T", adapter)
+	}
+	return &BoltDriver{a: a, index: newBoltVectorIndex()}, nil
+}
+
+func (d *BoltDriver) Dialect() string { return "bolt" }
+
+func (d *BoltDriver) Migrate() error {
+	if d.a == nil || d.a.DB == nil {
+		return nil
+	}
+	m := d.SchemaMigrator()
+	return m.Migrate(m.Latest())
+}
+
+// SchemaMigrator returns the migrator of the bucket layout. Rolling a version
+// back deletes its buckets and the data in them.
+func (d *BoltDriver) SchemaMigrator() Migrator { return boltMigrator{d} }
+
+type boltMigrator struct{ d *BoltDriver }
+
+func (m boltMigrator) Latest() int {
+	latest := 0
+	for v := range boltMigrations {
+		latest = max(latest, v)
+	}
+	return latest
+}
+
+func (m boltMigrator) Version() (int, error) {
+	version := 0
+	err := m.d.db().View(func(tx *bolt.Tx) error {
+		if sv := tx.Bucket([]byte("memori_schema_version")); sv != nil {
+			if b := sv.Get([]byte("num")); b != nil {
+				version = int(btoi(b))
+			}
+		}
+		return nil
+	})
+	return version, err
+}
+
+func (m boltMigrator) Plan(target int) ([]MigrationStep, error) {
+	current, err := m.Version()
+	if err != nil {
+		return nil, err
+	}
+	versions, down, err := migrationPath(boltMigrations, current, target)
+	if err != nil {
+		return nil, err
+	}
+	steps := make([]MigrationStep, 0, len(versions))
+	for _, v := range versions {
+		step := MigrationStep{Version: v, Down: down}
+		for _, name := range boltMigrations[v] {
+			if down {
+				step.Ops = append(step.Ops, "delete bucket "+name)
+			} else {
+				step.Ops = append(step.Ops, "create bucket "+name)
+			}
+		}
+		steps = append(steps, step)
+	}
+	return steps, nil
+}
+
+func (m boltMigrator) Migrate(target int) error {
+	steps, err := m.Plan(target)
+	if err != nil || len(steps) == 0 {
+		return err
+	}
+	err = m.d.db().Update(func(tx *bolt.Tx) error {
+		for _, step := range steps {
+			for _, name := range boltMigrations[step.Version] {
+				var err error
+				if step.Down {
+					if err = tx.DeleteBucket([]byte(name)); errors.Is(err, bolterrors.ErrBucketNotFound) {
+						err = nil
+					}
+				} else {
+					_, err = tx.CreateBucketIfNotExists([]byte(name))
+				}
+				if err != nil {
+					return fmt.Errorf("migration %d failed: %w", step.Version, err)
+				}
+			}
+			v := step.Version
+			if step.Down {
+				v--
+			}
+			if v == 0 {
+				continue
+			}
+			sv, err := tx.CreateBucketIfNotExists([]byte("memori_schema_version"))
+			if err != nil {
+				return err
+			}
+			if err := sv.Put([]byte("num"), itob(int64(v))); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err == nil && steps[0].Down {
+		// deleted facts must not linger in the vector index
+		m.d.index.reset()
+	}
+	return err
+}
+
+func (d *BoltDriver) db() *bolt.DB { return d.a.DB }
+
+// key helpers: ids are stored big-endian so that bucket order is id order.
+
+func itob(v int64) []byte {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, uint64(v))
+	return b
+}
+
+func btoi(b []byte) int64 {
+	if len(b) < 8 {
+		return 0
+	}
+	return int64(binary.BigEndian.Uint64(b))
+}
+
+// compositeKey joins an id prefix with a suffix for prefix-scanned index buckets.
+func compositeKey(prefix int64, suffix []byte) []byte {
+	return append(itob(prefix), suffix...)
+}
+
+func boltBucket(tx *bolt.Tx, name string) (*bolt.Bucket, error) {
+	b := tx.Bucket([]byte(name))
+	if b == nil {
+		return nil, fmt.Errorf("bolt bucket %s missing, run migrations first", name)
+	}
+	return b, nil
+}
+
+func boltGetJSON(tx *bolt.Tx, bucket string, id int64, v any) error {
+	b, err := boltBucket(tx, bucket)
+	if err != nil {
+		return err
+	}
+	raw := b.Get(itob(id))
+	if raw == nil {
+		return ErrNotFound
+	}
+	return json.Unmarshal(raw, v)
+}
+
+func boltPutJSON(tx *bolt.Tx, bucket string, id int64, v any) error {
+	b, err := boltBucket(tx, bucket)
+	if err != nil {
+		return err
+	}
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return b.Put(itob(id), raw)
+}
+
+// boltLookup resolves a secondary index entry to an id.
+func boltLookup(tx *bolt.Tx, bucket string, key []byte) (int64, error) {
+	b, err := boltBucket(tx, bucket)
+	if err != nil {
+		return 0, err
+	}
+	raw := b.Get(key)
+	if raw == nil {
+		return 0, ErrNotFound
+	}
+	return btoi(raw), nil
+}
+
+// boltNextID allocates the next id of a record bucket.
+func boltNextID(tx *bolt.Tx, bucket string) (int64, error) {
+	b, err := boltBucket(tx, bucket)
+	if err != nil {
+		return 0, err
+	}
+	seq, err := b.NextSequence()
+	return int64(seq), err
+}
+
+// boltVectorIndex keeps decoded, unit-length fact embeddings in memory per
+// entity so that searches do not decode the embedding bucket on every query.
+// An entity is loaded on first use and kept in sync by the fact writes, which
+// go through update.
+type boltVectorIndex struct {
+	mu       sync.RWMutex
+	entities map[int64]map[int64]boltIndexedVector
+
+	// writes serializes fact writes with their index changes.
+	writes sync.Mutex
+}
+
+// boltIndexedVector is a decoded fact embedding and the vector space it
+// belongs to.
+type boltIndexedVector struct {
+	meta EmbeddingMeta
+	vec  []float32
+}
+
+func newBoltVectorIndex() *boltVectorIndex {
+	return &boltVectorIndex{entities: make(map[int64]map[int64]boltIndexedVector)}
+}
+
+// scan calls fn for every indexed vector of an entity, loading the entity
+// from the embedding bucket first if needed.
+func (ix *boltVectorIndex) scan(db *bolt.DB, entityID int64, fn func(factID int64, v boltIndexedVector)) error {
+	if err := ix.load(db, entityID); err != nil {
+		return err
+	}
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+	for factID, v := range ix.entities[entityID] {
+		fn(factID, v)
+	}
+	return nil
+}
+
+// load reads an entity's vectors from the embedding bucket. The index lock is
+// held while reading so that a concurrent put cannot be lost; writers call put
+// only after their transaction has committed (see update).
+func (ix *boltVectorIndex) load(db *bolt.DB, entityID int64) error {
+	ix.mu.RLock()
+	_, ok := ix.entities[entityID]
+	ix.mu.RUnlock()
+	if ok {
+		return nil
+	}
+
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	if _, ok := ix.entities[entityID]; ok {
+		return nil
+	}
+	vecs := make(map[int64]boltIndexedVector)
+	err := db.View(func(tx *bolt.Tx) error {
+		byEntity, err := boltBucket(tx, "memori_entity_fact_by_entity")
+		if err != nil {
+			return err
+		}
+		facts, err := boltBucket(tx, "memori_entity_fact")
+		if err != nil {
+			return err
+		}
+		embs, err := boltBucket(tx, "memori_entity_fact_embedding")
+		if err != nil {
+			return err
+		}
+		prefix := itob(entityID)
+		c := byEntity.Cursor()
+		for k, _ := c.Seek(prefix); k != nil && len(k) == 16 && btoi(k[:8]) == entityID; k, _ = c.Next() {
+			factID := btoi(k[8:])
+			var rec boltFactRecord
+			if raw := facts.Get(itob(factID)); raw != nil {
+				if err := json.Unmarshal(raw, &rec); err != nil {
+					return err
+				}
+			}
+			vecs[factID] = boltIndexedVector{
+				meta: EmbeddingMeta{Provider: rec.Provider, Model: rec.Model, Dimension: rec.Dimension},
+				vec:  decodeUnit(embs.Get(itob(factID)), rec.Encoding),
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	ix.entities[entityID] = vecs
+	return nil
+}
+
+// update runs fn in a write transaction and, once it has committed, apply,
+// which changes the index to match. Fact writes are serialized from the
+// transaction to the index change, so the index sees them in commit order: two
+// writers of the same fact cannot leave the vector of the one that committed
+// first.
+func (ix *boltVectorIndex) update(db *bolt.DB, fn func(tx *bolt.Tx) error, apply func()) error {
+	ix.writes.Lock()
+	defer ix.writes.Unlock()
+	if err := db.Update(fn); err != nil {
+		return err
+	}
+	apply()
+	return nil
+}
+
+// put records a fact's vector if the entity is already loaded; unloaded
+// entities pick it up from the bucket on first search.
+func (ix *boltVectorIndex) put(entityID, factID int64, v boltIndexedVector) {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	if vecs, ok := ix.entities[entityID]; ok {
+		vecs[factID] = v
+	}
+}
+
+// remove forgets a deleted fact's vector.
+func (ix *boltVectorIndex) remove(entityID, factID int64) {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	delete(ix.entities[entityID], factID)
+}
+
+// reset forgets all loaded vectors.
+func (ix *boltVectorIndex) reset() {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	ix.entities = make(map[int64]map[int64]boltIndexedVector)
+}
+
+// drop forgets all vectors of a deleted entity.
+func (ix *boltVectorIndex) drop(entityID int64) {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	delete(ix.entities, entityID)
+}
@@ -28,9 +28,7 @@ func (d *MongoDriver) Migrate() error {
 	}
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
-	return d.migrateMongo(ctx)
+	return d.migrateMongo(ctx, mongoMigrator{d}.Latest())
 }
 
 func (d *MongoDriver) db() *mongo.Database { return d.a.DB }
-
-
@@ -6,102 +6,49 @@ import (
 )
 
 type SQLDriver struct {
-	a       *SQLAdapter
-	dialect string
-	repos   *sqlRepos
+	a          *SQLAdapter
+	dialect    SQLDialect
+	migrations map[int][]string
+	rollbacks  map[int][]string
+	repos      *sqlRepos
 }
 
-func newSQLDriver(dialect string) driverFactory {
+// NewSQLDriverFactory returns a DriverFactory that serves the built-in SQL
+// repos on top of a *SQLAdapter, using dialect for query syntax and
+// migrations for the schema.
+func NewSQLDriverFactory(dialect SQLDialect, migrations map[int][]string) DriverFactory {
+	return newSQLDriverFactory(dialect, migrations, nil)
+}
+
+func newSQLDriverFactory(dialect SQLDialect, migrations, rollbacks map[int][]string) DriverFactory {
 	return func(adapter Adapter) (Driver, error) {
 		a, ok := adapter.(*SQLAdapter)
 		if !ok {
 			return nil, fmt.Errorf("sql driver expects *SQLAdapter, got %T", adapter)
 		}
-		return &SQLDriver{a: a, dialect: dialect}, nil
+		return &SQLDriver{a: a, dialect: dialect, migrations: migrations, rollbacks: rollbacks}, nil
 	}
 }
 
-func (d *SQLDriver) Dialect() string { return d.dialect }
-
-func (d *SQLDriver) Migrate() error {
-	if d.a == nil || d.a.DB == nil {
-		return nil
-	}
-
-	var migrations map[int][]string
-	switch d.dialect {
-	case "sqlite":
-		migrations = sqliteMigrations
-	case "postgres":
-		migrations = postgresMigrations
-	default:
-		return fmt.Errorf("unsupported SQL dialect: %s", d.dialect)
-	}
-
-	currentVersion := d.getSchemaVersion()
-	maxVersion := 1 // Currently only version 1
-
-	if currentVersion >= maxVersion {
-		return nil
-	}
-
-	tx, err := d.a.DB.Begin()
-	if err != nil {
-		return err
-	}
-	defer tx.Rollback()
-
-	for v := currentVersion + 1; v <= maxVersion; v++ {
-		ops, ok := migrations[v]
-		if !ok {
-			continue
-		}
+func (d *SQLDriver) Dialect() string { return d.dialect.Name() }
 
-		for _, op := range ops {
-			if _, err := tx.Exec(op); err != nil {
-				return fmt.Errorf("migration %d failed: %w", v, err)
-			}
-		}
-
-		// Update schema version
-		var updateSQL string
-		if d.dialect == "postgres" {
-			if currentVersion == 0 {
-				updateSQL = "INSERT INTO memori_schema_version (num) VALUES ($1)"
-			} else {
-				updateSQL = "UPDATE memori_schema_version SET num = $1"
-			}
-			_, err = tx.Exec(updateSQL, v)
-		} else {
-			if currentVersion == 0 {
-				updateSQL = "INSERT INTO memori_schema_version (num) VALUES (?)"
-			} else {
-				updateSQL = "UPDATE memori_schema_version SET num = ?"
-			}
-			_, err = tx.Exec(updateSQL, v)
-		}
-		if err != nil {
-			return err
-		}
-		currentVersion = v
-	}
+// SQLDialect returns the dialect used to build queries.
+func (d *SQLDriver) SQLDialect() SQLDialect { return d.dialect }
 
-	return tx.Commit()
+// Migrator returns the migration runner for this driver's schema.
+func (d *SQLDriver) Migrator() *SQLMigrator {
+	return &SQLMigrator{DB: d.db(), Dialect: d.dialect, Migrations: d.migrations, Rollbacks: d.rollbacks}
 }
 
-func (d *SQLDriver) getSchemaVersion() int {
-	var version sql.NullInt64
-	err := d.a.DB.QueryRow("SELECT num FROM memori_schema_version LIMIT 1").Scan(&version)
-	if err == sql.ErrNoRows || !version.Valid {
-		return 0
+func (d *SQLDriver) Migrate() error {
+	if d.a == nil || d.a.DB == nil {
+		return nil
 	}
-	if err != nil {
-		return 0
+	if d.migrations == nil {
+		return fmt.Errorf("unsupported SQL dialect: %s", d.dialect.Name())
 	}
-	return int(version.Int64)
+	return d.Migrator().Up()
 }
 
 // Helpers for future repos:
 func (d *SQLDriver) db() *sql.DB { return d.a.DB }
-
-
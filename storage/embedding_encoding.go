@@ -0,0 +1,133 @@
+package storage
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"strings"
+
+	"memorigo/vecmath"
+)
+
+// EmbeddingEncoding identifies how a fact vector is serialized. It is stored
+// next to the blob (embedding_encoding) so that facts written with different
+// settings can be decoded side by side.
+type EmbeddingEncoding int
+
+const (
+	// EncodingFloat32 is little-endian float32, 4 bytes per dimension. Facts
+	// written before encodings were recorded use it.
+	EncodingFloat32 EmbeddingEncoding = 0
+	// EncodingInt8 is symmetric scalar quantization: a float32 scale followed by
+	// one signed byte per dimension, about 4x smaller than EncodingFloat32.
+	EncodingInt8 EmbeddingEncoding = 1
+
+	// EncodingNormalized is or-ed into the format when the vector was
+	// L2-normalized before encoding, so search can use a plain dot product.
+	EncodingNormalized EmbeddingEncoding = 1 << 8
+)
+
+// Format returns the serialization format without flags.
+func (e EmbeddingEncoding) Format() EmbeddingEncoding { return e &^ EncodingNormalized }
+
+// Normalized reports whether the vector was stored at unit length.
+func (e EmbeddingEncoding) Normalized() bool { return e&EncodingNormalized != 0 }
+
+func (e EmbeddingEncoding) String() string {
+	var name string
+	switch e.Format() {
+	case EncodingFloat32:
+		name = "float32"
+	case EncodingInt8:
+		name = "int8"
+	default:
+		return fmt.Sprintf("EmbeddingEncoding(%d)", int(e))
+	}
+	if e.Normalized() {
+		name += "+normalized"
+	}
+	return name
+}
+
+// ParseEmbeddingEncoding parses "float32" (or "") and "int8".
+func ParseEmbeddingEncoding(s string) (EmbeddingEncoding, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "", "float32", "none":
+		return EncodingFloat32, nil
+	case "int8":
+		return EncodingInt8, nil
+	default:
+		return 0, fmt.Errorf("storage: unknown embedding encoding %q", s)
+	}
+}
+
+// EncodeEmbedding serializes v with the given encoding. It does not normalize
+// v; set EncodingNormalized only for vectors that already are.
+func EncodeEmbedding(v []float32, enc EmbeddingEncoding) []byte {
+	if len(v) == 0 {
+		return nil
+	}
+	switch enc.Format() {
+	case EncodingInt8:
+		var maxAbs float64
+		for _, f := range v {
+			if a := math.Abs(float64(f)); a > maxAbs {
+				maxAbs = a
+			}
+		}
+		scale := float32(maxAbs / 127)
+		b := make([]byte, 4+len(v))
+		binary.LittleEndian.PutUint32(b, math.Float32bits(scale))
+		if scale == 0 {
+			return b
+		}
+		for i, f := range v {
+			q := math.Round(float64(f / scale))
+			b[4+i] = byte(int8(math.Max(-127, math.Min(127, q))))
+		}
+		return b
+	default:
+		b := make([]byte, len(v)*4)
+		for i, f := range v {
+			binary.LittleEndian.PutUint32(b[i*4:], math.Float32bits(f))
+		}
+		return b
+	}
+}
+
+// DecodeEmbedding converts a blob written by EncodeEmbedding back to
+// []float32. Malformed blobs decode to nil.
+func DecodeEmbedding(b []byte, enc EmbeddingEncoding) []float32 {
+	switch enc.Format() {
+	case EncodingInt8:
+		if len(b) <= 4 {
+			return nil
+		}
+		scale := math.Float32frombits(binary.LittleEndian.Uint32(b))
+		out := make([]float32, len(b)-4)
+		for i, q := range b[4:] {
+			out[i] = float32(int8(q)) * scale
+		}
+		return out
+	default:
+		if len(b) == 0 || len(b)%4 != 0 {
+			return nil
+		}
+		out := make([]float32, len(b)/4)
+		for i := 0; i < len(out); i++ {
+			u := binary.LittleEndian.Uint32(b[i*4:])
+			out[i] = math.Float32frombits(u)
+		}
+		return out
+	}
+}
+
+// decodeUnit decodes a stored vector at unit length, normalizing vectors that
+// were not stored normalized (e.g. facts written before normalization).
+func decodeUnit(b []byte, enc EmbeddingEncoding) []float32 {
+	v := DecodeEmbedding(b, enc)
+	if !enc.Normalized() {
+		vecmath.Normalize(v)
+	}
+	return v
+}
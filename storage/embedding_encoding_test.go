@@ -0,0 +1,135 @@
+package storage_test
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"testing"
+
+	"memorigo/storage"
+)
+
+func TestEmbeddingEncoding_RoundTrip(t *testing.T) {
+	v := []float32{0.5, -0.25, 0.125, 0, -1}
+	for _, enc := range []storage.EmbeddingEncoding{storage.EncodingFloat32, storage.EncodingInt8} {
+		got := storage.DecodeEmbedding(storage.EncodeEmbedding(v, enc), enc)
+		if len(got) != len(v) {
+			t.Fatalf("%s: decoded %d dims, want %d", enc, len(got), len(v))
+		}
+		for i := range v {
+			if math.Abs(float64(got[i]-v[i])) > 1.0/127 {
+				t.Fatalf("%s: component %d = %f, want %f", enc, i, got[i], v[i])
+			}
+		}
+	}
+	if n := len(storage.EncodeEmbedding(make([]float32, 1536), storage.EncodingInt8)); n != 1540 {
+		t.Fatalf("int8 blob = %d bytes, want 1540", n)
+	}
+}
+
+// matryoshkaVectors generates unit vectors whose variance decays with the
+// dimension index, mimicking Matryoshka-trained models where leading
+// dimensions carry most of the signal.
+func matryoshkaVectors(rng *rand.Rand, n, dim int) [][]float32 {
+	out := make([][]float32, n)
+	for i := range out {
+		v := make([]float32, dim)
+		for j := range v {
+			v[j] = float32(rng.NormFloat64() / math.Sqrt(1+float64(j)/64))
+		}
+		out[i] = normalize(v)
+	}
+	return out
+}
+
+func normalize(v []float32) []float32 {
+	var norm float64
+	for _, x := range v {
+		norm += float64(x) * float64(x)
+	}
+	inv := float32(1 / math.Sqrt(norm))
+	for i := range v {
+		v[i] *= inv
+	}
+	return v
+}
+
+func dot(a, b []float32) float64 {
+	var s float64
+	for i := range a {
+		s += float64(a[i]) * float64(b[i])
+	}
+	return s
+}
+
+func topK(query []float32, docs [][]float32, k int) []int {
+	idx := make([]int, len(docs))
+	scores := make([]float64, len(docs))
+	for i, d := range docs {
+		idx[i] = i
+		scores[i] = dot(query, d)
+	}
+	sort.Slice(idx, func(a, b int) bool { return scores[idx[a]] > scores[idx[b]] })
+	return idx[:k]
+}
+
+// BenchmarkEmbeddingEncoding reports recall@10 against exact float32 search
+// and the stored bytes per vector for each truncation/quantization setting.
+func BenchmarkEmbeddingEncoding(b *testing.B) {
+	const (
+		dim     = 1536
+		docs    = 2000
+		queries = 50
+		k       = 10
+	)
+	rng := rand.New(rand.NewSource(1))
+	corpus := matryoshkaVectors(rng, docs, dim)
+	// queries are noisy copies of corpus vectors so that neighbours exist
+	qs := make([][]float32, queries)
+	for i := range qs {
+		base := corpus[rng.Intn(docs)]
+		q := make([]float32, dim)
+		for j := range q {
+			q[j] = base[j] + float32(rng.NormFloat64()*0.02)
+		}
+		qs[i] = normalize(q)
+	}
+	exact := make([][]int, queries)
+	for i, q := range qs {
+		exact[i] = topK(q, corpus, k)
+	}
+
+	for _, truncate := range []int{dim, 768, 256} {
+		for _, enc := range []storage.EmbeddingEncoding{storage.EncodingFloat32, storage.EncodingInt8} {
+			b.Run(fmt.Sprintf("dims=%d/%s", truncate, enc), func(b *testing.B) {
+				var recall float64
+				var size int
+				for n := 0; n < b.N; n++ {
+					stored := make([][]float32, docs)
+					size = 0
+					for i, d := range corpus {
+						blob := storage.EncodeEmbedding(normalize(append([]float32(nil), d[:truncate]...)), enc)
+						size += len(blob)
+						stored[i] = storage.DecodeEmbedding(blob, enc)
+					}
+					hits := 0
+					for i, q := range qs {
+						want := make(map[int]bool, k)
+						for _, id := range exact[i] {
+							want[id] = true
+						}
+						for _, id := range topK(normalize(append([]float32(nil), q[:truncate]...)), stored, k) {
+							if want[id] {
+								hits++
+							}
+						}
+					}
+					recall = float64(hits) / float64(queries*k)
+				}
+				b.ReportMetric(recall, "recall@10")
+				b.ReportMetric(float64(size)/docs, "bytes/vector")
+			})
+		}
+	}
+}
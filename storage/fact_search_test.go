@@ -0,0 +1,159 @@
+package storage_test
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"path/filepath"
+	"slices"
+	"sort"
+	"sync"
+	"testing"
+
+	bolt "go.etcd.io/bbolt"
+
+	"memorigo/embed"
+	"memorigo/storage"
+)
+
+func TestSearchByEmbedding_VectorSpaces(t *testing.T) {
+	vector := storage.EncodeEmbedding([]float32{1, 0, 0}, storage.EncodingFloat32)
+	facts := []struct {
+		content string
+		meta    storage.EmbeddingMeta
+	}{
+		{"legacy", storage.EmbeddingMeta{}},
+		{"hash v2", storage.EmbeddingMeta{Provider: "hash", Model: "v2", Dimension: 3}},
+		{"hash v1", storage.EmbeddingMeta{Provider: "hash", Model: "v1", Dimension: 3}},
+		{"custom", storage.EmbeddingMeta{Provider: "custom", Dimension: 3}},
+	}
+	tests := []struct {
+		query storage.EmbeddingMeta
+		want  []string
+	}{
+		{storage.EmbeddingMeta{Provider: "hash", Model: "v2"}, []string{"hash v2", "legacy"}},
+		// A provider reporting no model only matches itself.
+		{storage.EmbeddingMeta{Provider: "custom"}, []string{"custom", "legacy"}},
+		{storage.EmbeddingMeta{Provider: "other"}, []string{"legacy"}},
+	}
+
+	for _, backend := range browseBackends {
+		t.Run(backend.name, func(t *testing.T) {
+			conn := backend.open(t)
+			m := storage.NewManager()
+			if err := m.Start(conn); err != nil {
+				t.Fatal(err)
+			}
+			if err := m.Build(); err != nil {
+				t.Fatal(err)
+			}
+			repos := m.Driver().(storage.Repos)
+			entityID, err := repos.Entity().Create("alice")
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, f := range facts {
+				emb := storage.FactEmbedding{EmbeddingMeta: f.meta, Encoding: storage.EncodingFloat32, Vector: vector}
+				if err := repos.EntityFact().Create(entityID, f.content, emb, f.content); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if db, ok := conn.(*sql.DB); ok {
+				// Rows written before the metadata columns existed.
+				if _, err := db.Exec("UPDATE memori_entity_fact SET embedding_provider = NULL, embedding_model = NULL, embedding_dimension = NULL WHERE uniq = 'legacy'"); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			for _, tt := range tests {
+				results, err := repos.EntityFact().SearchByEmbedding(entityID, []float32{1, 0, 0}, tt.query, 10, 100)
+				if err != nil {
+					t.Fatalf("%+v: %v", tt.query, err)
+				}
+				var got []string
+				for _, r := range results {
+					got = append(got, r.Content)
+				}
+				sort.Strings(got)
+				if !slices.Equal(got, tt.want) {
+					t.Errorf("%+v: facts = %q, want %q", tt.query, got, tt.want)
+				}
+			}
+
+			bob, err := repos.Entity().Create("bob")
+			if err != nil {
+				t.Fatal(err)
+			}
+			emb := storage.FactEmbedding{EmbeddingMeta: storage.EmbeddingMeta{Provider: "custom", Dimension: 3}, Encoding: storage.EncodingFloat32, Vector: vector}
+			if err := repos.EntityFact().Create(bob, "custom", emb, "custom"); err != nil {
+				t.Fatal(err)
+			}
+			_, err = repos.EntityFact().SearchByEmbedding(bob, []float32{1, 0, 0}, storage.EmbeddingMeta{Provider: "hash", Model: "v2"}, 10, 100)
+			if !errors.Is(err, embed.ErrEmbeddingDimensionMismatch) {
+				t.Fatalf("search of another vector space: %v, want ErrEmbeddingDimensionMismatch", err)
+			}
+		})
+	}
+}
+
+func TestBoltIndex_ConcurrentWritesMatchCommittedVector(t *testing.T) {
+	db, err := bolt.Open(filepath.Join(t.TempDir(), "index.db"), 0o600, &bolt.Options{NoSync: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	open := func() storage.Repos {
+		m := storage.NewManager()
+		if err := m.Start(db); err != nil {
+			t.Fatal(err)
+		}
+		if err := m.Build(); err != nil {
+			t.Fatal(err)
+		}
+		return m.Driver().(storage.Repos)
+	}
+	search := func(repos storage.Repos, entityID int64) float64 {
+		results, err := repos.EntityFact().SearchByEmbedding(entityID, []float32{1, 0}, storage.EmbeddingMeta{Provider: "test"}, 10, 100)
+		if err != nil || len(results) != 1 {
+			t.Fatalf("search = %+v, %v", results, err)
+		}
+		return results[0].Score
+	}
+
+	repos := open()
+	entityID, err := repos.Entity().Create("alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	meta := storage.EmbeddingMeta{Provider: "test", Dimension: 2}
+	upsert := func(i int) error {
+		angle := float64(i) * math.Pi / 100
+		vector := storage.EncodeEmbedding([]float32{float32(math.Cos(angle)), float32(math.Sin(angle))}, storage.EncodingFloat32)
+		emb := storage.FactEmbedding{EmbeddingMeta: meta, Encoding: storage.EncodingFloat32, Vector: vector}
+		return repos.EntityFact().Upsert(entityID, fmt.Sprintf("fact %d", i), emb, "same")
+	}
+	if err := upsert(0); err != nil {
+		t.Fatal(err)
+	}
+	search(repos, entityID) // loads the entity into the index
+
+	for round := 0; round < 20; round++ {
+		var wg sync.WaitGroup
+		for i := 1; i <= 16; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				if err := upsert(round*16 + i); err != nil {
+					t.Error(err)
+				}
+			}(i)
+		}
+		wg.Wait()
+		// A fresh driver reads the committed vector.
+		if warm, cold := search(repos, entityID), search(open(), entityID); warm != cold {
+			t.Fatalf("round %d: indexed score %v, committed %v", round, warm, cold)
+		}
+	}
+}
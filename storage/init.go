@@ -1,18 +1,34 @@
 package storage
 
 import (
-	_ "database/sql"
+	"database/sql"
+	"strings"
 )
 
 func init() {
 	RegisterAdapter(isSQLDB, newSQLAdapter)
 	RegisterAdapter(isMongoDB, newMongoAdapter)
+	RegisterAdapter(isBoltDB, newBoltAdapter)
 
 	// drivers
-	RegisterDriver("sqlite", newSQLDriver("sqlite"))
-	RegisterDriver("postgres", newSQLDriver("postgres"))
+	_ = RegisterSQLDialect(SQLDialectDescriptor{
+		Dialect: PostgresDialect,
+		Match: func(db *sql.DB) bool {
+			name := sqlDriverTypeName(db)
+			return strings.Contains(name, "pgx") || strings.Contains(name, "postgres") || strings.Contains(name, "pq.")
+		},
+		Migrations: postgresMigrations,
+		Rollbacks:  postgresRollbacks,
+	})
+	_ = RegisterSQLDialect(SQLDialectDescriptor{
+		Dialect: SQLiteDialect,
+		Match: func(db *sql.DB) bool {
+			return strings.Contains(sqlDriverTypeName(db), "sqlite")
+		},
+		Migrations: sqliteMigrations,
+		Rollbacks:  sqliteRollbacks,
+	})
 	RegisterDriver("mongodb", newMongoDriver)
+	RegisterDriver("bolt", newBoltDriver)
 
 }
-
-
@@ -0,0 +1,69 @@
+package storage
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Migrator inspects and changes the schema version of a backend. Migrate
+// only moves between versions; Driver.Migrate, used by Manager.Build, is
+// Migrate to Latest.
+type Migrator interface {
+	// Version returns the applied schema version, 0 for an empty store.
+	Version() (int, error)
+	// Latest returns the highest version known to the migrator.
+	Latest() int
+	// Plan returns the steps Migrate(target) would run, without running them.
+	Plan(target int) ([]MigrationStep, error)
+	// Migrate applies the migrations up to target, or rolls back those above
+	// it when target is below the applied version.
+	Migrate(target int) error
+}
+
+// MigrationStep is one version applied (Down false) or rolled back (Down
+// true), with a description of each operation it runs.
+type MigrationStep struct {
+	Version int      `json:"version"`
+	Down    bool     `json:"down"`
+	Ops     []string `json:"ops"`
+}
+
+// Migrator returns the migrator of the started driver. Built-in drivers all
+// have one; a registered driver can provide one with a
+// SchemaMigrator() Migrator method.
+func (m *Manager) Migrator() (Migrator, error) {
+	switch d := m.driver.(type) {
+	case nil:
+		return nil, fmt.Errorf("storage: no driver started")
+	case *SQLDriver:
+		if d.migrations == nil {
+			return nil, fmt.Errorf("unsupported SQL dialect: %s", d.dialect.Name())
+		}
+		return d.Migrator(), nil
+	case interface{ SchemaMigrator() Migrator }:
+		return d.SchemaMigrator(), nil
+	default:
+		return nil, fmt.Errorf("storage: driver %s has no migrator", m.driver.Dialect())
+	}
+}
+
+// migrationPath returns the versions to apply, in ascending order, to go from
+// current to target, or the versions to roll back, in descending order, when
+// target is below current.
+func migrationPath[T any](migrations map[int]T, current, target int) (versions []int, down bool, err error) {
+	latest := 0
+	for v := range migrations {
+		latest = max(latest, v)
+	}
+	if target < 0 || target > latest {
+		return nil, false, fmt.Errorf("storage: target version %d out of range 0..%d", target, latest)
+	}
+	for v := range migrations {
+		if (v > current && v <= target) || (v <= current && v > target) {
+			versions = append(versions, v)
+		}
+	}
+	down = target < current
+	sort.Slice(versions, func(i, j int) bool { return (versions[i] < versions[j]) != down })
+	return versions, down, nil
+}
@@ -0,0 +1,164 @@
+package storage
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// SQLMigrator applies versioned schema migrations to a SQL database and tracks
+// the applied version in memori_schema_version. Version 1 is expected to
+// create that table, and its rollback to drop it. Rollbacks are optional;
+// without them Migrate cannot go down.
+type SQLMigrator struct {
+	DB         *sql.DB
+	Dialect    SQLDialect
+	Migrations map[int][]string
+	Rollbacks  map[int][]string
+}
+
+// Latest returns the highest version known to the migrator.
+func (m *SQLMigrator) Latest() int {
+	latest := 0
+	for v := range m.Migrations {
+		if v > latest {
+			latest = v
+		}
+	}
+	return latest
+}
+
+// Version returns the currently applied schema version, or 0 for an empty
+// database. Errors other than a missing version table are returned.
+func (m *SQLMigrator) Version() (int, error) {
+	var version sql.NullInt64
+	err := m.DB.QueryRow("SELECT num FROM memori_schema_version LIMIT 1").Scan(&version)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return 0, nil
+	case err != nil:
+		// The version table does not exist before the first migration.
+		if exists, checkErr := m.hasVersionTable(); checkErr == nil && !exists {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("read schema version: %w", err)
+	case !version.Valid:
+		return 0, nil
+	}
+	return int(version.Int64), nil
+}
+
+// versionTableQueries look memori_schema_version up in the catalog of the
+// engines reachable through *sql.DB; the first one that runs decides.
+var versionTableQueries = []string{
+	"SELECT COUNT(*) FROM information_schema.tables WHERE table_name = 'memori_schema_version'",
+	"SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = 'memori_schema_version'",
+}
+
+func (m *SQLMigrator) hasVersionTable() (bool, error) {
+	var err error
+	for _, q := range versionTableQueries {
+		var n int
+		if err = m.DB.QueryRow(q).Scan(&n); err == nil {
+			return n > 0, nil
+		}
+	}
+	return false, err
+}
+
+// Pending returns the versions that Up would apply, in order.
+func (m *SQLMigrator) Pending() ([]int, error) {
+	current, err := m.Version()
+	if err != nil {
+		return nil, err
+	}
+	var out []int
+	for v := range m.Migrations {
+		if v > current {
+			out = append(out, v)
+		}
+	}
+	sort.Ints(out)
+	return out, nil
+}
+
+// Up applies all pending migrations in a single transaction.
+func (m *SQLMigrator) Up() error {
+	return m.Migrate(m.Latest())
+}
+
+// Plan returns the statements Migrate(target) would run.
+func (m *SQLMigrator) Plan(target int) ([]MigrationStep, error) {
+	current, err := m.Version()
+	if err != nil {
+		return nil, err
+	}
+	versions, down, err := migrationPath(m.Migrations, current, target)
+	if err != nil {
+		return nil, err
+	}
+	steps := make([]MigrationStep, 0, len(versions))
+	for _, v := range versions {
+		ops := m.Migrations[v]
+		if down {
+			var ok bool
+			if ops, ok = m.Rollbacks[v]; !ok {
+				return nil, fmt.Errorf("migration %d has no rollback", v)
+			}
+		}
+		steps = append(steps, MigrationStep{Version: v, Down: down, Ops: ops})
+	}
+	return steps, nil
+}
+
+// Migrate applies or rolls back migrations in a single transaction until the
+// schema is at target.
+func (m *SQLMigrator) Migrate(target int) error {
+	if m.DB == nil {
+		return nil
+	}
+	currentVersion, err := m.Version()
+	if err != nil {
+		return err
+	}
+	steps, err := m.Plan(target)
+	if err != nil || len(steps) == 0 {
+		return err
+	}
+
+	tx, err := m.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, step := range steps {
+		for _, op := range step.Ops {
+			if _, err := tx.Exec(op); err != nil {
+				if step.Down {
+					return fmt.Errorf("rollback of migration %d failed: %w", step.Version, err)
+				}
+				return fmt.Errorf("migration %d failed: %w", step.Version, err)
+			}
+		}
+
+		// Update schema version; rolling back version 1 drops its table
+		v := step.Version
+		if step.Down {
+			v--
+		}
+		updateSQL := "UPDATE memori_schema_version SET num = ?"
+		if currentVersion == 0 {
+			updateSQL = "INSERT INTO memori_schema_version (num) VALUES (?)"
+		}
+		if v > 0 {
+			if _, err := tx.Exec(Rebind(m.Dialect, updateSQL), v); err != nil {
+				return err
+			}
+		}
+		currentVersion = v
+	}
+
+	return tx.Commit()
+}
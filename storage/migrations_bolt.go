@@ -0,0 +1,29 @@
+package storage
+
+// boltMigrations lists the buckets created by each schema version. Secondary
+// indexes are buckets too; their keys are described next to each name.
+var boltMigrations = map[int][]string{
+	1: {
+		"memori_schema_version",
+		"memori_entity",
+		"memori_entity_by_external_id", // external_id -> id
+		"memori_process",
+		"memori_process_by_external_id", // external_id -> id
+		"memori_session",
+		"memori_session_by_uuid", // uuid -> id
+		"memori_conversation",
+		"memori_conversation_by_session", // session_id -> latest conversation id
+		"memori_conversation_message",
+		"memori_conversation_message_by_conversation", // conversation_id|id -> nil
+		"memori_entity_fact",
+		"memori_entity_fact_embedding", // id -> encoded embedding
+		"memori_entity_fact_by_entity", // entity_id|id -> nil
+		"memori_entity_fact_by_uniq",   // entity_id|uniq -> id
+	},
+	2: {
+		"memori_embedding_cache", // provider|model|text_hash -> encoded embedding
+	},
+	3: {
+		"memori_llm_usage",
+	},
+}
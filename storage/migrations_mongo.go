@@ -2,6 +2,10 @@ package storage
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
@@ -144,45 +148,69 @@ var mongoMigrations = map[int][]mongoMigrationOp{
 			Options: options.Index().SetUnique(true),
 		}},
 	},
+	2: {
+		{"memori_embedding_cache", mongo.IndexModel{
+			Keys:    bson.D{{Key: "provider", Value: 1}, {Key: "model", Value: 1}, {Key: "text_hash", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		}},
+	},
+	3: {
+		{"memori_llm_usage", mongo.IndexModel{
+			Keys:    bson.D{{Key: "uuid", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		}},
+		{"memori_llm_usage", mongo.IndexModel{
+			Keys:    bson.D{{Key: "entity_external_id", Value: 1}, {Key: "date_created", Value: 1}},
+			Options: options.Index().SetName("idx_memori_llm_usage_entity_date"),
+		}},
+	},
 }
 
-func (d *MongoDriver) migrateMongo(ctx context.Context) error {
+func (d *MongoDriver) migrateMongo(ctx context.Context, target int) error {
 	currentVersion := d.getSchemaVersion(ctx)
-	maxVersion := 1 // Currently only version 1
-
-	if currentVersion >= maxVersion {
-		return nil
+	versions, down, err := migrationPath(mongoMigrations, currentVersion, target)
+	if err != nil {
+		return err
 	}
 
-	for v := currentVersion + 1; v <= maxVersion; v++ {
-		ops, ok := mongoMigrations[v]
-		if !ok {
-			continue
-		}
-
-		for _, op := range ops {
+	for _, v := range versions {
+		for _, op := range mongoMigrations[v] {
 			coll := d.db().Collection(op.Collection)
-			_, err := coll.Indexes().CreateOne(ctx, op.Index)
-			if err != nil {
-				// Ignore duplicate index errors
-				if !mongo.IsDuplicateKeyError(err) {
-					return err
+			if down {
+				_, err = coll.Indexes().DropOne(ctx, mongoIndexName(op.Index))
+				var cmdErr mongo.CommandError
+				if errors.As(err, &cmdErr) && (cmdErr.Name == "IndexNotFound" || cmdErr.Name == "NamespaceNotFound") {
+					err = nil
 				}
+			} else if _, err = coll.Indexes().CreateOne(ctx, op.Index); mongo.IsDuplicateKeyError(err) {
+				// Ignore duplicate index errors
+				err = nil
+			}
+			if err != nil {
+				return err
 			}
 		}
 
 		// Update schema version
+		next := v
+		if down {
+			next = v - 1
+		}
 		svColl := d.db().Collection("memori_schema_version")
-		_, err := svColl.ReplaceOne(
-			ctx,
-			bson.M{"num": currentVersion},
-			bson.M{"num": v},
-			options.Replace().SetUpsert(true),
-		)
+		if next == 0 {
+			_, err = svColl.DeleteMany(ctx, bson.M{})
+		} else {
+			_, err = svColl.ReplaceOne(
+				ctx,
+				bson.M{"num": currentVersion},
+				bson.M{"num": next},
+				options.Replace().SetUpsert(true),
+			)
+		}
 		if err != nil {
 			return err
 		}
-		currentVersion = v
+		currentVersion = next
 	}
 
 	return nil
@@ -203,3 +231,62 @@ func (d *MongoDriver) getSchemaVersion(ctx context.Context) int {
 	return doc.Num
 }
 
+// mongoIndexName returns the name of an index, generated by the server the
+// same way when the model does not set one.
+func mongoIndexName(model mongo.IndexModel) string {
+	if model.Options != nil && model.Options.Name != nil {
+		return *model.Options.Name
+	}
+	var parts []string
+	for _, e := range model.Keys.(bson.D) {
+		parts = append(parts, fmt.Sprintf("%s_%v", e.Key, e.Value))
+	}
+	return strings.Join(parts, "_")
+}
+
+// SchemaMigrator returns the migrator of the index layout. Rolling a version
+// back drops its indexes; documents are kept.
+func (d *MongoDriver) SchemaMigrator() Migrator { return mongoMigrator{d} }
+
+type mongoMigrator struct{ d *MongoDriver }
+
+func (m mongoMigrator) Latest() int {
+	latest := 0
+	for v := range mongoMigrations {
+		latest = max(latest, v)
+	}
+	return latest
+}
+
+func (m mongoMigrator) Version() (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	return m.d.getSchemaVersion(ctx), nil
+}
+
+func (m mongoMigrator) Plan(target int) ([]MigrationStep, error) {
+	current, _ := m.Version()
+	versions, down, err := migrationPath(mongoMigrations, current, target)
+	if err != nil {
+		return nil, err
+	}
+	steps := make([]MigrationStep, 0, len(versions))
+	for _, v := range versions {
+		step := MigrationStep{Version: v, Down: down}
+		for _, op := range mongoMigrations[v] {
+			verb := "create index "
+			if down {
+				verb = "drop index "
+			}
+			step.Ops = append(step.Ops, verb+op.Collection+"."+mongoIndexName(op.Index))
+		}
+		steps = append(steps, step)
+	}
+	return steps, nil
+}
+
+func (m mongoMigrator) Migrate(target int) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	return m.d.migrateMongo(ctx, target)
+}
@@ -150,5 +150,90 @@ var postgresMigrations = map[int][]string{
 			CONSTRAINT fk_memori_know_graph_subject FOREIGN KEY (subject_id) REFERENCES memori_subject (id) ON DELETE CASCADE
 		)`,
 	},
+	2: {
+		`CREATE TABLE IF NOT EXISTS memori_embedding_cache(
+			provider VARCHAR(64) NOT NULL,
+			model VARCHAR(255) NOT NULL,
+			text_hash CHAR(64) NOT NULL,
+			embedding BYTEA NOT NULL,
+			date_created TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (provider, model, text_hash)
+		)`,
+	},
+	3: {
+		// vector space of each fact; NULL for facts written before version 3
+		`ALTER TABLE memori_entity_fact ADD COLUMN embedding_provider VARCHAR(64) DEFAULT NULL`,
+		`ALTER TABLE memori_entity_fact ADD COLUMN embedding_model VARCHAR(255) DEFAULT NULL`,
+		`ALTER TABLE memori_entity_fact ADD COLUMN embedding_dimension INTEGER DEFAULT NULL`,
+	},
+	4: {
+		// serialization of content_embedding, see storage.EmbeddingEncoding
+		`ALTER TABLE memori_entity_fact ADD COLUMN embedding_encoding INTEGER NOT NULL DEFAULT 0`,
+	},
+	5: {
+		// JSON array of storage.MessagePart for multimodal messages
+		`ALTER TABLE memori_conversation_message ADD COLUMN content_parts TEXT DEFAULT NULL`,
+	},
+	6: {
+		// token usage of each LLM call, see storage.UsageRepo
+		`CREATE TABLE IF NOT EXISTS memori_llm_usage(
+			id BIGSERIAL PRIMARY KEY,
+			uuid VARCHAR(36) NOT NULL,
+			conversation_id BIGINT DEFAULT NULL,
+			entity_external_id VARCHAR(100) NOT NULL DEFAULT '',
+			process_external_id VARCHAR(100) NOT NULL DEFAULT '',
+			session_uuid VARCHAR(36) NOT NULL DEFAULT '',
+			provider VARCHAR(64) NOT NULL DEFAULT '',
+			model VARCHAR(255) NOT NULL DEFAULT '',
+			prompt_tokens BIGINT NOT NULL DEFAULT 0,
+			completion_tokens BIGINT NOT NULL DEFAULT 0,
+			total_tokens BIGINT NOT NULL DEFAULT 0,
+			date_created TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			CONSTRAINT uk_memori_llm_usage_uuid UNIQUE (uuid)
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_memori_llm_usage_entity_date ON memori_llm_usage (entity_external_id, date_created)`,
+	},
+	7: {
+		// set on assistant messages of streams that ended before completing
+		`ALTER TABLE memori_conversation_message ADD COLUMN truncated BOOLEAN NOT NULL DEFAULT FALSE`,
+	},
 }
 
+// postgresRollbacks reverts each version of postgresMigrations, newest changes first.
+var postgresRollbacks = map[int][]string{
+	1: {
+		`DROP TABLE IF EXISTS memori_knowledge_graph`,
+		`DROP TABLE IF EXISTS memori_object`,
+		`DROP TABLE IF EXISTS memori_predicate`,
+		`DROP TABLE IF EXISTS memori_subject`,
+		`DROP TABLE IF EXISTS memori_process_attribute`,
+		`DROP TABLE IF EXISTS memori_entity_fact`,
+		`DROP TABLE IF EXISTS memori_conversation_message`,
+		`DROP TABLE IF EXISTS memori_conversation`,
+		`DROP TABLE IF EXISTS memori_session`,
+		`DROP TABLE IF EXISTS memori_process`,
+		`DROP TABLE IF EXISTS memori_entity`,
+		`DROP TABLE IF EXISTS memori_schema_version`,
+	},
+	2: {
+		`DROP TABLE IF EXISTS memori_embedding_cache`,
+	},
+	3: {
+		`ALTER TABLE memori_entity_fact DROP COLUMN embedding_dimension`,
+		`ALTER TABLE memori_entity_fact DROP COLUMN embedding_model`,
+		`ALTER TABLE memori_entity_fact DROP COLUMN embedding_provider`,
+	},
+	4: {
+		`ALTER TABLE memori_entity_fact DROP COLUMN embedding_encoding`,
+	},
+	5: {
+		`ALTER TABLE memori_conversation_message DROP COLUMN content_parts`,
+	},
+	6: {
+		`DROP INDEX IF EXISTS idx_memori_llm_usage_entity_date`,
+		`DROP TABLE IF EXISTS memori_llm_usage`,
+	},
+	7: {
+		`ALTER TABLE memori_conversation_message DROP COLUMN truncated`,
+	},
+}
@@ -1,7 +1,10 @@
 package storage
 
 import (
+	"errors"
 	"fmt"
+	"sort"
+	"sync"
 )
 
 type Adapter interface {
@@ -13,33 +16,99 @@ type Driver interface {
 	Migrate() error
 }
 
-type adapterMatcher func(conn any) bool
-type adapterFactory func(conn any) (Adapter, error)
-type driverFactory func(adapter Adapter) (Driver, error)
+// AdapterMatcher reports whether a connection handle (e.g. *sql.DB) belongs to an adapter.
+type AdapterMatcher func(conn any) bool
+
+// AdapterFactory wraps a matched connection handle into an Adapter.
+type AdapterFactory func(conn any) (Adapter, error)
+
+// DriverFactory builds the Driver for an adapter. The returned Driver should
+// also implement Repos so that Memori can read and write through it.
+type DriverFactory func(adapter Adapter) (Driver, error)
+
+// DriverDescriptor describes a complete storage backend so that packages
+// outside memorigo can plug one in with a single Register call.
+//
+// Match and NewAdapter are optional: a backend that reuses an existing
+// adapter (for example a new SQL engine behind *sql.DB, see RegisterSQLDialect)
+// only needs Dialect and NewDriver.
+type DriverDescriptor struct {
+	// Dialect is the name reported by the adapter and used to look up the driver.
+	Dialect string
+	// Match reports whether a connection handle belongs to this backend.
+	Match AdapterMatcher
+	// NewAdapter wraps a matched connection handle.
+	NewAdapter AdapterFactory
+	// NewDriver builds the driver (and repos) on top of the adapter.
+	NewDriver DriverFactory
+}
 
 var (
+	registryMu      sync.RWMutex
 	adapterRegistry = make([]struct {
-		match   adapterMatcher
-		factory adapterFactory
+		match   AdapterMatcher
+		factory AdapterFactory
 	}, 0)
-	driverRegistry = make(map[string]driverFactory)
+	driverRegistry = make(map[string]DriverFactory)
 )
 
-func RegisterAdapter(match adapterMatcher, factory adapterFactory) {
+// Register installs a storage backend described by desc.
+func Register(desc DriverDescriptor) error {
+	if desc.Dialect == "" {
+		return errors.New("storage: driver descriptor requires a dialect")
+	}
+	if desc.NewDriver == nil {
+		return fmt.Errorf("storage: driver descriptor %q requires NewDriver", desc.Dialect)
+	}
+	if (desc.Match == nil) != (desc.NewAdapter == nil) {
+		return fmt.Errorf("storage: driver descriptor %q must set both Match and NewAdapter or neither", desc.Dialect)
+	}
+	if desc.Match != nil {
+		RegisterAdapter(desc.Match, desc.NewAdapter)
+	}
+	RegisterDriver(desc.Dialect, desc.NewDriver)
+	return nil
+}
+
+// RegisterAdapter adds an adapter to the registry. Adapters registered later
+// are matched first, so a third-party adapter can take over a connection type
+// that a built-in adapter also accepts.
+func RegisterAdapter(match AdapterMatcher, factory AdapterFactory) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
 	adapterRegistry = append(adapterRegistry, struct {
-		match   adapterMatcher
-		factory adapterFactory
+		match   AdapterMatcher
+		factory AdapterFactory
 	}{match: match, factory: factory})
 }
 
-func RegisterDriver(dialect string, factory driverFactory) {
+// RegisterDriver binds a driver factory to a dialect name, replacing any
+// previous registration for the same dialect.
+func RegisterDriver(dialect string, factory DriverFactory) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
 	driverRegistry[dialect] = factory
 }
 
+// Dialects returns the names of all registered drivers in sorted order.
+func Dialects() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	out := make([]string, 0, len(driverRegistry))
+	for name := range driverRegistry {
+		out = append(out, name)
+	}
+	sort.Strings(out)
+	return out
+}
+
 func RegistryAdapter(conn any) (Adapter, error) {
-	for _, entry := range adapterRegistry {
-		if entry.match(conn) {
-			return entry.factory(conn)
+	registryMu.RLock()
+	entries := append(adapterRegistry[:0:0], adapterRegistry...)
+	registryMu.RUnlock()
+	for i := len(entries) - 1; i >= 0; i-- {
+		if entries[i].match(conn) {
+			return entries[i].factory(conn)
 		}
 	}
 	return nil, fmt.Errorf("%w: %T", ErrNoAdapter, conn)
@@ -47,11 +116,11 @@ func RegistryAdapter(conn any) (Adapter, error) {
 
 func RegistryDriver(adapter Adapter) (Driver, error) {
 	dialect := adapter.Dialect()
+	registryMu.RLock()
 	f, ok := driverRegistry[dialect]
+	registryMu.RUnlock()
 	if !ok {
 		return nil, fmt.Errorf("no driver registered for dialect: %s", dialect)
 	}
 	return f(adapter)
 }
-
-
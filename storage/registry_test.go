@@ -0,0 +1,245 @@
+package storage_test
+
+import (
+	"database/sql"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	_ "modernc.org/sqlite"
+
+	"memorigo/storage"
+)
+
+// fakeConn and fakeDriver stand in for a third-party backend that implements
+// only the core Repos.
+type fakeConn struct{}
+
+type fakeAdapter struct{}
+
+func (fakeAdapter) Dialect() string { return "fake" }
+
+type fakeDriver struct{ migrated *bool }
+
+func (fakeDriver) Dialect() string                        { return "fake" }
+func (d fakeDriver) Migrate() error                       { *d.migrated = true; return nil }
+func (fakeDriver) Entity() storage.EntityRepo             { return nil }
+func (fakeDriver) Process() storage.ProcessRepo           { return nil }
+func (fakeDriver) Session() storage.SessionRepo           { return nil }
+func (fakeDriver) Conversation() storage.ConversationRepo { return nil }
+func (fakeDriver) Message() storage.MessageRepo           { return nil }
+func (fakeDriver) EntityFact() storage.EntityFactRepo     { return nil }
+
+var _ storage.Repos = fakeDriver{}
+
+func TestRegister_ThirdPartyDriver(t *testing.T) {
+	if err := storage.Register(storage.DriverDescriptor{Dialect: "fake"}); err == nil {
+		t.Fatal("descriptor without NewDriver accepted")
+	}
+	if err := storage.Register(storage.DriverDescriptor{
+		Dialect:   "fake",
+		Match:     func(any) bool { return true },
+		NewDriver: func(storage.Adapter) (storage.Driver, error) { return nil, nil },
+	}); err == nil {
+		t.Fatal("descriptor with Match but no NewAdapter accepted")
+	}
+
+	migrated := false
+	err := storage.Register(storage.DriverDescriptor{
+		Dialect: "fake",
+		Match: func(conn any) bool {
+			_, ok := conn.(fakeConn)
+			return ok
+		},
+		NewAdapter: func(any) (storage.Adapter, error) { return fakeAdapter{}, nil },
+		NewDriver: func(storage.Adapter) (storage.Driver, error) {
+			return fakeDriver{migrated: &migrated}, nil
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Contains(storage.Dialects(), "fake") {
+		t.Fatalf("Dialects() = %v, missing fake", storage.Dialects())
+	}
+
+	m := storage.NewManager()
+	if err := m.Start(fakeConn{}); err != nil {
+		t.Fatal(err)
+	}
+	if m.Dialect() != "fake" {
+		t.Fatalf("dialect = %q, want fake", m.Dialect())
+	}
+	if err := m.Build(); err != nil || !migrated {
+		t.Fatalf("Build() = %v, migrated = %v", err, migrated)
+	}
+	if _, err := m.Migrator(); err == nil {
+		t.Fatal("driver without SchemaMigrator returned a migrator")
+	}
+
+	if _, err := storage.BrowseOf(m.Driver()); !errors.Is(err, storage.ErrUnsupported) || !strings.Contains(err.Error(), "fake") {
+		t.Fatalf("BrowseOf() error = %v, want ErrUnsupported naming the driver", err)
+	}
+	if _, err := storage.UsageOf(m.Driver()); !errors.Is(err, storage.ErrUnsupported) {
+		t.Fatalf("UsageOf() error = %v, want ErrUnsupported", err)
+	}
+	if _, err := storage.EmbeddingCacheOf(m.Driver()); !errors.Is(err, storage.ErrUnsupported) {
+		t.Fatalf("EmbeddingCacheOf() error = %v, want ErrUnsupported", err)
+	}
+}
+
+// testDialect is SQLite under another name, registered for a single database.
+type testDialect struct{}
+
+func (testDialect) Name() string            { return "sqlite-test" }
+func (testDialect) Placeholder(int) string  { return "?" }
+func (testDialect) SupportsReturning() bool { return true }
+func (testDialect) Upsert(conflict []string, assignments string) string {
+	return "ON CONFLICT(" + strings.Join(conflict, ", ") + ") DO UPDATE SET " + assignments
+}
+
+var testMigrations = map[int][]string{
+	1: {
+		"CREATE TABLE memori_schema_version (num INTEGER NOT NULL)",
+		"CREATE TABLE widget (id INTEGER PRIMARY KEY, name TEXT NOT NULL)",
+	},
+	2: {"ALTER TABLE widget ADD COLUMN color TEXT"},
+}
+
+var testRollbacks = map[int][]string{
+	1: {"DROP TABLE widget", "DROP TABLE memori_schema_version"},
+	2: {"ALTER TABLE widget DROP COLUMN color"},
+}
+
+func openSQLite(t *testing.T, name string) *sql.DB {
+	t.Helper()
+	db, err := sql.Open("sqlite", "file:"+name+"?mode=memory&cache=shared")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func TestRegisterSQLDialect(t *testing.T) {
+	if err := storage.RegisterSQLDialect(storage.SQLDialectDescriptor{}); err == nil {
+		t.Fatal("descriptor without dialect accepted")
+	}
+	if err := storage.RegisterSQLDialect(storage.SQLDialectDescriptor{Dialect: testDialect{}}); err == nil {
+		t.Fatal("descriptor without Match accepted")
+	}
+
+	db := openSQLite(t, "storage_dialect")
+	err := storage.RegisterSQLDialect(storage.SQLDialectDescriptor{
+		Dialect:    testDialect{},
+		Match:      func(conn *sql.DB) bool { return conn == db },
+		Migrations: testMigrations,
+		Rollbacks:  testRollbacks,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m := storage.NewManager()
+	if err := m.Start(db); err != nil {
+		t.Fatal(err)
+	}
+	if m.Dialect() != "sqlite-test" {
+		t.Fatalf("dialect = %q, want sqlite-test", m.Dialect())
+	}
+	if err := m.Build(); err != nil {
+		t.Fatal(err)
+	}
+	mig, err := m.Migrator()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, err := mig.Version(); err != nil || v != 2 {
+		t.Fatalf("Version() = %d, %v; want 2", v, err)
+	}
+	if _, err := db.Exec("INSERT INTO widget (name, color) VALUES ('a', 'red')"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Other databases keep the built-in SQLite dialect.
+	other := storage.NewManager()
+	if err := other.Start(openSQLite(t, "storage_dialect_other")); err != nil {
+		t.Fatal(err)
+	}
+	if other.Dialect() != "sqlite" {
+		t.Fatalf("dialect = %q, want sqlite", other.Dialect())
+	}
+}
+
+func TestSQLMigrator_UpAndDown(t *testing.T) {
+	db := openSQLite(t, "storage_migrator")
+	m := &storage.SQLMigrator{DB: db, Dialect: storage.SQLiteDialect, Migrations: testMigrations, Rollbacks: testRollbacks}
+
+	if v, err := m.Version(); err != nil || v != 0 {
+		t.Fatalf("Version() on empty database = %d, %v; want 0", v, err)
+	}
+	if pending, err := m.Pending(); err != nil || !slices.Equal(pending, []int{1, 2}) {
+		t.Fatalf("Pending() = %v, %v; want [1 2]", pending, err)
+	}
+
+	steps := []struct {
+		target  int
+		planned []int
+	}{
+		{1, []int{1}},
+		{2, []int{2}},
+		{0, []int{2, 1}},
+		{2, []int{1, 2}},
+	}
+	for _, step := range steps {
+		plan, err := m.Plan(step.target)
+		if err != nil {
+			t.Fatalf("Plan(%d): %v", step.target, err)
+		}
+		var versions []int
+		for _, p := range plan {
+			versions = append(versions, p.Version)
+		}
+		if !slices.Equal(versions, step.planned) {
+			t.Fatalf("Plan(%d) versions = %v, want %v", step.target, versions, step.planned)
+		}
+		if err := m.Migrate(step.target); err != nil {
+			t.Fatalf("Migrate(%d): %v", step.target, err)
+		}
+		if v, err := m.Version(); err != nil || v != step.target {
+			t.Fatalf("Version() after Migrate(%d) = %d, %v", step.target, v, err)
+		}
+	}
+
+	if _, err := m.Plan(3); err == nil {
+		t.Fatal("Plan beyond Latest accepted")
+	}
+	noDown := &storage.SQLMigrator{DB: db, Dialect: storage.SQLiteDialect, Migrations: testMigrations}
+	if err := noDown.Migrate(1); err == nil {
+		t.Fatal("rollback without Rollbacks accepted")
+	}
+}
+
+func TestSQLMigrator_VersionErrors(t *testing.T) {
+	db := openSQLite(t, "storage_migrator_errors")
+	m := &storage.SQLMigrator{DB: db, Dialect: storage.SQLiteDialect, Migrations: testMigrations}
+
+	// A version table that cannot be read is not an empty database.
+	if _, err := db.Exec("CREATE TABLE memori_schema_version (version INTEGER)"); err != nil {
+		t.Fatal(err)
+	}
+	if v, err := m.Version(); err == nil {
+		t.Fatalf("Version() with an unreadable version table = %d, want an error", v)
+	}
+	if err := m.Up(); err == nil {
+		t.Fatal("Up() re-ran migrations over an unreadable version table")
+	}
+
+	closed := openSQLite(t, "storage_migrator_closed")
+	closed.Close()
+	m.DB = closed
+	if v, err := m.Version(); err == nil {
+		t.Fatalf("Version() on a closed database = %d, want an error", v)
+	}
+}
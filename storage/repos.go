@@ -3,10 +3,9 @@ package storage
 import (
 	"context"
 	"database/sql"
-	"encoding/binary"
+	"encoding/json"
+	"errors"
 	"fmt"
-	"math"
-	"sort"
 	"strings"
 	"time"
 
@@ -14,6 +13,9 @@ import (
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"memorigo/embed"
+	"memorigo/vecmath"
 )
 
 func decodeAnyTime(v any) (time.Time, bool) {
@@ -34,12 +36,17 @@ func parseTimeString(s string) (time.Time, bool) {
 	if s == "" {
 		return time.Time{}, false
 	}
+	// time.Time.String appends the monotonic clock reading
+	if i := strings.Index(s, " m="); i > 0 {
+		s = s[:i]
+	}
 	// Common layouts:
 	layouts := []string{
 		time.RFC3339Nano,
 		time.RFC3339,
 		"2006-01-02 15:04:05", // SQLite datetime('now')
 		"2006-01-02 15:04:05.999999999",
+		"2006-01-02 15:04:05.999999999 -0700 MST", // time.Time.String, as written by modernc.org/sqlite
 	}
 	for _, layout := range layouts {
 		if t, err := time.Parse(layout, s); err == nil {
@@ -49,6 +56,18 @@ func parseTimeString(s string) (time.Time, bool) {
 	return time.Time{}, false
 }
 
+var (
+	// ErrNotFound is returned by drivers without a native "no rows" error when a
+	// lookup matches nothing.
+	ErrNotFound = errors.New("storage: record not found")
+	// ErrDuplicate is returned by drivers without native unique constraints when
+	// a create would violate one.
+	ErrDuplicate = errors.New("storage: duplicate record")
+	// ErrUnsupported is returned, wrapped, when a driver lacks an optional
+	// capability.
+	ErrUnsupported = errors.New("storage: not supported")
+)
+
 // Repos interface for driver operations
 type Repos interface {
 	Entity() EntityRepo
@@ -59,6 +78,59 @@ type Repos interface {
 	EntityFact() EntityFactRepo
 }
 
+// Optional capabilities of a driver, found by type assertion so that drivers
+// written against an earlier Repos keep compiling. The built-in drivers have
+// them all.
+type (
+	// EmbeddingCacheRepos persists the embedding cache.
+	EmbeddingCacheRepos interface {
+		EmbeddingCache() EmbeddingCacheRepo
+	}
+	// UsageRepos records and aggregates LLM token usage.
+	UsageRepos interface {
+		Usage() UsageRepo
+	}
+	// BrowseRepos lists and edits stored memory.
+	BrowseRepos interface {
+		Browse() BrowseRepo
+	}
+)
+
+// EmbeddingCacheOf returns the embedding cache repo of a driver, or an
+// ErrUnsupported error when it has none.
+func EmbeddingCacheOf(driver any) (EmbeddingCacheRepo, error) {
+	if d, ok := driver.(EmbeddingCacheRepos); ok {
+		return d.EmbeddingCache(), nil
+	}
+	return nil, unsupported(driver, "the embedding cache")
+}
+
+// UsageOf returns the usage repo of a driver, or an ErrUnsupported error
+// when it has none.
+func UsageOf(driver any) (UsageRepo, error) {
+	if d, ok := driver.(UsageRepos); ok {
+		return d.Usage(), nil
+	}
+	return nil, unsupported(driver, "usage records")
+}
+
+// BrowseOf returns the browse repo of a driver, or an ErrUnsupported error
+// when it has none.
+func BrowseOf(driver any) (BrowseRepo, error) {
+	if d, ok := driver.(BrowseRepos); ok {
+		return d.Browse(), nil
+	}
+	return nil, unsupported(driver, "browsing")
+}
+
+func unsupported(driver any, what string) error {
+	name := fmt.Sprintf("%T", driver)
+	if d, ok := driver.(Driver); ok {
+		name = d.Dialect()
+	}
+	return fmt.Errorf("%w: driver %s does not support %s", ErrUnsupported, name, what)
+}
+
 type EntityRepo interface {
 	Create(externalID string) (int64, error)
 	GetByExternalID(externalID string) (int64, error)
@@ -81,13 +153,97 @@ type ConversationRepo interface {
 }
 
 type MessageRepo interface {
-	Create(conversationID int64, role, msgType, content string) error
+	Create(msg MessageRecord) error
+}
+
+// MessageRecord is one conversation message to store. Content is the text of
+// the message; Parts, when set, is its structured (multimodal) content.
+// Truncated marks a reply that was cut short, e.g. by an aborted stream.
+type MessageRecord struct {
+	ConversationID int64
+	Role           string
+	Type           string
+	Content        string
+	Parts          []MessagePart
+	Truncated      bool
+}
+
+// MessagePart is one part of a multimodal message. Non-text parts are stored
+// as references: a URL, or a hash of inline data, never the data itself.
+type MessagePart struct {
+	Type     string `json:"type" bson:"type"` // text, image_url, input_audio, file
+	Text     string `json:"text,omitempty" bson:"text,omitempty"`
+	URL      string `json:"url,omitempty" bson:"url,omitempty"`
+	Hash     string `json:"hash,omitempty" bson:"hash,omitempty"` // "sha256:<hex>" of inline data
+	MimeType string `json:"mime_type,omitempty" bson:"mime_type,omitempty"`
+	Size     int    `json:"size,omitempty" bson:"size,omitempty"` // bytes of inline data
+	Detail   string `json:"detail,omitempty" bson:"detail,omitempty"`
+	FileID   string `json:"file_id,omitempty" bson:"file_id,omitempty"`
+	Filename string `json:"filename,omitempty" bson:"filename,omitempty"`
+}
+
+// encodeMessageParts returns the JSON stored in content_parts, or nil.
+func encodeMessageParts(parts []MessagePart) (any, error) {
+	if len(parts) == 0 {
+		return nil, nil
+	}
+	b, err := json.Marshal(parts)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
 }
 
 type EntityFactRepo interface {
-	Create(entityID int64, content string, embedding []byte, uniq string) error
-	Upsert(entityID int64, content string, embedding []byte, uniq string) error
-	SearchByEmbedding(entityID int64, queryEmbedding []float32, limit, embeddingsLimit int) ([]FactResult, error)
+	Create(entityID int64, content string, embedding FactEmbedding, uniq string) error
+	Upsert(entityID int64, content string, embedding FactEmbedding, uniq string) error
+	// SearchByEmbedding ranks an entity's facts against queryEmbedding. Facts
+	// from another vector space are never compared; when only such facts exist
+	// the error wraps embed.ErrEmbeddingDimensionMismatch.
+	SearchByEmbedding(entityID int64, queryEmbedding []float32, meta EmbeddingMeta, limit, embeddingsLimit int) ([]FactResult, error)
+	// ListStale returns up to limit facts, across all entities, whose embedding
+	// metadata differs from target.
+	ListStale(target EmbeddingMeta, limit int) ([]StoredFact, error)
+	UpdateEmbedding(entityID int64, uniq string, embedding FactEmbedding) error
+}
+
+// EmbeddingMeta records which embedder produced a vector. Vectors are only
+// comparable when provider, model and dimension all match.
+type EmbeddingMeta struct {
+	Provider  string `json:"provider,omitempty"`
+	Model     string `json:"model,omitempty"`
+	Dimension int    `json:"dimension,omitempty"`
+}
+
+// FactEmbedding is an encoded fact vector together with its provenance.
+type FactEmbedding struct {
+	EmbeddingMeta
+	Encoding EmbeddingEncoding
+	Vector   []byte
+}
+
+// sqlVector is the value stored in the NOT NULL content_embedding column; a
+// fact kept without a vector stores an empty one.
+func (e FactEmbedding) sqlVector() []byte {
+	if e.Vector == nil {
+		return []byte{}
+	}
+	return e.Vector
+}
+
+// StoredFact identifies a fact for maintenance jobs such as re-embedding.
+type StoredFact struct {
+	EntityID int64
+	Uniq     string
+	Content  string
+	EmbeddingMeta
+}
+
+// EmbeddingCacheRepo persists encoded embeddings keyed by provider, model and
+// the hex sha256 of the embedded text.
+type EmbeddingCacheRepo interface {
+	Get(provider, model, textHash string) ([]byte, error)
+	Put(provider, model, textHash string, embedding []byte) error
 }
 
 type FactResult struct {
@@ -99,15 +255,8 @@ type FactResult struct {
 
 // SQL repos implementation
 type sqlEntityRepo struct {
-	db      *sql.DB
-	dialect string
-}
-
-func (r *sqlEntityRepo) placeholder(n int) string {
-	if r.dialect == "postgres" {
-		return fmt.Sprintf("$%d", n)
-	}
-	return "?"
+	db *sql.DB
+	d  SQLDialect
 }
 
 func (r *sqlEntityRepo) Create(externalID string) (int64, error) {
@@ -116,18 +265,10 @@ func (r *sqlEntityRepo) Create(externalID string) (int64, error) {
 		return id, nil
 	}
 
-	var id int64
-	u := uuid.New().String()
-	now := time.Now()
-
-	var query string
-	if r.dialect == "postgres" {
-		query = "INSERT INTO memori_entity (uuid, external_id, date_created) VALUES ($1, $2, $3) RETURNING id"
-	} else {
-		query = "INSERT INTO memori_entity (uuid, external_id, date_created) VALUES (?, ?, ?) RETURNING id"
-	}
-
-	err := r.db.QueryRow(query, u, externalID, now).Scan(&id)
+	id, err := insertReturningID(r.db, r.d,
+		"INSERT INTO memori_entity (uuid, external_id, date_created) VALUES (?, ?, ?)",
+		uuid.New().String(), externalID, time.Now(),
+	)
 	if err != nil {
 		// Fallback to existing (handles unique constraint races)
 		return r.GetByExternalID(externalID)
@@ -137,32 +278,20 @@ func (r *sqlEntityRepo) Create(externalID string) (int64, error) {
 
 func (r *sqlEntityRepo) GetByExternalID(externalID string) (int64, error) {
 	var id int64
-	query := "SELECT id FROM memori_entity WHERE external_id = " + r.placeholder(1)
-	err := r.db.QueryRow(query, externalID).Scan(&id)
+	err := r.db.QueryRow(Rebind(r.d, "SELECT id FROM memori_entity WHERE external_id = ?"), externalID).Scan(&id)
 	return id, err
 }
 
 type sqlProcessRepo struct {
-	db      *sql.DB
-	dialect string
+	db *sql.DB
+	d  SQLDialect
 }
 
 func (r *sqlProcessRepo) Create(externalID string) (int64, error) {
-	u := uuid.New().String()
-	var id int64
-	now := time.Now()
-
-	var query string
-	if r.dialect == "postgres" {
-		query = "INSERT INTO memori_process (uuid, external_id, date_created) VALUES ($1, $2, $3) RETURNING id"
-	} else {
-		query = "INSERT INTO memori_process (uuid, external_id, date_created) VALUES (?, ?, ?) RETURNING id"
-	}
-
-	err := r.db.QueryRow(
-		query,
-		u, externalID, now,
-	).Scan(&id)
+	id, err := insertReturningID(r.db, r.d,
+		"INSERT INTO memori_process (uuid, external_id, date_created) VALUES (?, ?, ?)",
+		uuid.New().String(), externalID, time.Now(),
+	)
 	if err != nil {
 		return r.GetByExternalID(externalID)
 	}
@@ -171,36 +300,20 @@ func (r *sqlProcessRepo) Create(externalID string) (int64, error) {
 
 func (r *sqlProcessRepo) GetByExternalID(externalID string) (int64, error) {
 	var id int64
-	query := "SELECT id FROM memori_process WHERE external_id = " + func() string {
-		if r.dialect == "postgres" {
-			return "$1"
-		}
-		return "?"
-	}()
-	err := r.db.QueryRow(query, externalID).Scan(&id)
+	err := r.db.QueryRow(Rebind(r.d, "SELECT id FROM memori_process WHERE external_id = ?"), externalID).Scan(&id)
 	return id, err
 }
 
 type sqlSessionRepo struct {
-	db      *sql.DB
-	dialect string
+	db *sql.DB
+	d  SQLDialect
 }
 
 func (r *sqlSessionRepo) Create(entityID, processID *int64, sessionUUID uuid.UUID) (int64, error) {
-	var id int64
-	now := time.Now()
-
-	var query string
-	if r.dialect == "postgres" {
-		query = "INSERT INTO memori_session (uuid, entity_id, process_id, date_created) VALUES ($1, $2, $3, $4) RETURNING id"
-	} else {
-		query = "INSERT INTO memori_session (uuid, entity_id, process_id, date_created) VALUES (?, ?, ?, ?) RETURNING id"
-	}
-
-	err := r.db.QueryRow(
-		query,
-		sessionUUID.String(), entityID, processID, now,
-	).Scan(&id)
+	id, err := insertReturningID(r.db, r.d,
+		"INSERT INTO memori_session (uuid, entity_id, process_id, date_created) VALUES (?, ?, ?, ?)",
+		sessionUUID.String(), entityID, processID, time.Now(),
+	)
 	if err != nil {
 		return r.GetByUUID(sessionUUID)
 	}
@@ -209,32 +322,21 @@ func (r *sqlSessionRepo) Create(entityID, processID *int64, sessionUUID uuid.UUI
 
 func (r *sqlSessionRepo) GetByUUID(sessionUUID uuid.UUID) (int64, error) {
 	var id int64
-	query := "SELECT id FROM memori_session WHERE uuid = " + func() string {
-		if r.dialect == "postgres" {
-			return "$1"
-		}
-		return "?"
-	}()
-	err := r.db.QueryRow(query, sessionUUID.String()).Scan(&id)
+	err := r.db.QueryRow(Rebind(r.d, "SELECT id FROM memori_session WHERE uuid = ?"), sessionUUID.String()).Scan(&id)
 	return id, err
 }
 
 type sqlConversationRepo struct {
-	db      *sql.DB
-	dialect string
+	db *sql.DB
+	d  SQLDialect
 }
 
 func (r *sqlConversationRepo) Create(sessionID int64, timeoutMinutes int) (int64, error) {
 	// Check if existing conversation is still valid
 	var existingID sql.NullInt64
 	var existingCreated any
-	querySel := "SELECT id, date_created FROM memori_conversation WHERE session_id = %s ORDER BY date_created DESC LIMIT 1"
-	ph := "?"
-	if r.dialect == "postgres" {
-		ph = "$1"
-	}
 	err := r.db.QueryRow(
-		fmt.Sprintf(querySel, ph),
+		Rebind(r.d, "SELECT id, date_created FROM memori_conversation WHERE session_id = ? ORDER BY date_created DESC LIMIT 1"),
 		sessionID,
 	).Scan(&existingID, &existingCreated)
 	if err == nil && existingID.Valid {
@@ -246,142 +348,119 @@ func (r *sqlConversationRepo) Create(sessionID int64, timeoutMinutes int) (int64
 		}
 	}
 
-	u := uuid.New().String()
-	var id int64
-	now := time.Now()
-	var queryIns string
-	if r.dialect == "postgres" {
-		queryIns = "INSERT INTO memori_conversation (uuid, session_id, date_created) VALUES ($1, $2, $3) RETURNING id"
-	} else {
-		queryIns = "INSERT INTO memori_conversation (uuid, session_id, date_created) VALUES (?, ?, ?) RETURNING id"
-	}
-	err = r.db.QueryRow(
-		queryIns,
-		u, sessionID, now,
-	).Scan(&id)
-	return id, err
+	return insertReturningID(r.db, r.d,
+		"INSERT INTO memori_conversation (uuid, session_id, date_created) VALUES (?, ?, ?)",
+		uuid.New().String(), sessionID, time.Now(),
+	)
 }
 
 func (r *sqlConversationRepo) GetBySessionID(sessionID int64) (int64, error) {
 	var id int64
-	query := "SELECT id FROM memori_conversation WHERE session_id = %s ORDER BY date_created DESC LIMIT 1"
-	ph := "?"
-	if r.dialect == "postgres" {
-		ph = "$1"
-	}
-	err := r.db.QueryRow(fmt.Sprintf(query, ph), sessionID).Scan(&id)
+	query := "SELECT id FROM memori_conversation WHERE session_id = ? ORDER BY date_created DESC LIMIT 1"
+	err := r.db.QueryRow(Rebind(r.d, query), sessionID).Scan(&id)
 	return id, err
 }
 
 func (r *sqlConversationRepo) UpdateSummary(conversationID int64, summary string) error {
-	now := time.Now()
-	var query string
-	if r.dialect == "postgres" {
-		query = "UPDATE memori_conversation SET summary = $1, date_updated = $2 WHERE id = $3"
-	} else {
-		query = "UPDATE memori_conversation SET summary = ?, date_updated = ? WHERE id = ?"
-	}
-	_, err := r.db.Exec(query, summary, now, conversationID)
+	query := "UPDATE memori_conversation SET summary = ?, date_updated = ? WHERE id = ?"
+	_, err := r.db.Exec(Rebind(r.d, query), summary, time.Now(), conversationID)
 	return err
 }
 
 type sqlMessageRepo struct {
-	db      *sql.DB
-	dialect string
+	db *sql.DB
+	d  SQLDialect
 }
 
-func (r *sqlMessageRepo) Create(conversationID int64, role, msgType, content string) error {
-	u := uuid.New().String()
-	now := time.Now()
-	var query string
-	if r.dialect == "postgres" {
-		query = "INSERT INTO memori_conversation_message (uuid, conversation_id, role, type, content, date_created) VALUES ($1, $2, $3, $4, $5, $6)"
-	} else {
-		query = "INSERT INTO memori_conversation_message (uuid, conversation_id, role, type, content, date_created) VALUES (?, ?, ?, ?, ?, ?)"
+func (r *sqlMessageRepo) Create(msg MessageRecord) error {
+	parts, err := encodeMessageParts(msg.Parts)
+	if err != nil {
+		return err
 	}
-	_, err := r.db.Exec(
-		query,
-		u, conversationID, role, msgType, content, now,
+	query := "INSERT INTO memori_conversation_message (uuid, conversation_id, role, type, content, content_parts, truncated, date_created) VALUES (?, ?, ?, ?, ?, ?, ?, ?)"
+	_, err = r.db.Exec(
+		Rebind(r.d, query),
+		uuid.New().String(), msg.ConversationID, msg.Role, msg.Type, msg.Content, parts, msg.Truncated, time.Now(),
 	)
 	return err
 }
 
 type sqlEntityFactRepo struct {
-	db      *sql.DB
-	dialect string
+	db *sql.DB
+	d  SQLDialect
 }
 
-func (r *sqlEntityFactRepo) Create(entityID int64, content string, embedding []byte, uniq string) error {
-	u := uuid.New().String()
+func (r *sqlEntityFactRepo) Create(entityID int64, content string, embedding FactEmbedding, uniq string) error {
 	now := time.Now()
-	var query string
-	if r.dialect == "postgres" {
-		query = "INSERT INTO memori_entity_fact (uuid, entity_id, content, content_embedding, num_times, date_last_time, uniq, date_created) VALUES ($1, $2, $3, $4, 1, $5, $6, $7)"
-	} else {
-		query = "INSERT INTO memori_entity_fact (uuid, entity_id, content, content_embedding, num_times, date_last_time, uniq, date_created) VALUES (?, ?, ?, ?, 1, ?, ?, ?)"
-	}
+	query := `INSERT INTO memori_entity_fact (uuid, entity_id, content, content_embedding, embedding_encoding, embedding_provider, embedding_model, embedding_dimension, num_times, date_last_time, uniq, date_created)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, 1, ?, ?, ?)`
 	_, err := r.db.Exec(
-		query,
-		u, entityID, content, embedding, now, uniq, now,
+		Rebind(r.d, query),
+		uuid.New().String(), entityID, content, embedding.sqlVector(), embedding.Encoding, embedding.Provider, embedding.Model, embedding.Dimension, now, uniq, now,
 	)
 	return err
 }
 
-func (r *sqlEntityFactRepo) Upsert(entityID int64, content string, embedding []byte, uniq string) error {
-	u := uuid.New().String()
+func (r *sqlEntityFactRepo) Upsert(entityID int64, content string, embedding FactEmbedding, uniq string) error {
 	now := time.Now()
-	var query string
-	if r.dialect == "postgres" {
-		query = `INSERT INTO memori_entity_fact (uuid, entity_id, content, content_embedding, num_times, date_last_time, uniq, date_created)
-		 VALUES ($1, $2, $3, $4, 1, $5, $6, $7)
-		 ON CONFLICT(entity_id, uniq) DO UPDATE SET
+	query := `INSERT INTO memori_entity_fact (uuid, entity_id, content, content_embedding, embedding_encoding, embedding_provider, embedding_model, embedding_dimension, num_times, date_last_time, uniq, date_created)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, 1, ?, ?, ?)
+		 ` + r.d.Upsert([]string{"entity_id", "uniq"}, `
 			num_times = memori_entity_fact.num_times + 1,
-			date_last_time = $8,
-			date_updated = $9`
-	} else {
-		query = `INSERT INTO memori_entity_fact (uuid, entity_id, content, content_embedding, num_times, date_last_time, uniq, date_created)
-		 VALUES (?, ?, ?, ?, 1, ?, ?, ?)
-		 ON CONFLICT(entity_id, uniq) DO UPDATE SET
-			num_times = num_times + 1,
 			date_last_time = ?,
-			date_updated = ?`
-	}
+			date_updated = ?,
+			content_embedding = ?,
+			embedding_encoding = ?,
+			embedding_provider = ?,
+			embedding_model = ?,
+			embedding_dimension = ?`)
 	_, err := r.db.Exec(
-		query,
-		u, entityID, content, embedding, now, uniq, now, now, now,
+		Rebind(r.d, query),
+		uuid.New().String(), entityID, content, embedding.sqlVector(), embedding.Encoding, embedding.Provider, embedding.Model, embedding.Dimension, now, uniq, now,
+		now, now, embedding.sqlVector(), embedding.Encoding, embedding.Provider, embedding.Model, embedding.Dimension,
 	)
 	return err
 }
 
-func (r *sqlEntityFactRepo) SearchByEmbedding(entityID int64, queryEmbedding []float32, limit, embeddingsLimit int) ([]FactResult, error) {
-	// Fetch facts and compute cosine similarity in memory.
-	var query string
-	if r.dialect == "postgres" {
-		query = "SELECT content, content_embedding, num_times, date_last_time FROM memori_entity_fact WHERE entity_id = $1 LIMIT $2"
-	} else {
-		query = "SELECT content, content_embedding, num_times, date_last_time FROM memori_entity_fact WHERE entity_id = ? LIMIT ?"
-	}
+func (r *sqlEntityFactRepo) SearchByEmbedding(entityID int64, queryEmbedding []float32, meta EmbeddingMeta, limit, embeddingsLimit int) ([]FactResult, error) {
+	// Fetch facts from the query's vector space (or legacy rows written
+	// before the metadata columns existed, which are NULL) and compute cosine
+	// similarity in memory. An empty model is an identity of its own: the
+	// provider reports none.
+	query := `SELECT content, content_embedding, embedding_encoding, num_times, date_last_time FROM memori_entity_fact
+		WHERE entity_id = ? AND (embedding_model IS NULL OR (embedding_provider = ? AND embedding_model = ?))
+		LIMIT ?`
 	rows, err := r.db.Query(
-		query,
-		entityID, embeddingsLimit,
+		Rebind(r.d, query),
+		entityID, meta.Provider, meta.Model, embeddingsLimit,
 	)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
+	unit := vecmath.Normalized(queryEmbedding)
 	var results []FactResult
+	mismatched := 0
 	for rows.Next() {
 		var content string
 		var embedding []byte
+		var encoding EmbeddingEncoding
 		var numTimes int64
 		var dateLastAny any
-		if err := rows.Scan(&content, &embedding, &numTimes, &dateLastAny); err != nil {
+		if err := rows.Scan(&content, &embedding, &encoding, &numTimes, &dateLastAny); err != nil {
 			continue
 		}
 
-		emb := decodeEmbedding(embedding)
-		score := cosineSimilarity(queryEmbedding, emb)
+		emb := decodeUnit(embedding, encoding)
+		if len(emb) == 0 {
+			continue // not embedded yet
+		}
+		if len(emb) != len(unit) {
+			mismatched++
+			continue
+		}
+		score := float64(vecmath.Dot(unit, emb))
 
 		dateLastTime, _ := decodeAnyTime(dateLastAny)
 		results = append(results, FactResult{
@@ -391,51 +470,107 @@ func (r *sqlEntityFactRepo) SearchByEmbedding(entityID int64, queryEmbedding []f
 			DateLastTime: dateLastTime,
 		})
 	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
 
-	// Sort by score (desc) and limit
-	sort.Slice(results, func(i, j int) bool {
-		if results[i].Score == results[j].Score {
-			// tie-breaker: more recent first
-			return results[i].DateLastTime.After(results[j].DateLastTime)
+	if len(results) == 0 {
+		if mismatched == 0 {
+			// Facts may exist that were filtered out by model in SQL.
+			var total int64
+			countQuery := "SELECT COUNT(*) FROM memori_entity_fact WHERE entity_id = ? AND LENGTH(content_embedding) > 0"
+			if err := r.db.QueryRow(Rebind(r.d, countQuery), entityID).Scan(&total); err != nil {
+				return nil, err
+			}
+			mismatched = int(total)
+		}
+		if mismatched > 0 {
+			return nil, mismatchError(meta, len(queryEmbedding), mismatched)
 		}
-		return results[i].Score > results[j].Score
-	})
-
-	if len(results) > limit {
-		results = results[:limit]
 	}
-	return results, nil
+
+	return RankFacts(results, limit), nil
 }
 
-// decodeEmbedding converts little-endian []byte back to []float32.
-func decodeEmbedding(b []byte) []float32 {
-	if len(b) == 0 || len(b)%4 != 0 {
-		return nil
+func (r *sqlEntityFactRepo) ListStale(target EmbeddingMeta, limit int) ([]StoredFact, error) {
+	query := `SELECT entity_id, content, uniq, embedding_provider, embedding_model, embedding_dimension FROM memori_entity_fact
+		WHERE embedding_provider IS NULL OR embedding_provider <> ?
+			OR embedding_model IS NULL OR embedding_model <> ?
+			OR embedding_dimension IS NULL OR embedding_dimension <> ?
+		ORDER BY id LIMIT ?`
+	rows, err := r.db.Query(Rebind(r.d, query), target.Provider, target.Model, target.Dimension, limit)
+	if err != nil {
+		return nil, err
 	}
-	out := make([]float32, len(b)/4)
-	for i := 0; i < len(out); i++ {
-		u := binary.LittleEndian.Uint32(b[i*4:])
-		out[i] = math.Float32frombits(u)
+	defer rows.Close()
+
+	var out []StoredFact
+	for rows.Next() {
+		var f StoredFact
+		var provider, model sql.NullString
+		var dimension sql.NullInt64
+		if err := rows.Scan(&f.EntityID, &f.Content, &f.Uniq, &provider, &model, &dimension); err != nil {
+			return nil, err
+		}
+		f.Provider = provider.String
+		f.Model = model.String
+		f.Dimension = int(dimension.Int64)
+		out = append(out, f)
 	}
-	return out
+	return out, rows.Err()
 }
 
-func cosineSimilarity(a, b []float32) float64 {
-	if len(a) == 0 || len(b) == 0 || len(a) != len(b) {
-		return 0
-	}
-	var dot, na, nb float64
-	for i := range a {
-		fa := float64(a[i])
-		fb := float64(b[i])
-		dot += fa * fb
-		na += fa * fa
-		nb += fb * fb
+func (r *sqlEntityFactRepo) UpdateEmbedding(entityID int64, uniq string, embedding FactEmbedding) error {
+	query := `UPDATE memori_entity_fact SET content_embedding = ?, embedding_encoding = ?, embedding_provider = ?, embedding_model = ?, embedding_dimension = ?, date_updated = ?
+		WHERE entity_id = ? AND uniq = ?`
+	_, err := r.db.Exec(
+		Rebind(r.d, query),
+		embedding.sqlVector(), embedding.Encoding, embedding.Provider, embedding.Model, embedding.Dimension, time.Now(), entityID, uniq,
+	)
+	return err
+}
+
+type sqlEmbeddingCacheRepo struct {
+	db *sql.DB
+	d  SQLDialect
+}
+
+func (r *sqlEmbeddingCacheRepo) Get(provider, model, textHash string) ([]byte, error) {
+	var embedding []byte
+	query := "SELECT embedding FROM memori_embedding_cache WHERE provider = ? AND model = ? AND text_hash = ?"
+	err := r.db.QueryRow(Rebind(r.d, query), provider, model, textHash).Scan(&embedding)
+	return embedding, err
+}
+
+func (r *sqlEmbeddingCacheRepo) Put(provider, model, textHash string, embedding []byte) error {
+	query := "INSERT INTO memori_embedding_cache (provider, model, text_hash, embedding, date_created) VALUES (?, ?, ?, ?, ?) " +
+		r.d.Upsert([]string{"provider", "model", "text_hash"}, "embedding = ?")
+	_, err := r.db.Exec(Rebind(r.d, query), provider, model, textHash, embedding, time.Now(), embedding)
+	return err
+}
+
+// mismatchError reports that an entity only has facts from other vector spaces.
+func mismatchError(meta EmbeddingMeta, dimension, mismatched int) error {
+	return fmt.Errorf("%w: %d facts were not embedded with %s/%s (%d dims); run Memori.Reembed",
+		embed.ErrEmbeddingDimensionMismatch, mismatched, meta.Provider, meta.Model, dimension)
+}
+
+// RankFacts orders search results by score (desc), breaking ties by recency,
+// and truncates them to limit. Drivers share it so ranking stays consistent.
+func RankFacts(results []FactResult, limit int) []FactResult {
+	top := vecmath.NewTopK(limit, betterFact)
+	for _, r := range results {
+		top.Push(r)
 	}
-	if na == 0 || nb == 0 {
-		return 0
+	return top.Sorted()
+}
+
+func betterFact(a, b FactResult) bool {
+	if a.Score == b.Score {
+		// tie-breaker: more recent first
+		return a.DateLastTime.After(b.DateLastTime)
 	}
-	return dot / (math.Sqrt(na) * math.Sqrt(nb))
+	return a.Score > b.Score
 }
 
 // SQL driver repos
@@ -446,17 +581,23 @@ type sqlRepos struct {
 	conversation ConversationRepo
 	message      MessageRepo
 	entityFact   EntityFactRepo
+	embedCache   EmbeddingCacheRepo
+	usage        UsageRepo
+	browse       BrowseRepo
 }
 
 func (d *SQLDriver) Entity() EntityRepo {
 	if d.repos == nil {
 		d.repos = &sqlRepos{
-			entity:       &sqlEntityRepo{db: d.db(), dialect: d.dialect},
-			process:      &sqlProcessRepo{db: d.db(), dialect: d.dialect},
-			session:      &sqlSessionRepo{db: d.db(), dialect: d.dialect},
-			conversation: &sqlConversationRepo{db: d.db(), dialect: d.dialect},
-			message:      &sqlMessageRepo{db: d.db(), dialect: d.dialect},
-			entityFact:   &sqlEntityFactRepo{db: d.db(), dialect: d.dialect},
+			entity:       &sqlEntityRepo{db: d.db(), d: d.dialect},
+			process:      &sqlProcessRepo{db: d.db(), d: d.dialect},
+			session:      &sqlSessionRepo{db: d.db(), d: d.dialect},
+			conversation: &sqlConversationRepo{db: d.db(), d: d.dialect},
+			message:      &sqlMessageRepo{db: d.db(), d: d.dialect},
+			entityFact:   &sqlEntityFactRepo{db: d.db(), d: d.dialect},
+			embedCache:   &sqlEmbeddingCacheRepo{db: d.db(), d: d.dialect},
+			usage:        &sqlUsageRepo{db: d.db(), d: d.dialect},
+			browse:       &sqlBrowseRepo{db: d.db(), d: d.dialect},
 		}
 	}
 	return d.repos.entity
@@ -497,6 +638,27 @@ func (d *SQLDriver) Message() MessageRepo {
 	return d.repos.message
 }
 
+func (d *SQLDriver) EmbeddingCache() EmbeddingCacheRepo {
+	if d.repos == nil {
+		d.Entity()
+	}
+	return d.repos.embedCache
+}
+
+func (d *SQLDriver) Usage() UsageRepo {
+	if d.repos == nil {
+		d.Entity()
+	}
+	return d.repos.usage
+}
+
+func (d *SQLDriver) Browse() BrowseRepo {
+	if d.repos == nil {
+		d.Entity()
+	}
+	return d.repos.browse
+}
+
 // MongoDB repos
 
 type mongoEntityRepo struct {
@@ -740,19 +902,25 @@ type mongoMessageRepo struct {
 	db *mongo.Database
 }
 
-func (r *mongoMessageRepo) Create(conversationID int64, role, msgType, content string) error {
+func (r *mongoMessageRepo) Create(msg MessageRecord) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
 	coll := r.db.Collection("memori_conversation_message")
 	doc := bson.M{
 		"uuid":            uuid.New().String(),
-		"conversation_id": conversationID,
-		"role":            role,
-		"type":            msgType,
-		"content":         content,
+		"conversation_id": msg.ConversationID,
+		"role":            msg.Role,
+		"type":            msg.Type,
+		"content":         msg.Content,
 		"date_created":    time.Now(),
 	}
+	if len(msg.Parts) > 0 {
+		doc["content_parts"] = msg.Parts
+	}
+	if msg.Truncated {
+		doc["truncated"] = true
+	}
 	_, err := coll.InsertOne(ctx, doc)
 	return err
 }
@@ -761,26 +929,30 @@ type mongoEntityFactRepo struct {
 	db *mongo.Database
 }
 
-func (r *mongoEntityFactRepo) Create(entityID int64, content string, embedding []byte, uniq string) error {
+func (r *mongoEntityFactRepo) Create(entityID int64, content string, embedding FactEmbedding, uniq string) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
 	coll := r.db.Collection("memori_entity_fact")
 	doc := bson.M{
-		"uuid":              uuid.New().String(),
-		"entity_id":         entityID,
-		"content":           content,
-		"content_embedding": embedding,
-		"num_times":         int64(1),
-		"date_last_time":    time.Now(),
-		"uniq":              uniq,
-		"date_created":      time.Now(),
+		"uuid":                uuid.New().String(),
+		"entity_id":           entityID,
+		"content":             content,
+		"content_embedding":   embedding.Vector,
+		"embedding_encoding":  embedding.Encoding,
+		"embedding_provider":  embedding.Provider,
+		"embedding_model":     embedding.Model,
+		"embedding_dimension": embedding.Dimension,
+		"num_times":           int64(1),
+		"date_last_time":      time.Now(),
+		"uniq":                uniq,
+		"date_created":        time.Now(),
 	}
 	_, err := coll.InsertOne(ctx, doc)
 	return err
 }
 
-func (r *mongoEntityFactRepo) Upsert(entityID int64, content string, embedding []byte, uniq string) error {
+func (r *mongoEntityFactRepo) Upsert(entityID int64, content string, embedding FactEmbedding, uniq string) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
@@ -793,10 +965,14 @@ func (r *mongoEntityFactRepo) Upsert(entityID int64, content string, embedding [
 			"date_created": now,
 		},
 		"$set": bson.M{
-			"content":           content,
-			"content_embedding": embedding,
-			"date_last_time":    now,
-			"date_updated":      now,
+			"content":             content,
+			"content_embedding":   embedding.Vector,
+			"embedding_encoding":  embedding.Encoding,
+			"embedding_provider":  embedding.Provider,
+			"embedding_model":     embedding.Model,
+			"embedding_dimension": embedding.Dimension,
+			"date_last_time":      now,
+			"date_updated":        now,
 		},
 		"$inc": bson.M{
 			"num_times": int64(1),
@@ -806,7 +982,7 @@ func (r *mongoEntityFactRepo) Upsert(entityID int64, content string, embedding [
 	return err
 }
 
-func (r *mongoEntityFactRepo) SearchByEmbedding(entityID int64, queryEmbedding []float32, limit, embeddingsLimit int) ([]FactResult, error) {
+func (r *mongoEntityFactRepo) SearchByEmbedding(entityID int64, queryEmbedding []float32, meta EmbeddingMeta, limit, embeddingsLimit int) ([]FactResult, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
@@ -822,19 +998,31 @@ func (r *mongoEntityFactRepo) SearchByEmbedding(entityID int64, queryEmbedding [
 	}
 	defer cur.Close(ctx)
 
+	unit := vecmath.Normalized(queryEmbedding)
 	var results []FactResult
+	mismatched := 0
 	for cur.Next(ctx) {
 		var doc struct {
-			Content      string    `bson:"content"`
-			Embedding    []byte    `bson:"content_embedding"`
-			NumTimes     int64     `bson:"num_times"`
-			DateLastTime time.Time `bson:"date_last_time"`
+			Content      string            `bson:"content"`
+			Embedding    []byte            `bson:"content_embedding"`
+			Encoding     EmbeddingEncoding `bson:"embedding_encoding"`
+			Provider     string            `bson:"embedding_provider"`
+			Model        *string           `bson:"embedding_model"` // nil for legacy documents
+			NumTimes     int64             `bson:"num_times"`
+			DateLastTime time.Time         `bson:"date_last_time"`
 		}
 		if err := cur.Decode(&doc); err != nil {
 			continue
 		}
-		emb := decodeEmbedding(doc.Embedding)
-		score := cosineSimilarity(queryEmbedding, emb)
+		emb := decodeUnit(doc.Embedding, doc.Encoding)
+		if len(emb) == 0 {
+			continue // not embedded yet
+		}
+		if (doc.Model != nil && (doc.Provider != meta.Provider || *doc.Model != meta.Model)) || len(emb) != len(unit) {
+			mismatched++
+			continue
+		}
+		score := float64(vecmath.Dot(unit, emb))
 		results = append(results, FactResult{
 			Content:      doc.Content,
 			Score:        score,
@@ -842,19 +1030,99 @@ func (r *mongoEntityFactRepo) SearchByEmbedding(entityID int64, queryEmbedding [
 			DateLastTime: doc.DateLastTime,
 		})
 	}
+	if len(results) == 0 && mismatched > 0 {
+		return nil, mismatchError(meta, len(queryEmbedding), mismatched)
+	}
+
+	return RankFacts(results, limit), nil
+}
+
+func (r *mongoEntityFactRepo) ListStale(target EmbeddingMeta, limit int) ([]StoredFact, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	coll := r.db.Collection("memori_entity_fact")
+	filter := bson.M{"$or": bson.A{
+		bson.M{"embedding_provider": bson.M{"$ne": target.Provider}},
+		bson.M{"embedding_model": bson.M{"$ne": target.Model}},
+		bson.M{"embedding_dimension": bson.M{"$ne": target.Dimension}},
+	}}
+	cur, err := coll.Find(ctx, filter, options.Find().SetSort(bson.M{"_id": 1}).SetLimit(int64(limit)))
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
 
-	// Sort by score and recency
-	sort.Slice(results, func(i, j int) bool {
-		if results[i].Score == results[j].Score {
-			return results[i].DateLastTime.After(results[j].DateLastTime)
+	var out []StoredFact
+	for cur.Next(ctx) {
+		var doc struct {
+			EntityID  int64  `bson:"entity_id"`
+			Uniq      string `bson:"uniq"`
+			Content   string `bson:"content"`
+			Provider  string `bson:"embedding_provider"`
+			Model     string `bson:"embedding_model"`
+			Dimension int    `bson:"embedding_dimension"`
+		}
+		if err := cur.Decode(&doc); err != nil {
+			return nil, err
 		}
-		return results[i].Score > results[j].Score
-	})
+		out = append(out, StoredFact{
+			EntityID:      doc.EntityID,
+			Uniq:          doc.Uniq,
+			Content:       doc.Content,
+			EmbeddingMeta: EmbeddingMeta{Provider: doc.Provider, Model: doc.Model, Dimension: doc.Dimension},
+		})
+	}
+	return out, cur.Err()
+}
+
+func (r *mongoEntityFactRepo) UpdateEmbedding(entityID int64, uniq string, embedding FactEmbedding) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	coll := r.db.Collection("memori_entity_fact")
+	_, err := coll.UpdateOne(ctx, bson.M{"entity_id": entityID, "uniq": uniq}, bson.M{"$set": bson.M{
+		"content_embedding":   embedding.Vector,
+		"embedding_encoding":  embedding.Encoding,
+		"embedding_provider":  embedding.Provider,
+		"embedding_model":     embedding.Model,
+		"embedding_dimension": embedding.Dimension,
+		"date_updated":        time.Now(),
+	}})
+	return err
+}
+
+type mongoEmbeddingCacheRepo struct {
+	db *mongo.Database
+}
 
-	if len(results) > limit {
-		results = results[:limit]
+func (r *mongoEmbeddingCacheRepo) Get(provider, model, textHash string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	coll := r.db.Collection("memori_embedding_cache")
+	var doc struct {
+		Embedding []byte `bson:"embedding"`
+	}
+	err := coll.FindOne(ctx, bson.M{"provider": provider, "model": model, "text_hash": textHash}).Decode(&doc)
+	if err != nil {
+		return nil, err
+	}
+	return doc.Embedding, nil
+}
+
+func (r *mongoEmbeddingCacheRepo) Put(provider, model, textHash string, embedding []byte) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	coll := r.db.Collection("memori_embedding_cache")
+	filter := bson.M{"provider": provider, "model": model, "text_hash": textHash}
+	update := bson.M{
+		"$setOnInsert": bson.M{"date_created": time.Now()},
+		"$set":         bson.M{"embedding": embedding},
 	}
-	return results, nil
+	_, err := coll.UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
+	return err
 }
 
 // wire Mongo repos into MongoDriver
@@ -883,6 +1151,18 @@ func (d *MongoDriver) Message() MessageRepo {
 	return &mongoMessageRepo{db: d.db()}
 }
 
+func (d *MongoDriver) EmbeddingCache() EmbeddingCacheRepo {
+	return &mongoEmbeddingCacheRepo{db: d.db()}
+}
+
+func (d *MongoDriver) Usage() UsageRepo {
+	return &mongoUsageRepo{db: d.db()}
+}
+
+func (d *MongoDriver) Browse() BrowseRepo {
+	return &mongoBrowseRepo{db: d.db()}
+}
+
 // sequence helper for Mongo collections
 
 func nextSeq(db *mongo.Database, name string) (int64, error) {
@@ -0,0 +1,493 @@
+package storage
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	bolt "go.etcd.io/bbolt"
+
+	"memorigo/vecmath"
+)
+
+// Bolt records are stored as JSON under their big-endian id.
+
+type boltEntityRecord struct {
+	ID          int64      `json:"id"`
+	UUID        string     `json:"uuid"`
+	ExternalID  string     `json:"external_id"`
+	DateCreated time.Time  `json:"date_created"`
+	DateUpdated *time.Time `json:"date_updated,omitempty"`
+}
+
+type boltSessionRecord struct {
+	ID          int64      `json:"id"`
+	UUID        string     `json:"uuid"`
+	EntityID    *int64     `json:"entity_id,omitempty"`
+	ProcessID   *int64     `json:"process_id,omitempty"`
+	DateCreated time.Time  `json:"date_created"`
+	DateUpdated *time.Time `json:"date_updated,omitempty"`
+}
+
+type boltConversationRecord struct {
+	ID          int64      `json:"id"`
+	UUID        string     `json:"uuid"`
+	SessionID   int64      `json:"session_id"`
+	Summary     string     `json:"summary,omitempty"`
+	DateCreated time.Time  `json:"date_created"`
+	DateUpdated *time.Time `json:"date_updated,omitempty"`
+}
+
+type boltMessageRecord struct {
+	ID             int64         `json:"id"`
+	UUID           string        `json:"uuid"`
+	ConversationID int64         `json:"conversation_id"`
+	Role           string        `json:"role"`
+	Type           string        `json:"type,omitempty"`
+	Content        string        `json:"content"`
+	Parts          []MessagePart `json:"content_parts,omitempty"`
+	Truncated      bool          `json:"truncated,omitempty"`
+	DateCreated    time.Time     `json:"date_created"`
+	DateUpdated    *time.Time    `json:"date_updated,omitempty"`
+}
+
+type boltFactRecord struct {
+	ID           int64             `json:"id"`
+	UUID         string            `json:"uuid"`
+	EntityID     int64             `json:"entity_id"`
+	Content      string            `json:"content"`
+	Provider     string            `json:"embedding_provider,omitempty"`
+	Model        string            `json:"embedding_model,omitempty"`
+	Dimension    int               `json:"embedding_dimension,omitempty"`
+	Encoding     EmbeddingEncoding `json:"embedding_encoding,omitempty"`
+	NumTimes     int64             `json:"num_times"`
+	DateLastTime time.Time         `json:"date_last_time"`
+	Uniq         string            `json:"uniq"`
+	DateCreated  time.Time         `json:"date_created"`
+	DateUpdated  *time.Time        `json:"date_updated,omitempty"`
+}
+
+// boltExternalIDRepo implements the shared create/get logic of entities and
+// processes, which only differ by bucket.
+type boltExternalIDRepo struct {
+	db     *bolt.DB
+	bucket string
+}
+
+func (r *boltExternalIDRepo) Create(externalID string) (int64, error) {
+	var id int64
+	err := r.db.Update(func(tx *bolt.Tx) error {
+		existing, err := boltLookup(tx, r.bucket+"_by_external_id", []byte(externalID))
+		if err == nil {
+			id = existing
+			return nil
+		}
+		if id, err = boltNextID(tx, r.bucket); err != nil {
+			return err
+		}
+		rec := boltEntityRecord{
+			ID:          id,
+			UUID:        uuid.New().String(),
+			ExternalID:  externalID,
+			DateCreated: time.Now(),
+		}
+		if err := boltPutJSON(tx, r.bucket, id, rec); err != nil {
+			return err
+		}
+		return tx.Bucket([]byte(r.bucket+"_by_external_id")).Put([]byte(externalID), itob(id))
+	})
+	return id, err
+}
+
+func (r *boltExternalIDRepo) GetByExternalID(externalID string) (int64, error) {
+	var id int64
+	err := r.db.View(func(tx *bolt.Tx) error {
+		var err error
+		id, err = boltLookup(tx, r.bucket+"_by_external_id", []byte(externalID))
+		return err
+	})
+	return id, err
+}
+
+type boltSessionRepo struct {
+	db *bolt.DB
+}
+
+func (r *boltSessionRepo) Create(entityID, processID *int64, sessionUUID uuid.UUID) (int64, error) {
+	var id int64
+	err := r.db.Update(func(tx *bolt.Tx) error {
+		existing, err := boltLookup(tx, "memori_session_by_uuid", []byte(sessionUUID.String()))
+		if err == nil {
+			id = existing
+			return nil
+		}
+		if id, err = boltNextID(tx, "memori_session"); err != nil {
+			return err
+		}
+		rec := boltSessionRecord{
+			ID:          id,
+			UUID:        sessionUUID.String(),
+			EntityID:    entityID,
+			ProcessID:   processID,
+			DateCreated: time.Now(),
+		}
+		if err := boltPutJSON(tx, "memori_session", id, rec); err != nil {
+			return err
+		}
+		return tx.Bucket([]byte("memori_session_by_uuid")).Put([]byte(sessionUUID.String()), itob(id))
+	})
+	return id, err
+}
+
+func (r *boltSessionRepo) GetByUUID(sessionUUID uuid.UUID) (int64, error) {
+	var id int64
+	err := r.db.View(func(tx *bolt.Tx) error {
+		var err error
+		id, err = boltLookup(tx, "memori_session_by_uuid", []byte(sessionUUID.String()))
+		return err
+	})
+	return id, err
+}
+
+type boltConversationRepo struct {
+	db *bolt.DB
+}
+
+func (r *boltConversationRepo) Create(sessionID int64, timeoutMinutes int) (int64, error) {
+	var id int64
+	err := r.db.Update(func(tx *bolt.Tx) error {
+		// Try to reuse recent conversation for this session
+		if existingID, err := boltLookup(tx, "memori_conversation_by_session", itob(sessionID)); err == nil {
+			var existing boltConversationRecord
+			if err := boltGetJSON(tx, "memori_conversation", existingID, &existing); err == nil {
+				if time.Since(existing.DateCreated) < time.Duration(timeoutMinutes)*time.Minute {
+					id = existingID
+					return nil
+				}
+			}
+		}
+
+		var err error
+		if id, err = boltNextID(tx, "memori_conversation"); err != nil {
+			return err
+		}
+		rec := boltConversationRecord{
+			ID:          id,
+			UUID:        uuid.New().String(),
+			SessionID:   sessionID,
+			DateCreated: time.Now(),
+		}
+		if err := boltPutJSON(tx, "memori_conversation", id, rec); err != nil {
+			return err
+		}
+		return tx.Bucket([]byte("memori_conversation_by_session")).Put(itob(sessionID), itob(id))
+	})
+	return id, err
+}
+
+func (r *boltConversationRepo) GetBySessionID(sessionID int64) (int64, error) {
+	var id int64
+	err := r.db.View(func(tx *bolt.Tx) error {
+		var err error
+		id, err = boltLookup(tx, "memori_conversation_by_session", itob(sessionID))
+		return err
+	})
+	return id, err
+}
+
+func (r *boltConversationRepo) UpdateSummary(conversationID int64, summary string) error {
+	return r.db.Update(func(tx *bolt.Tx) error {
+		var rec boltConversationRecord
+		if err := boltGetJSON(tx, "memori_conversation", conversationID, &rec); err != nil {
+			return err
+		}
+		now := time.Now()
+		rec.Summary = summary
+		rec.DateUpdated = &now
+		return boltPutJSON(tx, "memori_conversation", conversationID, rec)
+	})
+}
+
+type boltMessageRepo struct {
+	db *bolt.DB
+}
+
+func (r *boltMessageRepo) Create(msg MessageRecord) error {
+	return r.db.Update(func(tx *bolt.Tx) error {
+		id, err := boltNextID(tx, "memori_conversation_message")
+		if err != nil {
+			return err
+		}
+		rec := boltMessageRecord{
+			ID:             id,
+			UUID:           uuid.New().String(),
+			ConversationID: msg.ConversationID,
+			Role:           msg.Role,
+			Type:           msg.Type,
+			Content:        msg.Content,
+			Parts:          msg.Parts,
+			Truncated:      msg.Truncated,
+			DateCreated:    time.Now(),
+		}
+		if err := boltPutJSON(tx, "memori_conversation_message", id, rec); err != nil {
+			return err
+		}
+		return tx.Bucket([]byte("memori_conversation_message_by_conversation")).Put(compositeKey(msg.ConversationID, itob(id)), nil)
+	})
+}
+
+type boltEntityFactRepo struct {
+	db    *bolt.DB
+	index *boltVectorIndex
+}
+
+func (r *boltEntityFactRepo) Create(entityID int64, content string, embedding FactEmbedding, uniq string) error {
+	return r.write(entityID, content, embedding, uniq, false)
+}
+
+func (r *boltEntityFactRepo) Upsert(entityID int64, content string, embedding FactEmbedding, uniq string) error {
+	return r.write(entityID, content, embedding, uniq, true)
+}
+
+func (r *boltEntityFactRepo) write(entityID int64, content string, embedding FactEmbedding, uniq string, upsert bool) error {
+	var factID int64
+	err := r.index.update(r.db, func(tx *bolt.Tx) error {
+		now := time.Now()
+		uniqKey := compositeKey(entityID, []byte(uniq))
+
+		if existingID, err := boltLookup(tx, "memori_entity_fact_by_uniq", uniqKey); err == nil {
+			if !upsert {
+				return ErrDuplicate
+			}
+			var rec boltFactRecord
+			if err := boltGetJSON(tx, "memori_entity_fact", existingID, &rec); err != nil {
+				return err
+			}
+			rec.Content = content
+			rec.Provider = embedding.Provider
+			rec.Model = embedding.Model
+			rec.Dimension = embedding.Dimension
+			rec.Encoding = embedding.Encoding
+			rec.NumTimes++
+			rec.DateLastTime = now
+			rec.DateUpdated = &now
+			factID = existingID
+			if err := boltPutJSON(tx, "memori_entity_fact", factID, rec); err != nil {
+				return err
+			}
+			return tx.Bucket([]byte("memori_entity_fact_embedding")).Put(itob(factID), embedding.Vector)
+		}
+
+		var err error
+		factID, err = boltInsertFact(tx, boltFactRecord{
+			EntityID:     entityID,
+			Content:      content,
+			Provider:     embedding.Provider,
+			Model:        embedding.Model,
+			Dimension:    embedding.Dimension,
+			Encoding:     embedding.Encoding,
+			NumTimes:     1,
+			DateLastTime: now,
+			Uniq:         uniq,
+			DateCreated:  now,
+		}, embedding.Vector)
+		return err
+	}, func() {
+		r.index.put(entityID, factID, boltIndexedVector{meta: embedding.EmbeddingMeta, vec: decodeUnit(embedding.Vector, embedding.Encoding)})
+	})
+	return err
+}
+
+// boltInsertFact stores a new fact record, assigning its id and uuid, and
+// indexes it. The caller checks uniq first.
+func boltInsertFact(tx *bolt.Tx, rec boltFactRecord, vector []byte) (int64, error) {
+	var err error
+	if rec.ID, err = boltNextID(tx, "memori_entity_fact"); err != nil {
+		return 0, err
+	}
+	rec.UUID = uuid.New().String()
+	if err := boltPutJSON(tx, "memori_entity_fact", rec.ID, rec); err != nil {
+		return 0, err
+	}
+	if err := tx.Bucket([]byte("memori_entity_fact_embedding")).Put(itob(rec.ID), vector); err != nil {
+		return 0, err
+	}
+	if err := tx.Bucket([]byte("memori_entity_fact_by_entity")).Put(compositeKey(rec.EntityID, itob(rec.ID)), nil); err != nil {
+		return 0, err
+	}
+	return rec.ID, tx.Bucket([]byte("memori_entity_fact_by_uniq")).Put(compositeKey(rec.EntityID, []byte(rec.Uniq)), itob(rec.ID))
+}
+
+func (r *boltEntityFactRepo) SearchByEmbedding(entityID int64, queryEmbedding []float32, meta EmbeddingMeta, limit, embeddingsLimit int) ([]FactResult, error) {
+	type scored struct {
+		id    int64
+		score float32
+	}
+	// Only the best candidates are hydrated; RankFacts applies the final ordering.
+	top := vecmath.NewTopK(embeddingsLimit, func(a, b scored) bool { return a.score > b.score })
+	unit := vecmath.Normalized(queryEmbedding)
+	mismatched := 0
+	err := r.index.scan(r.db, entityID, func(factID int64, v boltIndexedVector) {
+		if len(v.vec) == 0 {
+			return // not embedded yet
+		}
+		// Legacy records carry no provider; every embedded fact has one.
+		if (v.meta.Provider != "" && (v.meta.Provider != meta.Provider || v.meta.Model != meta.Model)) || len(v.vec) != len(unit) {
+			mismatched++
+			return
+		}
+		top.Push(scored{id: factID, score: vecmath.Dot(unit, v.vec)})
+	})
+	if err != nil {
+		return nil, err
+	}
+	if top.Len() == 0 && mismatched > 0 {
+		return nil, mismatchError(meta, len(queryEmbedding), mismatched)
+	}
+	candidates := top.Sorted()
+
+	var results []FactResult
+	err = r.db.View(func(tx *bolt.Tx) error {
+		for _, c := range candidates {
+			var rec boltFactRecord
+			if err := boltGetJSON(tx, "memori_entity_fact", c.id, &rec); err != nil {
+				continue
+			}
+			results = append(results, FactResult{
+				Content:      rec.Content,
+				Score:        float64(c.score),
+				NumTimes:     rec.NumTimes,
+				DateLastTime: rec.DateLastTime,
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return RankFacts(results, limit), nil
+}
+
+func (r *boltEntityFactRepo) ListStale(target EmbeddingMeta, limit int) ([]StoredFact, error) {
+	var out []StoredFact
+	err := r.db.View(func(tx *bolt.Tx) error {
+		b, err := boltBucket(tx, "memori_entity_fact")
+		if err != nil {
+			return err
+		}
+		c := b.Cursor()
+		for k, v := c.First(); k != nil && len(out) < limit; k, v = c.Next() {
+			var rec boltFactRecord
+			if err := json.Unmarshal(v, &rec); err != nil {
+				return err
+			}
+			meta := EmbeddingMeta{Provider: rec.Provider, Model: rec.Model, Dimension: rec.Dimension}
+			if meta == target {
+				continue
+			}
+			out = append(out, StoredFact{EntityID: rec.EntityID, Uniq: rec.Uniq, Content: rec.Content, EmbeddingMeta: meta})
+		}
+		return nil
+	})
+	return out, err
+}
+
+func (r *boltEntityFactRepo) UpdateEmbedding(entityID int64, uniq string, embedding FactEmbedding) error {
+	var factID int64
+	err := r.index.update(r.db, func(tx *bolt.Tx) error {
+		var err error
+		if factID, err = boltLookup(tx, "memori_entity_fact_by_uniq", compositeKey(entityID, []byte(uniq))); err != nil {
+			return err
+		}
+		var rec boltFactRecord
+		if err := boltGetJSON(tx, "memori_entity_fact", factID, &rec); err != nil {
+			return err
+		}
+		now := time.Now()
+		rec.Provider = embedding.Provider
+		rec.Model = embedding.Model
+		rec.Dimension = embedding.Dimension
+		rec.Encoding = embedding.Encoding
+		rec.DateUpdated = &now
+		if err := boltPutJSON(tx, "memori_entity_fact", factID, rec); err != nil {
+			return err
+		}
+		return tx.Bucket([]byte("memori_entity_fact_embedding")).Put(itob(factID), embedding.Vector)
+	}, func() {
+		r.index.put(entityID, factID, boltIndexedVector{meta: embedding.EmbeddingMeta, vec: decodeUnit(embedding.Vector, embedding.Encoding)})
+	})
+	return err
+}
+
+type boltEmbeddingCacheRepo struct {
+	db *bolt.DB
+}
+
+func boltEmbeddingCacheKey(provider, model, textHash string) []byte {
+	return []byte(provider + "\x00" + model + "\x00" + textHash)
+}
+
+func (r *boltEmbeddingCacheRepo) Get(provider, model, textHash string) ([]byte, error) {
+	var out []byte
+	err := r.db.View(func(tx *bolt.Tx) error {
+		b, err := boltBucket(tx, "memori_embedding_cache")
+		if err != nil {
+			return err
+		}
+		raw := b.Get(boltEmbeddingCacheKey(provider, model, textHash))
+		if raw == nil {
+			return ErrNotFound
+		}
+		out = append([]byte(nil), raw...)
+		return nil
+	})
+	return out, err
+}
+
+func (r *boltEmbeddingCacheRepo) Put(provider, model, textHash string, embedding []byte) error {
+	return r.db.Update(func(tx *bolt.Tx) error {
+		b, err := boltBucket(tx, "memori_embedding_cache")
+		if err != nil {
+			return err
+		}
+		return b.Put(boltEmbeddingCacheKey(provider, model, textHash), embedding)
+	})
+}
+
+// wire bolt repos into BoltDriver
+
+func (d *BoltDriver) Entity() EntityRepo {
+	return &boltExternalIDRepo{db: d.db(), bucket: "memori_entity"}
+}
+
+func (d *BoltDriver) Process() ProcessRepo {
+	return &boltExternalIDRepo{db: d.db(), bucket: "memori_process"}
+}
+
+func (d *BoltDriver) Session() SessionRepo {
+	return &boltSessionRepo{db: d.db()}
+}
+
+func (d *BoltDriver) Conversation() ConversationRepo {
+	return &boltConversationRepo{db: d.db()}
+}
+
+func (d *BoltDriver) EntityFact() EntityFactRepo {
+	return &boltEntityFactRepo{db: d.db(), index: d.index}
+}
+
+func (d *BoltDriver) Message() MessageRepo {
+	return &boltMessageRepo{db: d.db()}
+}
+
+func (d *BoltDriver) EmbeddingCache() EmbeddingCacheRepo {
+	return &boltEmbeddingCacheRepo{db: d.db()}
+}
+
+func (d *BoltDriver) Usage() UsageRepo {
+	return &boltUsageRepo{db: d.db()}
+}
+
+func (d *BoltDriver) Browse() BrowseRepo {
+	return &boltBrowseRepo{db: d.db(), index: d.index}
+}
@@ -0,0 +1,390 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	bolt "go.etcd.io/bbolt"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// UsageRepo records the token usage of LLM calls and aggregates it.
+type UsageRepo interface {
+	Create(rec UsageRecord) error
+	// Aggregate sums the records matching filter, one total per distinct
+	// combination of the groupBy dimensions; dimensions not grouped by are
+	// left empty in the totals.
+	Aggregate(filter UsageFilter, groupBy []UsageGroup) ([]UsageTotal, error)
+}
+
+// UsageRecord is the usage of one LLM call. Entity, process and session are
+// the external ids (the session uuid) so that reports need no joins.
+type UsageRecord struct {
+	ConversationID   int64
+	EntityID         string
+	ProcessID        string
+	SessionID        string
+	Provider         string
+	Model            string
+	PromptTokens     int
+	CompletionTokens int
+	TotalTokens      int
+}
+
+// UsageFilter restricts an aggregation; zero fields match everything.
+type UsageFilter struct {
+	EntityID  string
+	ProcessID string
+	SessionID string
+	Model     string
+	Since     time.Time // inclusive
+	Until     time.Time // exclusive
+}
+
+type UsageGroup string
+
+const (
+	UsageByEntity  UsageGroup = "entity"
+	UsageByProcess UsageGroup = "process"
+	UsageBySession UsageGroup = "session"
+	UsageByModel   UsageGroup = "model"
+)
+
+type UsageTotal struct {
+	EntityID         string
+	ProcessID        string
+	SessionID        string
+	Model            string
+	Calls            int64
+	PromptTokens     int64
+	CompletionTokens int64
+	TotalTokens      int64
+}
+
+// usageColumns maps groups to memori_llm_usage columns.
+var usageColumns = map[UsageGroup]string{
+	UsageByEntity:  "entity_external_id",
+	UsageByProcess: "process_external_id",
+	UsageBySession: "session_uuid",
+	UsageByModel:   "model",
+}
+
+func (rec UsageRecord) total() int {
+	if rec.TotalTokens > 0 {
+		return rec.TotalTokens
+	}
+	return rec.PromptTokens + rec.CompletionTokens
+}
+
+// Key returns the dimensions of t selected by groupBy, with the other
+// dimensions and all counters cleared; totals of one group share a key.
+func (t UsageTotal) Key(groupBy []UsageGroup) UsageTotal {
+	var k UsageTotal
+	for _, g := range groupBy {
+		switch g {
+		case UsageByEntity:
+			k.EntityID = t.EntityID
+		case UsageByProcess:
+			k.ProcessID = t.ProcessID
+		case UsageBySession:
+			k.SessionID = t.SessionID
+		case UsageByModel:
+			k.Model = t.Model
+		}
+	}
+	return k
+}
+
+func (t *UsageTotal) add(o UsageTotal) {
+	t.Calls += o.Calls
+	t.PromptTokens += o.PromptTokens
+	t.CompletionTokens += o.CompletionTokens
+	t.TotalTokens += o.TotalTokens
+}
+
+// groupUsage merges totals into one per distinct combination of groupBy,
+// sorted by those dimensions, for drivers without GROUP BY.
+func groupUsage(totals []UsageTotal, groupBy []UsageGroup) []UsageTotal {
+	index := make(map[UsageTotal]int)
+	var out []UsageTotal
+	for _, t := range totals {
+		k := t.Key(groupBy)
+		i, ok := index[k]
+		if !ok {
+			i = len(out)
+			index[k] = i
+			out = append(out, k)
+		}
+		out[i].add(t)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		a, b := out[i], out[j]
+		for _, s := range [][2]string{{a.EntityID, b.EntityID}, {a.ProcessID, b.ProcessID}, {a.SessionID, b.SessionID}, {a.Model, b.Model}} {
+			if s[0] != s[1] {
+				return s[0] < s[1]
+			}
+		}
+		return false
+	})
+	return out
+}
+
+func (f UsageFilter) match(rec usageDoc) bool {
+	switch {
+	case f.EntityID != "" && rec.EntityID != f.EntityID,
+		f.ProcessID != "" && rec.ProcessID != f.ProcessID,
+		f.SessionID != "" && rec.SessionID != f.SessionID,
+		f.Model != "" && rec.Model != f.Model,
+		!f.Since.IsZero() && rec.DateCreated.Before(f.Since),
+		!f.Until.IsZero() && !rec.DateCreated.Before(f.Until):
+		return false
+	}
+	return true
+}
+
+// usageDoc is the stored form of a usage record in bolt and Mongo.
+type usageDoc struct {
+	ID               int64     `json:"id" bson:"_id"`
+	UUID             string    `json:"uuid" bson:"uuid"`
+	ConversationID   int64     `json:"conversation_id" bson:"conversation_id"`
+	EntityID         string    `json:"entity_external_id" bson:"entity_external_id"`
+	ProcessID        string    `json:"process_external_id" bson:"process_external_id"`
+	SessionID        string    `json:"session_uuid" bson:"session_uuid"`
+	Provider         string    `json:"provider" bson:"provider"`
+	Model            string    `json:"model" bson:"model"`
+	PromptTokens     int       `json:"prompt_tokens" bson:"prompt_tokens"`
+	CompletionTokens int       `json:"completion_tokens" bson:"completion_tokens"`
+	TotalTokens      int       `json:"total_tokens" bson:"total_tokens"`
+	DateCreated      time.Time `json:"date_created" bson:"date_created"`
+}
+
+func newUsageDoc(id int64, rec UsageRecord) usageDoc {
+	return usageDoc{
+		ID:               id,
+		UUID:             uuid.New().String(),
+		ConversationID:   rec.ConversationID,
+		EntityID:         rec.EntityID,
+		ProcessID:        rec.ProcessID,
+		SessionID:        rec.SessionID,
+		Provider:         rec.Provider,
+		Model:            rec.Model,
+		PromptTokens:     rec.PromptTokens,
+		CompletionTokens: rec.CompletionTokens,
+		TotalTokens:      rec.total(),
+		DateCreated:      time.Now().UTC(),
+	}
+}
+
+func (d usageDoc) usageTotal() UsageTotal {
+	return UsageTotal{
+		EntityID:         d.EntityID,
+		ProcessID:        d.ProcessID,
+		SessionID:        d.SessionID,
+		Model:            d.Model,
+		Calls:            1,
+		PromptTokens:     int64(d.PromptTokens),
+		CompletionTokens: int64(d.CompletionTokens),
+		TotalTokens:      int64(d.TotalTokens),
+	}
+}
+
+type sqlUsageRepo struct {
+	db *sql.DB
+	d  SQLDialect
+}
+
+func (r *sqlUsageRepo) Create(rec UsageRecord) error {
+	query := `INSERT INTO memori_llm_usage (uuid, conversation_id, entity_external_id, process_external_id, session_uuid, provider, model, prompt_tokens, completion_tokens, total_tokens, date_created)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	_, err := r.db.Exec(
+		Rebind(r.d, query),
+		uuid.New().String(), rec.ConversationID, rec.EntityID, rec.ProcessID, rec.SessionID, rec.Provider, rec.Model,
+		rec.PromptTokens, rec.CompletionTokens, rec.total(), time.Now().UTC(),
+	)
+	return err
+}
+
+func (r *sqlUsageRepo) Aggregate(filter UsageFilter, groupBy []UsageGroup) ([]UsageTotal, error) {
+	var where []string
+	var args []any
+	for _, c := range []struct {
+		column string
+		value  string
+	}{
+		{"entity_external_id", filter.EntityID},
+		{"process_external_id", filter.ProcessID},
+		{"session_uuid", filter.SessionID},
+		{"model", filter.Model},
+	} {
+		if c.value != "" {
+			where = append(where, c.column+" = ?")
+			args = append(args, c.value)
+		}
+	}
+	if !filter.Since.IsZero() {
+		where = append(where, "date_created >= ?")
+		args = append(args, filter.Since.UTC())
+	}
+	if !filter.Until.IsZero() {
+		where = append(where, "date_created < ?")
+		args = append(args, filter.Until.UTC())
+	}
+
+	var cols []string
+	for _, g := range groupBy {
+		if c, ok := usageColumns[g]; ok {
+			cols = append(cols, c)
+		}
+	}
+
+	query := "SELECT "
+	if len(cols) > 0 {
+		query += strings.Join(cols, ", ") + ", "
+	}
+	query += "COUNT(*), COALESCE(SUM(prompt_tokens), 0), COALESCE(SUM(completion_tokens), 0), COALESCE(SUM(total_tokens), 0) FROM memori_llm_usage"
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	if len(cols) > 0 {
+		query += " GROUP BY " + strings.Join(cols, ", ") + " ORDER BY " + strings.Join(cols, ", ")
+	}
+
+	rows, err := r.db.Query(Rebind(r.d, query), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []UsageTotal
+	for rows.Next() {
+		var t UsageTotal
+		dest := make([]any, 0, len(cols)+4)
+		for _, g := range groupBy {
+			switch g {
+			case UsageByEntity:
+				dest = append(dest, &t.EntityID)
+			case UsageByProcess:
+				dest = append(dest, &t.ProcessID)
+			case UsageBySession:
+				dest = append(dest, &t.SessionID)
+			case UsageByModel:
+				dest = append(dest, &t.Model)
+			}
+		}
+		dest = append(dest, &t.Calls, &t.PromptTokens, &t.CompletionTokens, &t.TotalTokens)
+		if err := rows.Scan(dest...); err != nil {
+			return nil, err
+		}
+		// an ungrouped aggregate over no rows still returns one row
+		if t.Calls > 0 {
+			out = append(out, t)
+		}
+	}
+	return out, rows.Err()
+}
+
+type mongoUsageRepo struct {
+	db *mongo.Database
+}
+
+func (r *mongoUsageRepo) Create(rec UsageRecord) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	id, err := nextSeq(r.db, "memori_llm_usage")
+	if err != nil {
+		return err
+	}
+	_, err = r.db.Collection("memori_llm_usage").InsertOne(ctx, newUsageDoc(id, rec))
+	return err
+}
+
+func (r *mongoUsageRepo) Aggregate(filter UsageFilter, groupBy []UsageGroup) ([]UsageTotal, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	q := bson.M{}
+	for field, v := range map[string]string{
+		"entity_external_id":  filter.EntityID,
+		"process_external_id": filter.ProcessID,
+		"session_uuid":        filter.SessionID,
+		"model":               filter.Model,
+	} {
+		if v != "" {
+			q[field] = v
+		}
+	}
+	dates := bson.M{}
+	if !filter.Since.IsZero() {
+		dates["$gte"] = filter.Since
+	}
+	if !filter.Until.IsZero() {
+		dates["$lt"] = filter.Until
+	}
+	if len(dates) > 0 {
+		q["date_created"] = dates
+	}
+
+	cur, err := r.db.Collection("memori_llm_usage").Find(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	var totals []UsageTotal
+	for cur.Next(ctx) {
+		var doc usageDoc
+		if err := cur.Decode(&doc); err != nil {
+			return nil, err
+		}
+		totals = append(totals, doc.usageTotal())
+	}
+	if err := cur.Err(); err != nil {
+		return nil, err
+	}
+	return groupUsage(totals, groupBy), nil
+}
+
+type boltUsageRepo struct {
+	db *bolt.DB
+}
+
+func (r *boltUsageRepo) Create(rec UsageRecord) error {
+	return r.db.Update(func(tx *bolt.Tx) error {
+		id, err := boltNextID(tx, "memori_llm_usage")
+		if err != nil {
+			return err
+		}
+		return boltPutJSON(tx, "memori_llm_usage", id, newUsageDoc(id, rec))
+	})
+}
+
+func (r *boltUsageRepo) Aggregate(filter UsageFilter, groupBy []UsageGroup) ([]UsageTotal, error) {
+	var totals []UsageTotal
+	err := r.db.View(func(tx *bolt.Tx) error {
+		b, err := boltBucket(tx, "memori_llm_usage")
+		if err != nil {
+			return err
+		}
+		return b.ForEach(func(_, raw []byte) error {
+			var doc usageDoc
+			if err := json.Unmarshal(raw, &doc); err != nil {
+				return err
+			}
+			if filter.match(doc) {
+				totals = append(totals, doc.usageTotal())
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return groupUsage(totals, groupBy), nil
+}
@@ -0,0 +1,98 @@
+package vecmath
+
+// TopK keeps the k best items pushed into it using a bounded min-heap, so
+// selecting the best k of n items costs O(n log k) instead of sorting all n.
+type TopK[T any] struct {
+	k      int
+	better func(a, b T) bool
+	heap   []T // heap[0] is the worst kept item
+}
+
+// NewTopK returns a selector for the k items ranked highest by better.
+// k <= 0 keeps every item.
+func NewTopK[T any](k int, better func(a, b T) bool) *TopK[T] {
+	t := &TopK[T]{k: k, better: better}
+	if k > 0 {
+		t.heap = make([]T, 0, k)
+	}
+	return t
+}
+
+// Push offers x to the selector.
+func (t *TopK[T]) Push(x T) {
+	if t.k <= 0 || len(t.heap) < t.k {
+		t.heap = append(t.heap, x)
+		t.up(len(t.heap) - 1)
+		return
+	}
+	if !t.better(x, t.heap[0]) {
+		return
+	}
+	t.heap[0] = x
+	t.down(0)
+}
+
+// Len returns the number of items kept.
+func (t *TopK[T]) Len() int { return len(t.heap) }
+
+// Sorted returns the kept items, best first. The selector is emptied.
+func (t *TopK[T]) Sorted() []T {
+	out := make([]T, len(t.heap))
+	for i := len(out) - 1; i >= 0; i-- {
+		out[i] = t.heap[0]
+		last := len(t.heap) - 1
+		t.heap[0] = t.heap[last]
+		t.heap = t.heap[:last]
+		t.down(0)
+	}
+	return out
+}
+
+// worse reports whether heap[i] ranks below heap[j].
+func (t *TopK[T]) worse(i, j int) bool { return t.better(t.heap[j], t.heap[i]) }
+
+func (t *TopK[T]) up(i int) {
+	for i > 0 {
+		p := (i - 1) / 2
+		if !t.worse(i, p) {
+			return
+		}
+		t.heap[i], t.heap[p] = t.heap[p], t.heap[i]
+		i = p
+	}
+}
+
+func (t *TopK[T]) down(i int) {
+	n := len(t.heap)
+	for {
+		l := 2*i + 1
+		if l >= n {
+			return
+		}
+		m := l
+		if r := l + 1; r < n && t.worse(r, l) {
+			m = r
+		}
+		if !t.worse(m, i) {
+			return
+		}
+		t.heap[i], t.heap[m] = t.heap[m], t.heap[i]
+		i = m
+	}
+}
+
+// Scored is a search hit: an item index and its similarity.
+type Scored struct {
+	Index int
+	Score float32
+}
+
+// Search returns the k rows of matrix most similar to query by dot product,
+// best first. Rows and query are expected to be normalized.
+func Search(query []float32, matrix [][]float32, k int) []Scored {
+	top := NewTopK(k, func(a, b Scored) bool { return a.Score > b.Score })
+	for i, row := range matrix {
+		top.Push(Scored{Index: i, Score: Dot(query, row)})
+	}
+	return top.Sorted()
+}
@@ -0,0 +1,151 @@
+package vecmath_test
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"testing"
+
+	"memorigo/vecmath"
+)
+
+func naiveDot(a, b []float32) float64 {
+	var s float64
+	for i := range a {
+		s += float64(a[i]) * float64(b[i])
+	}
+	return s
+}
+
+func randomVectors(rng *rand.Rand, n, dim int) [][]float32 {
+	backing := make([]float32, n*dim)
+	out := make([][]float32, n)
+	for i := range out {
+		v := backing[i*dim : (i+1)*dim : (i+1)*dim]
+		for j := range v {
+			v[j] = float32(rng.NormFloat64())
+		}
+		vecmath.Normalize(v)
+		out[i] = v
+	}
+	return out
+}
+
+func TestDotMatchesNaive(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for _, dim := range []int{0, 1, 7, 8, 9, 64, 1536} {
+		vs := randomVectors(rng, 2, dim)
+		got, want := vecmath.Dot(vs[0], vs[1]), naiveDot(vs[0], vs[1])
+		if math.Abs(float64(got)-want) > 1e-5 {
+			t.Fatalf("dim %d: Dot = %f, want %f", dim, got, want)
+		}
+	}
+	if vecmath.Dot([]float32{1}, []float32{1, 2}) != 0 {
+		t.Fatalf("mismatched lengths must score 0")
+	}
+}
+
+func TestCosine(t *testing.T) {
+	a := []float32{3, 4}
+	b := []float32{6, 8}
+	if c := vecmath.Cosine(a, b); math.Abs(float64(c)-1) > 1e-6 {
+		t.Fatalf("parallel vectors: cosine = %f, want 1", c)
+	}
+	if c := vecmath.Cosine(a, []float32{-4, 3}); math.Abs(float64(c)) > 1e-6 {
+		t.Fatalf("orthogonal vectors: cosine = %f, want 0", c)
+	}
+	if c := vecmath.Cosine(a, []float32{0, 0}); c != 0 {
+		t.Fatalf("zero vector: cosine = %f, want 0", c)
+	}
+}
+
+func TestSearchMatchesSort(t *testing.T) {
+	rng := rand.New(rand.NewSource(2))
+	matrix := randomVectors(rng, 1000, 32)
+	query := randomVectors(rng, 1, 32)[0]
+
+	got := vecmath.Search(query, matrix, 10)
+
+	idx := make([]int, len(matrix))
+	for i := range idx {
+		idx[i] = i
+	}
+	sort.Slice(idx, func(a, b int) bool {
+		return vecmath.Dot(query, matrix[idx[a]]) > vecmath.Dot(query, matrix[idx[b]])
+	})
+	if len(got) != 10 {
+		t.Fatalf("got %d results, want 10", len(got))
+	}
+	for i, s := range got {
+		if s.Index != idx[i] {
+			t.Fatalf("rank %d: got %d, want %d", i, s.Index, idx[i])
+		}
+	}
+}
+
+func TestTopKKeepsAllWhenUnbounded(t *testing.T) {
+	top := vecmath.NewTopK(0, func(a, b int) bool { return a > b })
+	for _, x := range []int{3, 1, 4, 1, 5} {
+		top.Push(x)
+	}
+	got := top.Sorted()
+	want := []int{5, 4, 3, 1, 1}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+	}
+}
+
+var sink float32
+
+func BenchmarkDot(b *testing.B) {
+	rng := rand.New(rand.NewSource(3))
+	for _, dim := range []int{384, 1536} {
+		vs := randomVectors(rng, 2, dim)
+		b.Run(fmt.Sprintf("dim=%d", dim), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				sink = vecmath.Dot(vs[0], vs[1])
+			}
+		})
+	}
+}
+
+// BenchmarkSearch compares brute-force top-10 search with pre-normalized
+// vectors and a heap against the previous approach of computing float64
+// cosine for every fact and sorting all of them.
+func BenchmarkSearch(b *testing.B) {
+	const dim = 128
+	for _, n := range []int{10_000, 100_000, 1_000_000} {
+		if n > 100_000 && testing.Short() {
+			continue
+		}
+		rng := rand.New(rand.NewSource(4))
+		matrix := randomVectors(rng, n, dim)
+		query := randomVectors(rng, 1, dim)[0]
+
+		b.Run(fmt.Sprintf("facts=%d/dot+heap", n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				_ = vecmath.Search(query, matrix, 10)
+			}
+		})
+		b.Run(fmt.Sprintf("facts=%d/cosine64+sort", n), func(b *testing.B) {
+			scores := make([]float64, n)
+			idx := make([]int, n)
+			for i := 0; i < b.N; i++ {
+				for j, row := range matrix {
+					var dot, na, nb float64
+					for k := range row {
+						dot += float64(query[k]) * float64(row[k])
+						na += float64(query[k]) * float64(query[k])
+						nb += float64(row[k]) * float64(row[k])
+					}
+					scores[j] = dot / (math.Sqrt(na) * math.Sqrt(nb))
+					idx[j] = j
+				}
+				sort.Slice(idx, func(a, c int) bool { return scores[idx[a]] > scores[idx[c]] })
+			}
+		})
+	}
+}